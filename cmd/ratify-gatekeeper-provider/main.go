@@ -18,15 +18,21 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"time"
 
 	"github.com/notaryproject/ratify/v2/internal/httpserver"
 	"github.com/notaryproject/ratify/v2/internal/manager"
+	"github.com/notaryproject/ratify/v2/internal/store"
+	"github.com/notaryproject/ratify/v2/internal/store/blobcache"
 	"github.com/sirupsen/logrus"
 )
 
 var startManagerFunc = manager.StartManager
 
+// defaultBlobCacheSize is the default size of the in-memory blob cache.
+const defaultBlobCacheSize = 64 << 20 // 64 MiB
+
 // main is the entry point for the Ratify server.
 func main() {
 	if err := startRatify(parse()); err != nil {
@@ -44,8 +50,12 @@ type options struct {
 	disableCertRotation  bool
 	disableMutation      bool
 	disableCRDManager    bool
+	enablePodPullSecrets bool
 	verifyTimeout        time.Duration
 	mutateTimeout        time.Duration
+	blobCacheSize        int64
+	blobCacheDir         string
+	blobCacheDirSize     int64
 }
 
 func parse() *options {
@@ -60,6 +70,10 @@ func parse() *options {
 	flag.BoolVar(&opts.disableCertRotation, "disable-cert-rotation", false, "Disable certificate rotation")
 	flag.BoolVar(&opts.disableMutation, "disable-mutation", false, "Disable mutation wehbook")
 	flag.BoolVar(&opts.disableCRDManager, "disable-crd-manager", false, "Disable CRD manager for Gatekeeper provider")
+	flag.BoolVar(&opts.enablePodPullSecrets, "enable-pod-image-pull-secrets", false, "Authenticate to registries with the imagePullSecrets of the admitted Pod or its service account")
+	flag.Int64Var(&opts.blobCacheSize, "blob-cache-size", defaultBlobCacheSize, "Maximum size in bytes of the in-memory blob and manifest cache, 0 disables the cache")
+	flag.StringVar(&opts.blobCacheDir, "blob-cache-dir", "", "Directory of the on-disk blob and manifest cache, disabled if empty")
+	flag.Int64Var(&opts.blobCacheDirSize, "blob-cache-dir-size", blobcache.DefaultMaxDiskBytes, "Maximum size in bytes of the on-disk blob and manifest cache")
 
 	flag.Parse()
 	logrus.Infof("Starting Ratify with options: %+v", opts)
@@ -70,20 +84,32 @@ func startRatify(opts *options) error {
 	if len(opts.httpServerAddress) == 0 {
 		return errors.New("HTTP server address is required")
 	}
+	if opts.blobCacheSize > 0 {
+		cache, err := blobcache.New(blobcache.Options{
+			MaxMemoryBytes: opts.blobCacheSize,
+			Dir:            opts.blobCacheDir,
+			MaxDiskBytes:   opts.blobCacheDirSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create blob cache: %w", err)
+		}
+		store.SetBlobCache(cache)
+	}
 	var certRotatorReady chan struct{}
 	if !opts.disableCertRotation {
 		certRotatorReady = make(chan struct{})
 	}
 	serverOpts := &httpserver.ServerOptions{
-		HTTPServerAddress:    opts.httpServerAddress,
-		CertFile:             opts.certFile,
-		KeyFile:              opts.keyFile,
-		GatekeeperCACertFile: opts.gatekeeperCACertFile,
-		VerifyTimeout:        opts.verifyTimeout,
-		MutateTimeout:        opts.mutateTimeout,
-		DisableMutation:      opts.disableMutation,
-		DisableCRDManager:    opts.disableCRDManager,
-		CertRotatorReady:     certRotatorReady,
+		HTTPServerAddress:         opts.httpServerAddress,
+		CertFile:                  opts.certFile,
+		KeyFile:                   opts.keyFile,
+		GatekeeperCACertFile:      opts.gatekeeperCACertFile,
+		VerifyTimeout:             opts.verifyTimeout,
+		MutateTimeout:             opts.mutateTimeout,
+		DisableMutation:           opts.disableMutation,
+		DisableCRDManager:         opts.disableCRDManager,
+		EnablePodImagePullSecrets: opts.enablePodPullSecrets,
+		CertRotatorReady:          certRotatorReady,
 	}
 
 	go startManagerFunc(certRotatorReady, serverOpts.DisableMutation, serverOpts.DisableCRDManager)
@@ -21,6 +21,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/store/blobcache"
 )
 
 func TestMain_FailedStartingRatify(t *testing.T) {
@@ -67,6 +69,8 @@ func TestParse(t *testing.T) {
 				keyFile:           "key.pem",
 				verifyTimeout:     10 * time.Second,
 				mutateTimeout:     2 * time.Second,
+				blobCacheSize:     defaultBlobCacheSize,
+				blobCacheDirSize:  blobcache.DefaultMaxDiskBytes,
 			},
 		},
 		{
@@ -76,16 +80,48 @@ func TestParse(t *testing.T) {
 				"-mutate-timeout=10s",
 			},
 			expected: &options{
-				verifyTimeout: 30 * time.Second,
-				mutateTimeout: 10 * time.Second,
+				verifyTimeout:    30 * time.Second,
+				mutateTimeout:    10 * time.Second,
+				blobCacheSize:    defaultBlobCacheSize,
+				blobCacheDirSize: blobcache.DefaultMaxDiskBytes,
+			},
+		},
+		{
+			name: "blob cache options",
+			args: []string{
+				"-blob-cache-size=1024",
+				"-blob-cache-dir=/tmp/cache",
+				"-blob-cache-dir-size=4096",
+			},
+			expected: &options{
+				verifyTimeout:    5 * time.Second,
+				mutateTimeout:    2 * time.Second,
+				blobCacheSize:    1024,
+				blobCacheDir:     "/tmp/cache",
+				blobCacheDirSize: 4096,
+			},
+		},
+		{
+			name: "pod image pull secrets enabled",
+			args: []string{
+				"-enable-pod-image-pull-secrets",
+			},
+			expected: &options{
+				enablePodPullSecrets: true,
+				verifyTimeout:        5 * time.Second,
+				mutateTimeout:        2 * time.Second,
+				blobCacheSize:        defaultBlobCacheSize,
+				blobCacheDirSize:     blobcache.DefaultMaxDiskBytes,
 			},
 		},
 		{
 			name: "default values",
 			args: []string{},
 			expected: &options{
-				verifyTimeout: 5 * time.Second,
-				mutateTimeout: 2 * time.Second,
+				verifyTimeout:    5 * time.Second,
+				mutateTimeout:    2 * time.Second,
+				blobCacheSize:    defaultBlobCacheSize,
+				blobCacheDirSize: blobcache.DefaultMaxDiskBytes,
 			},
 		},
 	}
@@ -136,6 +172,17 @@ func TestStartRatify(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "invalid blob cache directory",
+			opts: &options{
+				httpServerAddress:   ":8080",
+				blobCacheSize:       1024,
+				blobCacheDir:        "main.go",
+				disableCertRotation: true,
+				disableCRDManager:   true,
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -24,12 +24,24 @@ import (
 	_ "github.com/notaryproject/ratify/v2/internal/store/registrystore"      // Register the registry store
 
 	// Register credential providers
-	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/azure"  // Register the Azure credential provider factory
-	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/static" // Register the static credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/alibabacloud"  // Register the Alibaba Cloud ACR credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/aws"           // Register the AWS ECR credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/azure"         // Register the Azure credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/dockerconfig"  // Register the Docker config credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/gcp"           // Register the Google Cloud credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/k8ssecret"     // Register the Kubernetes Secret credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/kubeletplugin" // Register the kubelet credential provider plugin credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/multi"         // Register the multi credential provider factory
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/static"        // Register the static credential provider factory
 
 	// Register verifiers
-	_ "github.com/notaryproject/ratify/v2/internal/verifier/cosign"   // Register the Cosign verifier
-	_ "github.com/notaryproject/ratify/v2/internal/verifier/notation" // Register the Notation verifier
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/attestationpolicy"   // Register the attestation policy verifier
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/cosign"              // Register the Cosign verifier
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/notation"            // Register the Notation verifier
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/sbom"                // Register the SBOM and license checker verifiers
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/schemavalidator"     // Register the JSON schema validator
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/slsa"                // Register the SLSA provenance verifier
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/vulnerabilityreport" // Register the vulnerability report verifier
 
 	// Register key providers
 	_ "github.com/notaryproject/ratify/v2/internal/verifier/keyprovider/azurekeyvault"      // Register the Azure Key Vault key provider
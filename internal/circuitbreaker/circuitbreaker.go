@@ -0,0 +1,246 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/pkg/metrics"
+)
+
+const (
+	// DefaultFailureThreshold is the default number of consecutive failures
+	// that opens a breaker.
+	DefaultFailureThreshold = 5
+
+	// DefaultOpenTimeout is the default duration a breaker stays open before
+	// a probe request is allowed.
+	DefaultOpenTimeout = 30 * time.Second
+)
+
+// ErrOpen is returned when a request is rejected because the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a breaker.
+type State string
+
+const (
+	// StateClosed allows all requests.
+	StateClosed State = "closed"
+
+	// StateOpen rejects all requests until the open timeout elapses.
+	StateOpen State = "open"
+
+	// StateHalfOpen allows a single probe request. The breaker is closed if
+	// the probe succeeds and opened again if it fails.
+	StateHalfOpen State = "half-open"
+)
+
+// Options defines when a breaker opens and how long it stays open.
+type Options struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker. If less than or equal to 0, [DefaultFailureThreshold] is used.
+	FailureThreshold int
+
+	// OpenTimeout is the duration the breaker stays open before a probe
+	// request is allowed. If less than or equal to 0, [DefaultOpenTimeout] is
+	// used.
+	OpenTimeout time.Duration
+}
+
+func (o Options) failureThreshold() int {
+	if o.FailureThreshold <= 0 {
+		return DefaultFailureThreshold
+	}
+	return o.FailureThreshold
+}
+
+func (o Options) openTimeout() time.Duration {
+	if o.OpenTimeout <= 0 {
+		return DefaultOpenTimeout
+	}
+	return o.OpenTimeout
+}
+
+// Status is a snapshot of a breaker.
+type Status struct {
+	// Name is the name of the breaker, e.g. the registry host.
+	Name string `json:"name"`
+
+	// State is the current state of the breaker.
+	State State `json:"state"`
+
+	// ConsecutiveFailures is the number of failures since the last success.
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+
+	// OpenedAt is the time the breaker was last opened. It is only set when
+	// the breaker is not closed.
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+// Breaker tracks the health of a single dependency such as a registry host.
+//
+// The state is owned by the breaker while the thresholds are supplied by each
+// caller, so that callers with different settings can share the observed
+// health of the same dependency.
+type Breaker struct {
+	name string
+
+	mu                  sync.Mutex
+	state               State
+	consecutiveFailures int
+	openedAt            time.Time
+	probeStartedAt      time.Time
+}
+
+// registry saves the breakers by name.
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Breaker)
+)
+
+// Get returns the process-wide breaker of the given name, creating a closed
+// breaker if it does not exist yet.
+func Get(name string) *Breaker {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	b, ok := registry[name]
+	if !ok {
+		b = &Breaker{
+			name:  name,
+			state: StateClosed,
+		}
+		registry[name] = b
+	}
+	return b
+}
+
+// Statuses returns the snapshots of all breakers sorted by name.
+func Statuses() []Status {
+	registryMu.Lock()
+	breakers := make([]*Breaker, 0, len(registry))
+	for _, b := range registry {
+		breakers = append(breakers, b)
+	}
+	registryMu.Unlock()
+
+	statuses := make([]Status, len(breakers))
+	for i, b := range breakers {
+		statuses[i] = b.Status()
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Allow reports whether a request may be sent. It returns [ErrOpen] if the
+// breaker is open, or if it is half-open and a probe request is already in
+// flight. A request that is allowed must be concluded by calling exactly one
+// of [Breaker.Success], [Breaker.Failure] or [Breaker.Abort].
+func (b *Breaker) Allow(opts Options) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	switch b.state {
+	case StateClosed:
+		return nil
+	case StateOpen:
+		if now.Sub(b.openedAt) < opts.openTimeout() {
+			return ErrOpen
+		}
+		b.setState(StateHalfOpen)
+		b.probeStartedAt = now
+		return nil
+	default:
+		// A probe that has not been concluded within the open timeout is
+		// considered lost, so another probe is allowed.
+		if now.Sub(b.probeStartedAt) < opts.openTimeout() {
+			return ErrOpen
+		}
+		b.probeStartedAt = now
+		return nil
+	}
+}
+
+// Success records a successful request and closes the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures = 0
+	b.probeStartedAt = time.Time{}
+	b.setState(StateClosed)
+}
+
+// Failure records a failed request. The breaker is opened if the probe
+// request failed or the number of consecutive failures reaches the threshold.
+func (b *Breaker) Failure(opts Options) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures++
+	if b.state == StateHalfOpen || b.consecutiveFailures >= opts.failureThreshold() {
+		b.openedAt = time.Now()
+		b.probeStartedAt = time.Time{}
+		b.setState(StateOpen)
+	}
+}
+
+// Abort concludes a request without recording its outcome, e.g. when the
+// caller cancelled it. A pending probe is released so that the next request
+// can probe again.
+func (b *Breaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.probeStartedAt = time.Time{}
+	}
+}
+
+// Status returns a snapshot of the breaker.
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := Status{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// setState transitions the breaker to the given state and reports the
+// transition. This method should be called while holding the lock.
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	metrics.ReportCircuitBreakerStateChange(context.Background(), b.name, string(state))
+}
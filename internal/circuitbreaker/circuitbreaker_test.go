@@ -0,0 +1,147 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	b := Get("opens-after-threshold.example.com")
+	opts := Options{FailureThreshold: 3, OpenTimeout: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(opts); err != nil {
+			t.Fatalf("expected request to be allowed, got %v", err)
+		}
+		b.Failure(opts)
+	}
+	if state := b.Status().State; state != StateClosed {
+		t.Fatalf("expected closed state, got %s", state)
+	}
+
+	if err := b.Allow(opts); err != nil {
+		t.Fatalf("expected request to be allowed, got %v", err)
+	}
+	b.Failure(opts)
+	status := b.Status()
+	if status.State != StateOpen {
+		t.Fatalf("expected open state, got %s", status.State)
+	}
+	if status.ConsecutiveFailures != 3 || status.OpenedAt == nil {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if err := b.Allow(opts); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen, got %v", err)
+	}
+}
+
+func TestBreaker_SuccessResetsFailures(t *testing.T) {
+	b := Get("success-resets.example.com")
+	opts := Options{FailureThreshold: 2, OpenTimeout: time.Hour}
+
+	b.Failure(opts)
+	b.Success()
+	b.Failure(opts)
+	if state := b.Status().State; state != StateClosed {
+		t.Fatalf("expected closed state, got %s", state)
+	}
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	opts := Options{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond}
+
+	t.Run("probe success closes the breaker", func(t *testing.T) {
+		b := Get("half-open-success.example.com")
+		b.Failure(opts)
+		time.Sleep(2 * opts.OpenTimeout)
+
+		if err := b.Allow(opts); err != nil {
+			t.Fatalf("expected probe to be allowed, got %v", err)
+		}
+		if state := b.Status().State; state != StateHalfOpen {
+			t.Fatalf("expected half-open state, got %s", state)
+		}
+		if err := b.Allow(opts); !errors.Is(err, ErrOpen) {
+			t.Fatalf("expected concurrent request to be rejected, got %v", err)
+		}
+		b.Success()
+		if state := b.Status().State; state != StateClosed {
+			t.Fatalf("expected closed state, got %s", state)
+		}
+	})
+
+	t.Run("probe failure opens the breaker", func(t *testing.T) {
+		b := Get("half-open-failure.example.com")
+		opts := Options{FailureThreshold: 5, OpenTimeout: 20 * time.Millisecond}
+		for i := 0; i < 5; i++ {
+			b.Failure(opts)
+		}
+		time.Sleep(2 * opts.OpenTimeout)
+
+		if err := b.Allow(opts); err != nil {
+			t.Fatalf("expected probe to be allowed, got %v", err)
+		}
+		b.Failure(opts)
+		if state := b.Status().State; state != StateOpen {
+			t.Fatalf("expected open state, got %s", state)
+		}
+	})
+
+	t.Run("aborted probe allows another probe", func(t *testing.T) {
+		b := Get("half-open-abort.example.com")
+		b.Failure(opts)
+		time.Sleep(2 * opts.OpenTimeout)
+
+		if err := b.Allow(opts); err != nil {
+			t.Fatalf("expected probe to be allowed, got %v", err)
+		}
+		b.Abort()
+		if err := b.Allow(opts); err != nil {
+			t.Fatalf("expected another probe to be allowed, got %v", err)
+		}
+	})
+}
+
+func TestGetAndStatuses(t *testing.T) {
+	first := Get("b-statuses.example.com")
+	if Get("b-statuses.example.com") != first {
+		t.Fatal("expected the same breaker for the same name")
+	}
+	Get("a-statuses.example.com")
+
+	var names []string
+	for _, status := range Statuses() {
+		if status.Name == "a-statuses.example.com" || status.Name == "b-statuses.example.com" {
+			names = append(names, status.Name)
+		}
+	}
+	if len(names) != 2 || names[0] != "a-statuses.example.com" {
+		t.Fatalf("expected sorted statuses, got %v", names)
+	}
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	opts := Options{}
+	if opts.failureThreshold() != DefaultFailureThreshold {
+		t.Errorf("expected default failure threshold, got %d", opts.failureThreshold())
+	}
+	if opts.openTimeout() != DefaultOpenTimeout {
+		t.Errorf("expected default open timeout, got %v", opts.openTimeout())
+	}
+}
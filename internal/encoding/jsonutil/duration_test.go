@@ -0,0 +1,91 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDuration_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "seconds",
+			data: `"30s"`,
+			want: 30 * time.Second,
+		},
+		{
+			name: "composite",
+			data: `"1h30m"`,
+			want: 90 * time.Minute,
+		},
+		{
+			name: "empty string",
+			data: `""`,
+			want: 0,
+		},
+		{
+			name:    "number",
+			data:    `30`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			data:    `"thirty seconds"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Duration
+			err := json.Unmarshal([]byte(tt.data), &d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && d.Duration() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, d.Duration())
+			}
+		})
+	}
+}
+
+func TestDuration_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Duration(90 * time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `"1m30s"` {
+		t.Fatalf("expected \"1m30s\", got %s", data)
+	}
+
+	// Round trip through Copy as the factories do.
+	var dst struct {
+		Timeout Duration `json:"timeout"`
+	}
+	if err := Copy(&dst, map[string]any{"timeout": "2s"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dst.Timeout.Duration() != 2*time.Second {
+		t.Fatalf("expected 2s, got %v", dst.Timeout.Duration())
+	}
+}
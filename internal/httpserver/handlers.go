@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/notaryproject/ratify/v2/internal/circuitbreaker"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
 	"github.com/sirupsen/logrus"
 	"oras.land/oras-go/v2/registry"
@@ -42,14 +43,24 @@ func (s *server) verify(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	}
 
 	results := make([]externaldata.Item, len(providerRequest.Request.Keys))
-	for idx, artifact := range providerRequest.Request.Keys {
+	for idx, requestKey := range providerRequest.Request.Keys {
 		results[idx] = externaldata.Item{
-			Key: artifact,
+			Key: requestKey,
 		}
+		artifactCtx, artifact, identity, err := s.parseVerifyKey(ctx, requestKey)
+		if err != nil {
+			results[idx].Error = err.Error()
+			continue
+		}
+		// Results of requests with their own credentials are cached per
+		// credential identity.
 		key := verifyKey(artifact)
+		if identity != "" {
+			key = verifyKey(identity + "_" + artifact)
+		}
 
 		// Fetch the cache value first.
-		result, err := s.verifyCache.Get(ctx, key)
+		result, err := s.verifyCache.Get(artifactCtx, key)
 		if err == nil && result != nil {
 			results[idx].Value = result
 			continue
@@ -62,12 +73,12 @@ func (s *server) verify(ctx context.Context, w http.ResponseWriter, r *http.Requ
 			if executor == nil {
 				return nil, errors.New("no valid executor configured")
 			}
-			result, err := executor.ValidateArtifact(ctx, artifact)
+			result, err := executor.ValidateArtifact(artifactCtx, artifact)
 			if err != nil {
 				return nil, err
 			}
 			renderedResult := convertResult(result)
-			if err = s.verifyCache.Set(ctx, key, renderedResult, 0); err != nil {
+			if err = s.verifyCache.Set(artifactCtx, key, renderedResult, 0); err != nil {
 				logrus.Warnf("failed to set verify cache for image %s: %v", artifact, err)
 			}
 			return renderedResult, nil
@@ -152,6 +163,17 @@ func (s *server) resolveReference(ctx context.Context, reference string) externa
 	return item
 }
 
+// status handles the status request and reports the state of the registry
+// circuit breakers.
+func (s *server) status(_ context.Context, w http.ResponseWriter, _ *http.Request) error {
+	response := statusResponse{
+		CircuitBreakers: circuitbreaker.Statuses(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(response)
+}
+
 func sendResponse(results []externaldata.Item, w http.ResponseWriter, respCode int, isMutation bool) error {
 	response := externaldata.ProviderResponse{
 		APIVersion: "externaldata.gatekeeper.sh/v1beta1",
@@ -26,6 +26,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/notaryproject/ratify/v2/internal/circuitbreaker"
 	"github.com/notaryproject/ratify/v2/internal/executor"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
 	"golang.org/x/sync/singleflight"
@@ -298,3 +299,32 @@ func TestMutate(t *testing.T) {
 		})
 	}
 }
+
+func TestStatus(t *testing.T) {
+	breaker := circuitbreaker.Get("status-test.example.com")
+	breaker.Failure(circuitbreaker.Options{FailureThreshold: 1})
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	w := httptest.NewRecorder()
+	server := &server{}
+	if err := server.status(context.Background(), w, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code 200, got %d", w.Code)
+	}
+
+	var response statusResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, status := range response.CircuitBreakers {
+		if status.Name == "status-test.example.com" {
+			if status.State != circuitbreaker.StateOpen {
+				t.Fatalf("expected open state, got %s", status.State)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected circuit breaker status in response, got %+v", response.CircuitBreakers)
+}
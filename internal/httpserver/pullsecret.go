@@ -0,0 +1,106 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider/k8ssecret"
+)
+
+// defaultServiceAccountName is the service account of Pods not specifying
+// one.
+const defaultServiceAccountName = "default"
+
+// podVerifyKey is a verify request key carrying the Pod context of the image,
+// e.g. {"image":"ghcr.io/org/app:v1","namespace":"tenant","serviceAccount":"app",
+// "imagePullSecrets":["registry"]}.
+type podVerifyKey struct {
+	// Image is the image to verify. Required.
+	Image string `json:"image"`
+
+	// Namespace is the namespace of the Pod. Required.
+	Namespace string `json:"namespace"`
+
+	// ServiceAccount is the service account of the Pod. Defaults to
+	// "default". Optional.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+
+	// PullSecrets are the names of the imagePullSecrets of the Pod. If not
+	// provided, the imagePullSecrets of the service account are used.
+	// Optional.
+	PullSecrets []string `json:"imagePullSecrets,omitempty"`
+}
+
+// newPullSecretProvider creates the credential provider returning the
+// credentials of the pull secrets, or of the imagePullSecrets of the service
+// account if no pull secrets are given. Registries without matching pull
+// secrets are accessed anonymously, as kubelet does.
+var newPullSecretProvider = func(namespace, serviceAccount string, pullSecrets []string) (ratify.RegistryCredentialGetter, error) {
+	opts := k8ssecret.Options{
+		Namespace:         namespace,
+		AnonymousFallback: true,
+	}
+	for _, name := range pullSecrets {
+		opts.Secrets = append(opts.Secrets, k8ssecret.SecretReference{Name: name})
+	}
+	if len(opts.Secrets) == 0 {
+		opts.ServiceAccountName = serviceAccount
+	}
+	return k8ssecret.NewProvider(opts)
+}
+
+// parseVerifyKey returns the artifact to verify and the identity of the
+// request credentials for the key. If the key carries a Pod context and
+// EnablePodImagePullSecrets is set, the returned context carries the
+// credentials of the imagePullSecrets of the Pod, or of its service account if
+// the Pod has none.
+func (s *server) parseVerifyKey(ctx context.Context, key string) (context.Context, string, string, error) {
+	if !s.EnablePodImagePullSecrets || !strings.HasPrefix(strings.TrimSpace(key), "{") {
+		return ctx, key, "", nil
+	}
+
+	var podKey podVerifyKey
+	if err := json.Unmarshal([]byte(key), &podKey); err != nil {
+		return nil, "", "", fmt.Errorf("failed to parse verify key: %w", err)
+	}
+	if podKey.Image == "" || podKey.Namespace == "" {
+		return nil, "", "", fmt.Errorf("verify key must contain the image and the namespace")
+	}
+	if podKey.ServiceAccount == "" {
+		podKey.ServiceAccount = defaultServiceAccountName
+	}
+	provider, err := newPullSecretProvider(podKey.Namespace, podKey.ServiceAccount, podKey.PullSecrets)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to create image pull secret credential provider: %w", err)
+	}
+	// Names of service accounts and Secrets cannot contain "/" or ",", so
+	// the identity is unambiguous.
+	identity := podKey.Namespace + "/" + podKey.ServiceAccount
+	if len(podKey.PullSecrets) > 0 {
+		identity += "/" + strings.Join(podKey.PullSecrets, ",")
+	}
+	ctx = credentialprovider.WithRequestCredential(ctx, credentialprovider.RequestCredential{
+		Identity: identity,
+		Provider: provider,
+	})
+	return ctx, podKey.Image, identity, nil
+}
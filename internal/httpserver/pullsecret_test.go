@@ -0,0 +1,226 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
+	"golang.org/x/sync/singleflight"
+)
+
+type anonymousCredentialGetter struct{}
+
+func (anonymousCredentialGetter) Get(context.Context, string) (ratify.RegistryCredential, error) {
+	return ratify.RegistryCredential{}, nil
+}
+
+// stubPullSecretProvider replaces the pull secret provider for the test and
+// records the requested service accounts and pull secrets.
+func stubPullSecretProvider(t *testing.T, err error) *[]string {
+	t.Helper()
+	requested := &[]string{}
+	original := newPullSecretProvider
+	newPullSecretProvider = func(namespace, serviceAccount string, pullSecrets []string) (ratify.RegistryCredentialGetter, error) {
+		*requested = append(*requested, namespace+"/"+serviceAccount+"/"+strings.Join(pullSecrets, ","))
+		if err != nil {
+			return nil, err
+		}
+		return anonymousCredentialGetter{}, nil
+	}
+	t.Cleanup(func() { newPullSecretProvider = original })
+	return requested
+}
+
+func TestParseVerifyKey(t *testing.T) {
+	tests := []struct {
+		name             string
+		enabled          bool
+		key              string
+		providerErr      error
+		expectArtifact   string
+		expectIdentity   string
+		expectRequested  []string
+		expectCredential bool
+		expectErr        bool
+	}{
+		{
+			name:           "disabled",
+			key:            `{"image":"ghcr.io/org/app:v1","namespace":"tenant"}`,
+			expectArtifact: `{"image":"ghcr.io/org/app:v1","namespace":"tenant"}`,
+		},
+		{
+			name:           "plain key",
+			enabled:        true,
+			key:            "ghcr.io/org/app:v1",
+			expectArtifact: "ghcr.io/org/app:v1",
+		},
+		{
+			name:             "pod key",
+			enabled:          true,
+			key:              `{"image":"ghcr.io/org/app:v1","namespace":"tenant","serviceAccount":"app"}`,
+			expectArtifact:   "ghcr.io/org/app:v1",
+			expectIdentity:   "tenant/app",
+			expectCredential: true,
+		},
+		{
+			name:             "pod key with image pull secrets",
+			enabled:          true,
+			key:              `{"image":"ghcr.io/org/app:v1","namespace":"tenant","serviceAccount":"app","imagePullSecrets":["regcred","other"]}`,
+			expectArtifact:   "ghcr.io/org/app:v1",
+			expectIdentity:   "tenant/app/regcred,other",
+			expectRequested:  []string{"tenant/app/regcred,other"},
+			expectCredential: true,
+		},
+		{
+			name:             "pod key without image pull secrets",
+			enabled:          true,
+			key:              `{"image":"ghcr.io/org/app:v1","namespace":"tenant","serviceAccount":"app","imagePullSecrets":[]}`,
+			expectArtifact:   "ghcr.io/org/app:v1",
+			expectIdentity:   "tenant/app",
+			expectRequested:  []string{"tenant/app/"},
+			expectCredential: true,
+		},
+		{
+			name:             "default service account",
+			enabled:          true,
+			key:              `{"image":"ghcr.io/org/app:v1","namespace":"tenant"}`,
+			expectArtifact:   "ghcr.io/org/app:v1",
+			expectIdentity:   "tenant/default",
+			expectCredential: true,
+		},
+		{
+			name:      "invalid pod key",
+			enabled:   true,
+			key:       `{"image":`,
+			expectErr: true,
+		},
+		{
+			name:      "missing namespace",
+			enabled:   true,
+			key:       `{"image":"ghcr.io/org/app:v1"}`,
+			expectErr: true,
+		},
+		{
+			name:        "provider error",
+			enabled:     true,
+			key:         `{"image":"ghcr.io/org/app:v1","namespace":"tenant"}`,
+			providerErr: errors.New("no cluster"),
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested := stubPullSecretProvider(t, tt.providerErr)
+			s := &server{ServerOptions: ServerOptions{EnablePodImagePullSecrets: tt.enabled}}
+			ctx, artifact, identity, err := s.parseVerifyKey(context.Background(), tt.key)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if artifact != tt.expectArtifact {
+				t.Fatalf("expected artifact %s, got %s", tt.expectArtifact, artifact)
+			}
+			if identity != tt.expectIdentity {
+				t.Fatalf("expected identity %s, got %s", tt.expectIdentity, identity)
+			}
+			if tt.expectRequested != nil && !slices.Equal(*requested, tt.expectRequested) {
+				t.Fatalf("expected pull secret providers %v, got %v", tt.expectRequested, *requested)
+			}
+			cred, ok := credentialprovider.RequestCredentialFromContext(ctx)
+			if ok != tt.expectCredential {
+				t.Fatalf("expected request credential: %v, got: %v", tt.expectCredential, ok)
+			}
+			if ok && cred.Identity != tt.expectIdentity {
+				t.Fatalf("expected request credential identity %s, got %s", tt.expectIdentity, cred.Identity)
+			}
+		})
+	}
+}
+
+func TestVerify_PodImagePullSecrets(t *testing.T) {
+	requested := stubPullSecretProvider(t, nil)
+	podKey := `{"image":"ghcr.io/org/app:v1","namespace":"tenant","serviceAccount":"app"}`
+	server := &server{
+		getExecutor: func() *executor.ScopedExecutor {
+			return &executor.ScopedExecutor{}
+		},
+		verifyCache: &mockResultCache{entries: map[string]*result{
+			// Results cached for other identities must not be returned.
+			"verify_ghcr.io/org/app:v1":            {Succeeded: true},
+			"verify_other/app_ghcr.io/org/app:v1":  {Succeeded: true},
+			"verify_tenant/app_ghcr.io/org/cached": {Succeeded: true},
+		}},
+		sfGroup:       new(singleflight.Group),
+		ServerOptions: ServerOptions{EnablePodImagePullSecrets: true},
+	}
+
+	body := `{"request":{"keys":[` + jsonString(t, podKey) + `,` +
+		jsonString(t, `{"image":"ghcr.io/org/cached","namespace":"tenant","serviceAccount":"app"}`) + `,` +
+		jsonString(t, `{"namespace":"tenant"}`) + `,` +
+		jsonString(t, `{"image":"ghcr.io/org/cached","namespace":"tenant","serviceAccount":"app","imagePullSecrets":["regcred"]}`) + `]}}`
+	req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	if err := server.verify(context.Background(), w, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var response externaldata.ProviderResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	items := response.Response.Items
+	if len(items) != 4 {
+		t.Fatalf("expected 4 items, got %d", len(items))
+	}
+	if items[0].Key != podKey || items[0].Value != nil || !strings.Contains(items[0].Error, "no executor configured") {
+		t.Fatalf("expected the image to be validated with the pod credentials, got %+v", items[0])
+	}
+	if items[1].Value == nil || items[1].Error != "" {
+		t.Fatalf("expected the cached result of the identity, got %+v", items[1])
+	}
+	if items[2].Error == "" {
+		t.Fatalf("expected error for a key without image, got %+v", items[2])
+	}
+	if items[3].Value != nil || !strings.Contains(items[3].Error, "no executor configured") {
+		t.Fatalf("expected the cached result of another identity not to be returned, got %+v", items[3])
+	}
+	if len(*requested) != 3 || (*requested)[0] != "tenant/app/" || (*requested)[2] != "tenant/app/regcred" {
+		t.Fatalf("unexpected pull secret providers requested: %v", *requested)
+	}
+}
+
+func jsonString(t *testing.T, s string) string {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("failed to marshal string: %v", err)
+	}
+	return string(data)
+}
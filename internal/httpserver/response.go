@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/circuitbreaker"
 	"github.com/sirupsen/logrus"
 )
 
@@ -44,6 +45,11 @@ type result struct {
 	ArtifactReports []*validationReport `json:"artifactReports"`
 }
 
+// statusResponse is the response of the status endpoint.
+type statusResponse struct {
+	CircuitBreakers []circuitbreaker.Status `json:"circuitBreakers"`
+}
+
 func convertResult(src *ratify.ValidationResult) *result {
 	if src == nil {
 		return nil
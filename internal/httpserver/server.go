@@ -41,6 +41,7 @@ const (
 	serverRootURL        = "/ratify/gatekeeper/v2"
 	verifyPath           = "verify"
 	mutatePath           = "mutate"
+	statusPath           = "status"
 	defaultVerifyTimeout = 5 * time.Second
 	defaultMutateTimeout = 2 * time.Second
 	readTimeout          = 5 * time.Second
@@ -101,6 +102,17 @@ type ServerOptions struct {
 	// Optional.
 	DisableCRDManager bool
 
+	// EnablePodImagePullSecrets enables verify requests to carry the
+	// namespace, service account and imagePullSecrets of the admitted Pod as
+	// a JSON key, e.g. {"image":"ghcr.io/org/app:v1","namespace":"tenant",
+	// "serviceAccount":"app","imagePullSecrets":["registry"]}. The registries
+	// are then accessed with the imagePullSecrets of the Pod, or of the
+	// service account if the Pod has none, instead of the configured
+	// credential providers. The
+	// Secrets and ServiceAccounts of each namespace are watched until the
+	// namespace has not been used for 30 minutes. Optional.
+	EnablePodImagePullSecrets bool
+
 	// CertRotatorReady is a channel that signals when the certificate rotator
 	// is ready. If not provided, the server will run without rotating the TLS
 	// certificates.
@@ -176,7 +188,7 @@ func (s *server) registerHandlers() error {
 			return err
 		}
 	}
-	return nil
+	return s.registerStatusHandler()
 }
 
 // TODO: implement mutate handler.
@@ -198,6 +210,15 @@ func (s *server) registerVerifyHandler() error {
 	return nil
 }
 
+func (s *server) registerStatusHandler() error {
+	statusURL, err := url.JoinPath(serverRootURL, statusPath)
+	if err != nil {
+		return err
+	}
+	s.router.Methods(http.MethodGet).Path(statusURL).Handler(s.statusHandler())
+	return nil
+}
+
 func (s *server) verifyHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		_ = s.verify(r.Context(), w, r)
@@ -210,6 +231,12 @@ func (s *server) mutateHandler() http.HandlerFunc {
 	}
 }
 
+func (s *server) statusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = s.status(r.Context(), w, r)
+	}
+}
+
 func middlewareWithTimeout(next http.Handler, timeout time.Duration) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
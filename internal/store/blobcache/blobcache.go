@@ -0,0 +1,189 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobcache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/notaryproject/ratify/v2/pkg/metrics"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxDiskBytes is the default size limit of the on-disk tier.
+	DefaultMaxDiskBytes = 1 << 30 // 1 GiB
+
+	defaultNumCounters = 100000
+)
+
+// Options configures a [Cache].
+type Options struct {
+	// MaxMemoryBytes limits the total size of the content cached in memory.
+	// Required.
+	MaxMemoryBytes int64
+
+	// Dir is the directory of the on-disk tier. The on-disk tier is disabled
+	// if empty. Optional.
+	Dir string
+
+	// MaxDiskBytes limits the total size of the content cached on disk. If
+	// less than or equal to 0, [DefaultMaxDiskBytes] is used. Optional.
+	MaxDiskBytes int64
+}
+
+// Cache is a size-bounded cache of blobs and manifests keyed by digest. As
+// the content is immutable by digest, a cache can be shared by all stores
+// regardless of the repository the content was fetched from.
+//
+// Content is kept in memory and, if configured, in an on-disk tier that
+// outlives the memory tier. Content fetched with request credentials is
+// partitioned by credential identity. The digest of the content is verified
+// on every read so that corrupted entries are never returned.
+type Cache struct {
+	memory *ristretto.Cache[string, []byte]
+	disk   *diskCache
+}
+
+// New creates a new [Cache].
+func New(opts Options) (*Cache, error) {
+	if opts.MaxMemoryBytes <= 0 {
+		return nil, fmt.Errorf("max memory bytes must be positive")
+	}
+	memory, err := ristretto.NewCache(&ristretto.Config[string, []byte]{
+		NumCounters: defaultNumCounters,
+		MaxCost:     opts.MaxMemoryBytes,
+		BufferItems: 64, // number of keys per Get buffer. 64 is recommended by the ristretto library.
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create memory cache: %w", err)
+	}
+
+	cache := &Cache{
+		memory: memory,
+	}
+	if opts.Dir != "" {
+		maxDiskBytes := opts.MaxDiskBytes
+		if maxDiskBytes <= 0 {
+			maxDiskBytes = DefaultMaxDiskBytes
+		}
+		if cache.disk, err = newDiskCache(opts.Dir, maxDiskBytes); err != nil {
+			memory.Close()
+			return nil, fmt.Errorf("failed to create disk cache: %w", err)
+		}
+	}
+	return cache, nil
+}
+
+// Get returns the cached content of the descriptor. Content read from the
+// on-disk tier is promoted to the memory tier.
+func (c *Cache) Get(ctx context.Context, desc ocispec.Descriptor) ([]byte, bool) {
+	data, ok := c.get(desc, partition(ctx))
+	metrics.ReportBlobCacheCount(ctx, ok)
+	return data, ok
+}
+
+func (c *Cache) get(desc ocispec.Descriptor, partition string) ([]byte, bool) {
+	if desc.Digest.Validate() != nil {
+		return nil, false
+	}
+	key := memoryKey(desc.Digest, partition)
+	if data, ok := c.memory.Get(key); ok {
+		if verify(desc, data) {
+			return data, true
+		}
+		logrus.Warnf("blob cache: memory entry %s failed digest verification, evicting", desc.Digest)
+		c.memory.Del(key)
+	}
+	if c.disk == nil || partition != "" {
+		return nil, false
+	}
+	data, ok := c.disk.get(desc.Digest)
+	if !ok {
+		return nil, false
+	}
+	if !verify(desc, data) {
+		logrus.Warnf("blob cache: disk entry %s failed digest verification, evicting", desc.Digest)
+		c.disk.delete(desc.Digest)
+		return nil, false
+	}
+	c.setMemory(key, data)
+	return data, true
+}
+
+// Set caches the content of the descriptor. Content that does not match the
+// size or digest of the descriptor is ignored.
+func (c *Cache) Set(ctx context.Context, desc ocispec.Descriptor, data []byte) {
+	if desc.Digest.Validate() != nil || !verify(desc, data) {
+		return
+	}
+	partition := partition(ctx)
+	c.setMemory(memoryKey(desc.Digest, partition), data)
+	if c.disk != nil && partition == "" {
+		if err := c.disk.set(desc.Digest, data); err != nil {
+			logrus.Warnf("blob cache: failed to write %s to disk: %v", desc.Digest, err)
+		}
+	}
+}
+
+func (c *Cache) setMemory(key string, data []byte) {
+	cost := int64(len(data))
+	if cost == 0 {
+		cost = 1
+	}
+	c.memory.Set(key, data, cost)
+	c.memory.Wait()
+}
+
+// partition returns the cache partition of the request. Content fetched with
+// the credentials of a request is only visible to requests with the same
+// credential identity, and is kept in the memory tier only.
+func partition(ctx context.Context) string {
+	if cred, ok := credentialprovider.RequestCredentialFromContext(ctx); ok {
+		return cred.Identity
+	}
+	return ""
+}
+
+// memoryKey returns the key of the digest in the memory tier.
+func memoryKey(d digest.Digest, partition string) string {
+	if partition == "" {
+		return d.String()
+	}
+	return partition + "@" + d.String()
+}
+
+// verify reports whether data matches the size and digest of the descriptor.
+func verify(desc ocispec.Descriptor, data []byte) bool {
+	if int64(len(data)) != desc.Size {
+		return false
+	}
+	algorithm := desc.Digest.Algorithm()
+	if !algorithm.Available() {
+		return false
+	}
+	return algorithm.FromBytes(data) == desc.Digest
+}
+
+// digestPath returns the relative path of the digest in the on-disk tier.
+// The digest must be validated before calling this function.
+func digestPath(d digest.Digest) string {
+	return string(d.Algorithm()) + "/" + d.Encoded()
+}
@@ -0,0 +1,178 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func descriptorFor(data []byte) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Options{}); err == nil {
+		t.Error("expected error for missing max memory bytes")
+	}
+	if _, err := New(Options{MaxMemoryBytes: 1024}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := New(Options{MaxMemoryBytes: 1024, Dir: file}); err == nil {
+		t.Error("expected error for a cache directory that is a file")
+	}
+}
+
+func TestCache_MemoryTier(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(Options{MaxMemoryBytes: 1024})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := []byte(`{"schemaVersion":2}`)
+	desc := descriptorFor(data)
+
+	if _, ok := cache.Get(ctx, desc); ok {
+		t.Fatal("expected cache miss")
+	}
+	cache.Set(ctx, desc, data)
+	got, ok := cache.Get(ctx, desc)
+	if !ok || string(got) != string(data) {
+		t.Fatalf("expected cache hit with %s, got %s (%v)", data, got, ok)
+	}
+}
+
+func TestCache_RejectsMismatchedContent(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(Options{MaxMemoryBytes: 1024, Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := []byte("content")
+	desc := descriptorFor(data)
+
+	cache.Set(ctx, desc, []byte("tampered"))
+	if _, ok := cache.Get(ctx, desc); ok {
+		t.Fatal("expected content with a mismatched digest not to be cached")
+	}
+
+	invalid := ocispec.Descriptor{Digest: "sha256:invalid", Size: int64(len(data))}
+	cache.Set(ctx, invalid, data)
+	if _, ok := cache.Get(ctx, invalid); ok {
+		t.Fatal("expected content with an invalid digest not to be cached")
+	}
+}
+
+func TestCache_DiskTier(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	data := []byte("signature envelope")
+	desc := descriptorFor(data)
+
+	cache, err := New(Options{MaxMemoryBytes: 1024, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Set(ctx, desc, data)
+
+	// A new cache on the same directory starts with an empty memory tier and
+	// serves the content from disk.
+	cache, err = New(Options{MaxMemoryBytes: 1024, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, ok := cache.Get(ctx, desc)
+	if !ok || string(got) != string(data) {
+		t.Fatalf("expected disk hit with %s, got %s (%v)", data, got, ok)
+	}
+
+	// Corrupted files on disk are detected and evicted.
+	cache, err = New(Options{MaxMemoryBytes: 1024, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(dir, "sha256", desc.Digest.Encoded())
+	if err := os.WriteFile(path, []byte("corrupted content!"), 0600); err != nil {
+		t.Fatalf("failed to corrupt file: %v", err)
+	}
+	if _, ok := cache.Get(ctx, desc); ok {
+		t.Fatal("expected corrupted entry to be rejected")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected corrupted file to be removed, got %v", err)
+	}
+}
+
+type tenantCredentialGetter struct{}
+
+func (tenantCredentialGetter) Get(context.Context, string) (ratify.RegistryCredential, error) {
+	return ratify.RegistryCredential{}, nil
+}
+
+func TestCache_PartitionedByRequestCredential(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := New(Options{MaxMemoryBytes: 1024, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tenantA := credentialprovider.WithRequestCredential(context.Background(), credentialprovider.RequestCredential{
+		Identity: "tenant-a/default",
+		Provider: tenantCredentialGetter{},
+	})
+	tenantB := credentialprovider.WithRequestCredential(context.Background(), credentialprovider.RequestCredential{
+		Identity: "tenant-b/default",
+		Provider: tenantCredentialGetter{},
+	})
+	data := []byte("private signature")
+	desc := descriptorFor(data)
+
+	cache.Set(tenantA, desc, data)
+	if _, ok := cache.Get(tenantA, desc); !ok {
+		t.Fatal("expected cache hit for the same identity")
+	}
+	if _, ok := cache.Get(tenantB, desc); ok {
+		t.Fatal("expected cache miss for another identity")
+	}
+	if _, ok := cache.Get(context.Background(), desc); ok {
+		t.Fatal("expected cache miss without request credentials")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sha256", desc.Digest.Encoded())); !os.IsNotExist(err) {
+		t.Fatalf("expected partitioned content not to be written to disk, got %v", err)
+	}
+
+	// Shared content is not visible to requests with their own credentials.
+	shared := []byte("public signature")
+	sharedDesc := descriptorFor(shared)
+	cache.Set(context.Background(), sharedDesc, shared)
+	if _, ok := cache.Get(tenantA, sharedDesc); ok {
+		t.Fatal("expected shared content not to be visible with request credentials")
+	}
+}
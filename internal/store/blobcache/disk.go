@@ -0,0 +1,215 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobcache
+
+import (
+	"container/list"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// diskEntry is an entry of the on-disk tier.
+type diskEntry struct {
+	digest digest.Digest
+	size   int64
+}
+
+// diskCache is the on-disk tier of [Cache]. Each entry is stored in a file
+// named by its digest and entries are evicted in least recently used order
+// once the total size exceeds the limit.
+type diskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[digest.Digest]*list.Element
+}
+
+// newDiskCache creates the on-disk tier in dir and indexes the entries left by
+// a previous process, ordered by their modification time.
+func newDiskCache(dir string, maxBytes int64) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	c := &diskCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[digest.Digest]*list.Element),
+	}
+
+	type existingEntry struct {
+		diskEntry
+		modTime time.Time
+	}
+	var existing []existingEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".tmp-") {
+			// Remove incomplete writes of a previous process.
+			_ = os.Remove(path)
+			return nil
+		}
+		dgst := digest.Digest(filepath.Dir(rel) + ":" + filepath.Base(rel))
+		if dgst.Validate() != nil {
+			// Skip unknown files.
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		existing = append(existing, existingEntry{
+			diskEntry: diskEntry{digest: dgst, size: info.Size()},
+			modTime:   info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index cache directory: %w", err)
+	}
+	sort.Slice(existing, func(i, j int) bool {
+		return existing[i].modTime.After(existing[j].modTime)
+	})
+	for _, e := range existing {
+		entry := e.diskEntry
+		c.entries[entry.digest] = c.lru.PushBack(&entry)
+		c.size += entry.size
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	return c, nil
+}
+
+// get reads the content of the digest.
+func (c *diskCache) get(dgst digest.Digest) ([]byte, bool) {
+	c.mu.Lock()
+	elem, ok := c.entries[dgst]
+	if ok {
+		c.lru.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(dgst))
+	if err != nil {
+		c.delete(dgst)
+		return nil, false
+	}
+	return data, true
+}
+
+// set writes the content of the digest. The file is written to a temporary
+// file first and renamed so that readers never observe partial content.
+func (c *diskCache) set(dgst digest.Digest, data []byte) error {
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return nil
+	}
+	c.mu.Lock()
+	if elem, ok := c.entries[dgst]; ok {
+		c.lru.MoveToFront(elem)
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+
+	path := c.path(dgst)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[dgst]; !ok {
+		c.entries[dgst] = c.lru.PushFront(&diskEntry{digest: dgst, size: size})
+		c.size += size
+	}
+	c.evict()
+	return nil
+}
+
+// delete removes the content of the digest.
+func (c *diskCache) delete(dgst digest.Digest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[dgst]; ok {
+		c.remove(elem)
+	}
+}
+
+// evict removes the least recently used entries until the total size is within
+// the limit. This method should be called while holding the lock.
+func (c *diskCache) evict() {
+	for c.size > c.maxBytes {
+		elem := c.lru.Back()
+		if elem == nil {
+			return
+		}
+		c.remove(elem)
+	}
+}
+
+// remove removes the entry and its file. This method should be called while
+// holding the lock.
+func (c *diskCache) remove(elem *list.Element) {
+	entry := elem.Value.(*diskEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, entry.digest)
+	c.size -= entry.size
+	_ = os.Remove(c.path(entry.digest))
+}
+
+func (c *diskCache) path(dgst digest.Digest) string {
+	return filepath.Join(c.dir, filepath.FromSlash(digestPath(dgst)))
+}
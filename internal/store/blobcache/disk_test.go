@@ -0,0 +1,112 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestDiskCache_Eviction(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := newDiskCache(dir, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := []byte("12345")
+	second := []byte("67890")
+	third := []byte("abcde")
+	for _, data := range [][]byte{first, second} {
+		if err := cache.set(digest.FromBytes(data), data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// Access the first entry so that the second one is the least recently
+	// used.
+	if _, ok := cache.get(digest.FromBytes(first)); !ok {
+		t.Fatal("expected first entry to be cached")
+	}
+	if err := cache.set(digest.FromBytes(third), third); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := cache.get(digest.FromBytes(second)); ok {
+		t.Error("expected second entry to be evicted")
+	}
+	for _, data := range [][]byte{first, third} {
+		if _, ok := cache.get(digest.FromBytes(data)); !ok {
+			t.Errorf("expected %s to be cached", data)
+		}
+	}
+	if cache.size != 10 {
+		t.Errorf("expected size 10, got %d", cache.size)
+	}
+
+	// Content larger than the limit is not cached.
+	large := []byte("larger than the limit")
+	if err := cache.set(digest.FromBytes(large), large); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cache.get(digest.FromBytes(large)); ok {
+		t.Error("expected large content not to be cached")
+	}
+}
+
+func TestNewDiskCache_IndexesExistingEntries(t *testing.T) {
+	dir := t.TempDir()
+	old := []byte("old entry")
+	recent := []byte("new entry")
+	for i, data := range [][]byte{old, recent} {
+		dgst := digest.FromBytes(data)
+		path := filepath.Join(dir, "sha256", dgst.Encoded())
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		modTime := time.Now().Add(time.Duration(i-2) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set modification time: %v", err)
+		}
+	}
+	tmp := filepath.Join(dir, "sha256", ".tmp-123")
+	if err := os.WriteFile(tmp, []byte("partial"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sha256", "unknown"), []byte("x"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	// Only the most recent entry fits in the limit.
+	cache, err := newDiskCache(dir, int64(len(recent)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cache.get(digest.FromBytes(recent)); !ok {
+		t.Error("expected recent entry to be indexed")
+	}
+	if _, ok := cache.get(digest.FromBytes(old)); ok {
+		t.Error("expected old entry to be evicted")
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("expected temporary file to be removed, got %v", err)
+	}
+}
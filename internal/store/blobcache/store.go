@@ -0,0 +1,67 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobcache
+
+import (
+	"context"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// store wraps a [ratify.Store] to serve blobs and manifests from a [Cache].
+type store struct {
+	ratify.Store
+	cache *Cache
+}
+
+// NewStore wraps s so that [ratify.Store.FetchBlob] and
+// [ratify.Store.FetchManifest] are served from cache when possible. Other
+// operations are passed through as tags and referrers are mutable.
+func NewStore(s ratify.Store, cache *Cache) ratify.Store {
+	return &store{
+		Store: s,
+		cache: cache,
+	}
+}
+
+// FetchBlob returns the blob from the cache or fetches it from the underlying
+// store.
+func (s *store) FetchBlob(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	if data, ok := s.cache.Get(ctx, desc); ok {
+		return data, nil
+	}
+	data, err := s.Store.FetchBlob(ctx, repo, desc)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(ctx, desc, data)
+	return data, nil
+}
+
+// FetchManifest returns the manifest from the cache or fetches it from the
+// underlying store.
+func (s *store) FetchManifest(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	if data, ok := s.cache.Get(ctx, desc); ok {
+		return data, nil
+	}
+	data, err := s.Store.FetchManifest(ctx, repo, desc)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(ctx, desc, data)
+	return data, nil
+}
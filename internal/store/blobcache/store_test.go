@@ -0,0 +1,94 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type countingStore struct {
+	ratify.Store
+	content   map[string][]byte
+	blobs     int
+	manifests int
+}
+
+func (s *countingStore) FetchBlob(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	s.blobs++
+	if data, ok := s.content[desc.Digest.String()]; ok {
+		return data, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (s *countingStore) FetchManifest(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	s.manifests++
+	if data, ok := s.content[desc.Digest.String()]; ok {
+		return data, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("blob")
+	manifest := []byte(`{"schemaVersion":2}`)
+	blobDesc := descriptorFor(blob)
+	manifestDesc := descriptorFor(manifest)
+	missingDesc := descriptorFor([]byte("missing"))
+
+	underlying := &countingStore{
+		content: map[string][]byte{
+			blobDesc.Digest.String():     blob,
+			manifestDesc.Digest.String(): manifest,
+		},
+	}
+	cache, err := New(Options{MaxMemoryBytes: 1024})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewStore(underlying, cache)
+
+	for i := 0; i < 3; i++ {
+		if data, err := s.FetchBlob(ctx, "registry.example.com/repo", blobDesc); err != nil || string(data) != string(blob) {
+			t.Fatalf("unexpected blob %s: %v", data, err)
+		}
+		// The same content can be served for a different repository.
+		if data, err := s.FetchManifest(ctx, "registry.example.com/other", manifestDesc); err != nil || string(data) != string(manifest) {
+			t.Fatalf("unexpected manifest %s: %v", data, err)
+		}
+	}
+	if underlying.blobs != 1 || underlying.manifests != 1 {
+		t.Fatalf("expected 1 fetch each, got %d blob and %d manifest fetches", underlying.blobs, underlying.manifests)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.FetchBlob(ctx, "registry.example.com/repo", missingDesc); err == nil {
+			t.Fatal("expected error for missing blob")
+		}
+		if _, err := s.FetchManifest(ctx, "registry.example.com/repo", missingDesc); err == nil {
+			t.Fatal("expected error for missing manifest")
+		}
+	}
+	if underlying.blobs != 3 || underlying.manifests != 3 {
+		t.Fatalf("expected errors not to be cached, got %d blob and %d manifest fetches", underlying.blobs, underlying.manifests)
+	}
+}
@@ -0,0 +1,359 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	cr20181201 "github.com/alibabacloud-go/cr-20181201/v2/client"
+	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
+	util "github.com/alibabacloud-go/tea-utils/v2/service"
+	"github.com/alibabacloud-go/tea/tea"
+	"github.com/aliyun/credentials-go/credentials"
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+)
+
+const (
+	// providerName is the name of the Alibaba Cloud ACR credential provider.
+	providerName = "alibabacloud"
+
+	// EnvRoleArn is the environment variable of the RAM role assumed by RRSA.
+	EnvRoleArn = "ALIBABA_CLOUD_ROLE_ARN"
+
+	// EnvOidcProviderArn is the environment variable of the OIDC provider
+	// used by RRSA.
+	EnvOidcProviderArn = "ALIBABA_CLOUD_OIDC_PROVIDER_ARN"
+
+	// EnvOidcTokenFile is the environment variable of the OIDC token file
+	// used by RRSA.
+	EnvOidcTokenFile = "ALIBABA_CLOUD_OIDC_TOKEN_FILE"
+
+	// EnvInstanceID is the environment variable of the default ACR instance
+	// ID.
+	EnvInstanceID = "ALIBABA_CLOUD_ACR_INSTANCE_ID"
+
+	// acrEndpoint is the ACR API endpoint of a region.
+	acrEndpoint = "cr.%s.aliyuncs.com"
+
+	// roleSessionName is the session name used when assuming RAM roles.
+	roleSessionName = "ratify"
+
+	// tokenExpiryBuffer is subtracted from the expiry of ACR authorization
+	// tokens so that they are refreshed before they expire.
+	tokenExpiryBuffer = 5 * time.Minute
+
+	// DefaultACRTokenTTL is the TTL used if ACR does not return the expiry of
+	// an authorization token.
+	DefaultACRTokenTTL = time.Hour - tokenExpiryBuffer
+)
+
+// registryHostPattern matches ACR Enterprise Edition registry hosts and
+// captures the instance name and the region, e.g.
+// myinstance-registry.cn-hangzhou.cr.aliyuncs.com or
+// myinstance-registry-vpc.cn-hangzhou.cr.aliyuncs.com.
+var registryHostPattern = regexp.MustCompile(`^(?:([^.\s]+)-)?registry(?:-intl)?(?:-vpc)?(?:-internal)?(?:\.distributed)?\.([^.]+-[^.]+)\.(?:cr\.)?aliyuncs\.com$`)
+
+// ACRProviderOptions contains configuration options for the Alibaba Cloud
+// ACR credential provider.
+type ACRProviderOptions struct {
+	// DefaultInstanceID is the ACR instance ID used for registries whose
+	// hostname does not contain an instance name, e.g.
+	// "registry.cn-hangzhou.aliyuncs.com". Defaults to the
+	// ALIBABA_CLOUD_ACR_INSTANCE_ID environment variable. Optional.
+	DefaultInstanceID string `json:"defaultInstanceID,omitempty"`
+
+	// InstanceIDs maps ACR instance names to instance IDs. Instances not
+	// listed are looked up by the instance name in the registry hostname.
+	// Optional.
+	InstanceIDs map[string]string `json:"instanceIDs,omitempty"`
+
+	// RoleARN is the RAM role assumed with RRSA. Defaults to the
+	// ALIBABA_CLOUD_ROLE_ARN environment variable. Optional.
+	RoleARN string `json:"roleARN,omitempty"`
+
+	// OIDCProviderARN is the ARN of the RRSA OIDC provider. Defaults to the
+	// ALIBABA_CLOUD_OIDC_PROVIDER_ARN environment variable. Optional.
+	OIDCProviderARN string `json:"oidcProviderARN,omitempty"`
+
+	// OIDCTokenFile is the path of the projected service account token used
+	// by RRSA. Defaults to the ALIBABA_CLOUD_OIDC_TOKEN_FILE environment
+	// variable. Optional.
+	OIDCTokenFile string `json:"oidcTokenFile,omitempty"`
+
+	// Endpoint overrides the ACR API endpoint of all regions, e.g. a VPC
+	// endpoint. It may contain a scheme, defaulting to https. Optional.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// CacheOptions configures the credential cache of the provider.
+	credentialprovider.CacheOptions
+}
+
+// ACRProvider is an implementation of
+// [credentialprovider.CredentialSourceProvider] that retrieves temporary
+// credentials for Alibaba Cloud ACR Enterprise Edition instances.
+type ACRProvider struct {
+	credential        credentials.Credential
+	defaultInstanceID string
+	instanceIDs       map[string]string
+	protocol          string
+	endpoint          string
+
+	mu                  sync.Mutex
+	clients             map[string]acrAPI
+	discoveredInstances map[string]string
+}
+
+// acrAPI is the subset of the ACR API used by the provider.
+type acrAPI interface {
+	GetAuthorizationTokenWithOptions(request *cr20181201.GetAuthorizationTokenRequest, runtime *util.RuntimeOptions) (*cr20181201.GetAuthorizationTokenResponse, error)
+	ListInstanceWithOptions(request *cr20181201.ListInstanceRequest, runtime *util.RuntimeOptions) (*cr20181201.ListInstanceResponse, error)
+}
+
+func init() {
+	// Register the Alibaba Cloud ACR credential provider factory
+	credentialprovider.RegisterCredentialProviderFactory(providerName, createACRProvider)
+}
+
+// createACRProvider creates a new Alibaba Cloud ACR credential provider from
+// CredentialProviderOptions.
+func createACRProvider(opts credentialprovider.Options) (ratify.RegistryCredentialGetter, error) {
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var acrOpts ACRProviderOptions
+	if err := json.Unmarshal(raw, &acrOpts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+
+	provider, err := NewACRProvider(acrOpts)
+	if err != nil {
+		return nil, err
+	}
+	return credentialprovider.NewCachedProviderWithOptions(providerName, provider, acrOpts.CacheOptions)
+}
+
+// NewACRProvider creates a new Alibaba Cloud ACR credential provider. RRSA
+// is used if it is configured by the options or the environment, otherwise
+// the default Alibaba Cloud credential chain is used.
+func NewACRProvider(opts ACRProviderOptions) (*ACRProvider, error) {
+	for name, id := range opts.InstanceIDs {
+		if name == "" || id == "" {
+			return nil, fmt.Errorf("instance name and instance ID cannot be empty")
+		}
+	}
+	credential, err := newCredential(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Alibaba Cloud credential: %w", err)
+	}
+
+	p := &ACRProvider{
+		credential:          credential,
+		defaultInstanceID:   opts.DefaultInstanceID,
+		instanceIDs:         opts.InstanceIDs,
+		protocol:            "https",
+		clients:             make(map[string]acrAPI),
+		discoveredInstances: make(map[string]string),
+	}
+	if p.defaultInstanceID == "" {
+		p.defaultInstanceID = os.Getenv(EnvInstanceID)
+	}
+	if opts.Endpoint != "" {
+		if strings.Contains(opts.Endpoint, "://") {
+			endpoint, err := url.Parse(opts.Endpoint)
+			if err != nil {
+				return nil, fmt.Errorf("invalid endpoint %s: %w", opts.Endpoint, err)
+			}
+			p.protocol = endpoint.Scheme
+			p.endpoint = endpoint.Host
+		} else {
+			p.endpoint = opts.Endpoint
+		}
+	}
+	return p, nil
+}
+
+// newCredential returns the RRSA credential if RRSA is configured, otherwise
+// the default credential chain.
+func newCredential(opts ACRProviderOptions) (credentials.Credential, error) {
+	roleARN := valueOrEnv(opts.RoleARN, EnvRoleArn)
+	oidcProviderARN := valueOrEnv(opts.OIDCProviderARN, EnvOidcProviderArn)
+	oidcTokenFile := valueOrEnv(opts.OIDCTokenFile, EnvOidcTokenFile)
+	if roleARN == "" && oidcProviderARN == "" && oidcTokenFile == "" {
+		return credentials.NewCredential(nil)
+	}
+	if roleARN == "" || oidcProviderARN == "" || oidcTokenFile == "" {
+		return nil, fmt.Errorf("roleARN, oidcProviderARN and oidcTokenFile are all required for RRSA")
+	}
+	return credentials.NewCredential(new(credentials.Config).
+		SetType("oidc_role_arn").
+		SetRoleArn(roleARN).
+		SetOIDCProviderArn(oidcProviderARN).
+		SetOIDCTokenFilePath(oidcTokenFile).
+		SetRoleSessionName(roleSessionName))
+}
+
+// GetWithTTL implements credentialprovider.CredentialSourceProvider interface.
+// It requests a temporary ACR authorization token for the instance serving the
+// registry.
+func (p *ACRProvider) GetWithTTL(_ context.Context, serverAddress string) (credentialprovider.CredentialWithTTL, error) {
+	instanceName, region, err := parseRegistryHost(serverAddress)
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, err
+	}
+	client, err := p.client(region)
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, err
+	}
+	instanceID, err := p.instanceID(client, region, instanceName)
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, err
+	}
+
+	response, err := client.GetAuthorizationTokenWithOptions(&cr20181201.GetAuthorizationTokenRequest{
+		InstanceId: tea.String(instanceID),
+	}, &util.RuntimeOptions{})
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("failed to get ACR authorization token for %s: %w", serverAddress, err)
+	}
+	body := response.Body
+	if body == nil || !tea.BoolValue(body.IsSuccess) {
+		code := ""
+		if body != nil {
+			code = tea.StringValue(body.Code)
+		}
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("failed to get ACR authorization token for %s: code %q", serverAddress, code)
+	}
+	if tea.StringValue(body.TempUsername) == "" || tea.StringValue(body.AuthorizationToken) == "" {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("received empty ACR authorization token for %s", serverAddress)
+	}
+
+	ttl := DefaultACRTokenTTL
+	if body.ExpireTime != nil {
+		ttl = time.Until(time.UnixMilli(tea.Int64Value(body.ExpireTime))) - tokenExpiryBuffer
+		if ttl < 0 {
+			ttl = 0
+		}
+	}
+
+	return credentialprovider.CredentialWithTTL{
+		Credential: ratify.RegistryCredential{
+			Username: tea.StringValue(body.TempUsername),
+			Password: tea.StringValue(body.AuthorizationToken),
+		},
+		TTL: ttl,
+	}, nil
+}
+
+// client returns the ACR API client of the region.
+func (p *ACRProvider) client(region string) (acrAPI, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if client, ok := p.clients[region]; ok {
+		return client, nil
+	}
+
+	endpoint := p.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf(acrEndpoint, region)
+	}
+	client, err := cr20181201.NewClient(&openapi.Config{
+		Credential: p.credential,
+		Endpoint:   tea.String(endpoint),
+		Protocol:   tea.String(p.protocol),
+		RegionId:   tea.String(region),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ACR client: %w", err)
+	}
+	p.clients[region] = client
+	return client, nil
+}
+
+// instanceID returns the ID of the ACR instance with the given name. The ID
+// is taken from the configuration, or looked up by the instance name and
+// cached. The default instance ID is only used for hosts without instance
+// name, so that a token is never requested for another instance.
+func (p *ACRProvider) instanceID(client acrAPI, region, instanceName string) (string, error) {
+	if instanceName == "" {
+		if p.defaultInstanceID == "" {
+			return "", fmt.Errorf("no instance ID configured for registries in region %s", region)
+		}
+		return p.defaultInstanceID, nil
+	}
+	if id, ok := p.instanceIDs[instanceName]; ok {
+		return id, nil
+	}
+
+	key := region + "/" + instanceName
+	p.mu.Lock()
+	id, ok := p.discoveredInstances[key]
+	p.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	response, err := client.ListInstanceWithOptions(&cr20181201.ListInstanceRequest{
+		InstanceName: tea.String(instanceName),
+		PageSize:     tea.Int32(100),
+	}, &util.RuntimeOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up ACR instance %s in region %s: %w", instanceName, region, err)
+	}
+	if response.Body != nil {
+		for _, instance := range response.Body.Instances {
+			if instance != nil && tea.StringValue(instance.InstanceName) == instanceName && tea.StringValue(instance.InstanceId) != "" {
+				id = tea.StringValue(instance.InstanceId)
+				p.mu.Lock()
+				p.discoveredInstances[key] = id
+				p.mu.Unlock()
+				return id, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("ACR instance %s not found in region %s", instanceName, region)
+}
+
+// parseRegistryHost returns the instance name and the region of an ACR
+// registry host. The instance name is empty if the host does not contain it.
+func parseRegistryHost(serverAddress string) (string, string, error) {
+	host := strings.ToLower(serverAddress)
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	matches := registryHostPattern.FindStringSubmatch(host)
+	if matches == nil {
+		return "", "", fmt.Errorf("%s is not an Alibaba Cloud ACR registry", serverAddress)
+	}
+	return matches[1], matches[2], nil
+}
+
+// valueOrEnv returns value if it is set, otherwise the environment variable.
+func valueOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
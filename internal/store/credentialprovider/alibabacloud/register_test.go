@@ -0,0 +1,344 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+)
+
+// fakeACRAPI is a local stand-in for the ACR GetAuthorizationToken and
+// ListInstance APIs.
+type fakeACRAPI struct {
+	server    *httptest.Server
+	instances map[string]string
+	expireAt  time.Time
+
+	mu               sync.Mutex
+	listCalls        int
+	tokenInstanceIDs []string
+}
+
+func newFakeACRAPI(t *testing.T) *fakeACRAPI {
+	t.Helper()
+	api := &fakeACRAPI{
+		instances: map[string]string{"myinstance": "cri-discovered"},
+		expireAt:  time.Now().Add(time.Hour),
+	}
+	api.server = httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(api.server.Close)
+
+	t.Setenv("ALIBABA_CLOUD_ACCESS_KEY_ID", "test-ak")
+	t.Setenv("ALIBABA_CLOUD_ACCESS_KEY_SECRET", "test-secret")
+	t.Setenv(EnvRoleArn, "")
+	t.Setenv(EnvOidcProviderArn, "")
+	t.Setenv(EnvOidcTokenFile, "")
+	t.Setenv(EnvInstanceID, "")
+	return api
+}
+
+func (api *fakeACRAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	switch r.Header.Get("x-acs-action") {
+	case "ListInstance":
+		api.listCalls++
+		var instances []map[string]string
+		name := r.Form.Get("InstanceName")
+		if name == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]any{"Code": "InternalError", "Message": "service unavailable"})
+			return
+		}
+		if id, ok := api.instances[name]; ok {
+			instances = append(instances, map[string]string{"InstanceName": name, "InstanceId": id})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"IsSuccess": true,
+			"Code":      "success",
+			"Instances": instances,
+		})
+	case "GetAuthorizationToken":
+		instanceID := r.Form.Get("InstanceId")
+		api.tokenInstanceIDs = append(api.tokenInstanceIDs, instanceID)
+		if instanceID == "cri-unknown" {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"IsSuccess": false,
+				"Code":      "INSTANCE_NOT_EXIST",
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"IsSuccess":          true,
+			"Code":               "success",
+			"TempUsername":       "cr_temp_user",
+			"AuthorizationToken": "token-" + instanceID,
+			"ExpireTime":         api.expireAt.UnixMilli(),
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (api *fakeACRAPI) lastInstanceID() string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.tokenInstanceIDs[len(api.tokenInstanceIDs)-1]
+}
+
+func TestParseRegistryHost(t *testing.T) {
+	tests := []struct {
+		host           string
+		expectInstance string
+		expectRegion   string
+		expectErr      bool
+	}{
+		{host: "myinstance-registry.cn-hangzhou.cr.aliyuncs.com", expectInstance: "myinstance", expectRegion: "cn-hangzhou"},
+		{host: "myinstance-registry-vpc.cn-shanghai.cr.aliyuncs.com", expectInstance: "myinstance", expectRegion: "cn-shanghai"},
+		{host: "my-instance-registry-intl.ap-southeast-1.cr.aliyuncs.com:443", expectInstance: "my-instance", expectRegion: "ap-southeast-1"},
+		{host: "registry.cn-beijing.aliyuncs.com", expectRegion: "cn-beijing"},
+		{host: "myregistry.azurecr.io", expectErr: true},
+		{host: "myinstance-registry.cn-hangzhou.cr.aliyuncs.com.evil.com", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			instance, region, err := parseRegistryHost(tt.host)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if instance != tt.expectInstance || region != tt.expectRegion {
+				t.Fatalf("expected %s/%s, got %s/%s", tt.expectInstance, tt.expectRegion, instance, region)
+			}
+		})
+	}
+}
+
+func TestACRProvider_GetWithTTL(t *testing.T) {
+	api := newFakeACRAPI(t)
+	provider, err := NewACRProvider(ACRProviderOptions{
+		Endpoint:          api.server.URL,
+		DefaultInstanceID: "cri-default",
+		InstanceIDs:       map[string]string{"configured": "cri-configured"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		serverAddress    string
+		expectInstanceID string
+		expectErr        bool
+	}{
+		{
+			name:             "configured instance",
+			serverAddress:    "configured-registry.cn-hangzhou.cr.aliyuncs.com",
+			expectInstanceID: "cri-configured",
+		},
+		{
+			name:             "discovered instance",
+			serverAddress:    "myinstance-registry-vpc.cn-hangzhou.cr.aliyuncs.com",
+			expectInstanceID: "cri-discovered",
+		},
+		{
+			name:          "unknown instance does not fall back to default",
+			serverAddress: "other-registry.cn-hangzhou.cr.aliyuncs.com",
+			expectErr:     true,
+		},
+		{
+			name:          "instance lookup error does not fall back to default",
+			serverAddress: "broken-registry.cn-hangzhou.cr.aliyuncs.com",
+			expectErr:     true,
+		},
+		{
+			name:             "host without instance name",
+			serverAddress:    "registry.cn-hangzhou.aliyuncs.com",
+			expectInstanceID: "cri-default",
+		},
+		{
+			name:          "not an ACR registry",
+			serverAddress: "docker.io",
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := provider.GetWithTTL(context.Background(), tt.serverAddress)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if got := api.lastInstanceID(); got != tt.expectInstanceID {
+				t.Fatalf("expected instance ID %s, got %s", tt.expectInstanceID, got)
+			}
+			if cred.Credential.Username != "cr_temp_user" || cred.Credential.Password != "token-"+tt.expectInstanceID {
+				t.Fatalf("unexpected credential %+v", cred.Credential)
+			}
+			if cred.TTL <= 50*time.Minute || cred.TTL > time.Hour-tokenExpiryBuffer {
+				t.Fatalf("unexpected TTL %v", cred.TTL)
+			}
+		})
+	}
+
+	// Discovered instance IDs are cached.
+	if _, err := provider.GetWithTTL(context.Background(), "myinstance-registry.cn-hangzhou.cr.aliyuncs.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	api.mu.Lock()
+	listCalls := api.listCalls
+	api.mu.Unlock()
+	if listCalls != 3 {
+		t.Fatalf("expected 3 instance lookups, got %d", listCalls)
+	}
+}
+
+func TestACRProvider_GetWithTTL_Errors(t *testing.T) {
+	api := newFakeACRAPI(t)
+	provider, err := NewACRProvider(ACRProviderOptions{
+		Endpoint:    api.server.URL,
+		InstanceIDs: map[string]string{"missing": "cri-unknown"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, serverAddress := range []string{
+		"registry.cn-hangzhou.aliyuncs.com",
+		"notfound-registry.cn-hangzhou.cr.aliyuncs.com",
+		"missing-registry.cn-hangzhou.cr.aliyuncs.com",
+	} {
+		if _, err := provider.GetWithTTL(context.Background(), serverAddress); err == nil {
+			t.Errorf("expected error for %s", serverAddress)
+		}
+	}
+
+	api.expireAt = time.Now().Add(time.Minute)
+	cred, err := provider.GetWithTTL(context.Background(), "myinstance-registry.cn-hangzhou.cr.aliyuncs.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.TTL != 0 {
+		t.Fatalf("expected token close to expiry not to be cached, got TTL %v", cred.TTL)
+	}
+}
+
+func TestNewACRProvider(t *testing.T) {
+	newFakeACRAPI(t)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("oidc-token"), 0600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		opts      ACRProviderOptions
+		env       map[string]string
+		expectErr bool
+	}{
+		{
+			name: "default credential chain",
+			opts: ACRProviderOptions{},
+		},
+		{
+			name: "RRSA options",
+			opts: ACRProviderOptions{
+				RoleARN:         "acs:ram::123456789:role/ratify",
+				OIDCProviderARN: "acs:ram::123456789:oidc-provider/ack",
+				OIDCTokenFile:   tokenFile,
+			},
+		},
+		{
+			name: "RRSA environment",
+			env: map[string]string{
+				EnvRoleArn:         "acs:ram::123456789:role/ratify",
+				EnvOidcProviderArn: "acs:ram::123456789:oidc-provider/ack",
+				EnvOidcTokenFile:   tokenFile,
+			},
+		},
+		{
+			name:      "incomplete RRSA options",
+			opts:      ACRProviderOptions{RoleARN: "acs:ram::123456789:role/ratify"},
+			expectErr: true,
+		},
+		{
+			name:      "empty instance ID",
+			opts:      ACRProviderOptions{InstanceIDs: map[string]string{"myinstance": ""}},
+			expectErr: true,
+		},
+		{
+			name:      "invalid endpoint",
+			opts:      ACRProviderOptions{Endpoint: "http://[::1"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			_, err := NewACRProvider(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestCreateACRProvider(t *testing.T) {
+	api := newFakeACRAPI(t)
+	t.Setenv(EnvInstanceID, "cri-env")
+
+	provider, err := credentialprovider.NewCredentialProvider(credentialprovider.Options{
+		"provider": providerName,
+		"endpoint": api.server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cred, err := provider.Get(context.Background(), "registry.cn-hangzhou.aliyuncs.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.Password != "token-cri-env" {
+		t.Fatalf("expected token of the instance from the environment, got %s", cred.Password)
+	}
+
+	if _, err = credentialprovider.NewCredentialProvider(credentialprovider.Options{
+		"provider":    providerName,
+		"instanceIDs": "invalid",
+	}); err == nil {
+		t.Fatal("expected error for invalid options")
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+)
+
+const (
+	// providerName is the name of the AWS ECR credential provider.
+	providerName = "aws"
+
+	// roleSessionName is the session name used when assuming IAM roles.
+	roleSessionName = "ratifyEcrBasicAuth"
+
+	// tokenExpiryBuffer is subtracted from the expiry of ECR authorization
+	// tokens so that they are refreshed before they expire.
+	tokenExpiryBuffer = 5 * time.Minute
+
+	// DefaultECRTokenTTL is the TTL used if ECR does not return the expiry of
+	// an authorization token. ECR tokens are valid for 12 hours.
+	DefaultECRTokenTTL = 12*time.Hour - tokenExpiryBuffer
+)
+
+// ecrHostPattern matches private ECR registry hosts and captures the account
+// ID and the region, e.g. 123456789012.dkr.ecr.us-west-2.amazonaws.com.
+var ecrHostPattern = regexp.MustCompile(`^(\d{12})\.dkr[\.\-]ecr(?:-fips)?\.([a-zA-Z0-9][a-zA-Z0-9-_]*)\.(?:amazonaws\.com(?:\.cn)?|on\.(?:aws|amazonwebservices\.com\.cn)|sc2s\.sgov\.gov|c2s\.ic\.gov|cloud\.adc-e\.uk|csp\.hci\.ic\.gov)$`)
+
+// ECRProviderOptions contains configuration options for the AWS ECR
+// credential provider.
+type ECRProviderOptions struct {
+	// RoleARN is the IAM role assumed to request ECR authorization tokens. If
+	// not set, the credentials from the default AWS credential chain (e.g.
+	// IRSA, EKS Pod Identity or the node role) are used. Optional.
+	RoleARN string `json:"roleARN,omitempty"`
+
+	// AccountRoles maps AWS account IDs to the IAM role assumed for the ECR
+	// registries of that account, overriding RoleARN. Optional.
+	AccountRoles map[string]string `json:"accountRoles,omitempty"`
+
+	// Endpoint overrides the ECR API endpoint, e.g. a VPC endpoint. Optional.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// CacheOptions configures the credential cache of the provider.
+	credentialprovider.CacheOptions
+}
+
+// ECRProvider is an implementation of
+// [credentialprovider.CredentialSourceProvider] that retrieves credentials for
+// private Amazon ECR registries. The region and the account are detected from
+// the registry host, so that a single provider serves registries across
+// regions and accounts.
+type ECRProvider struct {
+	config       awssdk.Config
+	roleARN      string
+	accountRoles map[string]string
+	endpoint     string
+
+	mu      sync.Mutex
+	clients map[ecrClientKey]ecrAPI
+}
+
+// ecrAPI is the subset of the ECR API used by the provider.
+type ecrAPI interface {
+	GetAuthorizationToken(ctx context.Context, params *ecr.GetAuthorizationTokenInput, optFns ...func(*ecr.Options)) (*ecr.GetAuthorizationTokenOutput, error)
+}
+
+// ecrClientKey identifies the ECR client of a region and an assumed role.
+type ecrClientKey struct {
+	region  string
+	roleARN string
+}
+
+func init() {
+	// Register the AWS ECR credential provider factory
+	credentialprovider.RegisterCredentialProviderFactory(providerName, createECRProvider)
+}
+
+// createECRProvider creates a new AWS ECR credential provider from
+// CredentialProviderOptions.
+func createECRProvider(opts credentialprovider.Options) (ratify.RegistryCredentialGetter, error) {
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var ecrOpts ECRProviderOptions
+	if err := json.Unmarshal(raw, &ecrOpts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+
+	provider, err := NewECRProvider(context.Background(), ecrOpts)
+	if err != nil {
+		return nil, err
+	}
+	return credentialprovider.NewCachedProviderWithOptions(providerName, provider, ecrOpts.CacheOptions)
+}
+
+// NewECRProvider creates a new AWS ECR credential provider using the default
+// AWS configuration.
+func NewECRProvider(ctx context.Context, opts ECRProviderOptions) (*ECRProvider, error) {
+	for accountID, roleARN := range opts.AccountRoles {
+		if roleARN == "" {
+			return nil, fmt.Errorf("role ARN of account %s cannot be empty", accountID)
+		}
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithWebIdentityRoleCredentialOptions(func(options *stscreds.WebIdentityRoleOptions) {
+		options.RoleSessionName = roleSessionName
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default AWS config: %w", err)
+	}
+	return &ECRProvider{
+		config:       cfg,
+		roleARN:      opts.RoleARN,
+		accountRoles: opts.AccountRoles,
+		endpoint:     opts.Endpoint,
+		clients:      make(map[ecrClientKey]ecrAPI),
+	}, nil
+}
+
+// GetWithTTL implements credentialprovider.CredentialSourceProvider interface.
+// It requests an ECR authorization token in the region of the registry.
+func (p *ECRProvider) GetWithTTL(ctx context.Context, serverAddress string) (credentialprovider.CredentialWithTTL, error) {
+	accountID, region, err := parseECRHost(serverAddress)
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, err
+	}
+	roleARN := p.roleARN
+	if accountRole, ok := p.accountRoles[accountID]; ok {
+		roleARN = accountRole
+	}
+
+	output, err := p.client(region, roleARN).GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("failed to get ECR authorization token for %s: %w", serverAddress, err)
+	}
+	if len(output.AuthorizationData) == 0 || output.AuthorizationData[0].AuthorizationToken == nil {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("received empty ECR authorization token for %s", serverAddress)
+	}
+	authData := output.AuthorizationData[0]
+
+	username, password, err := decodeAuthorizationToken(*authData.AuthorizationToken)
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, err
+	}
+
+	ttl := DefaultECRTokenTTL
+	if authData.ExpiresAt != nil {
+		ttl = time.Until(*authData.ExpiresAt) - tokenExpiryBuffer
+		if ttl < 0 {
+			ttl = 0
+		}
+	}
+
+	return credentialprovider.CredentialWithTTL{
+		Credential: ratify.RegistryCredential{
+			Username: username,
+			Password: password,
+		},
+		TTL: ttl,
+	}, nil
+}
+
+// client returns the ECR client of the region, assuming roleARN if set.
+// Clients are reused so that the credentials of assumed roles are cached.
+func (p *ECRProvider) client(region, roleARN string) ecrAPI {
+	key := ecrClientKey{region: region, roleARN: roleARN}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if client, ok := p.clients[key]; ok {
+		return client
+	}
+
+	cfg := p.config.Copy()
+	cfg.Region = region
+	if roleARN != "" {
+		cfg.Credentials = awssdk.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(options *stscreds.AssumeRoleOptions) {
+			options.RoleSessionName = roleSessionName
+		}))
+	}
+	client := ecr.NewFromConfig(cfg, func(options *ecr.Options) {
+		if p.endpoint != "" {
+			options.BaseEndpoint = awssdk.String(p.endpoint)
+		}
+	})
+	p.clients[key] = client
+	return client
+}
+
+// parseECRHost returns the account ID and the region of a private ECR
+// registry host.
+func parseECRHost(serverAddress string) (string, string, error) {
+	host := strings.ToLower(serverAddress)
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	matches := ecrHostPattern.FindStringSubmatch(host)
+	if matches == nil {
+		return "", "", fmt.Errorf("%s is not a private Amazon ECR registry", serverAddress)
+	}
+	return matches[1], matches[2], nil
+}
+
+// decodeAuthorizationToken decodes an ECR authorization token into the
+// username and password.
+func decodeAuthorizationToken(token string) (string, string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode ECR authorization token: %w", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok || username == "" || password == "" {
+		return "", "", fmt.Errorf("invalid ECR authorization token format")
+	}
+	return username, password, nil
+}
@@ -0,0 +1,321 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+)
+
+const testRoleARN = "arn:aws:iam::210987654321:role/ecr-reader"
+
+// ecrRequest records a request received by the fake AWS API.
+type ecrRequest struct {
+	region      string
+	accessKeyID string
+}
+
+// fakeAWSAPI is a local stand-in for the ECR GetAuthorizationToken API and
+// the STS AssumeRole API.
+type fakeAWSAPI struct {
+	server    *httptest.Server
+	expiresAt time.Time
+	status    int
+
+	mu       sync.Mutex
+	requests []ecrRequest
+}
+
+var credentialScopePattern = regexp.MustCompile(`Credential=([^/]+)/\d{8}/([^/]+)/([^/]+)/aws4_request`)
+
+func newFakeAWSAPI(t *testing.T) *fakeAWSAPI {
+	t.Helper()
+	api := &fakeAWSAPI{
+		expiresAt: time.Now().Add(12 * time.Hour),
+		status:    http.StatusOK,
+	}
+	api.server = httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(api.server.Close)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEFAULT")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_ENDPOINT_URL_STS", api.server.URL)
+	t.Setenv("AWS_MAX_ATTEMPTS", "1")
+	return api
+}
+
+func (api *fakeAWSAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("Action") == "AssumeRole" {
+		if r.Form.Get("RoleArn") != testRoleARN {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>AKIDROLE</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>%s</Arn>
+      <AssumedRoleId>id:%s</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</AssumeRoleResponse>`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339), testRoleARN, roleSessionName)
+		return
+	}
+
+	if r.Header.Get("X-Amz-Target") != "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	matches := credentialScopePattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if matches == nil || matches[3] != "ecr" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	api.mu.Lock()
+	api.requests = append(api.requests, ecrRequest{accessKeyID: matches[1], region: matches[2]})
+	api.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	if api.status != http.StatusOK {
+		w.WriteHeader(api.status)
+		fmt.Fprint(w, `{"__type":"ServerException","message":"internal error"}`)
+		return
+	}
+	token := base64.StdEncoding.EncodeToString([]byte("AWS:password-" + matches[2]))
+	fmt.Fprintf(w, `{"authorizationData":[{"authorizationToken":%q,"expiresAt":%d,"proxyEndpoint":"https://123456789012.dkr.ecr.%s.amazonaws.com"}]}`,
+		token, api.expiresAt.Unix(), matches[2])
+}
+
+func TestParseECRHost(t *testing.T) {
+	tests := []struct {
+		host            string
+		expectAccountID string
+		expectRegion    string
+		expectErr       bool
+	}{
+		{host: "123456789012.dkr.ecr.us-west-2.amazonaws.com", expectAccountID: "123456789012", expectRegion: "us-west-2"},
+		{host: "123456789012.dkr.ecr.us-west-2.amazonaws.com:443", expectAccountID: "123456789012", expectRegion: "us-west-2"},
+		{host: "123456789012.dkr.ecr-fips.us-gov-west-1.amazonaws.com", expectAccountID: "123456789012", expectRegion: "us-gov-west-1"},
+		{host: "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn", expectAccountID: "123456789012", expectRegion: "cn-north-1"},
+		{host: "123456789012.dkr-ecr.eu-central-1.on.aws", expectAccountID: "123456789012", expectRegion: "eu-central-1"},
+		{host: "public.ecr.aws", expectErr: true},
+		{host: "myregistry.azurecr.io", expectErr: true},
+		{host: "1234.dkr.ecr.us-west-2.amazonaws.com", expectErr: true},
+		{host: "123456789012.dkr.ecr.us-west-2.amazonaws.com.evil.com", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			accountID, region, err := parseECRHost(tt.host)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if accountID != tt.expectAccountID || region != tt.expectRegion {
+				t.Fatalf("expected %s/%s, got %s/%s", tt.expectAccountID, tt.expectRegion, accountID, region)
+			}
+		})
+	}
+}
+
+func TestDecodeAuthorizationToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		expectErr bool
+	}{
+		{name: "valid token", token: base64.StdEncoding.EncodeToString([]byte("AWS:password"))},
+		{name: "invalid base64", token: "not-base64!", expectErr: true},
+		{name: "missing separator", token: base64.StdEncoding.EncodeToString([]byte("AWS")), expectErr: true},
+		{name: "empty password", token: base64.StdEncoding.EncodeToString([]byte("AWS:")), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username, password, err := decodeAuthorizationToken(tt.token)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && (username != "AWS" || password != "password") {
+				t.Fatalf("unexpected credentials %s/%s", username, password)
+			}
+		})
+	}
+}
+
+func TestECRProvider_GetWithTTL(t *testing.T) {
+	api := newFakeAWSAPI(t)
+	provider, err := NewECRProvider(context.Background(), ECRProviderOptions{
+		Endpoint: api.server.URL,
+		AccountRoles: map[string]string{
+			"210987654321": testRoleARN,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		serverAddress     string
+		expectRegion      string
+		expectAccessKeyID string
+	}{
+		{
+			name:              "default credentials",
+			serverAddress:     "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			expectRegion:      "us-west-2",
+			expectAccessKeyID: "AKIDEFAULT",
+		},
+		{
+			name:              "other region",
+			serverAddress:     "123456789012.dkr.ecr.eu-west-1.amazonaws.com",
+			expectRegion:      "eu-west-1",
+			expectAccessKeyID: "AKIDEFAULT",
+		},
+		{
+			name:              "account with assumed role",
+			serverAddress:     "210987654321.dkr.ecr.ap-southeast-2.amazonaws.com",
+			expectRegion:      "ap-southeast-2",
+			expectAccessKeyID: "AKIDROLE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := provider.GetWithTTL(context.Background(), tt.serverAddress)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cred.Credential.Username != "AWS" || cred.Credential.Password != "password-"+tt.expectRegion {
+				t.Fatalf("unexpected credential %+v", cred.Credential)
+			}
+			if cred.TTL <= 11*time.Hour || cred.TTL > 12*time.Hour-tokenExpiryBuffer {
+				t.Fatalf("unexpected TTL %v", cred.TTL)
+			}
+			api.mu.Lock()
+			last := api.requests[len(api.requests)-1]
+			api.mu.Unlock()
+			if last.region != tt.expectRegion || last.accessKeyID != tt.expectAccessKeyID {
+				t.Fatalf("expected request signed for %s by %s, got %+v", tt.expectRegion, tt.expectAccessKeyID, last)
+			}
+		})
+	}
+}
+
+func TestECRProvider_GetWithTTL_Errors(t *testing.T) {
+	api := newFakeAWSAPI(t)
+	provider, err := NewECRProvider(context.Background(), ECRProviderOptions{Endpoint: api.server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := provider.GetWithTTL(context.Background(), "docker.io"); err == nil {
+		t.Fatal("expected error for non-ECR registry")
+	}
+
+	api.expiresAt = time.Now().Add(time.Minute)
+	cred, err := provider.GetWithTTL(context.Background(), "123456789012.dkr.ecr.us-west-2.amazonaws.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.TTL != 0 {
+		t.Fatalf("expected token close to expiry not to be cached, got TTL %v", cred.TTL)
+	}
+
+	api.status = http.StatusInternalServerError
+	if _, err := provider.GetWithTTL(context.Background(), "123456789012.dkr.ecr.us-west-2.amazonaws.com"); err == nil || !strings.Contains(err.Error(), "failed to get ECR authorization token") {
+		t.Fatalf("expected API error, got %v", err)
+	}
+}
+
+func TestCreateECRProvider(t *testing.T) {
+	api := newFakeAWSAPI(t)
+	tests := []struct {
+		name      string
+		opts      credentialprovider.Options
+		expectErr bool
+	}{
+		{
+			name: "valid options",
+			opts: credentialprovider.Options{
+				"provider": providerName,
+				"endpoint": api.server.URL,
+				"roleARN":  testRoleARN,
+			},
+		},
+		{
+			name: "empty account role",
+			opts: credentialprovider.Options{
+				"provider":     providerName,
+				"accountRoles": map[string]string{"123456789012": ""},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid options",
+			opts: credentialprovider.Options{
+				"provider":     providerName,
+				"accountRoles": "invalid",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := credentialprovider.NewCredentialProvider(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+
+			cred, err := provider.Get(context.Background(), "123456789012.dkr.ecr.us-west-2.amazonaws.com")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cred.Username != "AWS" || cred.Password != "password-us-west-2" {
+				t.Fatalf("unexpected credential %+v", cred)
+			}
+		})
+	}
+}
@@ -57,6 +57,9 @@ type IdentityProviderOptions struct {
 	ClientID string `json:"clientID,omitempty"`
 	// TenantID is the Azure AD tenant ID where the application is registered
 	TenantID string `json:"tenantID,omitempty"`
+
+	// CacheOptions configures the credential cache of the provider.
+	credentialprovider.CacheOptions
 }
 
 func init() {
@@ -85,7 +88,7 @@ func createAzureIdentityProvider(opts credentialprovider.Options) (ratify.Regist
 	}
 
 	// Wrap with caching provider
-	return credentialprovider.NewCachedProvider(azureProvider)
+	return credentialprovider.NewCachedProviderWithOptions("azure", azureProvider, azureOpts.CacheOptions)
 }
 
 // GetWithTTL implements credentialprovider.CredentialSourceProvider interface.
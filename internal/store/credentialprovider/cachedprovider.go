@@ -17,11 +17,34 @@ package credentialprovider
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/notaryproject/ratify-go"
 	"github.com/notaryproject/ratify/v2/internal/cache"
 	"github.com/notaryproject/ratify/v2/internal/cache/inmemory"
+	"github.com/notaryproject/ratify/v2/pkg/metrics"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultCacheSize is the default maximum number of registries whose
+	// credentials are cached by a CachedProvider.
+	DefaultCacheSize = 10
+
+	// DefaultRefreshRatio is the default fraction of the credential TTL after
+	// which a cached credential is refreshed in the background.
+	DefaultRefreshRatio = 0.8
+
+	// fetchTimeout bounds credential fetches that are detached from the
+	// context of the caller, i.e. shared and background fetches.
+	fetchTimeout = time.Minute
+
+	// refreshRetryInterval is the delay before a failed background refresh is
+	// retried.
+	refreshRetryInterval = 30 * time.Second
 )
 
 // CredentialWithTTL represents a credential response with its expiration time.
@@ -37,45 +60,166 @@ type CredentialSourceProvider interface {
 	GetWithTTL(ctx context.Context, serverAddress string) (CredentialWithTTL, error)
 }
 
+// CacheOptions configures the credential cache of a CachedProvider. It is
+// embedded into the options of the credential providers wrapped by a
+// CachedProvider.
+type CacheOptions struct {
+	// CacheSize is the maximum number of registries whose credentials are
+	// cached. Defaults to DefaultCacheSize. Optional.
+	CacheSize int `json:"cacheSize,omitempty"`
+
+	// RefreshRatio is the fraction of the credential TTL after which the
+	// cached credential is refreshed in the background, so that requests do
+	// not wait for the credential exchange when it expires. Must be in the
+	// range (0, 1]; 1 disables background refresh. Defaults to
+	// DefaultRefreshRatio. Optional.
+	RefreshRatio float64 `json:"refreshRatio,omitempty"`
+}
+
+// cachedCredential is a cached credential with the time it becomes due for a
+// background refresh.
+type cachedCredential struct {
+	credential ratify.RegistryCredential
+	refreshAt  time.Time
+	expiresAt  time.Time
+}
+
 // CachedProvider wraps a CredentialSourceProvider and provides caching functionality.
 // It implements the ratify.RegistryCredentialGetter interface.
+//
+// Concurrent cache misses of the same server address share a single fetch from
+// the source provider, and cached credentials are refreshed in the background
+// before they expire.
 type CachedProvider struct {
-	source CredentialSourceProvider
-	cache  cache.Cache[ratify.RegistryCredential]
+	name         string
+	source       CredentialSourceProvider
+	cache        cache.Cache[cachedCredential]
+	refreshRatio float64
+	fetchGroup   singleflight.Group
+
+	mu         sync.Mutex
+	refreshing map[string]struct{}
 }
 
-// NewCachedProvider creates a new cached credential provider that wraps the given source provider.
+// NewCachedProvider creates a new cached credential provider that wraps the
+// given source provider with the default cache options.
 func NewCachedProvider(source CredentialSourceProvider) (*CachedProvider, error) {
-	cache, err := inmemory.NewCache[ratify.RegistryCredential](10)
+	return NewCachedProviderWithOptions("", source, CacheOptions{})
+}
+
+// NewCachedProviderWithOptions creates a new cached credential provider that
+// wraps the given source provider. The name identifies the provider in
+// metrics.
+func NewCachedProviderWithOptions(name string, source CredentialSourceProvider, opts CacheOptions) (*CachedProvider, error) {
+	cacheSize := opts.CacheSize
+	switch {
+	case cacheSize < 0:
+		return nil, fmt.Errorf("cacheSize must not be negative, got %d", cacheSize)
+	case cacheSize == 0:
+		cacheSize = DefaultCacheSize
+	}
+	refreshRatio := opts.RefreshRatio
+	switch {
+	case refreshRatio < 0 || refreshRatio > 1:
+		return nil, fmt.Errorf("refreshRatio must be in the range (0, 1], got %v", refreshRatio)
+	case refreshRatio == 0:
+		refreshRatio = DefaultRefreshRatio
+	}
+
+	cache, err := inmemory.NewCache[cachedCredential](cacheSize)
 	if err != nil {
 		return nil, err
 	}
 
 	return &CachedProvider{
-		source: source,
-		cache:  cache,
+		name:         name,
+		source:       source,
+		cache:        cache,
+		refreshRatio: refreshRatio,
+		refreshing:   make(map[string]struct{}),
 	}, nil
 }
 
 // Get implements ratify.RegistryCredentialGetter interface.
 // It returns cached credentials if available and not expired, otherwise fetches
-// new credentials from the source provider and caches them.
+// new credentials from the source provider and caches them. Cached credentials
+// past the refresh point are returned immediately while a background refresh
+// is started.
 func (c *CachedProvider) Get(ctx context.Context, serverAddress string) (ratify.RegistryCredential, error) {
 	// Check if we have a cached credential
-	if credential, err := c.cache.Get(ctx, serverAddress); err == nil {
-		return credential, nil
+	if cached, err := c.cache.Get(ctx, serverAddress); err == nil {
+		if c.refreshRatio < 1 && !time.Now().Before(cached.refreshAt) {
+			c.refresh(ctx, serverAddress, cached)
+		}
+		return cached.credential, nil
+	}
+
+	// Cache miss, fetch new credentials once for all concurrent callers. The
+	// fetch is detached from the context of the first caller so that its
+	// cancellation does not fail the other callers.
+	resultCh := c.fetchGroup.DoChan(serverAddress, func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		return c.fetch(fetchCtx, serverAddress, false)
+	})
+	select {
+	case <-ctx.Done():
+		return ratify.RegistryCredential{}, ctx.Err()
+	case result := <-resultCh:
+		if result.Err != nil {
+			return ratify.RegistryCredential{}, result.Err
+		}
+		return result.Val.(ratify.RegistryCredential), nil
+	}
+}
+
+// refresh fetches the credential of the server address in the background
+// unless a refresh is already in progress. On failure, the cached credential
+// keeps being used until it expires and the refresh is retried after
+// refreshRetryInterval.
+func (c *CachedProvider) refresh(ctx context.Context, serverAddress string, cached cachedCredential) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.refreshing[serverAddress]; ok {
+		return
 	}
+	c.refreshing[serverAddress] = struct{}{}
 
-	// Cache miss, fetch new credentials
+	refreshCtx := context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, serverAddress)
+			c.mu.Unlock()
+		}()
+		fetchCtx, cancel := context.WithTimeout(refreshCtx, fetchTimeout)
+		defer cancel()
+		if _, err := c.fetch(fetchCtx, serverAddress, true); err != nil {
+			logrus.Warnf("failed to refresh credential of registry %s, keep using the cached credential: %v", serverAddress, err)
+			if ttl := time.Until(cached.expiresAt); ttl > 0 {
+				cached.refreshAt = time.Now().Add(refreshRetryInterval)
+				_ = c.cache.Set(refreshCtx, serverAddress, cached, ttl)
+			}
+		}
+	}()
+}
+
+// fetch retrieves the credential from the source provider and caches it.
+func (c *CachedProvider) fetch(ctx context.Context, serverAddress string, background bool) (ratify.RegistryCredential, error) {
 	credWithTTL, err := c.source.GetWithTTL(ctx, serverAddress)
 	if err != nil {
+		metrics.ReportCredentialProviderFailure(ctx, c.name, serverAddress, background)
 		return ratify.RegistryCredential{}, err
 	}
 
 	if credWithTTL.TTL > 0 {
-		defer func() {
-			_ = c.cache.Set(ctx, serverAddress, credWithTTL.Credential, credWithTTL.TTL)
-		}()
+		now := time.Now()
+		cached := cachedCredential{
+			credential: credWithTTL.Credential,
+			refreshAt:  now.Add(time.Duration(float64(credWithTTL.TTL) * c.refreshRatio)),
+			expiresAt:  now.Add(credWithTTL.TTL),
+		}
+		_ = c.cache.Set(ctx, serverAddress, cached, credWithTTL.TTL)
 	}
 	return credWithTTL.Credential, nil
 }
@@ -18,6 +18,8 @@ package credentialprovider
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -346,3 +348,246 @@ func TestCachedProvider_Interface_Compliance(t *testing.T) {
 	// Verify that CachedProvider implements ratify.RegistryCredentialGetter interface
 	var _ ratify.RegistryCredentialGetter = provider
 }
+
+// blockingCredentialSourceProvider is a concurrency-safe source provider that
+// counts calls and blocks each call until release is closed.
+type blockingCredentialSourceProvider struct {
+	calls   atomic.Int32
+	release chan struct{}
+	ttl     time.Duration
+	err     atomic.Pointer[error]
+}
+
+func (b *blockingCredentialSourceProvider) GetWithTTL(ctx context.Context, _ string) (CredentialWithTTL, error) {
+	call := b.calls.Add(1)
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return CredentialWithTTL{}, ctx.Err()
+	}
+	if err := b.err.Load(); err != nil {
+		return CredentialWithTTL{}, *err
+	}
+	return CredentialWithTTL{
+		Credential: ratify.RegistryCredential{Username: "user", Password: string(rune('0' + call))},
+		TTL:        b.ttl,
+	}, nil
+}
+
+func TestNewCachedProviderWithOptions(t *testing.T) {
+	tests := []struct {
+		name               string
+		opts               CacheOptions
+		expectRefreshRatio float64
+		expectErr          bool
+	}{
+		{
+			name:               "default options",
+			expectRefreshRatio: DefaultRefreshRatio,
+		},
+		{
+			name:               "custom options",
+			opts:               CacheOptions{CacheSize: 100, RefreshRatio: 0.5},
+			expectRefreshRatio: 0.5,
+		},
+		{
+			name:               "refresh disabled",
+			opts:               CacheOptions{RefreshRatio: 1},
+			expectRefreshRatio: 1,
+		},
+		{
+			name:      "negative cache size",
+			opts:      CacheOptions{CacheSize: -1},
+			expectErr: true,
+		},
+		{
+			name:      "refresh ratio out of range",
+			opts:      CacheOptions{RefreshRatio: 1.5},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewCachedProviderWithOptions("test", newMockCredentialSourceProvider(), tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err == nil && provider.refreshRatio != tt.expectRefreshRatio {
+				t.Fatalf("expected refresh ratio %v, got %v", tt.expectRefreshRatio, provider.refreshRatio)
+			}
+		})
+	}
+}
+
+func TestCachedProvider_Get_ConcurrentMisses(t *testing.T) {
+	source := &blockingCredentialSourceProvider{release: make(chan struct{}), ttl: time.Hour}
+	provider, err := NewCachedProvider(source)
+	if err != nil {
+		t.Fatalf("Failed to create cached provider: %v", err)
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := provider.Get(context.Background(), testServerAddress); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	// Wait until the first fetch is in progress before releasing it.
+	for source.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(source.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+
+	if calls := source.calls.Load(); calls != 1 {
+		t.Errorf("Expected source to be called once, got %d calls", calls)
+	}
+}
+
+func TestCachedProvider_Get_CallerCanceled(t *testing.T) {
+	source := &blockingCredentialSourceProvider{release: make(chan struct{}), ttl: time.Hour}
+	provider, err := NewCachedProvider(source)
+	if err != nil {
+		t.Fatalf("Failed to create cached provider: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.Get(ctx, testServerAddress); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context canceled error, got %v", err)
+	}
+
+	// The shared fetch is not canceled with the first caller.
+	close(source.release)
+	if _, err := provider.Get(context.Background(), testServerAddress); err != nil {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+	if calls := source.calls.Load(); calls != 1 {
+		t.Errorf("Expected source to be called once, got %d calls", calls)
+	}
+}
+
+func TestCachedProvider_Get_BackgroundRefresh(t *testing.T) {
+	source := &blockingCredentialSourceProvider{release: make(chan struct{}), ttl: time.Hour}
+	close(source.release)
+	provider, err := NewCachedProviderWithOptions("test", source, CacheOptions{RefreshRatio: 0.5})
+	if err != nil {
+		t.Fatalf("Failed to create cached provider: %v", err)
+	}
+	ctx := context.Background()
+
+	first, err := provider.Get(ctx, testServerAddress)
+	if err != nil {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+
+	// Move the refresh point of the cached credential into the past.
+	cached, err := provider.cache.Get(ctx, testServerAddress)
+	if err != nil {
+		t.Fatalf("Expected cached credential: %v", err)
+	}
+	if remaining := time.Until(cached.refreshAt); remaining < 29*time.Minute || remaining > 30*time.Minute {
+		t.Fatalf("Expected refresh in 30 minutes, got %v", remaining)
+	}
+	cached.refreshAt = time.Now().Add(-time.Second)
+	_ = provider.cache.Set(ctx, testServerAddress, cached, time.Hour)
+
+	// The cached credential is returned while it is refreshed.
+	credential, err := provider.Get(ctx, testServerAddress)
+	if err != nil {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+	if credential != first {
+		t.Fatalf("Expected cached credential %v, got %v", first, credential)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		credential, err = provider.Get(ctx, testServerAddress)
+		if err != nil {
+			t.Fatalf("Failed to get credential: %v", err)
+		}
+		if credential != first {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected credential to be refreshed in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if calls := source.calls.Load(); calls != 2 {
+		t.Errorf("Expected source to be called twice, got %d calls", calls)
+	}
+}
+
+func TestCachedProvider_Get_BackgroundRefreshFailure(t *testing.T) {
+	source := &blockingCredentialSourceProvider{release: make(chan struct{}), ttl: time.Hour}
+	close(source.release)
+	provider, err := NewCachedProviderWithOptions("test", source, CacheOptions{RefreshRatio: 0.5})
+	if err != nil {
+		t.Fatalf("Failed to create cached provider: %v", err)
+	}
+	ctx := context.Background()
+
+	first, err := provider.Get(ctx, testServerAddress)
+	if err != nil {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+	refreshErr := errors.New("exchange failed")
+	source.err.Store(&refreshErr)
+	cached, _ := provider.cache.Get(ctx, testServerAddress)
+	cached.refreshAt = time.Now().Add(-time.Second)
+	_ = provider.cache.Set(ctx, testServerAddress, cached, time.Hour)
+
+	if _, err := provider.Get(ctx, testServerAddress); err != nil {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for source.calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected credential refresh to be attempted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The cached credential keeps being used until it expires, and the
+	// refresh is not retried before the retry interval.
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		cached, err = provider.cache.Get(ctx, testServerAddress)
+		if err != nil {
+			t.Fatalf("Expected cached credential: %v", err)
+		}
+		if time.Now().Before(cached.refreshAt) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected refresh to be rescheduled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for range 5 {
+		credential, err := provider.Get(ctx, testServerAddress)
+		if err != nil {
+			t.Fatalf("Failed to get credential: %v", err)
+		}
+		if credential != first {
+			t.Fatalf("Expected cached credential %v, got %v", first, credential)
+		}
+	}
+	if calls := source.calls.Load(); calls != 2 {
+		t.Errorf("Expected source to be called twice, got %d calls", calls)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dockerauth parses Docker config files and looks up the registry
+// credentials in them. It is shared by the credential providers reading
+// Docker config.json files and dockerconfigjson Secrets.
+package dockerauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/notaryproject/ratify-go"
+)
+
+// dockerHubHost is the normalized host of Docker Hub.
+const dockerHubHost = "docker.io"
+
+// dockerHubAliases are the hosts referring to Docker Hub.
+var dockerHubAliases = map[string]struct{}{
+	"docker.io":            {},
+	"index.docker.io":      {},
+	"registry-1.docker.io": {},
+}
+
+// AuthConfig is a registry entry of a Docker config file.
+type AuthConfig struct {
+	// Auth is the base64 encoded "username:password".
+	Auth string `json:"auth,omitempty"`
+
+	// Username is the username to login to the registry.
+	Username string `json:"username,omitempty"`
+
+	// Password is the password to login to the registry.
+	Password string `json:"password,omitempty"`
+
+	// IdentityToken is used as refresh token to obtain access tokens.
+	IdentityToken string `json:"identitytoken,omitempty"`
+
+	// RegistryToken is a bearer token sent to the registry.
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
+
+// Config is a Docker config file, either in the config.json format or in the
+// legacy .dockercfg format.
+type Config struct {
+	// Auths maps registry hosts to credentials. Hosts may contain a scheme, a
+	// path and wildcards, e.g. "*.example.com".
+	Auths map[string]AuthConfig `json:"auths,omitempty"`
+
+	// CredHelpers maps registry hosts to the credential helper to use.
+	CredHelpers map[string]string `json:"credHelpers,omitempty"`
+
+	// CredsStore is the default credential helper.
+	CredsStore string `json:"credsStore,omitempty"`
+}
+
+// Parse parses the content of a Docker config.json file or a legacy
+// .dockercfg file.
+func Parse(data []byte) (*Config, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	_, hasAuths := raw["auths"]
+	_, hasCredHelpers := raw["credHelpers"]
+	_, hasCredsStore := raw["credsStore"]
+	if hasAuths || hasCredHelpers || hasCredsStore || len(raw) == 0 {
+		var config Config
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse docker config: %w", err)
+		}
+		return &config, nil
+	}
+
+	// The legacy format maps registry hosts to credentials at the top level.
+	config := Config{Auths: make(map[string]AuthConfig, len(raw))}
+	for host, value := range raw {
+		var auth AuthConfig
+		if err := json.Unmarshal(value, &auth); err != nil {
+			return nil, fmt.Errorf("failed to parse docker config entry %s: %w", host, err)
+		}
+		config.Auths[host] = auth
+	}
+	return &config, nil
+}
+
+// Credential converts the entry to a registry credential.
+func (a AuthConfig) Credential() (ratify.RegistryCredential, error) {
+	cred := ratify.RegistryCredential{
+		Username:     a.Username,
+		Password:     a.Password,
+		RefreshToken: a.IdentityToken,
+		AccessToken:  a.RegistryToken,
+	}
+	if a.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return ratify.RegistryCredential{}, fmt.Errorf("failed to decode auth field: %w", err)
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return ratify.RegistryCredential{}, fmt.Errorf("invalid auth field: expected username:password")
+		}
+		cred.Username = username
+		cred.Password = password
+	}
+	if cred.RefreshToken != "" && cred.Username == "<token>" {
+		// Docker stores identity tokens with a placeholder username.
+		cred.Username = ""
+		cred.Password = ""
+	}
+	return cred, nil
+}
+
+// Lookup returns the credentials entry of the registry from the given
+// configs. Exact host matches take precedence over wildcard matches, and
+// wildcard patterns with more literal characters take precedence over less
+// specific ones. Ties go to the config listed first.
+func Lookup(serverAddress string, configs ...*Config) (AuthConfig, bool) {
+	var (
+		best            AuthConfig
+		bestSpecificity int
+		found           bool
+	)
+	for _, config := range configs {
+		if config == nil {
+			continue
+		}
+		// Patterns of the config are visited in a stable order so that ties
+		// within a config resolve deterministically.
+		patterns := make([]string, 0, len(config.Auths))
+		for pattern := range config.Auths {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		configBest := -1
+		var configPattern string
+		for _, pattern := range patterns {
+			if specificity, ok := MatchHost(pattern, serverAddress); ok && specificity > configBest {
+				configBest, configPattern = specificity, pattern
+			}
+		}
+		if configBest >= 0 && (!found || configBest > bestSpecificity) {
+			best, bestSpecificity, found = config.Auths[configPattern], configBest, true
+		}
+	}
+	return best, found
+}
+
+// MatchHost reports whether the registry host pattern of a Docker config
+// entry matches serverAddress, and how specific the match is. The pattern may
+// contain a scheme and a path, which are ignored, and glob wildcards matching
+// a single DNS label each, e.g. "*.example.com". Ports must be equal.
+func MatchHost(pattern, serverAddress string) (int, bool) {
+	patternHost, patternPort := splitHostPort(NormalizeHost(pattern))
+	host, port := splitHostPort(NormalizeHost(serverAddress))
+	if patternPort != port {
+		return 0, false
+	}
+	if patternHost == host {
+		// Exact matches rank above any wildcard match.
+		return 1 << 16, true
+	}
+	if !strings.ContainsAny(patternHost, "*?[") {
+		return 0, false
+	}
+
+	patternLabels := strings.Split(patternHost, ".")
+	labels := strings.Split(host, ".")
+	if len(patternLabels) != len(labels) {
+		return 0, false
+	}
+	specificity := 0
+	for i, patternLabel := range patternLabels {
+		if ok, err := path.Match(patternLabel, labels[i]); err != nil || !ok {
+			return 0, false
+		}
+		specificity += len(strings.Trim(patternLabel, "*?"))
+	}
+	return specificity, true
+}
+
+// NormalizeHost returns the lowercase host and port of a registry address,
+// stripping the scheme and the path. Docker Hub aliases are normalized to
+// "docker.io".
+func NormalizeHost(address string) string {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if i := strings.Index(address, "://"); i >= 0 {
+		address = address[i+3:]
+	}
+	if i := strings.Index(address, "/"); i >= 0 {
+		address = address[:i]
+	}
+	if _, ok := dockerHubAliases[address]; ok {
+		return dockerHubHost
+	}
+	return address
+}
+
+// splitHostPort splits the address into host and port. The port is empty if
+// the address does not contain one.
+func splitHostPort(address string) (string, string) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, ""
+	}
+	return host, port
+}
@@ -0,0 +1,208 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockerauth
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expectHosts []string
+		expectErr   bool
+	}{
+		{
+			name:        "config.json format",
+			data:        `{"auths":{"registry.example.com":{"auth":"` + basicAuth("user", "pass") + `"}},"credHelpers":{"gcr.io":"gcloud"},"credsStore":"desktop"}`,
+			expectHosts: []string{"registry.example.com"},
+		},
+		{
+			name:        "legacy dockercfg format",
+			data:        `{"https://index.docker.io/v1/":{"auth":"` + basicAuth("user", "pass") + `","email":"user@example.com"}}`,
+			expectHosts: []string{"https://index.docker.io/v1/"},
+		},
+		{
+			name: "empty config",
+			data: `{}`,
+		},
+		{
+			name:      "invalid json",
+			data:      `{`,
+			expectErr: true,
+		},
+		{
+			name:      "invalid legacy entry",
+			data:      `{"registry.example.com":"invalid"}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if len(config.Auths) != len(tt.expectHosts) {
+				t.Fatalf("expected %d auths, got %d", len(tt.expectHosts), len(config.Auths))
+			}
+			for _, host := range tt.expectHosts {
+				if _, ok := config.Auths[host]; !ok {
+					t.Fatalf("expected auth for %s", host)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthConfig_Credential(t *testing.T) {
+	tests := []struct {
+		name           string
+		auth           AuthConfig
+		expectUsername string
+		expectPassword string
+		expectRefresh  string
+		expectAccess   string
+		expectErr      bool
+	}{
+		{
+			name:           "auth field",
+			auth:           AuthConfig{Auth: basicAuth("user", "pa:ss")},
+			expectUsername: "user",
+			expectPassword: "pa:ss",
+		},
+		{
+			name:           "username and password",
+			auth:           AuthConfig{Username: "user", Password: "pass"},
+			expectUsername: "user",
+			expectPassword: "pass",
+		},
+		{
+			name:          "identity token",
+			auth:          AuthConfig{Auth: basicAuth("<token>", ""), IdentityToken: "refresh"},
+			expectRefresh: "refresh",
+		},
+		{
+			name:         "registry token",
+			auth:         AuthConfig{RegistryToken: "access"},
+			expectAccess: "access",
+		},
+		{
+			name:      "invalid base64",
+			auth:      AuthConfig{Auth: "invalid!"},
+			expectErr: true,
+		},
+		{
+			name:      "missing separator",
+			auth:      AuthConfig{Auth: base64.StdEncoding.EncodeToString([]byte("user"))},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := tt.auth.Credential()
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if cred.Username != tt.expectUsername || cred.Password != tt.expectPassword ||
+				cred.RefreshToken != tt.expectRefresh || cred.AccessToken != tt.expectAccess {
+				t.Fatalf("unexpected credential %+v", cred)
+			}
+		})
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern       string
+		serverAddress string
+		expect        bool
+	}{
+		{pattern: "registry.example.com", serverAddress: "registry.example.com", expect: true},
+		{pattern: "https://registry.example.com/v2/", serverAddress: "Registry.Example.com", expect: true},
+		{pattern: "https://index.docker.io/v1/", serverAddress: "registry-1.docker.io", expect: true},
+		{pattern: "docker.io", serverAddress: "index.docker.io", expect: true},
+		{pattern: "registry.example.com:5000", serverAddress: "registry.example.com:5000", expect: true},
+		{pattern: "registry.example.com:5000", serverAddress: "registry.example.com", expect: false},
+		{pattern: "registry.example.com", serverAddress: "registry.example.com:5000", expect: false},
+		{pattern: "*.example.com", serverAddress: "registry.example.com", expect: true},
+		{pattern: "*.example.com", serverAddress: "a.registry.example.com", expect: false},
+		{pattern: "*.example.com", serverAddress: "example.com", expect: false},
+		{pattern: "registry.*.com", serverAddress: "registry.example.com", expect: true},
+		{pattern: "*-docker.pkg.dev", serverAddress: "us-docker.pkg.dev", expect: true},
+		{pattern: "*.example.com:5000", serverAddress: "registry.example.com:5000", expect: true},
+		{pattern: "other.example.com", serverAddress: "registry.example.com", expect: false},
+		{pattern: "[.example.com", serverAddress: "registry.example.com", expect: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.serverAddress, func(t *testing.T) {
+			if _, ok := MatchHost(tt.pattern, tt.serverAddress); ok != tt.expect {
+				t.Fatalf("MatchHost(%s, %s) = %v, want %v", tt.pattern, tt.serverAddress, ok, tt.expect)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	first := &Config{Auths: map[string]AuthConfig{
+		"*.example.com":         {Username: "wildcard"},
+		"*.registry.example.io": {Username: "first-wildcard"},
+		"shared.example.io":     {Username: "first-exact"},
+	}}
+	second := &Config{Auths: map[string]AuthConfig{
+		"registry.example.com":   {Username: "exact"},
+		"*.registry.example.io":  {Username: "second-wildcard"},
+		"a*.registry.example.io": {Username: "specific-wildcard"},
+		"shared.example.io":      {Username: "second-exact"},
+	}}
+
+	tests := []struct {
+		serverAddress  string
+		expectUsername string
+		expectFound    bool
+	}{
+		{serverAddress: "registry.example.com", expectUsername: "exact", expectFound: true},
+		{serverAddress: "other.example.com", expectUsername: "wildcard", expectFound: true},
+		{serverAddress: "bcd.registry.example.io", expectUsername: "first-wildcard", expectFound: true},
+		{serverAddress: "abc.registry.example.io", expectUsername: "specific-wildcard", expectFound: true},
+		{serverAddress: "shared.example.io", expectUsername: "first-exact", expectFound: true},
+		{serverAddress: "docker.io", expectFound: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serverAddress, func(t *testing.T) {
+			auth, found := Lookup(tt.serverAddress, first, nil, second)
+			if found != tt.expectFound {
+				t.Fatalf("expected found: %v, got: %v", tt.expectFound, found)
+			}
+			if auth.Username != tt.expectUsername {
+				t.Fatalf("expected username %s, got %s", tt.expectUsername, auth.Username)
+			}
+		})
+	}
+}
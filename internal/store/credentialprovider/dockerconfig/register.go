@@ -0,0 +1,239 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockerconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider/dockerauth"
+)
+
+const (
+	// providerName is the name of the Docker config credential provider.
+	providerName = "docker-config"
+
+	// DefaultCredentialHelperTTL is the time credentials returned by
+	// credential helpers are cached for if not configured.
+	DefaultCredentialHelperTTL = 5 * time.Minute
+
+	// configFileName is the name of the Docker config file.
+	configFileName = "config.json"
+
+	// credentialHelperPrefix is the prefix of credential helper executables.
+	credentialHelperPrefix = "docker-credential-"
+
+	// dockerHubServerURL is the server URL Docker uses for Docker Hub in
+	// credential helpers.
+	dockerHubServerURL = "https://index.docker.io/v1/"
+
+	// tokenUsername is the username credential helpers return for identity
+	// tokens.
+	tokenUsername = "<token>"
+)
+
+// errCredentialsNotFound is returned when a credential helper has no
+// credentials for the registry.
+var errCredentialsNotFound = errors.New("credentials not found in native keychain")
+
+// Options contains configuration options for the Docker config credential
+// provider.
+type Options struct {
+	// ConfigPath is the path to the Docker config.json file, or to the
+	// directory containing it. Defaults to config.json in the DOCKER_CONFIG
+	// directory, or in ~/.docker if DOCKER_CONFIG is not set. Optional.
+	ConfigPath string `json:"configPath,omitempty"`
+
+	// CredentialHelperTTL is the time credentials returned by credential
+	// helpers are cached for, e.g. "10m". Defaults to 5m. Optional.
+	CredentialHelperTTL jsonutil.Duration `json:"credentialHelperTTL,omitempty"`
+
+	// CacheOptions configures the credential cache of the provider.
+	credentialprovider.CacheOptions
+}
+
+// Provider is an implementation of [credentialprovider.CredentialSourceProvider]
+// that resolves registry credentials the way the docker and oras CLIs do:
+// from the credHelpers and credsStore credential helpers configured in the
+// Docker config file, falling back to its auths entries.
+type Provider struct {
+	configPath string
+	helperTTL  time.Duration
+}
+
+func init() {
+	// Register the Docker config credential provider factory
+	credentialprovider.RegisterCredentialProviderFactory(providerName, createProvider)
+}
+
+// createProvider creates a new Docker config credential provider from
+// CredentialProviderOptions.
+func createProvider(opts credentialprovider.Options) (ratify.RegistryCredentialGetter, error) {
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var dockerOpts Options
+	if err := json.Unmarshal(raw, &dockerOpts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+	provider, err := NewProvider(dockerOpts)
+	if err != nil {
+		return nil, err
+	}
+	return credentialprovider.NewCachedProviderWithOptions(providerName, provider, dockerOpts.CacheOptions)
+}
+
+// NewProvider creates a new Docker config credential provider.
+func NewProvider(opts Options) (*Provider, error) {
+	if opts.CredentialHelperTTL < 0 {
+		return nil, fmt.Errorf("credentialHelperTTL cannot be negative")
+	}
+	configPath := opts.ConfigPath
+	if configPath == "" {
+		configDir := os.Getenv("DOCKER_CONFIG")
+		if configDir == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to locate the docker config: %w", err)
+			}
+			configDir = filepath.Join(homeDir, ".docker")
+		}
+		configPath = filepath.Join(configDir, configFileName)
+	} else if info, err := os.Stat(configPath); err == nil && info.IsDir() {
+		configPath = filepath.Join(configPath, configFileName)
+	}
+	helperTTL := opts.CredentialHelperTTL.Duration()
+	if helperTTL == 0 {
+		helperTTL = DefaultCredentialHelperTTL
+	}
+	return &Provider{
+		configPath: configPath,
+		helperTTL:  helperTTL,
+	}, nil
+}
+
+// GetWithTTL implements credentialprovider.CredentialSourceProvider interface.
+// The config file is read on every call so that changes apply without
+// restarting. Credentials from credential helpers are cached for the
+// configured TTL, while credentials from auths entries are not cached.
+func (p *Provider) GetWithTTL(ctx context.Context, serverAddress string) (credentialprovider.CredentialWithTTL, error) {
+	data, err := os.ReadFile(p.configPath)
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("failed to read docker config: %w", err)
+	}
+	config, err := dockerauth.Parse(data)
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("failed to load docker config %s: %w", p.configPath, err)
+	}
+
+	if helper := credentialHelper(config, serverAddress); helper != "" {
+		cred, err := runCredentialHelper(ctx, helper, serverAddress)
+		switch {
+		case err == nil:
+			return credentialprovider.CredentialWithTTL{
+				Credential: cred,
+				TTL:        p.helperTTL,
+			}, nil
+		case !errors.Is(err, errCredentialsNotFound):
+			return credentialprovider.CredentialWithTTL{}, err
+		}
+	}
+
+	auth, found := dockerauth.Lookup(serverAddress, config)
+	if !found {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("no credentials found for registry %s in docker config %s", serverAddress, p.configPath)
+	}
+	cred, err := auth.Credential()
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("invalid credentials for registry %s: %w", serverAddress, err)
+	}
+	return credentialprovider.CredentialWithTTL{Credential: cred}, nil
+}
+
+// credentialHelper returns the credential helper for the registry. The most
+// specific credHelpers entry takes precedence over the credsStore.
+func credentialHelper(config *dockerauth.Config, serverAddress string) string {
+	helper := config.CredsStore
+	best := -1
+	for pattern, name := range config.CredHelpers {
+		specificity, ok := dockerauth.MatchHost(pattern, serverAddress)
+		if ok && (specificity > best || specificity == best && name < helper) {
+			helper, best = name, specificity
+		}
+	}
+	return helper
+}
+
+// helperCredential is the response of the get command of a credential helper.
+type helperCredential struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// runCredentialHelper gets the credentials of the registry from the
+// docker-credential-<helper> executable following the Docker credential
+// helper protocol.
+func runCredentialHelper(ctx context.Context, helper, serverAddress string) (ratify.RegistryCredential, error) {
+	if strings.ContainsAny(helper, `/\`) {
+		return ratify.RegistryCredential{}, fmt.Errorf("invalid credential helper name %q", helper)
+	}
+	serverURL := serverAddress
+	if dockerauth.NormalizeHost(serverAddress) == "docker.io" {
+		serverURL = dockerHubServerURL
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, credentialHelperPrefix+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Helpers report errors on stdout.
+		message := strings.TrimSpace(stdout.String())
+		if message == errCredentialsNotFound.Error() {
+			return ratify.RegistryCredential{}, errCredentialsNotFound
+		}
+		if message == "" {
+			message = strings.TrimSpace(stderr.String())
+		}
+		return ratify.RegistryCredential{}, fmt.Errorf("credential helper %s failed for registry %s: %w: %s", helper, serverAddress, err, message)
+	}
+
+	var resp helperCredential
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return ratify.RegistryCredential{}, fmt.Errorf("failed to parse the response of credential helper %s: %w", helper, err)
+	}
+	if resp.Username == tokenUsername {
+		return ratify.RegistryCredential{RefreshToken: resp.Secret}, nil
+	}
+	return ratify.RegistryCredential{
+		Username: resp.Username,
+		Password: resp.Secret,
+	}, nil
+}
@@ -0,0 +1,400 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockerconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider/dockerauth"
+)
+
+// testHelperScript is a credential helper returning credentials based on the
+// server URL read from stdin and recording the requested server URLs.
+const testHelperScript = `#!/bin/sh
+[ "$1" = "get" ] || exit 1
+read -r server
+echo "$server" >> "$(dirname "$0")/requests.log"
+case "$server" in
+  registry.example.com) echo '{"ServerURL":"registry.example.com","Username":"helper","Secret":"helper-secret"}' ;;
+  https://index.docker.io/v1/) echo '{"ServerURL":"https://index.docker.io/v1/","Username":"hub","Secret":"hub-secret"}' ;;
+  token.example.com) echo '{"ServerURL":"token.example.com","Username":"<token>","Secret":"identity-token"}' ;;
+  broken.example.com) echo 'not json' ;;
+  failing.example.com) echo 'helper exploded'; exit 1 ;;
+  *) echo 'credentials not found in native keychain'; exit 1 ;;
+esac
+`
+
+// setupHelper installs docker-credential-<name> into a directory on PATH and
+// returns the directory.
+func setupHelper(t *testing.T, name string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper test script requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, credentialHelperPrefix+name), []byte(testHelperScript), 0700); err != nil {
+		t.Fatalf("failed to write credential helper: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func helperRequests(t *testing.T, dir string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "requests.log"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("failed to read helper requests: %v", err)
+	}
+	return strings.Fields(string(data))
+}
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, configFileName)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write docker config: %v", err)
+	}
+	return path
+}
+
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+func TestNewProvider(t *testing.T) {
+	homeDir := t.TempDir()
+	dockerConfigDir := t.TempDir()
+	configDir := t.TempDir()
+	configFile := writeConfig(t, configDir, `{}`)
+
+	tests := []struct {
+		name             string
+		dockerConfig     string
+		opts             Options
+		expectConfigPath string
+		expectHelperTTL  time.Duration
+		expectErr        bool
+	}{
+		{
+			name:             "default to home directory",
+			expectConfigPath: filepath.Join(homeDir, ".docker", configFileName),
+			expectHelperTTL:  DefaultCredentialHelperTTL,
+		},
+		{
+			name:             "DOCKER_CONFIG",
+			dockerConfig:     dockerConfigDir,
+			expectConfigPath: filepath.Join(dockerConfigDir, configFileName),
+			expectHelperTTL:  DefaultCredentialHelperTTL,
+		},
+		{
+			name:             "config file path",
+			dockerConfig:     dockerConfigDir,
+			opts:             Options{ConfigPath: configFile, CredentialHelperTTL: jsonutil.Duration(time.Minute)},
+			expectConfigPath: configFile,
+			expectHelperTTL:  time.Minute,
+		},
+		{
+			name:             "config directory path",
+			opts:             Options{ConfigPath: configDir},
+			expectConfigPath: configFile,
+			expectHelperTTL:  DefaultCredentialHelperTTL,
+		},
+		{
+			name:      "negative TTL",
+			opts:      Options{CredentialHelperTTL: jsonutil.Duration(-time.Minute)},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", homeDir)
+			t.Setenv("USERPROFILE", homeDir)
+			t.Setenv("DOCKER_CONFIG", tt.dockerConfig)
+			provider, err := NewProvider(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if provider.configPath != tt.expectConfigPath {
+				t.Fatalf("expected config path %s, got %s", tt.expectConfigPath, provider.configPath)
+			}
+			if provider.helperTTL != tt.expectHelperTTL {
+				t.Fatalf("expected helper TTL %v, got %v", tt.expectHelperTTL, provider.helperTTL)
+			}
+		})
+	}
+}
+
+func TestCreateProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      credentialprovider.Options
+		expectErr bool
+	}{
+		{
+			name: "valid options",
+			opts: credentialprovider.Options{
+				"provider":            providerName,
+				"configPath":          "/etc/docker/config.json",
+				"credentialHelperTTL": "1m",
+			},
+		},
+		{
+			name: "invalid TTL",
+			opts: credentialprovider.Options{
+				"provider":            providerName,
+				"credentialHelperTTL": "soon",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := createProvider(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestGetWithTTL(t *testing.T) {
+	helperDir := setupHelper(t, "test")
+	configDir := t.TempDir()
+	configPath := writeConfig(t, configDir, `{
+		"auths": {
+			"auths.example.com": {"auth": "`+basicAuth("auths", "auths-password")+`"},
+			"*.example.io": {"identitytoken": "wildcard-token"},
+			"unknown.example.com": {"auth": "`+basicAuth("fallback", "fallback-password")+`"}
+		},
+		"credHelpers": {
+			"registry.example.com": "test",
+			"token.example.com": "test",
+			"broken.example.com": "test",
+			"failing.example.com": "test",
+			"unknown.example.com": "test",
+			"invalid.example.com": "../test"
+		}
+	}`)
+	provider, err := NewProvider(Options{ConfigPath: configPath, CredentialHelperTTL: jsonutil.Duration(time.Minute)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		serverAddress      string
+		expectUsername     string
+		expectPassword     string
+		expectRefreshToken string
+		expectTTL          time.Duration
+		expectErr          bool
+	}{
+		{
+			name:           "credential helper",
+			serverAddress:  "registry.example.com",
+			expectUsername: "helper",
+			expectPassword: "helper-secret",
+			expectTTL:      time.Minute,
+		},
+		{
+			name:               "identity token from credential helper",
+			serverAddress:      "token.example.com",
+			expectRefreshToken: "identity-token",
+			expectTTL:          time.Minute,
+		},
+		{
+			name:           "auths entry",
+			serverAddress:  "auths.example.com",
+			expectUsername: "auths",
+			expectPassword: "auths-password",
+		},
+		{
+			name:               "wildcard auths entry",
+			serverAddress:      "myregistry.example.io",
+			expectRefreshToken: "wildcard-token",
+		},
+		{
+			name:           "fall back to auths if helper has no credentials",
+			serverAddress:  "unknown.example.com",
+			expectUsername: "fallback",
+			expectPassword: "fallback-password",
+		},
+		{
+			name:          "invalid helper response",
+			serverAddress: "broken.example.com",
+			expectErr:     true,
+		},
+		{
+			name:          "failing helper",
+			serverAddress: "failing.example.com",
+			expectErr:     true,
+		},
+		{
+			name:          "invalid helper name",
+			serverAddress: "invalid.example.com",
+			expectErr:     true,
+		},
+		{
+			name:          "no credentials",
+			serverAddress: "quay.io",
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := provider.GetWithTTL(context.Background(), tt.serverAddress)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if cred.Credential.Username != tt.expectUsername || cred.Credential.Password != tt.expectPassword {
+				t.Fatalf("expected credentials %s:%s, got %s:%s", tt.expectUsername, tt.expectPassword, cred.Credential.Username, cred.Credential.Password)
+			}
+			if cred.Credential.RefreshToken != tt.expectRefreshToken {
+				t.Fatalf("expected refresh token %s, got %s", tt.expectRefreshToken, cred.Credential.RefreshToken)
+			}
+			if cred.TTL != tt.expectTTL {
+				t.Fatalf("expected TTL %v, got %v", tt.expectTTL, cred.TTL)
+			}
+		})
+	}
+
+	if requests := helperRequests(t, helperDir); len(requests) != 5 {
+		t.Fatalf("expected 5 helper requests, got %v", requests)
+	}
+}
+
+func TestGetWithTTL_CredsStore(t *testing.T) {
+	helperDir := setupHelper(t, "store")
+	configPath := writeConfig(t, t.TempDir(), `{
+		"auths": {"registry.example.com": {}},
+		"credsStore": "store",
+		"credHelpers": {"registry.example.com": "missing"}
+	}`)
+	provider, err := NewProvider(Options{ConfigPath: configPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cred, err := provider.GetWithTTL(context.Background(), "index.docker.io")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.Credential.Username != "hub" || cred.TTL != DefaultCredentialHelperTTL {
+		t.Fatalf("unexpected credentials from credsStore: %+v", cred)
+	}
+	if requests := helperRequests(t, helperDir); len(requests) != 1 || requests[0] != dockerHubServerURL {
+		t.Fatalf("expected docker hub server URL to be requested, got %v", requests)
+	}
+
+	// credHelpers take precedence over the credsStore.
+	if _, err := provider.GetWithTTL(context.Background(), "registry.example.com"); err == nil {
+		t.Fatal("expected error for missing credential helper")
+	}
+}
+
+func TestGetWithTTL_ConfigChanges(t *testing.T) {
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, configFileName)
+	provider, err := NewProvider(Options{ConfigPath: configPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := provider.GetWithTTL(context.Background(), "registry.example.com"); err == nil {
+		t.Fatal("expected error for missing config file")
+	}
+
+	writeConfig(t, configDir, `{invalid`)
+	if _, err := provider.GetWithTTL(context.Background(), "registry.example.com"); err == nil {
+		t.Fatal("expected error for invalid config file")
+	}
+
+	writeConfig(t, configDir, `{"auths": {"registry.example.com": {"auth": "`+basicAuth("user", "password")+`"}}}`)
+	cred, err := provider.GetWithTTL(context.Background(), "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.Credential.Username != "user" || cred.Credential.Password != "password" {
+		t.Fatalf("unexpected credentials: %+v", cred.Credential)
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	tests := []struct {
+		name          string
+		credHelpers   map[string]string
+		credsStore    string
+		serverAddress string
+		expected      string
+	}{
+		{
+			name:          "no helper",
+			serverAddress: "registry.example.com",
+		},
+		{
+			name:          "credsStore",
+			credsStore:    "desktop",
+			serverAddress: "registry.example.com",
+			expected:      "desktop",
+		},
+		{
+			name:          "credHelpers over credsStore",
+			credHelpers:   map[string]string{"registry.example.com": "ecr-login"},
+			credsStore:    "desktop",
+			serverAddress: "registry.example.com",
+			expected:      "ecr-login",
+		},
+		{
+			name:          "most specific credHelpers entry",
+			credHelpers:   map[string]string{"*.example.com": "wildcard", "registry.example.com": "exact"},
+			serverAddress: "registry.example.com",
+			expected:      "exact",
+		},
+		{
+			name:          "unmatched credHelpers",
+			credHelpers:   map[string]string{"other.example.com": "other"},
+			credsStore:    "desktop",
+			serverAddress: "registry.example.com",
+			expected:      "desktop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &dockerauth.Config{CredHelpers: tt.credHelpers, CredsStore: tt.credsStore}
+			if helper := credentialHelper(config, tt.serverAddress); helper != tt.expected {
+				t.Fatalf("expected helper %q, got %q", tt.expected, helper)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// providerName is the name of the Google Cloud credential provider.
+	providerName = "gcp"
+
+	// accessTokenUsername is the username used with OAuth2 access tokens by
+	// Artifact Registry and Container Registry.
+	accessTokenUsername = "oauth2accesstoken"
+
+	// cloudPlatformScope is the default OAuth2 scope of the access tokens.
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// tokenExpiryBuffer is subtracted from the expiry of access tokens so
+	// that they are refreshed before they expire.
+	tokenExpiryBuffer = 5 * time.Minute
+
+	// artifactRegistrySuffix is the suffix of regional Artifact Registry
+	// hosts, e.g. "us-central1-docker.pkg.dev".
+	artifactRegistrySuffix = "-docker.pkg.dev"
+)
+
+// defaultRegistries are the host patterns of Artifact Registry and Container
+// Registry. Regional Artifact Registry hosts are matched separately.
+var defaultRegistries = []string{
+	"docker.pkg.dev",
+	"gcr.io",
+	"*.gcr.io",
+}
+
+// ProviderOptions contains configuration options for the Google Cloud
+// credential provider.
+type ProviderOptions struct {
+	// CredentialsFile is the path of a service account key or a workload
+	// identity federation credential configuration. If not set, Application
+	// Default Credentials are used, i.e. GOOGLE_APPLICATION_CREDENTIALS or the
+	// metadata server on GKE and GCE. Optional.
+	CredentialsFile string `json:"credentialsFile,omitempty"`
+
+	// Scopes are the OAuth2 scopes of the access tokens. Defaults to the
+	// cloud-platform scope. Optional.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Registries are additional registry host patterns the access tokens are
+	// sent to, e.g. custom domains. A leading "*." matches any subdomain,
+	// e.g. "*.example.com" matches "registry.example.com" but not
+	// "example.com" or "evilexample.com". Tokens are only issued for Artifact
+	// Registry and Container Registry hosts by default. Optional.
+	Registries []string `json:"registries,omitempty"`
+
+	// CacheOptions configures the credential cache of the provider.
+	credentialprovider.CacheOptions
+}
+
+// Provider is an implementation of
+// [credentialprovider.CredentialSourceProvider] that returns Google Cloud
+// OAuth2 access tokens for Artifact Registry and Container Registry.
+type Provider struct {
+	credentialsFile string
+	scopes          []string
+	registries      []string
+
+	mu          sync.Mutex
+	tokenSource oauth2.TokenSource
+}
+
+func init() {
+	// Register the Google Cloud credential provider factory
+	credentialprovider.RegisterCredentialProviderFactory(providerName, createProvider)
+}
+
+// createProvider creates a new Google Cloud credential provider from
+// CredentialProviderOptions.
+func createProvider(opts credentialprovider.Options) (ratify.RegistryCredentialGetter, error) {
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var gcpOpts ProviderOptions
+	if err := json.Unmarshal(raw, &gcpOpts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+
+	provider, err := NewProvider(gcpOpts)
+	if err != nil {
+		return nil, err
+	}
+	return credentialprovider.NewCachedProviderWithOptions(providerName, provider, gcpOpts.CacheOptions)
+}
+
+// NewProvider creates a new Google Cloud credential provider. The credentials
+// are resolved on first use, so that the provider can be created before the
+// metadata server is reachable.
+func NewProvider(opts ProviderOptions) (*Provider, error) {
+	scopes := opts.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{cloudPlatformScope}
+	}
+	for _, pattern := range opts.Registries {
+		if pattern == "" || strings.Contains(strings.TrimPrefix(pattern, "*."), "*") {
+			return nil, fmt.Errorf("invalid registry pattern %q: only a leading \"*.\" wildcard is supported", pattern)
+		}
+	}
+	return &Provider{
+		credentialsFile: opts.CredentialsFile,
+		scopes:          scopes,
+		registries:      append(append([]string{}, defaultRegistries...), opts.Registries...),
+	}, nil
+}
+
+// GetWithTTL implements credentialprovider.CredentialSourceProvider interface.
+// It returns an OAuth2 access token valid until shortly before it expires.
+func (p *Provider) GetWithTTL(ctx context.Context, serverAddress string) (credentialprovider.CredentialWithTTL, error) {
+	if !p.isAllowedRegistry(serverAddress) {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("%s is not a Google Cloud registry", serverAddress)
+	}
+	tokenSource, err := p.getTokenSource(ctx)
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, err
+	}
+	token, err := tokenSource.Token()
+	if err != nil {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("failed to get Google Cloud access token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("received empty Google Cloud access token")
+	}
+
+	var ttl time.Duration
+	if !token.Expiry.IsZero() {
+		ttl = time.Until(token.Expiry) - tokenExpiryBuffer
+		if ttl < 0 {
+			ttl = 0
+		}
+	}
+
+	return credentialprovider.CredentialWithTTL{
+		Credential: ratify.RegistryCredential{
+			Username: accessTokenUsername,
+			Password: token.AccessToken,
+		},
+		TTL: ttl,
+	}, nil
+}
+
+// getTokenSource returns the token source, resolving the credentials on first
+// use. Failures are not cached so that resolution is retried on the next call.
+func (p *Provider) getTokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tokenSource != nil {
+		return p.tokenSource, nil
+	}
+
+	// Refresh tokens early so that an expiring token is not returned again
+	// after the cached credential expired.
+	params := google.CredentialsParams{
+		Scopes:            p.scopes,
+		EarlyTokenRefresh: tokenExpiryBuffer,
+	}
+	// The token source outlives the request, so it must not be bound to the
+	// request context.
+	ctx = context.WithoutCancel(ctx)
+	var creds *google.Credentials
+	if p.credentialsFile != "" {
+		data, err := os.ReadFile(p.credentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Google Cloud credentials file: %w", err)
+		}
+		if creds, err = google.CredentialsFromJSONWithParams(ctx, data, params); err != nil {
+			return nil, fmt.Errorf("failed to parse Google Cloud credentials file: %w", err)
+		}
+	} else {
+		var err error
+		if creds, err = google.FindDefaultCredentialsWithParams(ctx, params); err != nil {
+			return nil, fmt.Errorf("failed to find Google Cloud default credentials: %w", err)
+		}
+	}
+	p.tokenSource = creds.TokenSource
+	return p.tokenSource, nil
+}
+
+// isAllowedRegistry reports whether access tokens may be sent to the
+// registry.
+func (p *Provider) isAllowedRegistry(serverAddress string) bool {
+	host := strings.ToLower(serverAddress)
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	if region, ok := strings.CutSuffix(host, artifactRegistrySuffix); ok && region != "" && !strings.Contains(region, ".") {
+		return true
+	}
+	for _, pattern := range p.registries {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			// The suffix starts with a dot, so that only subdomains match.
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+)
+
+// newFakeMetadataServer returns a local stand-in for the GCE metadata server
+// issuing access tokens that expire in expiresIn seconds.
+func newFakeMetadataServer(t *testing.T, expiresIn int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var tokenRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Metadata-Flavor", "Google")
+		switch r.URL.Path {
+		case "/computeMetadata/v1/project/project-id":
+			fmt.Fprint(w, "test-project")
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			if r.URL.Query().Get("scopes") != cloudPlatformScope {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			n := tokenRequests.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"metadata-token-%d","expires_in":%d,"token_type":"Bearer"}`, n, expiresIn)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &tokenRequests
+}
+
+func isolateDefaultCredentials(t *testing.T) {
+	t.Helper()
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+}
+
+func TestProvider_MetadataServer(t *testing.T) {
+	isolateDefaultCredentials(t)
+	server, tokenRequests := newFakeMetadataServer(t, 3600)
+	t.Setenv("GCE_METADATA_HOST", server.Listener.Addr().String())
+
+	provider, err := credentialprovider.NewCredentialProvider(credentialprovider.Options{
+		"provider": providerName,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, registry := range []string{"us-central1-docker.pkg.dev", "gcr.io", "eu.gcr.io:443"} {
+		cred, err := provider.Get(context.Background(), registry)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", registry, err)
+		}
+		if cred.Username != accessTokenUsername || cred.Password != "metadata-token-1" {
+			t.Fatalf("unexpected credential %+v for %s", cred, registry)
+		}
+	}
+	if n := tokenRequests.Load(); n != 1 {
+		t.Fatalf("expected 1 token request, got %d", n)
+	}
+
+	// Tokens are not issued for other registries.
+	if _, err := provider.Get(context.Background(), "docker.io"); err == nil {
+		t.Fatal("expected error for non Google Cloud registry")
+	}
+
+	source, err := NewProvider(ProviderOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cred, err := source.GetWithTTL(context.Background(), "us-docker.pkg.dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.TTL <= 50*time.Minute || cred.TTL > time.Hour-tokenExpiryBuffer {
+		t.Fatalf("unexpected TTL %v", cred.TTL)
+	}
+}
+
+func TestProvider_CredentialsFile(t *testing.T) {
+	isolateDefaultCredentials(t)
+	var assertions atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("assertion") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assertions.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"service-account-token","expires_in":600,"token_type":"Bearer"}`)
+	}))
+	defer tokenServer.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	keyFile, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "test-project",
+		"private_key_id": "key-id",
+		"private_key":    string(keyPEM),
+		"client_email":   "ratify@test-project.iam.gserviceaccount.com",
+		"client_id":      "123",
+		"token_uri":      tokenServer.URL,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal key file: %v", err)
+	}
+	credentialsFile := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(credentialsFile, keyFile, 0600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+
+	provider, err := NewProvider(ProviderOptions{
+		CredentialsFile: credentialsFile,
+		Registries:      []string{"registry.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cred, err := provider.GetWithTTL(context.Background(), "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.Credential.Password != "service-account-token" {
+		t.Fatalf("unexpected credential %+v", cred.Credential)
+	}
+	if cred.TTL <= 4*time.Minute || cred.TTL > 10*time.Minute-tokenExpiryBuffer {
+		t.Fatalf("unexpected TTL %v", cred.TTL)
+	}
+
+	// Credential files that cannot be read fail on use and are retried.
+	provider, err = NewProvider(ProviderOptions{CredentialsFile: filepath.Join(t.TempDir(), "missing.json")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := provider.GetWithTTL(context.Background(), "gcr.io"); err == nil {
+			t.Fatal("expected error for missing credentials file")
+		}
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      credentialprovider.Options
+		expectErr bool
+	}{
+		{
+			name: "custom scopes and registries",
+			opts: credentialprovider.Options{
+				"provider":   providerName,
+				"scopes":     []string{"https://www.googleapis.com/auth/devstorage.read_only"},
+				"registries": []string{"*.example.com"},
+			},
+		},
+		{
+			name: "inner wildcard",
+			opts: credentialprovider.Options{
+				"provider":   providerName,
+				"registries": []string{"registry.*.com"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "wildcard without dot",
+			opts: credentialprovider.Options{
+				"provider":   providerName,
+				"registries": []string{"*example.com"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "empty registry",
+			opts: credentialprovider.Options{
+				"provider":   providerName,
+				"registries": []string{""},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid options",
+			opts: credentialprovider.Options{
+				"provider": providerName,
+				"scopes":   "invalid",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := credentialprovider.NewCredentialProvider(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestProvider_IsAllowedRegistry(t *testing.T) {
+	provider, err := NewProvider(ProviderOptions{Registries: []string{"*.registry.example.com"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		host   string
+		expect bool
+	}{
+		{host: "us-central1-docker.pkg.dev", expect: true},
+		{host: "europe-docker.pkg.dev", expect: true},
+		{host: "gcr.io", expect: true},
+		{host: "asia.gcr.io", expect: true},
+		{host: "team.registry.example.com", expect: true},
+		{host: "registry.example.com", expect: false},
+		{host: "-docker.pkg.dev", expect: false},
+		{host: "gcr.io.evil.com", expect: false},
+		{host: "evilgcr.io", expect: false},
+		{host: "evil.us-docker.pkg.dev", expect: false},
+		{host: "evilregistry.example.com", expect: false},
+		{host: "docker.io", expect: false},
+	}
+	for _, tt := range tests {
+		if got := provider.isAllowedRegistry(tt.host); got != tt.expect {
+			t.Errorf("isAllowedRegistry(%s) = %v, want %v", tt.host, got, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8ssecret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/pod"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider/dockerauth"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// providerName is the name of the Kubernetes Secret credential provider.
+	providerName = "k8s-secret"
+
+	// defaultIdleTimeout is how long the informers of a namespace keep
+	// running after the last read from the namespace.
+	defaultIdleTimeout = 30 * time.Minute
+)
+
+// SecretReference references a Kubernetes Secret.
+type SecretReference struct {
+	// Name is the name of the Secret. Required.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Secret. Defaults to the namespace of
+	// the provider. Optional.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Options contains configuration options for the Kubernetes Secret
+// credential provider.
+type Options struct {
+	// Secrets are the kubernetes.io/dockerconfigjson Secrets holding the
+	// registry credentials, in order of precedence. Optional.
+	Secrets []SecretReference `json:"secrets,omitempty"`
+
+	// ServiceAccountName is the name of a ServiceAccount whose
+	// imagePullSecrets are used after the Secrets listed above. Optional.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Namespace is the default namespace of the Secrets and the namespace of
+	// the ServiceAccount. Defaults to the namespace Ratify runs in. Optional.
+	Namespace string `json:"namespace,omitempty"`
+
+	// AnonymousFallback makes registries without matching credentials
+	// accessed anonymously instead of failing. Optional.
+	AnonymousFallback bool `json:"anonymousFallback,omitempty"`
+}
+
+// Provider is an implementation of [ratify.RegistryCredentialGetter] that
+// returns registry credentials from Kubernetes dockerconfigjson Secrets. The
+// Secrets are watched, so rotated credentials apply on the next request.
+type Provider struct {
+	secrets            []SecretReference
+	serviceAccountName string
+	namespace          string
+	anonymousFallback  bool
+	watcher            *watcher
+}
+
+func init() {
+	// Register the Kubernetes Secret credential provider factory
+	credentialprovider.RegisterCredentialProviderFactory(providerName, createProvider)
+}
+
+// createProvider creates a new Kubernetes Secret credential provider from
+// CredentialProviderOptions.
+func createProvider(opts credentialprovider.Options) (ratify.RegistryCredentialGetter, error) {
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var secretOpts Options
+	if err := json.Unmarshal(raw, &secretOpts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+	return NewProvider(secretOpts)
+}
+
+// NewProvider creates a new Kubernetes Secret credential provider. The
+// Secrets are watched with informers shared by all providers.
+func NewProvider(opts Options) (*Provider, error) {
+	return newProvider(opts, sharedWatcher)
+}
+
+// newProvider creates a new provider watching the Secrets with w.
+func newProvider(opts Options, w *watcher) (*Provider, error) {
+	if len(opts.Secrets) == 0 && opts.ServiceAccountName == "" {
+		return nil, fmt.Errorf("at least one secret or a service account name is required")
+	}
+	p := &Provider{
+		serviceAccountName: opts.ServiceAccountName,
+		namespace:          opts.Namespace,
+		anonymousFallback:  opts.AnonymousFallback,
+		watcher:            w,
+	}
+	if p.namespace == "" {
+		p.namespace = pod.Namespace()
+	}
+	for _, secret := range opts.Secrets {
+		if secret.Name == "" {
+			return nil, fmt.Errorf("secret name cannot be empty")
+		}
+		if secret.Namespace == "" {
+			secret.Namespace = p.namespace
+		}
+		p.secrets = append(p.secrets, secret)
+	}
+
+	// Start watching right away so that the first request finds the caches
+	// synced.
+	if _, err := p.listers(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Get implements [ratify.RegistryCredentialGetter]. It returns the most
+// specific entry matching serverAddress from the configured Secrets and the
+// imagePullSecrets of the ServiceAccount.
+func (p *Provider) Get(ctx context.Context, serverAddress string) (ratify.RegistryCredential, error) {
+	listers, err := p.listers()
+	if err != nil {
+		return ratify.RegistryCredential{}, err
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), listers.synced...) {
+		return ratify.RegistryCredential{}, fmt.Errorf("failed to sync secrets: %w", ctx.Err())
+	}
+
+	var configs []*dockerauth.Config
+	for _, ref := range p.secrets {
+		config, err := getDockerConfig(listers.secrets[ref.Namespace], ref, false)
+		if err != nil {
+			return ratify.RegistryCredential{}, err
+		}
+		configs = append(configs, config)
+	}
+	if p.serviceAccountName != "" {
+		serviceAccount, err := listers.serviceAccounts.ServiceAccounts(p.namespace).Get(p.serviceAccountName)
+		if err != nil {
+			return ratify.RegistryCredential{}, fmt.Errorf("failed to get service account %s/%s: %w", p.namespace, p.serviceAccountName, err)
+		}
+		for _, imagePullSecret := range serviceAccount.ImagePullSecrets {
+			ref := SecretReference{Name: imagePullSecret.Name, Namespace: p.namespace}
+			config, err := getDockerConfig(listers.secrets[p.namespace], ref, true)
+			if err != nil {
+				return ratify.RegistryCredential{}, err
+			}
+			configs = append(configs, config)
+		}
+	}
+
+	auth, found := dockerauth.Lookup(serverAddress, configs...)
+	if !found {
+		if p.anonymousFallback {
+			return ratify.RegistryCredential{}, nil
+		}
+		return ratify.RegistryCredential{}, fmt.Errorf("no credentials found for registry %s in the configured secrets", serverAddress)
+	}
+	cred, err := auth.Credential()
+	if err != nil {
+		return ratify.RegistryCredential{}, fmt.Errorf("invalid credentials for registry %s: %w", serverAddress, err)
+	}
+	return cred, nil
+}
+
+// providerListers are the listers used by a provider.
+type providerListers struct {
+	secrets         map[string]corelisters.SecretLister
+	serviceAccounts corelisters.ServiceAccountLister
+	synced          []cache.InformerSynced
+}
+
+// listers returns the listers of the namespaces the provider reads from.
+func (p *Provider) listers() (*providerListers, error) {
+	listers := &providerListers{secrets: make(map[string]corelisters.SecretLister)}
+	namespaces := make([]string, 0, len(p.secrets)+1)
+	for _, secret := range p.secrets {
+		namespaces = append(namespaces, secret.Namespace)
+	}
+	if p.serviceAccountName != "" {
+		namespaces = append(namespaces, p.namespace)
+	}
+	for _, namespace := range namespaces {
+		if _, ok := listers.secrets[namespace]; ok {
+			continue
+		}
+		withServiceAccounts := p.serviceAccountName != "" && namespace == p.namespace
+		informers, err := p.watcher.namespace(namespace, withServiceAccounts)
+		if err != nil {
+			return nil, err
+		}
+		listers.secrets[namespace] = informers.secrets
+		listers.synced = append(listers.synced, informers.secretsSynced)
+		if withServiceAccounts {
+			listers.serviceAccounts = informers.serviceAccounts
+			listers.synced = append(listers.synced, informers.serviceAccountsSynced)
+		}
+	}
+	return listers, nil
+}
+
+// getDockerConfig returns the Docker config of the referenced Secret. Missing
+// Secrets are skipped. Secrets of other types are an error, unless they are
+// imagePullSecrets of the ServiceAccount, which are skipped.
+func getDockerConfig(lister corelisters.SecretLister, ref SecretReference, skipUnsupported bool) (*dockerauth.Config, error) {
+	secret, err := lister.Secrets(ref.Namespace).Get(ref.Name)
+	if apierrors.IsNotFound(err) {
+		logrus.Debugf("secret %s/%s not found", ref.Namespace, ref.Name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+
+	var data []byte
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		data = secret.Data[corev1.DockerConfigJsonKey]
+	case corev1.SecretTypeDockercfg:
+		data = secret.Data[corev1.DockerConfigKey]
+	default:
+		if skipUnsupported {
+			logrus.Debugf("image pull secret %s/%s of type %s is not supported", ref.Namespace, ref.Name, secret.Type)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("secret %s/%s has unsupported type %s", ref.Namespace, ref.Name, secret.Type)
+	}
+	config, err := dockerauth.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	return config, nil
+}
+
+// sharedWatcher watches Secrets and ServiceAccounts for all providers, so that
+// recreating providers on configuration changes does not start new watches.
+//
+// Each namespace read from holds one watch per resource type and a cache of
+// its dockerconfigjson Secrets and ServiceAccounts. The informers of a
+// namespace are stopped once it has not been read from for
+// defaultIdleTimeout, so the footprint is bounded by the namespaces used
+// within that period, e.g. the namespaces of the Pods admitted in the last 30
+// minutes when Pod imagePullSecrets are enabled. A namespace read from again
+// after eviction is listed anew.
+var sharedWatcher = &watcher{
+	newClient: func() (kubernetes.Interface, error) {
+		config, err := ctrl.GetConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get kubernetes config: %w", err)
+		}
+		return kubernetes.NewForConfig(config)
+	},
+	idleTimeout: defaultIdleTimeout,
+}
+
+// watcher maintains informers per namespace.
+type watcher struct {
+	newClient func() (kubernetes.Interface, error)
+
+	// idleTimeout is how long the informers of a namespace keep running
+	// after the last read. Zero keeps them running for the lifetime of the
+	// process.
+	idleTimeout time.Duration
+
+	// now returns the current time. Defaults to time.Now.
+	now func() time.Time
+
+	mu         sync.Mutex
+	client     kubernetes.Interface
+	namespaces map[string]*namespaceInformers
+}
+
+// namespaceInformers are the informers of a namespace.
+type namespaceInformers struct {
+	factory               informers.SharedInformerFactory
+	stop                  chan struct{}
+	lastUsed              time.Time
+	secrets               corelisters.SecretLister
+	secretsSynced         cache.InformerSynced
+	serviceAccounts       corelisters.ServiceAccountLister
+	serviceAccountsSynced cache.InformerSynced
+}
+
+// namespace returns the started informers of the namespace. The informers of
+// other namespaces that have been idle for longer than the idle timeout are
+// stopped.
+func (w *watcher) namespace(namespace string, withServiceAccounts bool) (*namespaceInformers, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.client == nil {
+		client, err := w.newClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+		}
+		w.client = client
+		w.namespaces = make(map[string]*namespaceInformers)
+	}
+
+	now := time.Now()
+	if w.now != nil {
+		now = w.now()
+	}
+	w.evictIdle(now)
+
+	ns, ok := w.namespaces[namespace]
+	if !ok {
+		factory := informers.NewSharedInformerFactoryWithOptions(w.client, 0,
+			informers.WithNamespace(namespace),
+			informers.WithTransform(stripUnusedSecretData))
+		secretInformer := factory.Core().V1().Secrets()
+		ns = &namespaceInformers{
+			factory:       factory,
+			stop:          make(chan struct{}),
+			secrets:       secretInformer.Lister(),
+			secretsSynced: secretInformer.Informer().HasSynced,
+		}
+		w.namespaces[namespace] = ns
+	}
+	ns.lastUsed = now
+	if withServiceAccounts && ns.serviceAccounts == nil {
+		serviceAccountInformer := ns.factory.Core().V1().ServiceAccounts()
+		ns.serviceAccounts = serviceAccountInformer.Lister()
+		ns.serviceAccountsSynced = serviceAccountInformer.Informer().HasSynced
+	}
+	// Start is a no-op for informers already running.
+	ns.factory.Start(ns.stop)
+	return ns, nil
+}
+
+// evictIdle stops and removes the informers of the namespaces that have not
+// been read from within the idle timeout.
+func (w *watcher) evictIdle(now time.Time) {
+	if w.idleTimeout <= 0 {
+		return
+	}
+	for namespace, ns := range w.namespaces {
+		if now.Sub(ns.lastUsed) > w.idleTimeout {
+			logrus.Debugf("stopping idle secret informers of namespace %s", namespace)
+			close(ns.stop)
+			delete(w.namespaces, namespace)
+		}
+	}
+}
+
+// stripUnusedSecretData drops the data of Secrets that cannot hold registry
+// credentials, so that they are not kept in memory.
+func stripUnusedSecretData(obj any) (any, error) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return obj, nil
+	}
+	secret.ManagedFields = nil
+	if secret.Type != corev1.SecretTypeDockerConfigJson && secret.Type != corev1.SecretTypeDockercfg {
+		secret.Data = nil
+		secret.StringData = nil
+	}
+	return secret, nil
+}
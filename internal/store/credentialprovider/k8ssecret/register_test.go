@@ -0,0 +1,416 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8ssecret
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testNamespace = "ratify"
+
+func dockerConfigSecret(name string, auths map[string][2]string) *corev1.Secret {
+	config := `{"auths":{`
+	first := true
+	for host, cred := range auths {
+		if !first {
+			config += ","
+		}
+		first = false
+		auth := base64.StdEncoding.EncodeToString([]byte(cred[0] + ":" + cred[1]))
+		config += fmt.Sprintf("%q:{%q:%q}", host, "auth", auth)
+	}
+	config += "}}"
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(config)},
+	}
+}
+
+func newTestWatcher(client kubernetes.Interface) *watcher {
+	return &watcher{
+		newClient: func() (kubernetes.Interface, error) {
+			return client, nil
+		},
+	}
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestCreateProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      credentialprovider.Options
+		expectErr bool
+	}{
+		{
+			name:      "no secrets",
+			opts:      credentialprovider.Options{"provider": providerName},
+			expectErr: true,
+		},
+		{
+			name: "empty secret name",
+			opts: credentialprovider.Options{
+				"provider": providerName,
+				"secrets":  []map[string]any{{"namespace": testNamespace}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid options",
+			opts: credentialprovider.Options{
+				"provider": providerName,
+				"secrets":  "invalid",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := createProvider(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestNewProvider_DefaultNamespace(t *testing.T) {
+	t.Setenv("RATIFY_NAMESPACE", testNamespace)
+	provider, err := newProvider(Options{
+		Secrets:            []SecretReference{{Name: "a"}, {Name: "b", Namespace: "other"}},
+		ServiceAccountName: "default",
+	}, newTestWatcher(fake.NewClientset()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if provider.namespace != testNamespace {
+		t.Fatalf("expected namespace %s, got %s", testNamespace, provider.namespace)
+	}
+	if provider.secrets[0].Namespace != testNamespace || provider.secrets[1].Namespace != "other" {
+		t.Fatalf("unexpected secret namespaces: %+v", provider.secrets)
+	}
+}
+
+func TestNewProvider_ClientError(t *testing.T) {
+	w := &watcher{
+		newClient: func() (kubernetes.Interface, error) {
+			return nil, fmt.Errorf("no cluster")
+		},
+	}
+	if _, err := newProvider(Options{Secrets: []SecretReference{{Name: "a"}}}, w); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGet(t *testing.T) {
+	client := fake.NewClientset(
+		dockerConfigSecret("primary", map[string][2]string{
+			"https://registry.example.com": {"primary", "primary-password"},
+			"*.azurecr.io":                 {"wildcard", "wildcard-password"},
+		}),
+		dockerConfigSecret("secondary", map[string][2]string{
+			"registry.example.com":        {"secondary", "secondary-password"},
+			"myregistry.azurecr.io":       {"exact", "exact-password"},
+			"https://index.docker.io/v1/": {"hub", "hub-password"},
+		}),
+		dockerConfigSecret("pull-secret", map[string][2]string{
+			"ghcr.io": {"pull", "pull-password"},
+		}),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: testNamespace},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("secret")},
+		},
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "verifier", Namespace: testNamespace},
+			ImagePullSecrets: []corev1.LocalObjectReference{
+				{Name: "opaque"},
+				{Name: "missing"},
+				{Name: "pull-secret"},
+			},
+		},
+	)
+	provider, err := newProvider(Options{
+		Secrets:            []SecretReference{{Name: "primary"}, {Name: "missing"}, {Name: "secondary"}},
+		ServiceAccountName: "verifier",
+		Namespace:          testNamespace,
+	}, newTestWatcher(client))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		serverAddress  string
+		expectUsername string
+		expectErr      bool
+	}{
+		{
+			name:           "earlier secret wins on equal match",
+			serverAddress:  "registry.example.com",
+			expectUsername: "primary",
+		},
+		{
+			name:           "exact host preferred over wildcard",
+			serverAddress:  "myregistry.azurecr.io",
+			expectUsername: "exact",
+		},
+		{
+			name:           "wildcard host",
+			serverAddress:  "other.azurecr.io",
+			expectUsername: "wildcard",
+		},
+		{
+			name:           "docker hub alias",
+			serverAddress:  "docker.io",
+			expectUsername: "hub",
+		},
+		{
+			name:           "service account image pull secret",
+			serverAddress:  "ghcr.io",
+			expectUsername: "pull",
+		},
+		{
+			name:          "no match",
+			serverAddress: "quay.io",
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := provider.Get(testContext(t), tt.serverAddress)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if cred.Username != tt.expectUsername {
+				t.Fatalf("expected username %s, got %s", tt.expectUsername, cred.Username)
+			}
+		})
+	}
+}
+
+func TestGet_AnonymousFallback(t *testing.T) {
+	client := fake.NewClientset(dockerConfigSecret("creds", map[string][2]string{
+		"registry.example.com": {"user", "password"},
+	}))
+	provider, err := newProvider(Options{
+		Secrets:           []SecretReference{{Name: "creds"}},
+		Namespace:         testNamespace,
+		AnonymousFallback: true,
+	}, newTestWatcher(client))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cred, err := provider.Get(testContext(t), "quay.io")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred != (ratify.RegistryCredential{}) {
+		t.Fatalf("expected anonymous credentials, got username %q", cred.Username)
+	}
+}
+
+func TestGet_UnsupportedSecretType(t *testing.T) {
+	client := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: testNamespace},
+		Type:       corev1.SecretTypeOpaque,
+	})
+	provider, err := newProvider(Options{
+		Secrets:   []SecretReference{{Name: "opaque"}},
+		Namespace: testNamespace,
+	}, newTestWatcher(client))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := provider.Get(testContext(t), "registry.example.com"); err == nil {
+		t.Fatal("expected error for unsupported secret type")
+	}
+}
+
+func TestGet_MissingServiceAccount(t *testing.T) {
+	provider, err := newProvider(Options{
+		ServiceAccountName: "missing",
+		Namespace:          testNamespace,
+	}, newTestWatcher(fake.NewClientset()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := provider.Get(testContext(t), "registry.example.com"); err == nil {
+		t.Fatal("expected error for missing service account")
+	}
+}
+
+func TestGet_Rotation(t *testing.T) {
+	client := fake.NewClientset(dockerConfigSecret("creds", map[string][2]string{
+		"registry.example.com": {"user", "old-password"},
+	}))
+	provider, err := newProvider(Options{
+		Secrets:   []SecretReference{{Name: "creds"}},
+		Namespace: testNamespace,
+	}, newTestWatcher(client))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := testContext(t)
+	cred, err := provider.Get(ctx, "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.Password != "old-password" {
+		t.Fatalf("expected old password, got %s", cred.Password)
+	}
+
+	updated := dockerConfigSecret("creds", map[string][2]string{
+		"registry.example.com": {"user", "new-password"},
+	})
+	if _, err := client.CoreV1().Secrets(testNamespace).Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	for {
+		cred, err = provider.Get(ctx, "registry.example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cred.Password == "new-password" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("rotated credentials were not picked up")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if err := client.CoreV1().Secrets(testNamespace).Delete(ctx, "creds", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete secret: %v", err)
+	}
+	for {
+		if _, err = provider.Get(ctx, "registry.example.com"); err != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("deleted secret was still used")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestWatcher_EvictIdle(t *testing.T) {
+	now := time.Now()
+	w := newTestWatcher(fake.NewClientset())
+	w.idleTimeout = time.Minute
+	w.now = func() time.Time { return now }
+
+	idle, err := w.namespace("idle", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	active, err := w.namespace("active", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now = now.Add(45 * time.Second)
+	again, err := w.namespace("active", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again != active {
+		t.Fatal("expected informers of the active namespace to be reused")
+	}
+	if _, ok := w.namespaces["idle"]; ok {
+		t.Fatal("expected informers of the idle namespace to be evicted")
+	}
+	select {
+	case <-idle.stop:
+	default:
+		t.Fatal("expected informers of the idle namespace to be stopped")
+	}
+
+	restarted, err := w.namespace("idle", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restarted == idle {
+		t.Fatal("expected new informers for the evicted namespace")
+	}
+}
+
+func TestWatcher_NoIdleTimeout(t *testing.T) {
+	now := time.Now()
+	w := newTestWatcher(fake.NewClientset())
+	w.now = func() time.Time { return now }
+
+	first, err := w.namespace(testNamespace, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now = now.Add(24 * time.Hour)
+	if _, err := w.namespace("other", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.namespaces[testNamespace] != first {
+		t.Fatal("expected informers to keep running without idle timeout")
+	}
+}
+
+func TestStripUnusedSecretData(t *testing.T) {
+	opaque := &corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{"password": []byte("secret")},
+	}
+	obj, err := stripUnusedSecretData(opaque)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(obj.(*corev1.Secret).Data) != 0 {
+		t.Fatal("expected data of opaque secret to be dropped")
+	}
+
+	docker := dockerConfigSecret("creds", map[string][2]string{"registry.example.com": {"user", "password"}})
+	obj, err = stripUnusedSecretData(docker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(obj.(*corev1.Secret).Data) == 0 {
+		t.Fatal("expected data of dockerconfigjson secret to be kept")
+	}
+
+	if obj, _ := stripUnusedSecretData("not a secret"); obj != "not a secret" {
+		t.Fatal("expected other objects to pass through")
+	}
+}
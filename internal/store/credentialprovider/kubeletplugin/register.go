@@ -0,0 +1,341 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeletplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider/dockerauth"
+	"github.com/sirupsen/logrus"
+	kubeletconfigv1 "k8s.io/kubelet/config/v1"
+	credentialproviderv1 "k8s.io/kubelet/pkg/apis/credentialprovider/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// providerName is the name of the kubelet credential provider plugin
+// credential provider.
+const providerName = "kubelet-plugin"
+
+var (
+	// supportedConfigAPIVersions are the supported apiVersions of the
+	// CredentialProviderConfig file.
+	supportedConfigAPIVersions = map[string]struct{}{
+		"kubelet.config.k8s.io/v1":      {},
+		"kubelet.config.k8s.io/v1beta1": {},
+	}
+
+	// supportedPluginAPIVersions are the supported apiVersions of the
+	// CredentialProviderRequest and CredentialProviderResponse.
+	supportedPluginAPIVersions = map[string]struct{}{
+		"credentialprovider.kubelet.k8s.io/v1":      {},
+		"credentialprovider.kubelet.k8s.io/v1beta1": {},
+	}
+)
+
+// Options contains configuration options for the kubelet credential provider
+// plugin credential provider.
+type Options struct {
+	// ConfigPath is the path to the kubelet CredentialProviderConfig file, the
+	// file passed to the kubelet with --image-credential-provider-config.
+	// Required.
+	ConfigPath string `json:"configPath"`
+
+	// BinDir is the directory containing the plugin binaries, the directory
+	// passed to the kubelet with --image-credential-provider-bin-dir.
+	// Required.
+	BinDir string `json:"binDir"`
+}
+
+// Provider is an implementation of [ratify.RegistryCredentialGetter] that
+// obtains registry credentials from kubelet credential provider plugins.
+//
+// The provider only knows the registry a credential is requested for, so the
+// registry host is sent to the plugins as the image, and paths in matchImages
+// patterns are not matched.
+type Provider struct {
+	plugins []*plugin
+}
+
+// plugin is a credential provider plugin configured in the
+// CredentialProviderConfig.
+type plugin struct {
+	name                 string
+	path                 string
+	args                 []string
+	env                  []string
+	apiVersion           string
+	matchImages          []string
+	defaultCacheDuration time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// cacheEntry is a cached plugin response.
+type cacheEntry struct {
+	auth      map[string]credentialproviderv1.AuthConfig
+	expiresAt time.Time
+}
+
+func init() {
+	// Register the kubelet credential provider plugin credential provider
+	// factory
+	credentialprovider.RegisterCredentialProviderFactory(providerName, createProvider)
+}
+
+// createProvider creates a new kubelet credential provider plugin credential
+// provider from CredentialProviderOptions.
+func createProvider(opts credentialprovider.Options) (ratify.RegistryCredentialGetter, error) {
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var pluginOpts Options
+	if err := json.Unmarshal(raw, &pluginOpts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+	return NewProvider(pluginOpts)
+}
+
+// NewProvider creates a new kubelet credential provider plugin credential
+// provider from the CredentialProviderConfig file.
+func NewProvider(opts Options) (*Provider, error) {
+	if opts.ConfigPath == "" {
+		return nil, fmt.Errorf("configPath is required")
+	}
+	if opts.BinDir == "" {
+		return nil, fmt.Errorf("binDir is required")
+	}
+	data, err := os.ReadFile(opts.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential provider config: %w", err)
+	}
+	var config kubeletconfigv1.CredentialProviderConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse credential provider config %s: %w", opts.ConfigPath, err)
+	}
+	if _, ok := supportedConfigAPIVersions[config.APIVersion]; !ok || config.Kind != "CredentialProviderConfig" {
+		return nil, fmt.Errorf("unsupported credential provider config %s of kind %q", config.APIVersion, config.Kind)
+	}
+
+	provider := &Provider{}
+	names := make(map[string]struct{}, len(config.Providers))
+	for _, cp := range config.Providers {
+		if err := validateCredentialProvider(cp); err != nil {
+			return nil, err
+		}
+		if _, ok := names[cp.Name]; ok {
+			return nil, fmt.Errorf("duplicate credential provider %s", cp.Name)
+		}
+		names[cp.Name] = struct{}{}
+		if cp.TokenAttributes != nil && cp.TokenAttributes.RequireServiceAccount != nil && *cp.TokenAttributes.RequireServiceAccount {
+			// Service account tokens are bound to the pod pulling the image,
+			// which does not exist for Ratify.
+			logrus.Warnf("skipping credential provider %s that requires a service account token", cp.Name)
+			continue
+		}
+
+		env := os.Environ()
+		for _, envVar := range cp.Env {
+			env = append(env, envVar.Name+"="+envVar.Value)
+		}
+		provider.plugins = append(provider.plugins, &plugin{
+			name:                 cp.Name,
+			path:                 filepath.Join(opts.BinDir, cp.Name),
+			args:                 cp.Args,
+			env:                  env,
+			apiVersion:           cp.APIVersion,
+			matchImages:          cp.MatchImages,
+			defaultCacheDuration: cp.DefaultCacheDuration.Duration,
+			cache:                make(map[string]cacheEntry),
+		})
+	}
+	return provider, nil
+}
+
+// validateCredentialProvider validates a provider of the
+// CredentialProviderConfig the way the kubelet does.
+func validateCredentialProvider(cp kubeletconfigv1.CredentialProvider) error {
+	if cp.Name == "" {
+		return fmt.Errorf("credential provider name is required")
+	}
+	if strings.ContainsAny(cp.Name, `/\`) || cp.Name == "." || cp.Name == ".." {
+		return fmt.Errorf("invalid credential provider name %q", cp.Name)
+	}
+	if len(cp.MatchImages) == 0 {
+		return fmt.Errorf("matchImages of credential provider %s is required", cp.Name)
+	}
+	if cp.DefaultCacheDuration == nil || cp.DefaultCacheDuration.Duration < 0 {
+		return fmt.Errorf("defaultCacheDuration of credential provider %s is required and cannot be negative", cp.Name)
+	}
+	if _, ok := supportedPluginAPIVersions[cp.APIVersion]; !ok {
+		return fmt.Errorf("unsupported apiVersion %q of credential provider %s", cp.APIVersion, cp.Name)
+	}
+	return nil
+}
+
+// Get implements [ratify.RegistryCredentialGetter]. The plugins matching
+// serverAddress are tried in the order of the config, and the first plugin
+// returning credentials for serverAddress wins.
+func (p *Provider) Get(ctx context.Context, serverAddress string) (ratify.RegistryCredential, error) {
+	var errs []error
+	for _, plugin := range p.plugins {
+		if !plugin.matches(serverAddress) {
+			continue
+		}
+		auth, err := plugin.getAuth(ctx, serverAddress)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if cred, ok := lookupAuth(auth, serverAddress); ok {
+			return cred, nil
+		}
+	}
+	if len(errs) > 0 {
+		return ratify.RegistryCredential{}, errors.Join(errs...)
+	}
+	return ratify.RegistryCredential{}, fmt.Errorf("no credential provider plugin returned credentials for registry %s", serverAddress)
+}
+
+// matches reports whether serverAddress matches the matchImages of the
+// plugin.
+func (p *plugin) matches(serverAddress string) bool {
+	for _, pattern := range p.matchImages {
+		if _, ok := dockerauth.MatchHost(pattern, serverAddress); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getAuth returns the cached auth of the plugin for serverAddress, or invokes
+// the plugin if there is none.
+func (p *plugin) getAuth(ctx context.Context, serverAddress string) (map[string]credentialproviderv1.AuthConfig, error) {
+	registryKey := dockerauth.NormalizeHost(serverAddress)
+	now := time.Now()
+	p.mu.Lock()
+	for _, key := range []string{registryKey, ""} {
+		if entry, ok := p.cache[key]; ok {
+			if now.Before(entry.expiresAt) {
+				p.mu.Unlock()
+				return entry.auth, nil
+			}
+			delete(p.cache, key)
+		}
+	}
+	p.mu.Unlock()
+
+	resp, err := p.exec(ctx, serverAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheDuration := p.defaultCacheDuration
+	if resp.CacheDuration != nil {
+		cacheDuration = resp.CacheDuration.Duration
+	}
+	if cacheDuration > 0 {
+		// The provider only requests credentials per registry, so image and
+		// registry cache keys are equivalent.
+		key := registryKey
+		if resp.CacheKeyType == credentialproviderv1.GlobalPluginCacheKeyType {
+			key = ""
+		}
+		p.mu.Lock()
+		p.cache[key] = cacheEntry{auth: resp.Auth, expiresAt: now.Add(cacheDuration)}
+		p.mu.Unlock()
+	}
+	return resp.Auth, nil
+}
+
+// exec invokes the plugin with a CredentialProviderRequest for serverAddress
+// and returns the validated CredentialProviderResponse.
+func (p *plugin) exec(ctx context.Context, serverAddress string) (*credentialproviderv1.CredentialProviderResponse, error) {
+	req := credentialproviderv1.CredentialProviderRequest{Image: serverAddress}
+	req.APIVersion = p.apiVersion
+	req.Kind = "CredentialProviderRequest"
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request to credential provider plugin %s: %w", p.name, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path, p.args...)
+	cmd.Env = p.env
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("credential provider plugin %s failed for registry %s: %w: %s", p.name, serverAddress, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp credentialproviderv1.CredentialProviderResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response of credential provider plugin %s: %w", p.name, err)
+	}
+	if resp.APIVersion != p.apiVersion || resp.Kind != "CredentialProviderResponse" {
+		return nil, fmt.Errorf("credential provider plugin %s returned %s %s, expected %s CredentialProviderResponse", p.name, resp.APIVersion, resp.Kind, p.apiVersion)
+	}
+	switch resp.CacheKeyType {
+	case credentialproviderv1.ImagePluginCacheKeyType, credentialproviderv1.RegistryPluginCacheKeyType, credentialproviderv1.GlobalPluginCacheKeyType:
+	default:
+		return nil, fmt.Errorf("credential provider plugin %s returned invalid cacheKeyType %q", p.name, resp.CacheKeyType)
+	}
+	if resp.CacheDuration != nil && resp.CacheDuration.Duration < 0 {
+		return nil, fmt.Errorf("credential provider plugin %s returned negative cacheDuration", p.name)
+	}
+	return &resp, nil
+}
+
+// lookupAuth returns the credentials of the most specific auth key matching
+// serverAddress.
+func lookupAuth(auth map[string]credentialproviderv1.AuthConfig, serverAddress string) (ratify.RegistryCredential, bool) {
+	var (
+		best      credentialproviderv1.AuthConfig
+		bestKey   string
+		found     bool
+		bestMatch int
+	)
+	for key, authConfig := range auth {
+		specificity, ok := dockerauth.MatchHost(key, serverAddress)
+		if !ok {
+			continue
+		}
+		if !found || specificity > bestMatch || specificity == bestMatch && key < bestKey {
+			best, bestKey, bestMatch, found = authConfig, key, specificity, true
+		}
+	}
+	if !found {
+		return ratify.RegistryCredential{}, false
+	}
+	return ratify.RegistryCredential{
+		Username: best.Username,
+		Password: best.Password,
+	}, true
+}
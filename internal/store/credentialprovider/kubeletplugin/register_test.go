@@ -0,0 +1,367 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeletplugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+)
+
+// testPluginScript is a credential provider plugin that records its requests
+// and returns the response stored in the file named by RESPONSE_FILE.
+const testPluginScript = `#!/bin/sh
+cat >> "$(dirname "$0")/$(basename "$0").requests"
+echo >> "$(dirname "$0")/$(basename "$0").requests"
+[ "$1" = "--fail" ] && { echo "plugin failed" >&2; exit 1; }
+cat "$RESPONSE_FILE"
+`
+
+type testEnv struct {
+	binDir string
+	dir    string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("credential provider plugin test script requires a POSIX shell")
+	}
+	return &testEnv{binDir: t.TempDir(), dir: t.TempDir()}
+}
+
+// addPlugin installs a plugin returning response.
+func (e *testEnv) addPlugin(t *testing.T, name, response string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(e.binDir, name), []byte(testPluginScript), 0700); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	responseFile := filepath.Join(e.dir, name+".json")
+	if err := os.WriteFile(responseFile, []byte(response), 0600); err != nil {
+		t.Fatalf("failed to write plugin response: %v", err)
+	}
+	return responseFile
+}
+
+// requests returns the requests received by the plugin.
+func (e *testEnv) requests(t *testing.T, name string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(e.binDir, name+".requests"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("failed to read plugin requests: %v", err)
+	}
+	return strings.Fields(string(data))
+}
+
+func (e *testEnv) writeConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(e.dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func response(cacheKeyType, cacheDuration string, auth string) string {
+	resp := `{"apiVersion":"credentialprovider.kubelet.k8s.io/v1","kind":"CredentialProviderResponse","cacheKeyType":"` + cacheKeyType + `"`
+	if cacheDuration != "" {
+		resp += `,"cacheDuration":"` + cacheDuration + `"`
+	}
+	return resp + `,"auth":` + auth + `}`
+}
+
+func TestNewProvider(t *testing.T) {
+	env := newTestEnv(t)
+	tests := []struct {
+		name          string
+		config        string
+		binDir        string
+		expectPlugins int
+		expectErr     bool
+	}{
+		{
+			name: "valid config",
+			config: `
+apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: ecr-credential-provider
+  matchImages: ["*.dkr.ecr.*.amazonaws.com"]
+  defaultCacheDuration: 12h
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  args: ["get-credentials"]
+  env:
+  - name: AWS_PROFILE
+    value: ratify
+- name: acr-credential-provider
+  matchImages: ["*.azurecr.io"]
+  defaultCacheDuration: 10m
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+`,
+			expectPlugins: 2,
+		},
+		{
+			name: "skip providers requiring service account tokens",
+			config: `
+apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: token-provider
+  matchImages: ["*.azurecr.io"]
+  defaultCacheDuration: 10m
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  tokenAttributes:
+    serviceAccountTokenAudience: registry
+    requireServiceAccount: true
+`,
+			expectPlugins: 0,
+		},
+		{
+			name:      "missing bin dir",
+			config:    `{"apiVersion":"kubelet.config.k8s.io/v1","kind":"CredentialProviderConfig","providers":[]}`,
+			binDir:    "-",
+			expectErr: true,
+		},
+		{
+			name:      "invalid config",
+			config:    `providers: [`,
+			expectErr: true,
+		},
+		{
+			name:      "unsupported config version",
+			config:    `{"apiVersion":"kubelet.config.k8s.io/v2","kind":"CredentialProviderConfig","providers":[]}`,
+			expectErr: true,
+		},
+		{
+			name:      "unknown field",
+			config:    `{"apiVersion":"kubelet.config.k8s.io/v1","kind":"CredentialProviderConfig","plugins":[]}`,
+			expectErr: true,
+		},
+		{
+			name: "missing matchImages",
+			config: `{"apiVersion":"kubelet.config.k8s.io/v1","kind":"CredentialProviderConfig","providers":[
+				{"name":"p","defaultCacheDuration":"1m","apiVersion":"credentialprovider.kubelet.k8s.io/v1"}]}`,
+			expectErr: true,
+		},
+		{
+			name: "missing defaultCacheDuration",
+			config: `{"apiVersion":"kubelet.config.k8s.io/v1","kind":"CredentialProviderConfig","providers":[
+				{"name":"p","matchImages":["gcr.io"],"apiVersion":"credentialprovider.kubelet.k8s.io/v1"}]}`,
+			expectErr: true,
+		},
+		{
+			name: "unsupported plugin version",
+			config: `{"apiVersion":"kubelet.config.k8s.io/v1","kind":"CredentialProviderConfig","providers":[
+				{"name":"p","matchImages":["gcr.io"],"defaultCacheDuration":"1m","apiVersion":"credentialprovider.kubelet.k8s.io/v1alpha1"}]}`,
+			expectErr: true,
+		},
+		{
+			name: "invalid plugin name",
+			config: `{"apiVersion":"kubelet.config.k8s.io/v1","kind":"CredentialProviderConfig","providers":[
+				{"name":"../p","matchImages":["gcr.io"],"defaultCacheDuration":"1m","apiVersion":"credentialprovider.kubelet.k8s.io/v1"}]}`,
+			expectErr: true,
+		},
+		{
+			name: "duplicate plugin name",
+			config: `{"apiVersion":"kubelet.config.k8s.io/v1","kind":"CredentialProviderConfig","providers":[
+				{"name":"p","matchImages":["gcr.io"],"defaultCacheDuration":"1m","apiVersion":"credentialprovider.kubelet.k8s.io/v1"},
+				{"name":"p","matchImages":["gcr.io"],"defaultCacheDuration":"1m","apiVersion":"credentialprovider.kubelet.k8s.io/v1"}]}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binDir := env.binDir
+			if tt.binDir == "-" {
+				binDir = ""
+			}
+			provider, err := NewProvider(Options{ConfigPath: env.writeConfig(t, tt.config), BinDir: binDir})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err == nil && len(provider.plugins) != tt.expectPlugins {
+				t.Fatalf("expected %d plugins, got %d", tt.expectPlugins, len(provider.plugins))
+			}
+		})
+	}
+}
+
+func TestCreateProvider(t *testing.T) {
+	if _, err := createProvider(credentialprovider.Options{"provider": providerName}); err == nil {
+		t.Fatal("expected error for missing configPath")
+	}
+	if _, err := createProvider(credentialprovider.Options{"provider": providerName, "configPath": "/missing/config.yaml", "binDir": "/usr/libexec"}); err == nil {
+		t.Fatal("expected error for missing config file")
+	}
+}
+
+func TestGet(t *testing.T) {
+	env := newTestEnv(t)
+	registryResponse := env.addPlugin(t, "registry-plugin", response("Registry", "", `{
+		"*.example.com": {"username": "wildcard", "password": "wildcard-password"},
+		"registry.example.com": {"username": "exact", "password": "exact-password"}
+	}`))
+	globalResponse := env.addPlugin(t, "global-plugin", response("Global", "1h", `{
+		"*.example.io": {"username": "global", "password": "global-password"}
+	}`))
+	uncachedResponse := env.addPlugin(t, "uncached-plugin", response("Image", "0s", `{
+		"uncached.example.org": {"username": "uncached", "password": "uncached-password"}
+	}`))
+	failingResponse := env.addPlugin(t, "failing-plugin", response("Registry", "", `{}`))
+	config := `
+apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: failing-plugin
+  matchImages: ["failing.example.com", "*.example.net"]
+  defaultCacheDuration: 1h
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  args: ["--fail"]
+  env:
+  - {name: RESPONSE_FILE, value: ` + failingResponse + `}
+- name: registry-plugin
+  matchImages: ["*.example.com"]
+  defaultCacheDuration: 1h
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  env:
+  - {name: RESPONSE_FILE, value: ` + registryResponse + `}
+- name: global-plugin
+  matchImages: ["*.example.io", "*.example.net"]
+  defaultCacheDuration: 1m
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  env:
+  - {name: RESPONSE_FILE, value: ` + globalResponse + `}
+- name: uncached-plugin
+  matchImages: ["uncached.example.org"]
+  defaultCacheDuration: 1h
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  env:
+  - {name: RESPONSE_FILE, value: ` + uncachedResponse + `}
+`
+	provider, err := NewProvider(Options{ConfigPath: env.writeConfig(t, config), BinDir: env.binDir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		serverAddress  string
+		expectUsername string
+		expectErr      bool
+	}{
+		{
+			name:           "most specific auth key",
+			serverAddress:  "registry.example.com",
+			expectUsername: "exact",
+		},
+		{
+			name:           "registry cached per registry",
+			serverAddress:  "registry.example.com",
+			expectUsername: "exact",
+		},
+		{
+			name:           "wildcard auth key",
+			serverAddress:  "other.example.com",
+			expectUsername: "wildcard",
+		},
+		{
+			name:           "global cache key",
+			serverAddress:  "a.example.io",
+			expectUsername: "global",
+		},
+		{
+			name:           "global cache reused across registries",
+			serverAddress:  "b.example.io",
+			expectUsername: "global",
+		},
+		{
+			name:           "fall through failing plugin",
+			serverAddress:  "failing.example.com",
+			expectUsername: "wildcard",
+		},
+		{
+			name:          "failing plugin and no matching auth key",
+			serverAddress: "c.example.net",
+			expectErr:     true,
+		},
+		{
+			name:           "uncached",
+			serverAddress:  "uncached.example.org",
+			expectUsername: "uncached",
+		},
+		{
+			name:           "uncached again",
+			serverAddress:  "uncached.example.org",
+			expectUsername: "uncached",
+		},
+		{
+			name:          "no matching plugin",
+			serverAddress: "quay.io",
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := provider.Get(context.Background(), tt.serverAddress)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if cred.Username != tt.expectUsername {
+				t.Fatalf("expected username %q, got %q", tt.expectUsername, cred.Username)
+			}
+		})
+	}
+
+	// Registries are cached separately.
+	if requests := env.requests(t, "registry-plugin"); len(requests) != 3 {
+		t.Fatalf("expected 3 requests to registry-plugin, got %v", requests)
+	}
+	// a.example.io is cached globally; c.example.net does not match the
+	// returned auth keys but is served from the global cache.
+	if requests := env.requests(t, "global-plugin"); len(requests) != 1 ||
+		!strings.Contains(requests[0], `"image":"a.example.io"`) ||
+		!strings.Contains(requests[0], `"kind":"CredentialProviderRequest"`) {
+		t.Fatalf("expected a single request to global-plugin, got %v", requests)
+	}
+	if requests := env.requests(t, "uncached-plugin"); len(requests) != 2 {
+		t.Fatalf("expected 2 requests to uncached-plugin, got %v", requests)
+	}
+
+	// Expired entries are refreshed.
+	for _, plugin := range provider.plugins {
+		for key, entry := range plugin.cache {
+			entry.expiresAt = time.Now().Add(-time.Second)
+			plugin.cache[key] = entry
+		}
+	}
+	if _, err := provider.Get(context.Background(), "b.example.io"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests := env.requests(t, "global-plugin"); len(requests) != 2 {
+		t.Fatalf("expected expired credentials to be refreshed, got %v", requests)
+	}
+}
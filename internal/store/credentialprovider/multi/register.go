@@ -0,0 +1,128 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider/dockerauth"
+)
+
+// providerName is the name of the multi credential provider.
+const providerName = "multi"
+
+// Options contains configuration options for the multi credential provider.
+type Options struct {
+	// Registries maps registry host patterns, e.g. "*.azurecr.io" or
+	// "ghcr.io", to the credential provider configuration used for the
+	// matching registries. Exact hosts take precedence over wildcard patterns,
+	// and more specific wildcard patterns over less specific ones. Required.
+	Registries map[string]credentialprovider.Options `json:"registries"`
+
+	// AnonymousFallback makes registries not matching any pattern accessed
+	// anonymously instead of failing. Optional.
+	AnonymousFallback bool `json:"anonymousFallback,omitempty"`
+}
+
+// Provider is an implementation of [ratify.RegistryCredentialGetter] that
+// routes each registry to the credential provider configured for the most
+// specific matching host pattern.
+type Provider struct {
+	routes            []route
+	anonymousFallback bool
+}
+
+// route is a host pattern and the credential provider of the matching
+// registries.
+type route struct {
+	pattern  string
+	provider ratify.RegistryCredentialGetter
+}
+
+func init() {
+	// Register the multi credential provider factory
+	credentialprovider.RegisterCredentialProviderFactory(providerName, createProvider)
+}
+
+// createProvider creates a new multi credential provider from
+// CredentialProviderOptions.
+func createProvider(opts credentialprovider.Options) (ratify.RegistryCredentialGetter, error) {
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var multiOpts Options
+	if err := json.Unmarshal(raw, &multiOpts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+	return NewProvider(multiOpts)
+}
+
+// NewProvider creates a new multi credential provider. The credential
+// provider of each host pattern is created with
+// [credentialprovider.NewCredentialProvider].
+func NewProvider(opts Options) (*Provider, error) {
+	if len(opts.Registries) == 0 {
+		return nil, fmt.Errorf("at least one registry is required")
+	}
+	patterns := make([]string, 0, len(opts.Registries))
+	for pattern := range opts.Registries {
+		patterns = append(patterns, pattern)
+	}
+	// Sort the patterns so that ties between equally specific patterns
+	// resolve deterministically.
+	sort.Strings(patterns)
+
+	p := &Provider{anonymousFallback: opts.AnonymousFallback}
+	for _, pattern := range patterns {
+		if dockerauth.NormalizeHost(pattern) == "" {
+			return nil, fmt.Errorf("registry pattern cannot be empty")
+		}
+		provider, err := credentialprovider.NewCredentialProvider(opts.Registries[pattern])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create credential provider for registry %s: %w", pattern, err)
+		}
+		p.routes = append(p.routes, route{pattern: pattern, provider: provider})
+	}
+	return p, nil
+}
+
+// Get implements [ratify.RegistryCredentialGetter]. It returns the
+// credentials from the credential provider of the most specific pattern
+// matching serverAddress.
+func (p *Provider) Get(ctx context.Context, serverAddress string) (ratify.RegistryCredential, error) {
+	var (
+		provider ratify.RegistryCredentialGetter
+		best     int
+	)
+	for _, r := range p.routes {
+		if specificity, ok := dockerauth.MatchHost(r.pattern, serverAddress); ok && (provider == nil || specificity > best) {
+			provider, best = r.provider, specificity
+		}
+	}
+	if provider == nil {
+		if p.anonymousFallback {
+			return ratify.RegistryCredential{}, nil
+		}
+		return ratify.RegistryCredential{}, fmt.Errorf("no credential provider configured for registry %s", serverAddress)
+	}
+	return provider.Get(ctx, serverAddress)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multi
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/static"
+)
+
+const failingProviderName = "multi-test-failing"
+
+func init() {
+	credentialprovider.RegisterCredentialProviderFactory(failingProviderName, func(credentialprovider.Options) (ratify.RegistryCredentialGetter, error) {
+		return failingProvider{}, nil
+	})
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Get(context.Context, string) (ratify.RegistryCredential, error) {
+	return ratify.RegistryCredential{}, errors.New("failed to get credentials")
+}
+
+func staticOptions(username string) credentialprovider.Options {
+	return credentialprovider.Options{
+		"provider": "static",
+		"username": username,
+		"password": username + "-password",
+	}
+}
+
+func TestCreateProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      credentialprovider.Options
+		expectErr bool
+	}{
+		{
+			name: "valid options",
+			opts: credentialprovider.Options{
+				"provider": providerName,
+				"registries": map[string]any{
+					"*.azurecr.io": staticOptions("acr"),
+					"ghcr.io":      staticOptions("ghcr"),
+				},
+				"anonymousFallback": true,
+			},
+		},
+		{
+			name:      "no registries",
+			opts:      credentialprovider.Options{"provider": providerName},
+			expectErr: true,
+		},
+		{
+			name: "empty pattern",
+			opts: credentialprovider.Options{
+				"provider":   providerName,
+				"registries": map[string]any{"": staticOptions("empty")},
+			},
+			expectErr: true,
+		},
+		{
+			name: "unregistered child provider",
+			opts: credentialprovider.Options{
+				"provider":   providerName,
+				"registries": map[string]any{"ghcr.io": map[string]any{"provider": "unknown"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "missing child provider type",
+			opts: credentialprovider.Options{
+				"provider":   providerName,
+				"registries": map[string]any{"ghcr.io": map[string]any{}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid options",
+			opts: credentialprovider.Options{
+				"provider":   providerName,
+				"registries": []string{"ghcr.io"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := credentialprovider.NewCredentialProvider(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	registries := map[string]credentialprovider.Options{
+		"*.azurecr.io":                staticOptions("acr"),
+		"myregistry.azurecr.io":       staticOptions("myregistry"),
+		"*.example.*":                 staticOptions("broad"),
+		"*.example.com":               staticOptions("narrow"),
+		"ghcr.io":                     staticOptions("ghcr"),
+		"https://index.docker.io/v1/": staticOptions("hub"),
+		"failing.io":                  {"provider": failingProviderName},
+	}
+
+	tests := []struct {
+		name              string
+		anonymousFallback bool
+		serverAddress     string
+		expectUsername    string
+		expectErr         bool
+	}{
+		{
+			name:           "exact host",
+			serverAddress:  "ghcr.io",
+			expectUsername: "ghcr",
+		},
+		{
+			name:           "wildcard host",
+			serverAddress:  "other.azurecr.io",
+			expectUsername: "acr",
+		},
+		{
+			name:           "exact host over wildcard",
+			serverAddress:  "myregistry.azurecr.io",
+			expectUsername: "myregistry",
+		},
+		{
+			name:           "most specific wildcard",
+			serverAddress:  "registry.example.com",
+			expectUsername: "narrow",
+		},
+		{
+			name:           "less specific wildcard",
+			serverAddress:  "registry.example.org",
+			expectUsername: "broad",
+		},
+		{
+			name:           "docker hub alias",
+			serverAddress:  "docker.io",
+			expectUsername: "hub",
+		},
+		{
+			name:          "child provider error",
+			serverAddress: "failing.io",
+			expectErr:     true,
+		},
+		{
+			name:          "no match",
+			serverAddress: "quay.io",
+			expectErr:     true,
+		},
+		{
+			name:              "anonymous fallback",
+			anonymousFallback: true,
+			serverAddress:     "quay.io",
+		},
+		{
+			name:              "anonymous fallback does not hide child errors",
+			anonymousFallback: true,
+			serverAddress:     "failing.io",
+			expectErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(Options{Registries: registries, AnonymousFallback: tt.anonymousFallback})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cred, err := provider.Get(context.Background(), tt.serverAddress)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if cred.Username != tt.expectUsername {
+				t.Fatalf("expected username %q, got %q", tt.expectUsername, cred.Username)
+			}
+			if tt.expectUsername != "" && cred.Password != tt.expectUsername+"-password" {
+				t.Fatalf("unexpected password for %s", tt.expectUsername)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialprovider
+
+import (
+	"context"
+
+	"github.com/notaryproject/ratify-go"
+)
+
+// requestCredentialKey is the context key of the [RequestCredential].
+type requestCredentialKey struct{}
+
+// RequestCredential is the registry credential provider of a single request,
+// used by stores instead of their configured credential provider.
+type RequestCredential struct {
+	// Identity identifies the credentials, e.g. the namespace and service
+	// account they were resolved for. Content fetched with the credentials is
+	// only cached for requests with the same identity. Required.
+	Identity string
+
+	// Provider provides the registry credentials. Required.
+	Provider ratify.RegistryCredentialGetter
+}
+
+// WithRequestCredential returns a copy of ctx carrying the request credential.
+func WithRequestCredential(ctx context.Context, cred RequestCredential) context.Context {
+	return context.WithValue(ctx, requestCredentialKey{}, cred)
+}
+
+// RequestCredentialFromContext returns the request credential carried by ctx.
+func RequestCredentialFromContext(ctx context.Context) (RequestCredential, bool) {
+	cred, ok := ctx.Value(requestCredentialKey{}).(RequestCredential)
+	return cred, ok && cred.Identity != "" && cred.Provider != nil
+}
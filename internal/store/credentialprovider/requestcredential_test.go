@@ -0,0 +1,74 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+)
+
+type testCredentialGetter struct{}
+
+func (testCredentialGetter) Get(context.Context, string) (ratify.RegistryCredential, error) {
+	return ratify.RegistryCredential{Username: "tenant"}, nil
+}
+
+func TestRequestCredentialFromContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expectOK bool
+	}{
+		{
+			name: "no request credential",
+			ctx:  context.Background(),
+		},
+		{
+			name: "request credential",
+			ctx: WithRequestCredential(context.Background(), RequestCredential{
+				Identity: "tenant/default",
+				Provider: testCredentialGetter{},
+			}),
+			expectOK: true,
+		},
+		{
+			name: "missing identity",
+			ctx: WithRequestCredential(context.Background(), RequestCredential{
+				Provider: testCredentialGetter{},
+			}),
+		},
+		{
+			name: "missing provider",
+			ctx: WithRequestCredential(context.Background(), RequestCredential{
+				Identity: "tenant/default",
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, ok := RequestCredentialFromContext(tt.ctx)
+			if ok != tt.expectOK {
+				t.Fatalf("expected ok: %v, got: %v", tt.expectOK, ok)
+			}
+			if ok && cred.Identity != "tenant/default" {
+				t.Fatalf("unexpected identity %q", cred.Identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// credentialFiles are the paths of the files holding credentials.
+type credentialFiles struct {
+	username     string
+	password     string
+	refreshToken string
+}
+
+// credentialValues are the credentials read from credentialFiles.
+type credentialValues struct {
+	username     string
+	password     string
+	refreshToken string
+}
+
+// fileCredentials holds credentials read from files and reloads them whenever
+// the files change.
+type fileCredentials struct {
+	files   credentialFiles
+	values  atomic.Pointer[credentialValues]
+	watcher *fsnotify.Watcher
+}
+
+var (
+	// sharedFileCredentialsMu guards sharedFileCredentials.
+	sharedFileCredentialsMu sync.Mutex
+
+	// sharedFileCredentials are the watched credential files of all static
+	// providers, so that recreating providers on configuration changes does
+	// not start new watchers.
+	sharedFileCredentials = make(map[credentialFiles]*fileCredentials)
+)
+
+// getFileCredentials returns the watched credentials of the files, loading
+// them and starting the watch on first use.
+func getFileCredentials(files credentialFiles) (*fileCredentials, error) {
+	sharedFileCredentialsMu.Lock()
+	defer sharedFileCredentialsMu.Unlock()
+	if fc, ok := sharedFileCredentials[files]; ok {
+		return fc, nil
+	}
+	fc, err := newFileCredentials(files)
+	if err != nil {
+		return nil, err
+	}
+	sharedFileCredentials[files] = fc
+	return fc, nil
+}
+
+// newFileCredentials loads the credentials from the files and starts watching
+// them.
+func newFileCredentials(files credentialFiles) (*fileCredentials, error) {
+	fc := &fileCredentials{files: files}
+	if err := fc.load(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	// Watch the parent directories rather than the files, as mounted Secret
+	// volumes are updated by atomically swapping a symlinked directory, which
+	// replaces the files instead of writing to them.
+	watched := make(map[string]struct{})
+	for _, path := range []string{files.username, files.password, files.refreshToken} {
+		if path == "" {
+			continue
+		}
+		dir := filepath.Dir(path)
+		if _, ok := watched[dir]; ok {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to watch directory %s: %w", dir, err)
+		}
+		watched[dir] = struct{}{}
+	}
+	fc.watcher = watcher
+	go fc.watch()
+	return fc, nil
+}
+
+// credentials returns the most recently loaded credentials.
+func (fc *fileCredentials) credentials() *credentialValues {
+	return fc.values.Load()
+}
+
+// load reads the credential files. The contents are never logged or included
+// in errors.
+func (fc *fileCredentials) load() error {
+	var values credentialValues
+	for _, f := range []struct {
+		path  string
+		value *string
+	}{
+		{fc.files.username, &values.username},
+		{fc.files.password, &values.password},
+		{fc.files.refreshToken, &values.refreshToken},
+	} {
+		if f.path == "" {
+			continue
+		}
+		content, err := os.ReadFile(f.path)
+		if err != nil {
+			return fmt.Errorf("failed to read credential file %s: %w", f.path, err)
+		}
+		*f.value = strings.TrimRight(string(content), "\r\n")
+	}
+	fc.values.Store(&values)
+	return nil
+}
+
+// watch reloads the credentials on changes in the watched directories. If
+// the files cannot be read, the previously loaded credentials are kept.
+func (fc *fileCredentials) watch() {
+	for {
+		select {
+		case event, ok := <-fc.watcher.Events:
+			// If the watcher is closed, exit the loop.
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := fc.load(); err != nil {
+				logrus.Warnf("failed to reload static credentials, keep using the previous ones: %v", err)
+				continue
+			}
+			logrus.Debugf("reloaded static credentials after %s %s", event.Op, event.Name)
+		case err, ok := <-fc.watcher.Errors:
+			// If the watcher is closed, exit the loop.
+			if !ok {
+				return
+			}
+			logrus.Errorf("error watching credential files: %v", err)
+		}
+	}
+}
+
+// close stops watching the files.
+func (fc *fileCredentials) close() error {
+	return fc.watcher.Close()
+}
@@ -0,0 +1,165 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCredentialFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write file %s: %v", path, err)
+	}
+}
+
+// waitForCredentials waits until the loaded credentials satisfy check.
+func waitForCredentials(t *testing.T, fc *fileCredentials, check func(*credentialValues) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !check(fc.credentials()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("credentials were not reloaded, got %+v", fc.credentials())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	usernameFile := filepath.Join(dir, "username")
+	passwordFile := filepath.Join(dir, "password")
+	writeCredentialFile(t, usernameFile, "user\n")
+	writeCredentialFile(t, passwordFile, "secret\r\n")
+
+	fc, err := newFileCredentials(credentialFiles{username: usernameFile, password: passwordFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer fc.close()
+	if values := fc.credentials(); values.username != "user" || values.password != "secret" {
+		t.Fatalf("expected trimmed credentials, got %+v", values)
+	}
+
+	if _, err := newFileCredentials(credentialFiles{password: filepath.Join(dir, "missing")}); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestFileCredentials_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	writeCredentialFile(t, passwordFile, "old")
+
+	fc, err := newFileCredentials(credentialFiles{password: passwordFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer fc.close()
+
+	writeCredentialFile(t, passwordFile, "new")
+	waitForCredentials(t, fc, func(v *credentialValues) bool { return v.password == "new" })
+
+	// Replacing the file keeps the watch working.
+	tmp := filepath.Join(dir, "password.tmp")
+	writeCredentialFile(t, tmp, "replaced")
+	if err := os.Rename(tmp, passwordFile); err != nil {
+		t.Fatalf("failed to replace file: %v", err)
+	}
+	waitForCredentials(t, fc, func(v *credentialValues) bool { return v.password == "replaced" })
+
+	// A removed file keeps the previous credentials.
+	if err := os.Remove(passwordFile); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if values := fc.credentials(); values.password != "replaced" {
+		t.Fatalf("expected previous credentials to be kept, got %+v", values)
+	}
+
+	writeCredentialFile(t, passwordFile, "restored")
+	waitForCredentials(t, fc, func(v *credentialValues) bool { return v.password == "restored" })
+}
+
+func TestFileCredentials_SecretVolumeUpdate(t *testing.T) {
+	// Mounted Secret volumes link each key to a file in a timestamped
+	// directory through the ..data symlink, which is swapped on updates.
+	dir := t.TempDir()
+	mountDir := filepath.Join(dir, "mount")
+	if err := os.Mkdir(mountDir, 0700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeVersion := func(version, token string) {
+		versionDir := filepath.Join(mountDir, version)
+		if err := os.Mkdir(versionDir, 0700); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		writeCredentialFile(t, filepath.Join(versionDir, "token"), token)
+		link := filepath.Join(mountDir, "..data_tmp")
+		if err := os.Symlink(version, link); err != nil {
+			t.Fatalf("failed to create symlink: %v", err)
+		}
+		if err := os.Rename(link, filepath.Join(mountDir, "..data")); err != nil {
+			t.Fatalf("failed to swap symlink: %v", err)
+		}
+	}
+	writeVersion("..v1", "token-1")
+	if err := os.Symlink(filepath.Join("..data", "token"), filepath.Join(mountDir, "token")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	fc, err := newFileCredentials(credentialFiles{refreshToken: filepath.Join(mountDir, "token")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer fc.close()
+	if values := fc.credentials(); values.refreshToken != "token-1" {
+		t.Fatalf("expected token-1, got %+v", values)
+	}
+
+	writeVersion("..v2", "token-2")
+	waitForCredentials(t, fc, func(v *credentialValues) bool { return v.refreshToken == "token-2" })
+}
+
+func TestGetFileCredentials_Shared(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	writeCredentialFile(t, passwordFile, "secret")
+	files := credentialFiles{password: passwordFile}
+	t.Cleanup(func() {
+		sharedFileCredentialsMu.Lock()
+		defer sharedFileCredentialsMu.Unlock()
+		if fc, ok := sharedFileCredentials[files]; ok {
+			_ = fc.close()
+			delete(sharedFileCredentials, files)
+		}
+	})
+
+	first, err := getFileCredentials(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := getFileCredentials(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != second {
+		t.Fatal("expected the file credentials to be shared")
+	}
+}
@@ -25,10 +25,12 @@ import (
 )
 
 // Provider is an implementation of [ratify.RegistryCredentialGetter]
-// that provides static credentials for registry authentication.
+// that provides static credentials for registry authentication. Credentials
+// read from files are reloaded whenever the files change.
 type Provider struct {
 	username string
 	password string
+	files    *fileCredentials
 }
 
 // Options contains configuration options for the static
@@ -41,6 +43,19 @@ type Options struct {
 	// Password is the password to login to the registry.
 	// If username is not set, this will be used as a refresh token. Optional.
 	Password string `json:"password,omitempty"`
+
+	// UsernameFile is the path to a file containing the username, e.g. a key
+	// of a mounted Secret volume. Cannot be combined with username. Optional.
+	UsernameFile string `json:"usernameFile,omitempty"`
+
+	// PasswordFile is the path to a file containing the password. If neither
+	// username nor usernameFile is set, the password is used as a refresh
+	// token. Cannot be combined with password. Optional.
+	PasswordFile string `json:"passwordFile,omitempty"`
+
+	// RefreshTokenFile is the path to a file containing a refresh token.
+	// Cannot be combined with any other option. Optional.
+	RefreshTokenFile string `json:"refreshTokenFile,omitempty"`
 }
 
 func init() {
@@ -62,10 +77,38 @@ func createStaticCredentialProvider(opts credentialprovider.Options) (ratify.Reg
 		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
 	}
 
-	// Create the inline credential provider with the configuration
-	return &Provider{
+	files, err := validateOptions(&staticOpts)
+	if err != nil {
+		return nil, err
+	}
+	provider := &Provider{
 		username: staticOpts.Username,
 		password: staticOpts.Password,
+	}
+	if files != (credentialFiles{}) {
+		if provider.files, err = getFileCredentials(files); err != nil {
+			return nil, err
+		}
+	}
+	return provider, nil
+}
+
+// validateOptions validates the combination of inline and file based
+// credentials and returns the credential files.
+func validateOptions(opts *Options) (credentialFiles, error) {
+	if opts.Username != "" && opts.UsernameFile != "" {
+		return credentialFiles{}, fmt.Errorf("username and usernameFile cannot be used together")
+	}
+	if opts.Password != "" && opts.PasswordFile != "" {
+		return credentialFiles{}, fmt.Errorf("password and passwordFile cannot be used together")
+	}
+	if opts.RefreshTokenFile != "" && (opts.Username != "" || opts.UsernameFile != "" || opts.Password != "" || opts.PasswordFile != "") {
+		return credentialFiles{}, fmt.Errorf("refreshTokenFile cannot be used together with username or password")
+	}
+	return credentialFiles{
+		username:     opts.UsernameFile,
+		password:     opts.PasswordFile,
+		refreshToken: opts.RefreshTokenFile,
 	}, nil
 }
 
@@ -73,16 +116,32 @@ func createStaticCredentialProvider(opts credentialprovider.Options) (ratify.Reg
 // The serverAddress parameter is ignored as this provider returns the same
 // credentials for all registries.
 func (p *Provider) Get(_ context.Context, _ string) (ratify.RegistryCredential, error) {
-	if p.username == "" {
+	username, password := p.username, p.password
+	if p.files != nil {
+		values := p.files.credentials()
+		if p.files.files.refreshToken != "" {
+			return ratify.RegistryCredential{
+				RefreshToken: values.refreshToken,
+			}, nil
+		}
+		if p.files.files.username != "" {
+			username = values.username
+		}
+		if p.files.files.password != "" {
+			password = values.password
+		}
+	}
+
+	if username == "" {
 		// If username is not set, use password as refresh token
 		return ratify.RegistryCredential{
-			RefreshToken: p.password,
+			RefreshToken: password,
 		}, nil
 	}
 
 	// Return username/password credentials
 	return ratify.RegistryCredential{
-		Username: p.username,
-		Password: p.password,
+		Username: username,
+		Password: password,
 	}, nil
 }
@@ -17,7 +17,9 @@ package static
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/notaryproject/ratify-go"
 	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
@@ -323,3 +325,131 @@ func BenchmarkStaticCredentialProvider_Get(b *testing.B) {
 		}
 	}
 }
+
+func TestCreateStaticCredentialProvider_Files(t *testing.T) {
+	dir := t.TempDir()
+	usernameFile := filepath.Join(dir, "username")
+	passwordFile := filepath.Join(dir, "password")
+	tokenFile := filepath.Join(dir, "token")
+	writeCredentialFile(t, usernameFile, "fileuser\n")
+	writeCredentialFile(t, passwordFile, "filepass\n")
+	writeCredentialFile(t, tokenFile, "filetoken\n")
+
+	tests := []struct {
+		name        string
+		opts        credentialprovider.Options
+		expectError bool
+		expected    ratify.RegistryCredential
+	}{
+		{
+			name: "username and password files",
+			opts: credentialprovider.Options{
+				"usernameFile": usernameFile,
+				"passwordFile": passwordFile,
+			},
+			expected: ratify.RegistryCredential{Username: "fileuser", Password: "filepass"},
+		},
+		{
+			name: "inline username and password file",
+			opts: credentialprovider.Options{
+				"username":     "testuser",
+				"passwordFile": passwordFile,
+			},
+			expected: ratify.RegistryCredential{Username: "testuser", Password: "filepass"},
+		},
+		{
+			name: "password file only (refresh token mode)",
+			opts: credentialprovider.Options{
+				"passwordFile": passwordFile,
+			},
+			expected: ratify.RegistryCredential{RefreshToken: "filepass"},
+		},
+		{
+			name: "refresh token file",
+			opts: credentialprovider.Options{
+				"refreshTokenFile": tokenFile,
+			},
+			expected: ratify.RegistryCredential{RefreshToken: "filetoken"},
+		},
+		{
+			name: "username and usernameFile",
+			opts: credentialprovider.Options{
+				"username":     "testuser",
+				"usernameFile": usernameFile,
+			},
+			expectError: true,
+		},
+		{
+			name: "password and passwordFile",
+			opts: credentialprovider.Options{
+				"password":     "testpass",
+				"passwordFile": passwordFile,
+			},
+			expectError: true,
+		},
+		{
+			name: "refreshTokenFile and password",
+			opts: credentialprovider.Options{
+				"password":         "testpass",
+				"refreshTokenFile": tokenFile,
+			},
+			expectError: true,
+		},
+		{
+			name: "missing file",
+			opts: credentialprovider.Options{
+				"passwordFile": filepath.Join(dir, "missing"),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := createStaticCredentialProvider(tt.opts)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			cred, err := provider.Get(context.Background(), "registry.example.com")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cred != tt.expected {
+				t.Fatalf("expected credential %+v, got %+v", tt.expected, cred)
+			}
+		})
+	}
+}
+
+func TestStaticCredentialProvider_GetRotatedFile(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	writeCredentialFile(t, passwordFile, "old")
+
+	provider, err := createStaticCredentialProvider(credentialprovider.Options{
+		"username":     "testuser",
+		"passwordFile": passwordFile,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeCredentialFile(t, passwordFile, "new")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cred, err := provider.Get(context.Background(), "registry.example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cred.Password == "new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("rotated password was not picked up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
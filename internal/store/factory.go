@@ -17,8 +17,10 @@ package store
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/blobcache"
 )
 
 // NewOptions defines the options for creating a new [ratify.Store].
@@ -36,6 +38,15 @@ type NewOptions struct {
 // registry saves the registered store factories.
 var registry map[string]func(NewOptions) (ratify.Store, error)
 
+// sharedBlobCache is the content cache shared by all stores created by [New].
+var sharedBlobCache atomic.Pointer[blobcache.Cache]
+
+// SetBlobCache sets the content-addressed cache of blobs and manifests shared
+// by all stores created by [New] afterwards. Passing nil disables the cache.
+func SetBlobCache(cache *blobcache.Cache) {
+	sharedBlobCache.Store(cache)
+}
+
 // Register registers a store factory to the system.
 func Register(storeType string, create func(NewOptions) (ratify.Store, error)) {
 	if storeType == "" {
@@ -84,6 +95,9 @@ func New(opts []NewOptions, globalScopes []string) (ratify.Store, error) {
 		}
 	}
 
+	if cache := sharedBlobCache.Load(); cache != nil {
+		return blobcache.NewStore(storeMux, cache), nil
+	}
 	return storeMux, nil
 }
 
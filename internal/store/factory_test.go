@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/blobcache"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
@@ -209,3 +210,32 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestNew_BlobCache(t *testing.T) {
+	Register("mock-store-with-cache", newMockStore)
+	defer delete(registry, "mock-store-with-cache")
+	opts := []NewOptions{{Type: "mock-store-with-cache"}}
+
+	s, err := New(opts, []string{"example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.(*ratify.StoreMux); !ok {
+		t.Fatalf("expected store mux without blob cache, got %T", s)
+	}
+
+	cache, err := blobcache.New(blobcache.Options{MaxMemoryBytes: 1024})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	SetBlobCache(cache)
+	defer SetBlobCache(nil)
+
+	s, err = New(opts, []string{"example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.(*ratify.StoreMux); ok {
+		t.Fatal("expected store mux to be wrapped by the blob cache")
+	}
+}
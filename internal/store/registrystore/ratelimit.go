@@ -0,0 +1,223 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimitDeadline is returned when a request cannot be sent to the
+// registry before the deadline of its context because it is queued behind the
+// rate limit or the in-flight request cap of the registry host.
+var ErrRateLimitDeadline = errors.New("request would exceed the deadline while waiting for the registry rate limit")
+
+// rateLimitOptions configures per-registry rate limits of outbound requests.
+type rateLimitOptions struct {
+	hostRateLimitOptions
+
+	// Registries overrides the limits for individual registry hosts, keyed by
+	// the host (and port, if any) the requests are sent to, e.g.
+	// "registry-1.docker.io". Optional.
+	Registries map[string]hostRateLimitOptions `json:"registries,omitempty"`
+}
+
+// hostRateLimitOptions are the limits applied to each registry host.
+type hostRateLimitOptions struct {
+	// RequestsPerSecond is the sustained rate of requests sent to a registry
+	// host. 0 means no rate limit. Optional.
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty"`
+
+	// Burst is the maximum number of requests sent at once before the rate
+	// limit applies. Defaults to RequestsPerSecond rounded up. Optional.
+	Burst int `json:"burst,omitempty"`
+
+	// MaxInFlight is the maximum number of concurrent requests to a registry
+	// host, including reading the response body. 0 means no limit. Optional.
+	MaxInFlight int `json:"maxInFlight,omitempty"`
+}
+
+// validate checks the options and applies the default burst.
+func (o *hostRateLimitOptions) validate() error {
+	if o.RequestsPerSecond < 0 || o.Burst < 0 || o.MaxInFlight < 0 {
+		return fmt.Errorf("requestsPerSecond, burst and maxInFlight cannot be negative")
+	}
+	if o.RequestsPerSecond > 0 && o.Burst == 0 {
+		o.Burst = int(math.Ceil(o.RequestsPerSecond))
+	}
+	return nil
+}
+
+// hostLimiter holds the token bucket and in-flight slots of a registry host.
+type hostLimiter struct {
+	limiter  *rate.Limiter
+	inFlight chan struct{}
+}
+
+// hostLimiterKey identifies a host limiter. Stores configured with the same
+// limits for a host share the limiter, so that the limits apply to all
+// executors talking to the registry.
+type hostLimiterKey struct {
+	host string
+	hostRateLimitOptions
+}
+
+var hostLimiters sync.Map // map[hostLimiterKey]*hostLimiter
+
+// getHostLimiter returns the shared limiter of the host for the given limits.
+func getHostLimiter(host string, opts hostRateLimitOptions) *hostLimiter {
+	key := hostLimiterKey{host: host, hostRateLimitOptions: opts}
+	if l, ok := hostLimiters.Load(key); ok {
+		return l.(*hostLimiter)
+	}
+	l := &hostLimiter{}
+	if opts.RequestsPerSecond > 0 {
+		l.limiter = rate.NewLimiter(rate.Limit(opts.RequestsPerSecond), opts.Burst)
+	}
+	if opts.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, opts.MaxInFlight)
+	}
+	actual, _ := hostLimiters.LoadOrStore(key, l)
+	return actual.(*hostLimiter)
+}
+
+// rateLimitTransport is an [http.RoundTripper] that enforces token-bucket rate
+// limits and in-flight request caps per registry host. Requests that cannot be
+// sent before the deadline of their context fail fast with
+// [ErrRateLimitDeadline].
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	defaults   hostRateLimitOptions
+	registries map[string]hostRateLimitOptions
+}
+
+// newRateLimitTransport wraps base with the rate limits configured by opts.
+func newRateLimitTransport(base http.RoundTripper, opts *rateLimitOptions) (*rateLimitTransport, error) {
+	t := &rateLimitTransport{
+		base:       base,
+		defaults:   opts.hostRateLimitOptions,
+		registries: make(map[string]hostRateLimitOptions, len(opts.Registries)),
+	}
+	if err := t.defaults.validate(); err != nil {
+		return nil, fmt.Errorf("invalid rate limit: %w", err)
+	}
+	for host, hostOpts := range opts.Registries {
+		if host == "" {
+			return nil, fmt.Errorf("rate limit registry host cannot be empty")
+		}
+		if err := hostOpts.validate(); err != nil {
+			return nil, fmt.Errorf("invalid rate limit for registry %s: %w", host, err)
+		}
+		t.registries[host] = hostOpts
+	}
+	return t, nil
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	opts, ok := t.registries[req.URL.Host]
+	if !ok {
+		opts = t.defaults
+	}
+	if opts.RequestsPerSecond == 0 && opts.MaxInFlight == 0 {
+		return t.base.RoundTrip(req)
+	}
+	l := getHostLimiter(req.URL.Host, opts)
+
+	if err := l.acquire(req); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if l.inFlight == nil {
+		return resp, err
+	}
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+	// Keep the slot until the response body is consumed.
+	resp.Body = &releaseOnCloseBody{ReadCloser: resp.Body, release: l.release}
+	return resp, nil
+}
+
+// acquire waits for an in-flight slot and a rate limit token.
+func (l *hostLimiter) acquire(req *http.Request) error {
+	ctx := req.Context()
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("request to registry %s: %w", req.URL.Host, ErrRateLimitDeadline)
+			}
+			return ctx.Err()
+		}
+	}
+	if l.limiter == nil {
+		return nil
+	}
+
+	reservation := l.limiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		reservation.Cancel()
+		l.release()
+		return fmt.Errorf("request to registry %s: %w", req.URL.Host, ErrRateLimitDeadline)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		reservation.Cancel()
+		l.release()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// release frees the in-flight slot taken by acquire.
+func (l *hostLimiter) release() {
+	if l.inFlight != nil {
+		<-l.inFlight
+	}
+}
+
+// releaseOnCloseBody releases the in-flight slot of a request once the
+// response body is closed.
+type releaseOnCloseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close implements [io.Closer].
+func (b *releaseOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
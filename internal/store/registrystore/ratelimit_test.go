@@ -0,0 +1,223 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func okRoundTripper() roundTripperFunc {
+	return func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	}
+}
+
+func newRequestWithTimeout(t *testing.T, url string, timeout time.Duration) *http.Request {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	return req
+}
+
+func TestNewRateLimitTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      *rateLimitOptions
+		expectErr bool
+	}{
+		{
+			name: "valid limits",
+			opts: &rateLimitOptions{
+				hostRateLimitOptions: hostRateLimitOptions{RequestsPerSecond: 10, MaxInFlight: 5},
+				Registries: map[string]hostRateLimitOptions{
+					"registry-1.docker.io": {RequestsPerSecond: 1, Burst: 2},
+				},
+			},
+		},
+		{
+			name:      "negative requests per second",
+			opts:      &rateLimitOptions{hostRateLimitOptions: hostRateLimitOptions{RequestsPerSecond: -1}},
+			expectErr: true,
+		},
+		{
+			name: "negative registry max in flight",
+			opts: &rateLimitOptions{
+				Registries: map[string]hostRateLimitOptions{"example.com": {MaxInFlight: -1}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "empty registry host",
+			opts: &rateLimitOptions{
+				Registries: map[string]hostRateLimitOptions{"": {MaxInFlight: 1}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newRateLimitTransport(okRoundTripper(), tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransport_RequestsPerSecond(t *testing.T) {
+	transport, err := newRateLimitTransport(okRoundTripper(), &rateLimitOptions{
+		hostRateLimitOptions: hostRateLimitOptions{RequestsPerSecond: 20, Burst: 1},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	url := "https://rps.ratelimit.test/v2/"
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := transport.RoundTrip(newRequestWithTimeout(t, url, time.Second))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("expected requests to be rate limited, took %v", elapsed)
+	}
+
+	// The next token is not available before the deadline.
+	start = time.Now()
+	_, err = transport.RoundTrip(newRequestWithTimeout(t, url, 10*time.Millisecond))
+	if !errors.Is(err, ErrRateLimitDeadline) {
+		t.Fatalf("expected ErrRateLimitDeadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 10*time.Millisecond {
+		t.Fatalf("expected request to fail fast, took %v", elapsed)
+	}
+}
+
+func TestRateLimitTransport_MaxInFlight(t *testing.T) {
+	transport, err := newRateLimitTransport(okRoundTripper(), &rateLimitOptions{
+		hostRateLimitOptions: hostRateLimitOptions{MaxInFlight: 1},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	url := "https://inflight.ratelimit.test/v2/"
+
+	resp, err := transport.RoundTrip(newRequestWithTimeout(t, url, time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The slot is held until the response body is closed.
+	if _, err = transport.RoundTrip(newRequestWithTimeout(t, url, 20*time.Millisecond)); !errors.Is(err, ErrRateLimitDeadline) {
+		t.Fatalf("expected ErrRateLimitDeadline, got %v", err)
+	}
+
+	resp.Body.Close()
+	resp.Body.Close()
+	resp, err = transport.RoundTrip(newRequestWithTimeout(t, url, time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	// Failed requests release the slot.
+	failing, err := newRateLimitTransport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}), &rateLimitOptions{hostRateLimitOptions: hostRateLimitOptions{MaxInFlight: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = failing.RoundTrip(newRequestWithTimeout(t, "https://failing.ratelimit.test/v2/", 20*time.Millisecond)); errors.Is(err, ErrRateLimitDeadline) {
+			t.Fatalf("expected slot to be released after a failed request")
+		}
+	}
+}
+
+func TestRateLimitTransport_Registries(t *testing.T) {
+	opts := &rateLimitOptions{
+		Registries: map[string]hostRateLimitOptions{
+			"limited.ratelimit.test": {MaxInFlight: 1},
+		},
+	}
+	transport, err := newRateLimitTransport(okRoundTripper(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A second store configured with the same limits shares the limiter.
+	other, err := newRateLimitTransport(okRoundTripper(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := transport.RoundTrip(newRequestWithTimeout(t, "https://limited.ratelimit.test/v2/", time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if _, err = other.RoundTrip(newRequestWithTimeout(t, "https://limited.ratelimit.test/v2/", 20*time.Millisecond)); !errors.Is(err, ErrRateLimitDeadline) {
+		t.Fatalf("expected ErrRateLimitDeadline, got %v", err)
+	}
+	// Other registries are not limited.
+	for i := 0; i < 2; i++ {
+		resp, err := other.RoundTrip(newRequestWithTimeout(t, "https://unlimited.ratelimit.test/v2/", time.Second))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+	}
+}
+
+func TestWithResilience_RateLimitNotRetried(t *testing.T) {
+	calls := 0
+	client, err := withResilience(&http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})}, &options{
+		Retry:     fastRetryOptions(),
+		RateLimit: &rateLimitOptions{hostRateLimitOptions: hostRateLimitOptions{RequestsPerSecond: 1, Burst: 1}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := client.Do(newRequestWithTimeout(t, "https://retry.ratelimit.test/v2/", time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if _, err = client.Do(newRequestWithTimeout(t, "https://retry.ratelimit.test/v2/", 100*time.Millisecond)); !errors.Is(err, ErrRateLimitDeadline) {
+		t.Fatalf("expected ErrRateLimitDeadline, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
@@ -17,8 +17,6 @@ package registrystore
 
 import (
 	"crypto/tls"
-	"crypto/x509"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -60,40 +58,135 @@ type options struct {
 	// registry. Either CABase64 or CAPem can be used, but CAPem is preferred.
 	// Optional.
 	CABase64 string `json:"caBase64,omitempty"`
+
+	// ClientCertPem is a PEM encoded client certificate chain presented to the
+	// registry for mutual TLS authentication. Must be used together with
+	// ClientKeyPem or ClientKeyBase64. Optional.
+	ClientCertPem string `json:"clientCertPem,omitempty"`
+
+	// ClientCertBase64 is a base64 encoded PEM client certificate chain.
+	// Either ClientCertBase64 or ClientCertPem can be used, but ClientCertPem
+	// is preferred. Optional.
+	ClientCertBase64 string `json:"clientCertBase64,omitempty"`
+
+	// ClientKeyPem is the PEM encoded private key of the client certificate.
+	// Optional.
+	ClientKeyPem string `json:"clientKeyPem,omitempty"`
+
+	// ClientKeyBase64 is the base64 encoded PEM private key of the client
+	// certificate. Either ClientKeyBase64 or ClientKeyPem can be used, but
+	// ClientKeyPem is preferred. Optional.
+	ClientKeyBase64 string `json:"clientKeyBase64,omitempty"`
+
+	// ClientCertFile is the path to a PEM encoded client certificate chain.
+	// The file is reloaded when it changes, so it can be a mounted Secret.
+	// Must be used together with ClientKeyFile and cannot be combined with the
+	// inline client certificate options. Optional.
+	ClientCertFile string `json:"clientCertFile,omitempty"`
+
+	// ClientKeyFile is the path to the PEM encoded private key of the client
+	// certificate. The file is reloaded when it changes. Optional.
+	ClientKeyFile string `json:"clientKeyFile,omitempty"`
+
+	// Proxy configures the HTTP proxy used to reach the registry. If not set,
+	// the proxy is taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables. Optional.
+	Proxy *proxyOptions `json:"proxy,omitempty"`
+
+	// Headers are static HTTP headers added to every request sent to the
+	// registry, e.g. tenant identifiers. Optional.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Transport tunes the connection pool, timeouts and HTTP/2 usage of the
+	// HTTP transport. Optional.
+	Transport *transportOptions `json:"transport,omitempty"`
+
+	// Retry configures retries of idempotent requests on network errors, 429
+	// and 5xx responses. Retries are enabled by default. Optional.
+	Retry *retryOptions `json:"retry,omitempty"`
+
+	// CircuitBreaker configures the per-registry circuit breaker that fails
+	// fast while a registry keeps failing. The breaker is enabled by default.
+	// Optional.
+	CircuitBreaker *circuitBreakerOptions `json:"circuitBreaker,omitempty"`
+
+	// RateLimit configures token-bucket rate limits and in-flight request caps
+	// per registry host. Requests are not limited by default. Optional.
+	RateLimit *rateLimitOptions `json:"rateLimit,omitempty"`
 }
 
-// createHTTPClient creates an HTTP client with optional CA PEM configuration
-func createHTTPClient(caPem, caBase64 string) (*http.Client, error) {
-	if caPem == "" && caBase64 == "" {
-		// Use default HTTP client if no CA is provided
+// createHTTPClient creates an HTTP client with optional CA bundle, mutual
+// TLS client certificate, proxy, static headers and transport configuration.
+func createHTTPClient(opts *options) (*http.Client, error) {
+	rootCAs, err := loadRootCAs(opts.CAPem, opts.CABase64)
+	if err != nil {
+		return nil, err
+	}
+	getClientCertificate, err := newClientCertificateFunc(opts)
+	if err != nil {
+		return nil, err
+	}
+	if rootCAs == nil && getClientCertificate == nil && opts.Proxy == nil &&
+		opts.Transport == nil && len(opts.Headers) == 0 {
+		// Use default HTTP client if no customization is provided
 		return http.DefaultClient, nil
 	}
 
-	caCertPool := x509.NewCertPool()
-	var caBundle []byte
-	// If both CA PEM and CA Base64 are provided, prefer CA PEM
-	if caPem != "" {
-		caBundle = []byte(caPem)
-	} else {
-		// If CABase64 is provided, decode it and append to the cert pool
-		var err error
-		if caBundle, err = base64.StdEncoding.DecodeString(caBase64); err != nil {
-			return nil, fmt.Errorf("failed to decode CA Base64: %w", err)
+	// Create a custom HTTP client with the TLS configuration
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if rootCAs != nil || getClientCertificate != nil {
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion:           tls.VersionTLS12,
+			RootCAs:              rootCAs,
+			GetClientCertificate: getClientCertificate,
 		}
 	}
-	if !caCertPool.AppendCertsFromPEM(caBundle) {
-		return nil, fmt.Errorf("failed to parse CA certificate: invalid PEM format")
+	if err = configureTransport(transport, opts.Proxy, opts.Transport); err != nil {
+		return nil, err
 	}
 
-	// Create a custom HTTP client with the TLS configuration
-	transport := http.DefaultTransport.(*http.Transport).Clone()
-	transport.TLSClientConfig = &tls.Config{
-		MinVersion: tls.VersionTLS12,
-		RootCAs:    caCertPool,
+	var roundTripper http.RoundTripper = transport
+	if len(opts.Headers) > 0 {
+		if roundTripper, err = newHeaderTransport(roundTripper, opts.Headers); err != nil {
+			return nil, err
+		}
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: roundTripper,
+	}, nil
+}
+
+// withResilience wraps the transport of client with the per-registry circuit
+// breaker, rate limits and retries. The breaker and the rate limits are
+// applied on every attempt so that retries stop as soon as the breaker opens
+// and count against the rate limits.
+func withResilience(client *http.Client, opts *options) (*http.Client, error) {
+	roundTripper := client.Transport
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+
+	var err error
+	if opts.CircuitBreaker == nil || !opts.CircuitBreaker.Disabled {
+		if roundTripper, err = newCircuitBreakerTransport(roundTripper, opts.CircuitBreaker); err != nil {
+			return nil, err
+		}
+	}
+	if opts.RateLimit != nil {
+		if roundTripper, err = newRateLimitTransport(roundTripper, opts.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+	if roundTripper, err = newRetryTransport(roundTripper, opts.Retry); err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport:     roundTripper,
+		CheckRedirect: client.CheckRedirect,
+		Jar:           client.Jar,
+		Timeout:       client.Timeout,
 	}, nil
 }
 
@@ -115,11 +208,14 @@ func init() {
 			return nil, fmt.Errorf("failed to create credential provider: %w", err)
 		}
 
-		// Create HTTP client with optional CA bundle
-		httpClient, err := createHTTPClient(params.CAPem, params.CABase64)
+		// Create HTTP client with the TLS and transport customizations
+		httpClient, err := createHTTPClient(&params)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 		}
+		if httpClient, err = withResilience(httpClient, &params); err != nil {
+			return nil, fmt.Errorf("failed to configure retry, rate limit and circuit breaker: %w", err)
+		}
 
 		registryStoreOpts := ratify.RegistryStoreOptions{
 			HTTPClient:         httpClient,
@@ -131,6 +227,6 @@ func init() {
 			CredentialProvider: credProvider,
 		}
 
-		return ratify.NewRegistryStore(registryStoreOpts), nil
+		return newRequestCredentialStore(registryStoreOpts)
 	})
 }
@@ -429,7 +429,7 @@ func TestCreateHTTPClient(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client, err := createHTTPClient(tt.caPem, tt.caBase64)
+			client, err := createHTTPClient(&options{CAPem: tt.caPem, CABase64: tt.caBase64})
 
 			if tt.expectError && err == nil {
 				t.Errorf("expected error but got none")
@@ -0,0 +1,120 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/cache"
+	"github.com/notaryproject/ratify/v2/internal/cache/inmemory"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// maxIdentityStores limits the number of credential identities a store
+	// keeps a registry store for.
+	maxIdentityStores = 256
+
+	// identityStoreTTL is the time the registry store of a credential
+	// identity is kept for.
+	identityStoreTTL = 30 * time.Minute
+)
+
+// errNoRequestCredential is returned if the registry store of a credential
+// identity is used without request credentials.
+var errNoRequestCredential = errors.New("no request credential")
+
+// requestCredentialStore is a [ratify.Store] that serves requests carrying a
+// [credentialprovider.RequestCredential] from a registry store dedicated to
+// the credential identity, so that registry tokens obtained with the
+// credentials of one identity are never used for another. Other requests are
+// served by the store with the configured credential provider.
+type requestCredentialStore struct {
+	ratify.Store
+	opts   ratify.RegistryStoreOptions
+	stores cache.Cache[ratify.Store]
+}
+
+// newRequestCredentialStore wraps the registry store created with opts.
+func newRequestCredentialStore(opts ratify.RegistryStoreOptions) (*requestCredentialStore, error) {
+	stores, err := inmemory.NewCache[ratify.Store](maxIdentityStores)
+	if err != nil {
+		return nil, err
+	}
+	identityOpts := opts
+	identityOpts.CredentialProvider = requestCredentialGetter{}
+	return &requestCredentialStore{
+		Store:  ratify.NewRegistryStore(opts),
+		opts:   identityOpts,
+		stores: stores,
+	}, nil
+}
+
+// storeFor returns the store serving the request.
+func (s *requestCredentialStore) storeFor(ctx context.Context) ratify.Store {
+	cred, ok := credentialprovider.RequestCredentialFromContext(ctx)
+	if !ok {
+		return s.Store
+	}
+	if store, err := s.stores.Get(ctx, cred.Identity); err == nil {
+		return store
+	}
+	store := ratify.NewRegistryStore(s.opts)
+	_ = s.stores.Set(ctx, cred.Identity, store, identityStoreTTL)
+	return store
+}
+
+// Resolve implements [ratify.Store].
+func (s *requestCredentialStore) Resolve(ctx context.Context, ref string) (ocispec.Descriptor, error) {
+	return s.storeFor(ctx).Resolve(ctx, ref)
+}
+
+// ListReferrers implements [ratify.Store].
+func (s *requestCredentialStore) ListReferrers(ctx context.Context, ref string, artifactTypes []string, fn func(referrers []ocispec.Descriptor) error) error {
+	return s.storeFor(ctx).ListReferrers(ctx, ref, artifactTypes, fn)
+}
+
+// FetchBlob implements [ratify.Store].
+func (s *requestCredentialStore) FetchBlob(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	return s.storeFor(ctx).FetchBlob(ctx, repo, desc)
+}
+
+// FetchManifest implements [ratify.Store].
+func (s *requestCredentialStore) FetchManifest(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	return s.storeFor(ctx).FetchManifest(ctx, repo, desc)
+}
+
+// requestCredentialGetter is a [ratify.RegistryCredentialGetter] returning
+// the credentials of the request credential carried by the context.
+type requestCredentialGetter struct{}
+
+// Get implements [ratify.RegistryCredentialGetter].
+func (requestCredentialGetter) Get(ctx context.Context, serverAddress string) (ratify.RegistryCredential, error) {
+	cred, ok := credentialprovider.RequestCredentialFromContext(ctx)
+	if !ok {
+		return ratify.RegistryCredential{}, errNoRequestCredential
+	}
+	credential, err := cred.Provider.Get(ctx, serverAddress)
+	if err != nil {
+		return ratify.RegistryCredential{}, fmt.Errorf("failed to get request credentials for %s: %w", cred.Identity, err)
+	}
+	return credential, nil
+}
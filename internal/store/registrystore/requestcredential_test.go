@@ -0,0 +1,169 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type usernameCredentialGetter string
+
+func (g usernameCredentialGetter) Get(context.Context, string) (ratify.RegistryCredential, error) {
+	return ratify.RegistryCredential{Username: string(g), Password: string(g) + "-password"}, nil
+}
+
+// basicAuthRegistry is a registry serving a single manifest to the users in
+// allowed, recording the users of the requests.
+type basicAuthRegistry struct {
+	allowed map[string]bool
+
+	mu    sync.Mutex
+	users []string
+}
+
+func (r *basicAuthRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	username, _, ok := req.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.mu.Lock()
+	r.users = append(r.users, username)
+	r.mu.Unlock()
+	if !r.allowed[username] {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+	w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+	w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+	w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+	w.WriteHeader(http.StatusOK)
+	if req.Method == http.MethodGet {
+		_, _ = w.Write(manifest)
+	}
+}
+
+func (r *basicAuthRegistry) lastUser() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.users) == 0 {
+		return ""
+	}
+	return r.users[len(r.users)-1]
+}
+
+func TestRequestCredentialStore(t *testing.T) {
+	registry := &basicAuthRegistry{allowed: map[string]bool{"global": true, "tenant-a": true}}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	ref := serverURL.Host + "/repo:v1"
+
+	store, err := newRequestCredentialStore(ratify.RegistryStoreOptions{
+		PlainHTTP:          true,
+		CredentialProvider: usernameCredentialGetter("global"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tenantA := credentialprovider.WithRequestCredential(context.Background(), credentialprovider.RequestCredential{
+		Identity: "tenant-a/default",
+		Provider: usernameCredentialGetter("tenant-a"),
+	})
+	tenantB := credentialprovider.WithRequestCredential(context.Background(), credentialprovider.RequestCredential{
+		Identity: "tenant-b/default",
+		Provider: usernameCredentialGetter("tenant-b"),
+	})
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		expectUser string
+		expectErr  bool
+	}{
+		{
+			name:       "configured credential provider",
+			ctx:        context.Background(),
+			expectUser: "global",
+		},
+		{
+			name:       "request credential",
+			ctx:        tenantA,
+			expectUser: "tenant-a",
+		},
+		{
+			name:       "request credential is not shared across identities",
+			ctx:        tenantB,
+			expectUser: "tenant-b",
+			expectErr:  true,
+		},
+		{
+			name:       "identity store is reused",
+			ctx:        tenantA,
+			expectUser: "tenant-a",
+		},
+		{
+			name:       "configured credential provider after request credentials",
+			ctx:        context.Background(),
+			expectUser: "global",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Resolve(tt.ctx, ref)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if user := registry.lastUser(); user != tt.expectUser {
+				t.Fatalf("expected request as %s, got %s", tt.expectUser, user)
+			}
+		})
+	}
+}
+
+func TestRequestCredentialGetter(t *testing.T) {
+	if _, err := (requestCredentialGetter{}).Get(context.Background(), "registry.example.com"); err == nil {
+		t.Fatal("expected error without request credential")
+	}
+	ctx := credentialprovider.WithRequestCredential(context.Background(), credentialprovider.RequestCredential{
+		Identity: "tenant-a/default",
+		Provider: usernameCredentialGetter("tenant-a"),
+	})
+	cred, err := (requestCredentialGetter{}).Get(ctx, "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cred.Username != "tenant-a" {
+		t.Fatalf("expected tenant-a credentials, got %s", cred.Username)
+	}
+}
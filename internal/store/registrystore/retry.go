@@ -0,0 +1,291 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/circuitbreaker"
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
+	"github.com/notaryproject/ratify/v2/pkg/metrics"
+)
+
+const (
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 200 * time.Millisecond
+	defaultMaxBackoff     = 2 * time.Second
+
+	// defaultRetryAfterFactor is the multiple of the maximum backoff that
+	// caps a Retry-After delay by default.
+	defaultRetryAfterFactor = 5
+
+	// maxDrainBytes limits the bytes read from a discarded response body so
+	// that the connection can be reused.
+	maxDrainBytes = 4 * 1024
+)
+
+// retryOptions configures retries of idempotent registry requests.
+type retryOptions struct {
+	// MaxAttempts is the maximum number of attempts per request, including the
+	// first one. Set to 1 to disable retries. If less than or equal to 0, a
+	// default (currently 3) is used. Optional.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+
+	// InitialBackoff is the backoff before the first retry. It doubles on each
+	// retry. Defaults to "200ms". Optional.
+	InitialBackoff jsonutil.Duration `json:"initialBackoff,omitempty"`
+
+	// MaxBackoff caps the exponential backoff. A Retry-After header returned
+	// by the registry takes precedence, up to MaxRetryAfter. Defaults to "2s".
+	// Optional.
+	MaxBackoff jsonutil.Duration `json:"maxBackoff,omitempty"`
+
+	// MaxRetryAfter caps the delay requested by a Retry-After header, so
+	// that a registry cannot stall verification with a large value. Defaults
+	// to 5 times MaxBackoff. Optional.
+	MaxRetryAfter jsonutil.Duration `json:"maxRetryAfter,omitempty"`
+}
+
+// circuitBreakerOptions configures the per-registry circuit breaker.
+type circuitBreakerOptions struct {
+	// Disabled disables the circuit breaker. Optional.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed requests to a
+	// registry host that opens the breaker. If less than or equal to 0, a
+	// default (currently 5) is used. Optional.
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+
+	// OpenTimeout is the duration the breaker rejects requests before a probe
+	// request is sent to the registry. Defaults to "30s". Optional.
+	OpenTimeout jsonutil.Duration `json:"openTimeout,omitempty"`
+}
+
+// retryTransport is an [http.RoundTripper] that retries idempotent requests
+// on network errors, 429 and 5xx responses with jittered exponential backoff,
+// or after the delay of a Retry-After header capped by maxRetryAfter.
+// Retries stop early if the next attempt cannot start before the deadline of
+// the request context.
+type retryTransport struct {
+	base           http.RoundTripper
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRetryAfter  time.Duration
+}
+
+// newRetryTransport wraps base with retries configured by opts.
+func newRetryTransport(base http.RoundTripper, opts *retryOptions) (*retryTransport, error) {
+	t := &retryTransport{
+		base:           base,
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		maxRetryAfter:  defaultRetryAfterFactor * defaultMaxBackoff,
+	}
+	if opts == nil {
+		return t, nil
+	}
+	if opts.InitialBackoff < 0 || opts.MaxBackoff < 0 || opts.MaxRetryAfter < 0 {
+		return nil, fmt.Errorf("retry backoff cannot be negative")
+	}
+	if opts.MaxAttempts > 0 {
+		t.maxAttempts = opts.MaxAttempts
+	}
+	if opts.InitialBackoff > 0 {
+		t.initialBackoff = opts.InitialBackoff.Duration()
+	}
+	if opts.MaxBackoff > 0 {
+		t.maxBackoff = opts.MaxBackoff.Duration()
+	}
+	if t.maxBackoff < t.initialBackoff {
+		return nil, fmt.Errorf("retry maxBackoff %v cannot be less than initialBackoff %v", t.maxBackoff, t.initialBackoff)
+	}
+	t.maxRetryAfter = defaultRetryAfterFactor * t.maxBackoff
+	if opts.MaxRetryAfter > 0 {
+		t.maxRetryAfter = opts.MaxRetryAfter.Duration()
+	}
+	return t, nil
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isRetryable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		reason, retry := shouldRetry(ctx, resp, err)
+		if !retry || attempt >= t.maxAttempts {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(retryAfter, t.maxRetryAfter)
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			// The next attempt cannot complete in time, so return the last
+			// outcome rather than waiting for the deadline to expire.
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+			resp.Body.Close()
+		}
+		metrics.ReportRegistryRetryCount(ctx, req.URL.Host, reason)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// backoff returns the jittered delay before the given retry attempt. The
+// delay is uniformly distributed in [d/2, d) where d is the exponential
+// backoff capped by the maximum backoff.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	d := t.initialBackoff
+	for i := 1; i < attempt && d < t.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > t.maxBackoff {
+		d = t.maxBackoff
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
+
+// isRetryable reports whether the request is idempotent and can be resent.
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// shouldRetry reports whether the outcome of an attempt is transient and
+// returns the reason used for metrics.
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, circuitbreaker.ErrOpen) || errors.Is(err, ErrRateLimitDeadline) {
+			return "", false
+		}
+		return "error", true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented) {
+		return strconv.Itoa(resp.StatusCode), true
+	}
+	return "", false
+}
+
+// parseRetryAfter parses the Retry-After header in either delay-seconds or
+// HTTP-date format.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// circuitBreakerTransport is an [http.RoundTripper] that fails fast with
+// [circuitbreaker.ErrOpen] while the breaker of the registry host is open.
+// Network errors and 5xx responses count as failures.
+type circuitBreakerTransport struct {
+	base http.RoundTripper
+	opts circuitbreaker.Options
+}
+
+// newCircuitBreakerTransport wraps base with the per-registry circuit breaker
+// configured by opts.
+func newCircuitBreakerTransport(base http.RoundTripper, opts *circuitBreakerOptions) (*circuitBreakerTransport, error) {
+	t := &circuitBreakerTransport{
+		base: base,
+	}
+	if opts == nil {
+		return t, nil
+	}
+	if opts.FailureThreshold < 0 || opts.OpenTimeout < 0 {
+		return nil, fmt.Errorf("circuit breaker failureThreshold and openTimeout cannot be negative")
+	}
+	t.opts = circuitbreaker.Options{
+		FailureThreshold: opts.FailureThreshold,
+		OpenTimeout:      opts.OpenTimeout.Duration(),
+	}
+	return t, nil
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := circuitbreaker.Get(req.URL.Host)
+	if err := breaker.Allow(t.opts); err != nil {
+		return nil, fmt.Errorf("request to registry %s rejected: %w", req.URL.Host, err)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		breaker.Abort()
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		breaker.Failure(t.opts)
+	default:
+		breaker.Success()
+	}
+	return resp, err
+}
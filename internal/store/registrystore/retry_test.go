@@ -0,0 +1,366 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/circuitbreaker"
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
+)
+
+// newFlakyServer returns a server that responds with the given status codes
+// in order and with 200 afterwards.
+func newFlakyServer(t *testing.T, header http.Header, statusCodes ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		call := int(calls.Add(1))
+		if call <= len(statusCodes) {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(statusCodes[call-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func fastRetryOptions() *retryOptions {
+	return &retryOptions{
+		MaxAttempts:    3,
+		InitialBackoff: jsonutil.Duration(time.Millisecond),
+		MaxBackoff:     jsonutil.Duration(2 * time.Millisecond),
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		statusCodes    []int
+		expectedStatus int
+		expectedCalls  int32
+	}{
+		{
+			name:           "retries 503 until success",
+			method:         http.MethodGet,
+			statusCodes:    []int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  3,
+		},
+		{
+			name:           "retries 429",
+			method:         http.MethodHead,
+			statusCodes:    []int{http.StatusTooManyRequests},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  2,
+		},
+		{
+			name:           "gives up after max attempts",
+			method:         http.MethodGet,
+			statusCodes:    []int{500, 500, 500, 500},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCalls:  3,
+		},
+		{
+			name:           "does not retry 404",
+			method:         http.MethodGet,
+			statusCodes:    []int{http.StatusNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedCalls:  1,
+		},
+		{
+			name:           "does not retry 501",
+			method:         http.MethodGet,
+			statusCodes:    []int{http.StatusNotImplemented},
+			expectedStatus: http.StatusNotImplemented,
+			expectedCalls:  1,
+		},
+		{
+			name:           "does not retry POST",
+			method:         http.MethodPost,
+			statusCodes:    []int{http.StatusServiceUnavailable},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newFlakyServer(t, nil, tt.statusCodes...)
+			transport, err := newRetryTransport(http.DefaultTransport, fastRetryOptions())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req, err := http.NewRequest(tt.method, server.URL, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if calls.Load() != tt.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tt.expectedCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestRetryTransport_RetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"1"}}
+
+	t.Run("honours retry after", func(t *testing.T) {
+		server, calls := newFlakyServer(t, header, http.StatusServiceUnavailable)
+		opts := fastRetryOptions()
+		opts.MaxRetryAfter = jsonutil.Duration(2 * time.Second)
+		transport, err := newRetryTransport(http.DefaultTransport, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		start := time.Now()
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("expected to wait for Retry-After, waited %v", elapsed)
+		}
+		if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+			t.Errorf("expected success after 2 calls, got status %d after %d calls", resp.StatusCode, calls.Load())
+		}
+	})
+
+	t.Run("caps retry after", func(t *testing.T) {
+		server, calls := newFlakyServer(t, http.Header{"Retry-After": []string{"3600"}}, http.StatusTooManyRequests)
+		transport, err := newRetryTransport(http.DefaultTransport, fastRetryOptions())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		start := time.Now()
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected Retry-After to be capped, waited %v", elapsed)
+		}
+		if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+			t.Errorf("expected success after 2 calls, got status %d after %d calls", resp.StatusCode, calls.Load())
+		}
+	})
+
+	t.Run("returns early when retry after exceeds the deadline", func(t *testing.T) {
+		server, calls := newFlakyServer(t, header, http.StatusServiceUnavailable)
+		opts := fastRetryOptions()
+		opts.MaxRetryAfter = jsonutil.Duration(2 * time.Second)
+		transport, err := newRetryTransport(http.DefaultTransport, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		start := time.Now()
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+			t.Errorf("expected to return without waiting, waited %v", elapsed)
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+			t.Errorf("expected the 503 response after 1 call, got status %d after %d calls", resp.StatusCode, calls.Load())
+		}
+	})
+}
+
+func TestRetryTransport_NetworkError(t *testing.T) {
+	var calls atomic.Int32
+	base := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("connection reset by peer")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	transport, err := newRetryTransport(base, fastRetryOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://registry.example.com/v2/", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("expected success after 2 calls, got status %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestNewRetryTransport(t *testing.T) {
+	transport, err := newRetryTransport(http.DefaultTransport, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transport.maxAttempts != defaultMaxAttempts || transport.initialBackoff != defaultInitialBackoff || transport.maxBackoff != defaultMaxBackoff ||
+		transport.maxRetryAfter != defaultRetryAfterFactor*defaultMaxBackoff {
+		t.Errorf("expected default settings, got %+v", transport)
+	}
+
+	transport, err = newRetryTransport(http.DefaultTransport, &retryOptions{MaxBackoff: jsonutil.Duration(time.Second)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transport.maxRetryAfter != defaultRetryAfterFactor*time.Second {
+		t.Errorf("expected maxRetryAfter to default to a multiple of maxBackoff, got %v", transport.maxRetryAfter)
+	}
+
+	for _, opts := range []*retryOptions{
+		{InitialBackoff: jsonutil.Duration(-time.Second)},
+		{InitialBackoff: jsonutil.Duration(time.Second), MaxBackoff: jsonutil.Duration(time.Millisecond)},
+		{MaxRetryAfter: jsonutil.Duration(-time.Second)},
+	} {
+		if _, err := newRetryTransport(http.DefaultTransport, opts); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+}
+
+func TestRetryTransport_Backoff(t *testing.T) {
+	transport := &retryTransport{initialBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+	for attempt, maxDelay := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		5: time.Second,
+	} {
+		delay := transport.backoff(attempt)
+		if delay < maxDelay/2 || delay >= maxDelay {
+			t.Errorf("attempt %d: expected delay in [%v, %v), got %v", attempt, maxDelay/2, maxDelay, delay)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("expected 3s, got %v %v", d, ok)
+	}
+	if d, ok := parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)); !ok || d != 0 {
+		t.Errorf("expected 0 for a past date, got %v %v", d, ok)
+	}
+	if d, ok := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); !ok || d <= 0 {
+		t.Errorf("expected positive delay for a future date, got %v %v", d, ok)
+	}
+	for _, value := range []string{"", "-1", "soon"} {
+		if _, ok := parseRetryAfter(value); ok {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	server, calls := newFlakyServer(t, nil, 500, 500, 500, 500, 500, 500)
+	transport, err := newCircuitBreakerTransport(http.DefaultTransport, &circuitBreakerOptions{
+		FailureThreshold: 2,
+		OpenTimeout:      jsonutil.Duration(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	retry, err := newRetryTransport(transport, &retryOptions{
+		MaxAttempts:    5,
+		InitialBackoff: jsonutil.Duration(time.Millisecond),
+		MaxBackoff:     jsonutil.Duration(time.Millisecond),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err = retry.RoundTrip(req)
+	if !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Fatalf("expected ErrOpen, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected retries to stop once the breaker opened after 2 calls, got %d", calls.Load())
+	}
+
+	serverURL, _ := url.Parse(server.URL)
+	status := circuitbreaker.Get(serverURL.Host).Status()
+	if status.State != circuitbreaker.StateOpen {
+		t.Fatalf("expected open state, got %s", status.State)
+	}
+	if !strings.Contains(err.Error(), serverURL.Host) {
+		t.Errorf("expected error to name the registry host, got %v", err)
+	}
+
+	if _, err := newCircuitBreakerTransport(http.DefaultTransport, &circuitBreakerOptions{FailureThreshold: -1}); err == nil {
+		t.Error("expected error for negative failure threshold")
+	}
+}
+
+func TestWithResilience(t *testing.T) {
+	client, err := withResilience(http.DefaultClient, &options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	retry, ok := client.Transport.(*retryTransport)
+	if !ok {
+		t.Fatalf("expected retry transport, got %T", client.Transport)
+	}
+	if _, ok := retry.base.(*circuitBreakerTransport); !ok {
+		t.Fatalf("expected circuit breaker transport, got %T", retry.base)
+	}
+
+	client, err = withResilience(http.DefaultClient, &options{CircuitBreaker: &circuitBreakerOptions{Disabled: true}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retry := client.Transport.(*retryTransport); retry.base != http.DefaultTransport {
+		t.Fatalf("expected circuit breaker to be disabled, got %T", retry.base)
+	}
+
+	if _, err = withResilience(http.DefaultClient, &options{Retry: &retryOptions{MaxBackoff: jsonutil.Duration(-time.Second)}}); err == nil {
+		t.Fatal("expected error for invalid retry options")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
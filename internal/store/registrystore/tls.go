@@ -0,0 +1,178 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// getClientCertificateFunc is the signature of
+// [tls.Config.GetClientCertificate].
+type getClientCertificateFunc func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+
+// loadRootCAs returns the cert pool built from the configured CA bundle, or nil
+// if no CA bundle is configured so that the system roots are used.
+func loadRootCAs(caPem, caBase64 string) (*x509.CertPool, error) {
+	if caPem == "" && caBase64 == "" {
+		return nil, nil
+	}
+
+	caCertPool := x509.NewCertPool()
+	var caBundle []byte
+	// If both CA PEM and CA Base64 are provided, prefer CA PEM
+	if caPem != "" {
+		caBundle = []byte(caPem)
+	} else {
+		// If CABase64 is provided, decode it and append to the cert pool
+		var err error
+		if caBundle, err = base64.StdEncoding.DecodeString(caBase64); err != nil {
+			return nil, fmt.Errorf("failed to decode CA Base64: %w", err)
+		}
+	}
+	if !caCertPool.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("failed to parse CA certificate: invalid PEM format")
+	}
+	return caCertPool, nil
+}
+
+// newClientCertificateFunc returns the function presenting the configured
+// client certificate during the TLS handshake. It returns nil if no client
+// certificate is configured.
+//
+// Inline certificates are parsed once. Certificates referenced by file path
+// are reloaded on the next handshake after either file changes, so that
+// rotated certificates (e.g. mounted Kubernetes Secrets) apply without
+// recreating the store.
+func newClientCertificateFunc(opts *options) (getClientCertificateFunc, error) {
+	hasInline := opts.ClientCertPem != "" || opts.ClientCertBase64 != "" ||
+		opts.ClientKeyPem != "" || opts.ClientKeyBase64 != ""
+	hasFile := opts.ClientCertFile != "" || opts.ClientKeyFile != ""
+
+	switch {
+	case !hasInline && !hasFile:
+		return nil, nil
+	case hasInline && hasFile:
+		return nil, fmt.Errorf("inline client certificate options cannot be combined with clientCertFile or clientKeyFile")
+	case hasFile:
+		if opts.ClientCertFile == "" || opts.ClientKeyFile == "" {
+			return nil, fmt.Errorf("clientCertFile and clientKeyFile must be provided together")
+		}
+		loader := &clientCertificateFileLoader{
+			certFile: opts.ClientCertFile,
+			keyFile:  opts.ClientKeyFile,
+		}
+		if _, err := loader.GetClientCertificate(nil); err != nil {
+			return nil, err
+		}
+		return loader.GetClientCertificate, nil
+	}
+
+	certPEM, err := pemOrBase64(opts.ClientCertPem, opts.ClientCertBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode client certificate Base64: %w", err)
+	}
+	keyPEM, err := pemOrBase64(opts.ClientKeyPem, opts.ClientKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode client key Base64: %w", err)
+	}
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return nil, fmt.Errorf("both client certificate and client key must be provided")
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse client certificate and key: %w", err)
+	}
+	return func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &cert, nil
+	}, nil
+}
+
+// pemOrBase64 returns the PEM content, preferring pemContent over the decoded
+// base64Content if both are set.
+func pemOrBase64(pemContent, base64Content string) ([]byte, error) {
+	if pemContent != "" {
+		return []byte(pemContent), nil
+	}
+	if base64Content == "" {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(base64Content)
+}
+
+// clientCertificateFileLoader loads a client certificate key pair from files
+// and reloads it whenever the modification time of either file changes.
+type clientCertificateFileLoader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// GetClientCertificate implements [tls.Config.GetClientCertificate].
+// If the files cannot be reloaded after a change, the previously loaded
+// certificate keeps being used.
+func (l *clientCertificateFileLoader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	certInfo, err := os.Stat(l.certFile)
+	if err != nil {
+		return l.cachedOrError(fmt.Errorf("failed to stat client certificate file: %w", err))
+	}
+	keyInfo, err := os.Stat(l.keyFile)
+	if err != nil {
+		return l.cachedOrError(fmt.Errorf("failed to stat client key file: %w", err))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cert != nil && certInfo.ModTime().Equal(l.certModTime) && keyInfo.ModTime().Equal(l.keyModTime) {
+		return l.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		if l.cert != nil {
+			logrus.Warnf("failed to reload client certificate, keep using the previous one: %v", err)
+			return l.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load client certificate and key: %w", err)
+	}
+	l.cert = &cert
+	l.certModTime = certInfo.ModTime()
+	l.keyModTime = keyInfo.ModTime()
+	return l.cert, nil
+}
+
+// cachedOrError returns the previously loaded certificate if there is one,
+// otherwise the given error.
+func (l *clientCertificateFileLoader) cachedOrError(err error) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cert != nil {
+		logrus.Warnf("failed to reload client certificate, keep using the previous one: %v", err)
+		return l.cert, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,294 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// generateTestClientCertificate creates a self-signed client certificate and
+// returns the PEM encoded certificate and private key.
+func generateTestClientCertificate(t *testing.T, commonName string) (string, string) {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write file %s: %v", path, err)
+	}
+}
+
+func TestNewClientCertificateFunc(t *testing.T) {
+	certPEM, keyPEM := generateTestClientCertificate(t, "client")
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	tests := []struct {
+		name       string
+		opts       *options
+		expectErr  bool
+		expectFunc bool
+	}{
+		{
+			name:       "no client certificate",
+			opts:       &options{},
+			expectFunc: false,
+		},
+		{
+			name:       "inline PEM",
+			opts:       &options{ClientCertPem: certPEM, ClientKeyPem: keyPEM},
+			expectFunc: true,
+		},
+		{
+			name: "inline Base64",
+			opts: &options{
+				ClientCertBase64: base64.StdEncoding.EncodeToString([]byte(certPEM)),
+				ClientKeyBase64:  base64.StdEncoding.EncodeToString([]byte(keyPEM)),
+			},
+			expectFunc: true,
+		},
+		{
+			name:       "mixed PEM certificate and Base64 key",
+			opts:       &options{ClientCertPem: certPEM, ClientKeyBase64: base64.StdEncoding.EncodeToString([]byte(keyPEM))},
+			expectFunc: true,
+		},
+		{
+			name:       "files",
+			opts:       &options{ClientCertFile: certFile, ClientKeyFile: keyFile},
+			expectFunc: true,
+		},
+		{
+			name:      "missing key",
+			opts:      &options{ClientCertPem: certPEM},
+			expectErr: true,
+		},
+		{
+			name:      "invalid Base64 certificate",
+			opts:      &options{ClientCertBase64: "invalid-base64", ClientKeyPem: keyPEM},
+			expectErr: true,
+		},
+		{
+			name:      "invalid Base64 key",
+			opts:      &options{ClientCertPem: certPEM, ClientKeyBase64: "invalid-base64"},
+			expectErr: true,
+		},
+		{
+			name:      "mismatched key",
+			opts:      &options{ClientCertPem: certPEM, ClientKeyPem: "invalid-key"},
+			expectErr: true,
+		},
+		{
+			name:      "missing key file",
+			opts:      &options{ClientCertFile: certFile},
+			expectErr: true,
+		},
+		{
+			name:      "non-existent files",
+			opts:      &options{ClientCertFile: filepath.Join(dir, "missing.crt"), ClientKeyFile: keyFile},
+			expectErr: true,
+		},
+		{
+			name:      "inline combined with files",
+			opts:      &options{ClientCertPem: certPEM, ClientKeyPem: keyPEM, ClientCertFile: certFile, ClientKeyFile: keyFile},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := newClientCertificateFunc(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if (fn != nil) != tt.expectFunc {
+				t.Fatalf("expected function: %v, got: %v", tt.expectFunc, fn != nil)
+			}
+			if fn != nil {
+				cert, err := fn(nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if cert == nil || len(cert.Certificate) == 0 {
+					t.Fatal("expected client certificate")
+				}
+			}
+		})
+	}
+}
+
+func TestClientCertificateFileLoader_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	oldCert, oldKey := generateTestClientCertificate(t, "old")
+	writeFile(t, certFile, oldCert)
+	writeFile(t, keyFile, oldKey)
+
+	loader := &clientCertificateFileLoader{certFile: certFile, keyFile: keyFile}
+	cert, err := loader.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cn := leafCommonName(t, cert); cn != "old" {
+		t.Fatalf("expected common name old, got %s", cn)
+	}
+
+	newCert, newKey := generateTestClientCertificate(t, "new")
+	writeFile(t, certFile, newCert)
+	writeFile(t, keyFile, newKey)
+	future := time.Now().Add(time.Minute)
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.Chtimes(file, future, future); err != nil {
+			t.Fatalf("failed to update modification time: %v", err)
+		}
+	}
+	cert, err = loader.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cn := leafCommonName(t, cert); cn != "new" {
+		t.Fatalf("expected common name new, got %s", cn)
+	}
+
+	// A broken update keeps the previous certificate.
+	writeFile(t, keyFile, "invalid-key")
+	later := future.Add(time.Minute)
+	if err := os.Chtimes(keyFile, later, later); err != nil {
+		t.Fatalf("failed to update modification time: %v", err)
+	}
+	cert, err = loader.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cn := leafCommonName(t, cert); cn != "new" {
+		t.Fatalf("expected common name new, got %s", cn)
+	}
+
+	// A removed file keeps the previous certificate.
+	if err := os.Remove(certFile); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	if _, err = loader.GetClientCertificate(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCreateHTTPClient_MutualTLS(t *testing.T) {
+	certPEM, keyPEM := generateTestClientCertificate(t, "client")
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM([]byte(certPEM)) {
+		t.Fatal("failed to append client CA")
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCAPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	t.Run("with client certificate", func(t *testing.T) {
+		client, err := createHTTPClient(&options{
+			CAPem:         serverCAPem,
+			ClientCertPem: certPEM,
+			ClientKeyPem:  keyPEM,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		client, err := createHTTPClient(&options{CAPem: serverCAPem})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+			t.Fatal("expected handshake error without client certificate")
+		}
+	})
+}
+
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
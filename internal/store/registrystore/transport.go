@@ -0,0 +1,215 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrystore
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// defaultKeepAlive is the keep-alive period used by [http.DefaultTransport].
+const defaultKeepAlive = 30 * time.Second
+
+// proxyOptions configures the HTTP proxy used to reach the registry.
+type proxyOptions struct {
+	// URL is the URL of the proxy, e.g. "http://proxy.example.com:3128".
+	// Required.
+	URL string `json:"url"`
+
+	// Username is the username to authenticate against the proxy. Optional.
+	Username string `json:"username,omitempty"`
+
+	// Password is the password to authenticate against the proxy. Optional.
+	Password string `json:"password,omitempty"`
+
+	// NoProxy is a list of hosts that bypass the proxy. Each entry follows
+	// the NO_PROXY environment variable format, e.g. "internal.example.com",
+	// ".example.com", "10.0.0.0/8" or "registry.local:5000". Optional.
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// transportOptions tunes the HTTP transport used to reach the registry. Zero
+// values keep the defaults of [http.DefaultTransport].
+type transportOptions struct {
+	// MaxIdleConns is the maximum number of idle connections across all hosts.
+	// Optional.
+	MaxIdleConns int `json:"maxIdleConns,omitempty"`
+
+	// MaxIdleConnsPerHost is the maximum number of idle connections to keep
+	// per host. Optional.
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+
+	// MaxConnsPerHost limits the total number of connections per host,
+	// including connections in the dialing, active, and idle states. Optional.
+	MaxConnsPerHost int `json:"maxConnsPerHost,omitempty"`
+
+	// DialTimeout is the maximum amount of time to establish a TCP
+	// connection, e.g. "10s". Optional.
+	DialTimeout jsonutil.Duration `json:"dialTimeout,omitempty"`
+
+	// TLSHandshakeTimeout is the maximum amount of time to wait for a TLS
+	// handshake. Optional.
+	TLSHandshakeTimeout jsonutil.Duration `json:"tlsHandshakeTimeout,omitempty"`
+
+	// ResponseHeaderTimeout is the maximum amount of time to wait for the
+	// response headers after the request is written. Optional.
+	ResponseHeaderTimeout jsonutil.Duration `json:"responseHeaderTimeout,omitempty"`
+
+	// IdleConnTimeout is the maximum amount of time an idle connection is kept
+	// open. Optional.
+	IdleConnTimeout jsonutil.Duration `json:"idleConnTimeout,omitempty"`
+
+	// DisableHTTP2 disables HTTP/2 and only uses HTTP/1.1. Optional.
+	DisableHTTP2 bool `json:"disableHttp2,omitempty"`
+}
+
+// configureTransport applies the proxy and transport options to transport.
+func configureTransport(transport *http.Transport, proxy *proxyOptions, opts *transportOptions) error {
+	if proxy != nil {
+		proxyFunc, err := newProxyFunc(proxy)
+		if err != nil {
+			return err
+		}
+		transport.Proxy = proxyFunc
+	}
+	if opts == nil {
+		return nil
+	}
+
+	for name, value := range map[string]int{
+		"maxIdleConns":        opts.MaxIdleConns,
+		"maxIdleConnsPerHost": opts.MaxIdleConnsPerHost,
+		"maxConnsPerHost":     opts.MaxConnsPerHost,
+	} {
+		if value < 0 {
+			return fmt.Errorf("%s cannot be negative", name)
+		}
+	}
+	for name, value := range map[string]jsonutil.Duration{
+		"dialTimeout":           opts.DialTimeout,
+		"tlsHandshakeTimeout":   opts.TLSHandshakeTimeout,
+		"responseHeaderTimeout": opts.ResponseHeaderTimeout,
+		"idleConnTimeout":       opts.IdleConnTimeout,
+	} {
+		if value < 0 {
+			return fmt.Errorf("%s cannot be negative", name)
+		}
+	}
+
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
+	if opts.DialTimeout > 0 {
+		dialer := &net.Dialer{
+			Timeout:   opts.DialTimeout.Duration(),
+			KeepAlive: defaultKeepAlive,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if opts.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.TLSHandshakeTimeout.Duration()
+	}
+	if opts.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout.Duration()
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout.Duration()
+	}
+	if opts.DisableHTTP2 {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		transport.Protocols = protocols
+	}
+	return nil
+}
+
+// newProxyFunc creates the proxy selection function of [http.Transport] from
+// the proxy options.
+func newProxyFunc(opts *proxyOptions) (func(*http.Request) (*url.URL, error), error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("proxy url is required")
+	}
+	proxyURL, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+	}
+	if proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy url must contain a scheme and host")
+	}
+	if opts.Username != "" || opts.Password != "" {
+		proxyURL.User = url.UserPassword(opts.Username, opts.Password)
+	}
+
+	config := &httpproxy.Config{
+		HTTPProxy:  proxyURL.String(),
+		HTTPSProxy: proxyURL.String(),
+		NoProxy:    strings.Join(opts.NoProxy, ","),
+	}
+	proxyFunc := config.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+// headerTransport is an [http.RoundTripper] that adds static headers to every
+// request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+// newHeaderTransport wraps base to add the given headers to every request.
+func newHeaderTransport(base http.RoundTripper, headers map[string]string) (*headerTransport, error) {
+	h := make(http.Header, len(headers))
+	for key, value := range headers {
+		if key == "" {
+			return nil, fmt.Errorf("header name cannot be empty")
+		}
+		if strings.EqualFold(key, "Authorization") || strings.EqualFold(key, "Host") {
+			return nil, fmt.Errorf("header %q cannot be set as a static header", key)
+		}
+		h.Set(key, value)
+	}
+	return &headerTransport{
+		base:    base,
+		headers: h,
+	}, nil
+}
+
+// RoundTrip implements [http.RoundTripper]. Headers already set on the
+// request take precedence over the static headers.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = values
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,40 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// FetchArtifactLayers fetches the manifest of the artifact being verified and
+// returns its layers. It is used by verifiers of artifacts whose content is
+// stored in the layer blobs, e.g. SBOMs and vulnerability reports.
+func FetchArtifactLayers(ctx context.Context, opts *ratify.VerifyOptions) ([]ocispec.Descriptor, error) {
+	manifestBytes, err := opts.Store.FetchManifest(ctx, opts.Repository, opts.ArtifactDescriptor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest for artifact: %w", err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	return manifest.Layers, nil
+}
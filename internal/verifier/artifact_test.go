@@ -0,0 +1,86 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type mockManifestStore struct {
+	ratify.Store
+	manifest []byte
+	err      error
+}
+
+func (m *mockManifestStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	return m.manifest, m.err
+}
+
+func TestFetchArtifactLayers(t *testing.T) {
+	layer := ocispec.Descriptor{
+		MediaType: "application/json",
+		Digest:    digest.FromString("test"),
+		Size:      4,
+	}
+	manifest := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[{"mediaType":"application/json","digest":"` + layer.Digest.String() + `","size":4}]}`
+
+	tests := []struct {
+		name         string
+		store        *mockManifestStore
+		expectLayers int
+		expectErr    bool
+	}{
+		{
+			name:         "manifest with layer",
+			store:        &mockManifestStore{manifest: []byte(manifest)},
+			expectLayers: 1,
+		},
+		{
+			name:      "fetch error",
+			store:     &mockManifestStore{err: errors.New("not found")},
+			expectErr: true,
+		},
+		{
+			name:      "invalid manifest",
+			store:     &mockManifestStore{manifest: []byte("invalid")},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers, err := FetchArtifactLayers(context.Background(), &ratify.VerifyOptions{
+				Store:      tt.store,
+				Repository: "registry.example.com/app",
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if len(layers) != tt.expectLayers {
+				t.Fatalf("expected %d layers, got %d", tt.expectLayers, len(layers))
+			}
+			if tt.expectLayers > 0 && layers[0].Digest != layer.Digest {
+				t.Fatalf("expected layer digest %s, got %s", layer.Digest, layers[0].Digest)
+			}
+		})
+	}
+}
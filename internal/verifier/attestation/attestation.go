@@ -0,0 +1,301 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package attestation loads in-toto attestations stored as OCI referrers and
+// verifies their signatures.
+package attestation
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protodsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+)
+
+const (
+	// ArtifactTypeSigstoreBundle is the artifact type of attestations stored
+	// in the Sigstore bundle format.
+	ArtifactTypeSigstoreBundle = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+	// ArtifactTypeDSSE is the artifact type of attestations stored as DSSE
+	// envelopes, e.g. by Cosign.
+	ArtifactTypeDSSE = "application/vnd.dsse.envelope.v1+json"
+
+	// ArtifactTypeInToto is the artifact type of in-toto attestations stored
+	// as DSSE envelopes.
+	ArtifactTypeInToto = "application/vnd.in-toto+json"
+
+	// payloadTypeInToto is the DSSE payload type of in-toto statements.
+	payloadTypeInToto = "application/vnd.in-toto+json"
+
+	// mediaTypeSigstoreBundle01 is the media type of the bundles created for
+	// DSSE envelopes, which carry inclusion promises instead of proofs.
+	mediaTypeSigstoreBundle01 = "application/vnd.dev.sigstore.bundle+json;version=0.1"
+
+	// Annotations of Cosign attestation layers.
+	annotationKeyBundle = "dev.sigstore.cosign/bundle"
+	annotationKeyCert   = "dev.sigstore.cosign/certificate"
+	annotationKeyChain  = "dev.sigstore.cosign/chain"
+)
+
+// IsAttestation checks if the artifact is an attestation in a supported
+// format.
+func IsAttestation(artifact ocispec.Descriptor) bool {
+	switch artifact.ArtifactType {
+	case ArtifactTypeSigstoreBundle, ArtifactTypeDSSE, ArtifactTypeInToto:
+		return artifact.MediaType == ocispec.MediaTypeImageManifest
+	}
+	return false
+}
+
+// Statement is an in-toto statement.
+// See https://github.com/in-toto/attestation/blob/main/spec/v1/statement.md
+type Statement struct {
+	// Type is the statement type, e.g. "https://in-toto.io/Statement/v1".
+	Type string `json:"_type"`
+
+	// Subject lists the artifacts the statement is about.
+	Subject []Subject `json:"subject"`
+
+	// PredicateType identifies the type of the predicate, e.g.
+	// "https://slsa.dev/provenance/v1".
+	PredicateType string `json:"predicateType"`
+
+	// Predicate is the raw predicate.
+	Predicate json.RawMessage `json:"predicate"`
+}
+
+// Subject is an artifact of a statement.
+type Subject struct {
+	Name   string            `json:"name,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Attestation is a single attestation found in an artifact.
+type Attestation struct {
+	// Digest is the digest of the blob holding the attestation.
+	Digest digest.Digest
+
+	// Statement is the statement of the attestation. It is nil if the
+	// attestation could not be verified.
+	Statement *Statement
+
+	// Err is the error verifying the attestation.
+	Err error
+}
+
+// signedEntity is an attestation envelope wrapped into a Sigstore bundle.
+type signedEntity struct {
+	layer  ocispec.Descriptor
+	bundle *bundle.Bundle
+	err    error
+}
+
+// dsseEnvelope is the JSON encoding of a DSSE envelope.
+// See https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     []byte `json:"payload"`
+	Signatures  []struct {
+		KeyID string `json:"keyid"`
+		Sig   []byte `json:"sig"`
+	} `json:"signatures"`
+}
+
+// loadEntities fetches the attestation blobs of the artifact and converts
+// them into Sigstore bundles. Blobs that cannot be converted are returned with
+// an error, so that other attestations in the artifact are still verified.
+func loadEntities(ctx context.Context, opts *ratify.VerifyOptions, keyBased, ignoreTLog bool) ([]*signedEntity, error) {
+	layers, err := verifier.FetchArtifactLayers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*signedEntity
+	for _, layer := range layers {
+		content, err := opts.Store.FetchBlob(ctx, opts.Repository, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch attestation blob %s: %w", layer.Digest, err)
+		}
+		entity := &signedEntity{layer: layer}
+		if opts.ArtifactDescriptor.ArtifactType == ArtifactTypeSigstoreBundle {
+			entity.bundle, entity.err = parseSigstoreBundle(content)
+		} else {
+			entity.bundle, entity.err = envelopeToBundle(layer, content, keyBased, ignoreTLog)
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// parseSigstoreBundle parses a Sigstore bundle holding a DSSE envelope.
+func parseSigstoreBundle(content []byte) (*bundle.Bundle, error) {
+	var b bundle.Bundle
+	if err := b.UnmarshalJSON(content); err != nil {
+		return nil, fmt.Errorf("failed to parse Sigstore bundle: %w", err)
+	}
+	if b.GetDsseEnvelope() == nil {
+		return nil, errors.New("Sigstore bundle does not contain a DSSE envelope")
+	}
+	return &b, nil
+}
+
+// envelopeToBundle wraps a DSSE envelope into a Sigstore bundle. The signing
+// certificate and the transparency log entry are taken from the Cosign
+// annotations of the layer.
+func envelopeToBundle(layer ocispec.Descriptor, content []byte, keyBased, ignoreTLog bool) (*bundle.Bundle, error) {
+	var envelope dsseEnvelope
+	if err := json.Unmarshal(content, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse DSSE envelope: %w", err)
+	}
+	if envelope.PayloadType != payloadTypeInToto {
+		return nil, fmt.Errorf("unsupported DSSE payload type %q", envelope.PayloadType)
+	}
+	if len(envelope.Signatures) == 0 {
+		return nil, errors.New("DSSE envelope is not signed")
+	}
+	dsse := &protodsse.Envelope{
+		Payload:     envelope.Payload,
+		PayloadType: envelope.PayloadType,
+	}
+	for _, sig := range envelope.Signatures {
+		dsse.Signatures = append(dsse.Signatures, &protodsse.Signature{
+			Sig:   sig.Sig,
+			Keyid: sig.KeyID,
+		})
+	}
+
+	material := &protobundle.VerificationMaterial{}
+	if !ignoreTLog {
+		if _, ok := layer.Annotations[annotationKeyBundle]; ok {
+			entry, err := tlogEntry(layer.Annotations[annotationKeyBundle])
+			if err != nil {
+				return nil, err
+			}
+			material.TlogEntries = []*protorekor.TransparencyLogEntry{entry}
+		}
+	}
+	if keyBased {
+		material.Content = &protobundle.VerificationMaterial_PublicKey{
+			PublicKey: &protocommon.PublicKeyIdentifier{},
+		}
+	} else {
+		chain, err := certificateChain(layer.Annotations[annotationKeyCert], layer.Annotations[annotationKeyChain])
+		if err != nil {
+			return nil, err
+		}
+		material.Content = chain
+	}
+
+	b, err := bundle.NewBundle(&protobundle.Bundle{
+		MediaType:            mediaTypeSigstoreBundle01,
+		VerificationMaterial: material,
+		Content:              &protobundle.Bundle_DsseEnvelope{DsseEnvelope: dsse},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Sigstore bundle: %w", err)
+	}
+	return b, nil
+}
+
+// certificateChain decodes the PEM signing certificate and the optional PEM
+// chain of the Cosign annotations.
+func certificateChain(certPEM, chainPEM string) (*protobundle.VerificationMaterial_X509CertificateChain, error) {
+	if certPEM == "" {
+		return nil, fmt.Errorf("signing certificate annotation %s not found", annotationKeyCert)
+	}
+	chain := &protocommon.X509CertificateChain{}
+	rest := []byte(certPEM + "\n" + chainPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		chain.Certificates = append(chain.Certificates, &protocommon.X509Certificate{RawBytes: block.Bytes})
+	}
+	if len(chain.Certificates) == 0 {
+		return nil, errors.New("failed to decode signing certificate")
+	}
+	return &protobundle.VerificationMaterial_X509CertificateChain{X509CertificateChain: chain}, nil
+}
+
+// rekorBundle is the Cosign bundle annotation holding the Rekor entry.
+type rekorBundle struct {
+	SignedEntryTimestamp []byte `json:"SignedEntryTimestamp"`
+	Payload              struct {
+		Body           []byte `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogIndex       int64  `json:"logIndex"`
+		LogID          string `json:"logID"`
+	} `json:"Payload"`
+}
+
+// tlogEntry converts the Cosign bundle annotation into a transparency log
+// entry with an inclusion promise.
+func tlogEntry(annotation string) (*protorekor.TransparencyLogEntry, error) {
+	var rb rekorBundle
+	if err := json.Unmarshal([]byte(annotation), &rb); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle annotation: %w", err)
+	}
+	if len(rb.SignedEntryTimestamp) == 0 || len(rb.Payload.Body) == 0 || rb.Payload.LogID == "" {
+		return nil, errors.New("bundle annotation is incomplete")
+	}
+	logID, err := hex.DecodeString(rb.Payload.LogID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode log ID: %w", err)
+	}
+	var body struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := json.Unmarshal(rb.Payload.Body, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse transparency log entry body: %w", err)
+	}
+	return &protorekor.TransparencyLogEntry{
+		LogIndex:          rb.Payload.LogIndex,
+		LogId:             &protocommon.LogId{KeyId: logID},
+		KindVersion:       &protorekor.KindVersion{Kind: body.Kind, Version: body.APIVersion},
+		IntegratedTime:    rb.Payload.IntegratedTime,
+		InclusionPromise:  &protorekor.InclusionPromise{SignedEntryTimestamp: rb.SignedEntryTimestamp},
+		CanonicalizedBody: rb.Payload.Body,
+	}, nil
+}
+
+// parseStatement decodes the in-toto statement of a verified envelope.
+func parseStatement(b *bundle.Bundle) (*Statement, error) {
+	envelope := b.GetDsseEnvelope()
+	if envelope == nil {
+		return nil, errors.New("bundle does not contain a DSSE envelope")
+	}
+	var statement Statement
+	if err := json.Unmarshal(envelope.Payload, &statement); err != nil {
+		return nil, fmt.Errorf("failed to parse in-toto statement: %w", err)
+	}
+	return &statement, nil
+}
@@ -0,0 +1,264 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation/attestationtest"
+)
+
+func generateCertificatePEM(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestIsAttestation(t *testing.T) {
+	tests := []struct {
+		name     string
+		artifact ocispec.Descriptor
+		want     bool
+	}{
+		{
+			name:     "Sigstore bundle",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: ArtifactTypeSigstoreBundle},
+			want:     true,
+		},
+		{
+			name:     "DSSE envelope",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: ArtifactTypeDSSE},
+			want:     true,
+		},
+		{
+			name:     "in-toto",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: ArtifactTypeInToto},
+			want:     true,
+		},
+		{
+			name:     "index",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex, ArtifactType: ArtifactTypeInToto},
+		},
+		{
+			name:     "other artifact",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: "application/spdx+json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAttestation(tt.artifact); got != tt.want {
+				t.Errorf("IsAttestation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvelopeToBundle(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	statement := attestationtest.NewStatement(attestationtest.Subject, "https://example.com/predicate", "{}")
+	envelope := signer.SignEnvelope(t, payloadTypeInToto, statement)
+	certPEM := generateCertificatePEM(t, signer.Key)
+
+	tests := []struct {
+		name        string
+		layer       ocispec.Descriptor
+		content     string
+		keyBased    bool
+		ignoreTLog  bool
+		errContains string
+	}{
+		{
+			name:     "key-based",
+			content:  envelope,
+			keyBased: true,
+		},
+		{
+			name:    "keyless",
+			layer:   ocispec.Descriptor{Annotations: map[string]string{annotationKeyCert: certPEM}},
+			content: envelope,
+		},
+		{
+			name:        "keyless without certificate",
+			content:     envelope,
+			errContains: "signing certificate annotation",
+		},
+		{
+			name:        "keyless with invalid certificate",
+			layer:       ocispec.Descriptor{Annotations: map[string]string{annotationKeyCert: "invalid"}},
+			content:     envelope,
+			errContains: "failed to decode signing certificate",
+		},
+		{
+			name:        "invalid bundle annotation",
+			layer:       ocispec.Descriptor{Annotations: map[string]string{annotationKeyBundle: "invalid"}},
+			content:     envelope,
+			keyBased:    true,
+			errContains: "failed to parse bundle annotation",
+		},
+		{
+			name:       "bundle annotation ignored without transparency log",
+			layer:      ocispec.Descriptor{Annotations: map[string]string{annotationKeyBundle: "invalid"}},
+			content:    envelope,
+			keyBased:   true,
+			ignoreTLog: true,
+		},
+		{
+			name:        "invalid envelope",
+			content:     "invalid",
+			keyBased:    true,
+			errContains: "failed to parse DSSE envelope",
+		},
+		{
+			name:        "unsupported payload type",
+			content:     signer.SignEnvelope(t, "text/plain", statement),
+			keyBased:    true,
+			errContains: "unsupported DSSE payload type",
+		},
+		{
+			name:        "unsigned envelope",
+			content:     `{"payloadType":"application/vnd.in-toto+json","payload":"e30=","signatures":[]}`,
+			keyBased:    true,
+			errContains: "DSSE envelope is not signed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := envelopeToBundle(tt.layer, []byte(tt.content), tt.keyBased, tt.ignoreTLog)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := parseStatement(b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.PredicateType != "https://example.com/predicate" {
+				t.Errorf("expected predicate type to be preserved, got %q", got.PredicateType)
+			}
+		})
+	}
+}
+
+func TestTLogEntry(t *testing.T) {
+	body := base64.StdEncoding.EncodeToString([]byte(`{"apiVersion":"0.0.1","kind":"intoto"}`))
+	tests := []struct {
+		name        string
+		annotation  string
+		errContains string
+	}{
+		{
+			name:       "valid",
+			annotation: fmt.Sprintf(`{"SignedEntryTimestamp":"c2V0","Payload":{"body":%q,"integratedTime":1,"logIndex":2,"logID":"abcd"}}`, body),
+		},
+		{
+			name:        "invalid JSON",
+			annotation:  "invalid",
+			errContains: "failed to parse bundle annotation",
+		},
+		{
+			name:        "missing signed entry timestamp",
+			annotation:  fmt.Sprintf(`{"Payload":{"body":%q,"logID":"abcd"}}`, body),
+			errContains: "bundle annotation is incomplete",
+		},
+		{
+			name:        "invalid log ID",
+			annotation:  fmt.Sprintf(`{"SignedEntryTimestamp":"c2V0","Payload":{"body":%q,"logID":"xyz"}}`, body),
+			errContains: "failed to decode log ID",
+		},
+		{
+			name:        "invalid body",
+			annotation:  `{"SignedEntryTimestamp":"c2V0","Payload":{"body":"aW52YWxpZA==","logID":"abcd"}}`,
+			errContains: "failed to parse transparency log entry body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := tlogEntry(tt.annotation)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if entry.LogIndex != 2 || entry.IntegratedTime != 1 || entry.KindVersion.Kind != "intoto" {
+				t.Errorf("unexpected entry: %v", entry)
+			}
+			if entry.InclusionPromise == nil {
+				t.Error("expected inclusion promise")
+			}
+		})
+	}
+}
+
+func TestParseSigstoreBundle(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		errContains string
+	}{
+		{
+			name:        "invalid JSON",
+			content:     "invalid",
+			errContains: "failed to parse Sigstore bundle",
+		},
+		{
+			name:        "unsupported media type",
+			content:     `{"mediaType":"application/json"}`,
+			errContains: "failed to parse Sigstore bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSigstoreBundle([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
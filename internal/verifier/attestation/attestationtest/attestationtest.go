@@ -0,0 +1,190 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package attestationtest provides helpers for testing verifiers of in-toto
+// attestations: a store serving a single artifact and a signer creating DSSE
+// envelopes.
+package attestationtest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// PayloadTypeInToto is the DSSE payload type of in-toto statements.
+const PayloadTypeInToto = "application/vnd.in-toto+json"
+
+// Subject is the digest of the artifact the test statements are about.
+var Subject = digest.FromString("subject")
+
+// Store is a [ratify.Store] serving a single artifact.
+type Store struct {
+	Manifest    []byte
+	Blobs       map[digest.Digest][]byte
+	ManifestErr error
+}
+
+// NewStore returns a store serving an artifact of the given type with the
+// layers, and the descriptor of the artifact. The content of each layer is
+// taken from its Data field.
+func NewStore(t testing.TB, artifactType string, layers ...ocispec.Descriptor) (*Store, ocispec.Descriptor) {
+	t.Helper()
+	store := &Store{Blobs: make(map[digest.Digest][]byte)}
+	manifest := ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: artifactType}
+	for _, layer := range layers {
+		blob := layer.Data
+		layer.Data = nil
+		layer.Digest = digest.FromBytes(blob)
+		layer.Size = int64(len(blob))
+		store.Blobs[layer.Digest] = blob
+		manifest.Layers = append(manifest.Layers, layer)
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	store.Manifest = manifestBytes
+	return store, ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Digest:       digest.FromBytes(manifestBytes),
+		Size:         int64(len(manifestBytes)),
+	}
+}
+
+// NewLayer returns a layer descriptor carrying its content in Data.
+func NewLayer(mediaType, content string, annotations map[string]string) ocispec.Descriptor {
+	return ocispec.Descriptor{MediaType: mediaType, Data: []byte(content), Annotations: annotations}
+}
+
+// NewLayers returns a layer descriptor of the media type for each content.
+func NewLayers(mediaType string, contents ...string) []ocispec.Descriptor {
+	layers := make([]ocispec.Descriptor, 0, len(contents))
+	for _, content := range contents {
+		layers = append(layers, NewLayer(mediaType, content, nil))
+	}
+	return layers
+}
+
+// Resolve implements [ratify.Store].
+func (s *Store) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, nil
+}
+
+// ListReferrers implements [ratify.Store]. The artifact has no referrers.
+func (s *Store) ListReferrers(_ context.Context, _ string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	return nil
+}
+
+// FetchBlob implements [ratify.Store].
+func (s *Store) FetchBlob(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	blob, ok := s.Blobs[desc.Digest]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return blob, nil
+}
+
+// FetchManifest implements [ratify.Store].
+func (s *Store) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	if s.ManifestErr != nil {
+		return nil, s.ManifestErr
+	}
+	return s.Manifest, nil
+}
+
+// NewStatement returns an in-toto statement about the subject.
+func NewStatement(subject digest.Digest, predicateType, predicate string) string {
+	return fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"app","digest":{"%s":"%s"}}],"predicateType":%q,"predicate":%s}`,
+		subject.Algorithm(), subject.Encoded(), predicateType, predicate)
+}
+
+// Signer signs in-toto statements as DSSE envelopes with an ECDSA P-256 key.
+type Signer struct {
+	// Key is the signing key.
+	Key *ecdsa.PrivateKey
+
+	// PublicKey is the PEM encoded public key.
+	PublicKey string
+}
+
+// NewSigner returns a signer with a new key.
+func NewSigner(t testing.TB) *Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return &Signer{
+		Key:       key,
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}
+}
+
+// Sign returns a DSSE envelope of an in-toto statement about [Subject].
+func (s *Signer) Sign(t testing.TB, predicateType, predicate string) string {
+	t.Helper()
+	return s.SignEnvelope(t, PayloadTypeInToto, NewStatement(Subject, predicateType, predicate))
+}
+
+// SignEnvelope returns a DSSE envelope of the payload.
+func (s *Signer) SignEnvelope(t testing.TB, payloadType, payload string) string {
+	t.Helper()
+	pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+	hash := sha256.Sum256([]byte(pae))
+	sig, err := ecdsa.SignASN1(rand.Reader, s.Key, hash[:])
+	if err != nil {
+		t.Fatalf("failed to sign envelope: %v", err)
+	}
+	envelope, err := json.Marshal(map[string]any{
+		"payloadType": payloadType,
+		"payload":     base64.StdEncoding.EncodeToString([]byte(payload)),
+		"signatures":  []map[string]string{{"sig": base64.StdEncoding.EncodeToString(sig)}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	return string(envelope)
+}
+
+// SignatureParameters returns verifier parameters trusting the public key of
+// the signer without transparency log, merged with params.
+func (s *Signer) SignatureParameters(params map[string]any) map[string]any {
+	merged := map[string]any{
+		"keys":       map[string]any{"inline": map[string]any{"keys": s.PublicKey}},
+		"ignoreTLog": true,
+	}
+	for key, value := range params {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,320 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sirupsen/logrus"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier/keyprovider"
+	"github.com/notaryproject/ratify/v2/internal/verifier/trustedroot"
+)
+
+// trustedRootTTL is how long a trusted root fetched via TUF is used before it
+// is fetched again.
+const trustedRootTTL = 24 * time.Hour
+
+// SignatureOptions defines how the signatures of attestations are verified.
+// Attestations are verified with public keys if Keys is set, otherwise
+// keyless. The trusted root of the Sigstore public-good instance is used
+// unless a custom trusted root is configured.
+type SignatureOptions struct {
+	// CertificateIdentity is the identity to be used for keyless verification.
+	// Optional.
+	CertificateIdentity string `json:"certificateIdentity,omitempty"`
+
+	// CertificateIdentityRegex is a regex pattern to match the certificate.
+	// Optional.
+	CertificateIdentityRegex string `json:"certificateIdentityRegex,omitempty"`
+
+	// CertificateOIDCIssuer is the OIDC issuer URL to be used for keyless
+	// verification. Optional.
+	CertificateOIDCIssuer string `json:"certificateOIDCIssuer,omitempty"`
+
+	// CertificateOIDCIssuerRegex is a regex pattern to match the OIDC issuer.
+	// Optional.
+	CertificateOIDCIssuerRegex string `json:"certificateOIDCIssuerRegex,omitempty"`
+
+	// IgnoreTLog indicates whether to ignore the transparency log during
+	// verification. Optional. Keyless verification requires a timestamp, so
+	// it only applies to key-based verification.
+	IgnoreTLog bool `json:"ignoreTLog,omitempty"`
+
+	// IgnoreCTLog indicates whether to ignore the certificate transparency log
+	// during keyless verification. Optional.
+	IgnoreCTLog bool `json:"ignoreCTLog,omitempty"`
+
+	// Keys provides public keys to be used for signature verification.
+	// Optional. If not provided, keyless verification is used. Only one set of
+	// key provider is allowed to be provided.
+	Keys map[string]any `json:"keys,omitempty"`
+
+	// Options customizes the trusted root, e.g. for a private Sigstore
+	// deployment. Optional.
+	trustedroot.Options
+}
+
+// SignatureVerifier verifies the signatures of attestations and returns their
+// in-toto statements.
+type SignatureVerifier struct {
+	keyProvider keyprovider.KeyProvider
+	identity    *verify.CertificateIdentity
+	ignoreTLog  bool
+	ignoreCTLog bool
+
+	// getTrustedRoot returns the Sigstore trusted root. The root is fetched
+	// on first use so that key-based verification without a transparency log
+	// works offline.
+	getTrustedRoot func() (root.TrustedMaterial, error)
+}
+
+// NewSignatureVerifier creates a [SignatureVerifier] from the options.
+func NewSignatureVerifier(opts SignatureOptions) (*SignatureVerifier, error) {
+	if len(opts.Keys) > 1 {
+		return nil, fmt.Errorf("only one set of key provider is allowed")
+	}
+	getTrustedRoot, err := newTrustedRootGetter(&opts.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trusted root: %w", err)
+	}
+	v := &SignatureVerifier{
+		ignoreCTLog:    opts.IgnoreCTLog,
+		getTrustedRoot: getTrustedRoot,
+	}
+	for keyType, keyConfig := range opts.Keys {
+		provider, err := keyprovider.CreateKeyProvider(keyType, keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create key provider %s: %w", keyType, err)
+		}
+		v.keyProvider = provider
+		v.ignoreTLog = opts.IgnoreTLog
+		return v, nil
+	}
+
+	if opts.CertificateIdentity == "" && opts.CertificateIdentityRegex == "" {
+		return nil, errors.New("either keys or a certificate identity must be provided")
+	}
+	identity, err := verify.NewShortCertificateIdentity(
+		opts.CertificateOIDCIssuer,
+		opts.CertificateOIDCIssuerRegex,
+		opts.CertificateIdentity,
+		opts.CertificateIdentityRegex,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate identity: %w", err)
+	}
+	v.identity = &identity
+	return v, nil
+}
+
+// Verify fetches the attestations of the artifact and verifies their
+// signatures. Attestations failing verification are returned with an error,
+// while errors fetching the artifact are returned directly.
+func (v *SignatureVerifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) ([]*Attestation, error) {
+	verifiers, policy, err := v.verifiers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	entities, err := loadEntities(ctx, opts, v.keyProvider != nil, v.ignoreTLog)
+	if err != nil {
+		return nil, err
+	}
+
+	attestations := make([]*Attestation, 0, len(entities))
+	for _, entity := range entities {
+		attestation := &Attestation{Digest: entity.layer.Digest}
+		attestations = append(attestations, attestation)
+		if entity.err != nil {
+			attestation.Err = entity.err
+			continue
+		}
+		if err := verifyEntity(verifiers, entity, policy); err != nil {
+			attestation.Err = err
+			continue
+		}
+		attestation.Statement, attestation.Err = parseStatement(entity.bundle)
+	}
+	return attestations, nil
+}
+
+// verifyEntity verifies the entity against each verifier and succeeds if any
+// of them accepts it.
+func verifyEntity(verifiers []*verify.Verifier, entity *signedEntity, policy verify.PolicyBuilder) error {
+	var errs []error
+	for _, verifier := range verifiers {
+		if _, err := verifier.Verify(entity.bundle, policy); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to verify attestation signature: %w", errors.Join(errs...))
+}
+
+// verifiers creates the Sigstore verifiers and the policy for the subject.
+// Key-based verification creates a verifier per key, since trusted public key
+// material is not selected by key ID.
+func (v *SignatureVerifier) verifiers(ctx context.Context, opts *ratify.VerifyOptions) ([]*verify.Verifier, verify.PolicyBuilder, error) {
+	subjectDigest, err := hex.DecodeString(opts.SubjectDescriptor.Digest.Encoded())
+	if err != nil {
+		return nil, verify.PolicyBuilder{}, fmt.Errorf("failed to decode subject digest: %w", err)
+	}
+	artifactPolicy := verify.WithArtifactDigest(opts.SubjectDescriptor.Digest.Algorithm().String(), subjectDigest)
+
+	var verifierOpts []verify.VerifierOption
+	var trustedRoot root.TrustedMaterial
+	if v.keyProvider == nil || !v.ignoreTLog {
+		if trustedRoot, err = v.getTrustedRoot(); err != nil {
+			return nil, verify.PolicyBuilder{}, err
+		}
+		verifierOpts = append(verifierOpts, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	} else {
+		verifierOpts = append(verifierOpts, verify.WithCurrentTime())
+	}
+
+	if v.keyProvider == nil {
+		if !v.ignoreCTLog {
+			verifierOpts = append(verifierOpts, verify.WithSignedCertificateTimestamps(1))
+		}
+		verifier, err := verify.NewVerifier(trustedRoot, verifierOpts...)
+		if err != nil {
+			return nil, verify.PolicyBuilder{}, fmt.Errorf("failed to create verifier: %w", err)
+		}
+		return []*verify.Verifier{verifier}, verify.NewPolicy(artifactPolicy, verify.WithCertificateIdentity(*v.identity)), nil
+	}
+
+	keys, err := v.keyProvider.GetKeys(ctx)
+	if err != nil {
+		return nil, verify.PolicyBuilder{}, fmt.Errorf("failed to get public keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, verify.PolicyBuilder{}, errors.New("no public keys found")
+	}
+	verifiers := make([]*verify.Verifier, 0, len(keys))
+	for _, key := range keys {
+		trustedMaterial := root.TrustedMaterialCollection{trustedPublicKeyMaterial(key)}
+		if trustedRoot != nil {
+			trustedMaterial = append(trustedMaterial, trustedRoot)
+		}
+		verifier, err := verify.NewVerifier(trustedMaterial, verifierOpts...)
+		if err != nil {
+			return nil, verify.PolicyBuilder{}, fmt.Errorf("failed to create verifier: %w", err)
+		}
+		verifiers = append(verifiers, verifier)
+	}
+	return verifiers, verify.NewPolicy(artifactPolicy, verify.WithKey()), nil
+}
+
+// trustedPublicKeyMaterial creates trusted material from a public key with
+// its validity period. The signature algorithm defaults to SHA256.
+func trustedPublicKeyMaterial(key *keyprovider.PublicKey) root.TrustedMaterial {
+	hash := key.SignatureAlgorithm
+	if hash == 0 {
+		hash = crypto.SHA256
+	}
+	return root.NewTrustedPublicKeyMaterial(func(string) (root.TimeConstrainedVerifier, error) {
+		verifier, err := signature.LoadVerifier(key.Key, hash)
+		if err != nil {
+			return nil, err
+		}
+		return root.NewExpiringKey(verifier, key.ValidityPeriodStart, key.ValidityPeriodEnd), nil
+	})
+}
+
+// newTrustedRootGetter returns a function returning the trusted root
+// configured by the options. Trusted roots fetched via TUF are refreshed
+// after [trustedRootTTL], while inline and file based trusted roots are
+// loaded once.
+func newTrustedRootGetter(opts *trustedroot.Options) (func() (root.TrustedMaterial, error), error) {
+	if !opts.IsSet() {
+		return newTrustedRootFetcher(func() (root.TrustedMaterial, error) {
+			return root.FetchTrustedRootWithOptions(tuf.DefaultOptions())
+		}, trustedRootTTL).get, nil
+	}
+	trustedRoot, tufOpts, err := trustedroot.Load(opts)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tufOpts != nil:
+		return newTrustedRootFetcher(func() (root.TrustedMaterial, error) {
+			return root.FetchTrustedRootWithOptions(tufOpts)
+		}, trustedRootTTL).get, nil
+	case opts.TUFMirror != nil:
+		// The trusted root of the mirror is extended with custom keys, so it
+		// is loaded again on refresh.
+		fetcher := newTrustedRootFetcher(func() (root.TrustedMaterial, error) {
+			reloaded, _, err := trustedroot.Load(opts)
+			return reloaded, err
+		}, trustedRootTTL)
+		fetcher.trustedRoot, fetcher.fetchedAt = trustedRoot, time.Now()
+		return fetcher.get, nil
+	default:
+		return func() (root.TrustedMaterial, error) {
+			return trustedRoot, nil
+		}, nil
+	}
+}
+
+// trustedRootFetcher fetches a trusted root and reuses it until the TTL
+// expires.
+type trustedRootFetcher struct {
+	fetch func() (root.TrustedMaterial, error)
+	ttl   time.Duration
+
+	mu          sync.Mutex
+	trustedRoot root.TrustedMaterial
+	fetchedAt   time.Time
+}
+
+// newTrustedRootFetcher creates a [trustedRootFetcher] fetching the trusted
+// root with fetch.
+func newTrustedRootFetcher(fetch func() (root.TrustedMaterial, error), ttl time.Duration) *trustedRootFetcher {
+	return &trustedRootFetcher{fetch: fetch, ttl: ttl}
+}
+
+// get returns the trusted root, fetching it if it has not been fetched yet
+// or the TTL has expired. If a refresh fails, the previous trusted root is
+// kept and the refresh is retried on the next call.
+func (f *trustedRootFetcher) get() (root.TrustedMaterial, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.trustedRoot != nil && time.Since(f.fetchedAt) < f.ttl {
+		return f.trustedRoot, nil
+	}
+	fetched, err := f.fetch()
+	if err != nil {
+		if f.trustedRoot != nil {
+			logrus.Warnf("Failed to refresh trusted root, using the previous one: %v", err)
+			return f.trustedRoot, nil
+		}
+		return nil, fmt.Errorf("failed to fetch trusted root: %w", err)
+	}
+	f.trustedRoot, f.fetchedAt = fetched, time.Now()
+	return f.trustedRoot, nil
+}
@@ -0,0 +1,347 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/sigstore-go/pkg/root"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation/attestationtest"
+	"github.com/notaryproject/ratify/v2/internal/verifier/keyprovider"
+	"github.com/notaryproject/ratify/v2/internal/verifier/trustedroot"
+)
+
+const (
+	testRepo        = "registry.example.com/app"
+	testKeyProvider = "attestation-test-keys"
+)
+
+// testKeys are the keys served by the test key provider.
+var testKeys []*keyprovider.PublicKey
+
+type testKeyProviderImpl struct{}
+
+func (testKeyProviderImpl) GetCertificates(_ context.Context) ([]*x509.Certificate, error) {
+	return nil, nil
+}
+
+func (testKeyProviderImpl) GetKeys(_ context.Context) ([]*keyprovider.PublicKey, error) {
+	if testKeys == nil {
+		return nil, errors.New("keys not available")
+	}
+	return testKeys, nil
+}
+
+func init() {
+	keyprovider.RegisterKeyProvider(testKeyProvider, func(_ any) (keyprovider.KeyProvider, error) {
+		return testKeyProviderImpl{}, nil
+	})
+}
+
+func newVerifyOptions(store ratify.Store, artifact ocispec.Descriptor) *ratify.VerifyOptions {
+	return &ratify.VerifyOptions{
+		Store:              store,
+		Repository:         testRepo,
+		SubjectDescriptor:  ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: attestationtest.Subject},
+		ArtifactDescriptor: artifact,
+	}
+}
+
+// newSigstoreBundle returns a Sigstore bundle holding the DSSE envelope,
+// verified with a public key.
+func newSigstoreBundle(envelope string) string {
+	return fmt.Sprintf(`{"mediaType":%q,"verificationMaterial":{"publicKey":{"hint":"test"}},"dsseEnvelope":%s}`,
+		"application/vnd.dev.sigstore.bundle.v0.3+json", envelope)
+}
+
+func TestNewSignatureVerifier(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        SignatureOptions
+		errContains string
+	}{
+		{
+			name: "keys",
+			opts: SignatureOptions{Keys: map[string]any{testKeyProvider: nil}},
+		},
+		{
+			name: "keyless",
+			opts: SignatureOptions{
+				CertificateIdentity:   "https://github.com/org/repo/.github/workflows/release.yml@refs/heads/main",
+				CertificateOIDCIssuer: "https://token.actions.githubusercontent.com",
+			},
+		},
+		{
+			name:        "multiple key providers",
+			opts:        SignatureOptions{Keys: map[string]any{testKeyProvider: nil, "other": nil}},
+			errContains: "only one set of key provider is allowed",
+		},
+		{
+			name:        "unknown key provider",
+			opts:        SignatureOptions{Keys: map[string]any{"unknown": nil}},
+			errContains: "failed to create key provider",
+		},
+		{
+			name:        "no keys or identity",
+			opts:        SignatureOptions{CertificateOIDCIssuer: "https://token.actions.githubusercontent.com"},
+			errContains: "either keys or a certificate identity must be provided",
+		},
+		{
+			name:        "keyless without issuer",
+			opts:        SignatureOptions{CertificateIdentity: "user@example.com"},
+			errContains: "failed to create certificate identity",
+		},
+		{
+			name: "TUF mirror",
+			opts: SignatureOptions{
+				Keys:    map[string]any{testKeyProvider: nil},
+				Options: trustedroot.Options{TUFMirror: &trustedroot.TUFMirrorOptions{URL: "https://tuf.example.com"}},
+			},
+		},
+		{
+			name: "invalid trusted root",
+			opts: SignatureOptions{
+				Keys:    map[string]any{testKeyProvider: nil},
+				Options: trustedroot.Options{TrustedRoot: "{"},
+			},
+			errContains: "failed to create trusted root",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSignatureVerifier(tt.opts)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTrustedRootFetcher(t *testing.T) {
+	trustedRoot := root.TrustedMaterialCollection{}
+	t.Run("reuses trusted root until the TTL expires", func(t *testing.T) {
+		fetches := 0
+		fetcher := newTrustedRootFetcher(func() (root.TrustedMaterial, error) {
+			fetches++
+			return trustedRoot, nil
+		}, time.Hour)
+		for range 2 {
+			if _, err := fetcher.get(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if fetches != 1 {
+			t.Errorf("fetches = %d, want 1", fetches)
+		}
+		fetcher.fetchedAt = time.Now().Add(-2 * time.Hour)
+		if _, err := fetcher.get(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fetches != 2 {
+			t.Errorf("fetches = %d, want 2 after the TTL expired", fetches)
+		}
+	})
+
+	t.Run("keeps previous trusted root on refresh failure", func(t *testing.T) {
+		fetcher := newTrustedRootFetcher(func() (root.TrustedMaterial, error) {
+			return nil, errors.New("network error")
+		}, time.Hour)
+		if _, err := fetcher.get(); err == nil || !strings.Contains(err.Error(), "failed to fetch trusted root") {
+			t.Fatalf("expected fetch error, got %v", err)
+		}
+		fetcher.trustedRoot, fetcher.fetchedAt = trustedRoot, time.Now().Add(-2*time.Hour)
+		got, err := fetcher.get()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got == nil {
+			t.Error("expected previous trusted root")
+		}
+	})
+}
+
+func TestSignatureVerifier_Verify(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	otherSigner := attestationtest.NewSigner(t)
+	statement := attestationtest.NewStatement(attestationtest.Subject, "https://example.com/predicate", `{"a":1}`)
+	envelope := signer.SignEnvelope(t, payloadTypeInToto, statement)
+	otherSubject := signer.SignEnvelope(t, payloadTypeInToto, attestationtest.NewStatement(digest.FromString("other"), "https://example.com/predicate", "{}"))
+
+	tests := []struct {
+		name         string
+		keys         []*ecdsa.PrivateKey
+		artifactType string
+		layers       []ocispec.Descriptor
+		wantErrs     []string
+	}{
+		{
+			name:         "DSSE envelope",
+			keys:         []*ecdsa.PrivateKey{signer.Key},
+			artifactType: ArtifactTypeDSSE,
+			layers:       []ocispec.Descriptor{attestationtest.NewLayer(ArtifactTypeDSSE, envelope, nil)},
+			wantErrs:     []string{""},
+		},
+		{
+			name:         "Sigstore bundle",
+			keys:         []*ecdsa.PrivateKey{signer.Key},
+			artifactType: ArtifactTypeSigstoreBundle,
+			layers:       []ocispec.Descriptor{attestationtest.NewLayer(ArtifactTypeSigstoreBundle, newSigstoreBundle(envelope), nil)},
+			wantErrs:     []string{""},
+		},
+		{
+			name:         "second key matches",
+			keys:         []*ecdsa.PrivateKey{otherSigner.Key, signer.Key},
+			artifactType: ArtifactTypeInToto,
+			layers:       []ocispec.Descriptor{attestationtest.NewLayer(ArtifactTypeDSSE, envelope, nil)},
+			wantErrs:     []string{""},
+		},
+		{
+			name:         "untrusted key",
+			keys:         []*ecdsa.PrivateKey{otherSigner.Key},
+			artifactType: ArtifactTypeDSSE,
+			layers:       []ocispec.Descriptor{attestationtest.NewLayer(ArtifactTypeDSSE, envelope, nil)},
+			wantErrs:     []string{"failed to verify attestation signature"},
+		},
+		{
+			name:         "statement about another subject",
+			keys:         []*ecdsa.PrivateKey{signer.Key},
+			artifactType: ArtifactTypeDSSE,
+			layers:       []ocispec.Descriptor{attestationtest.NewLayer(ArtifactTypeDSSE, otherSubject, nil)},
+			wantErrs:     []string{"failed to verify attestation signature"},
+		},
+		{
+			name:         "invalid layer does not affect other layers",
+			keys:         []*ecdsa.PrivateKey{signer.Key},
+			artifactType: ArtifactTypeDSSE,
+			layers: []ocispec.Descriptor{
+				attestationtest.NewLayer(ArtifactTypeDSSE, "invalid", nil),
+				attestationtest.NewLayer(ArtifactTypeDSSE, envelope, nil),
+			},
+			wantErrs: []string{"failed to parse DSSE envelope", ""},
+		},
+		{
+			name:         "invalid Sigstore bundle",
+			keys:         []*ecdsa.PrivateKey{signer.Key},
+			artifactType: ArtifactTypeSigstoreBundle,
+			layers:       []ocispec.Descriptor{attestationtest.NewLayer(ArtifactTypeSigstoreBundle, "invalid", nil)},
+			wantErrs:     []string{"failed to parse Sigstore bundle"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testKeys = nil
+			for _, k := range tt.keys {
+				testKeys = append(testKeys, &keyprovider.PublicKey{Key: &k.PublicKey, SignatureAlgorithm: crypto.SHA256})
+			}
+			v, err := NewSignatureVerifier(SignatureOptions{Keys: map[string]any{testKeyProvider: nil}, IgnoreTLog: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			store, artifact := attestationtest.NewStore(t, tt.artifactType, tt.layers...)
+			attestations, err := v.Verify(context.Background(), newVerifyOptions(store, artifact))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(attestations) != len(tt.wantErrs) {
+				t.Fatalf("expected %d attestations, got %d", len(tt.wantErrs), len(attestations))
+			}
+			for i, attestation := range attestations {
+				if tt.wantErrs[i] == "" {
+					if attestation.Err != nil {
+						t.Fatalf("unexpected error: %v", attestation.Err)
+					}
+					if attestation.Statement == nil || string(attestation.Statement.Predicate) != `{"a":1}` {
+						t.Errorf("unexpected statement: %+v", attestation.Statement)
+					}
+					continue
+				}
+				if attestation.Err == nil || !strings.Contains(attestation.Err.Error(), tt.wantErrs[i]) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErrs[i], attestation.Err)
+				}
+				if attestation.Statement != nil {
+					t.Errorf("expected no statement for unverified attestation")
+				}
+			}
+		})
+	}
+}
+
+func TestSignatureVerifier_VerifyErrors(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	envelope := signer.SignEnvelope(t, payloadTypeInToto, attestationtest.NewStatement(attestationtest.Subject, "https://example.com/predicate", "{}"))
+
+	t.Run("manifest error", func(t *testing.T) {
+		testKeys = []*keyprovider.PublicKey{{Key: &signer.Key.PublicKey, SignatureAlgorithm: crypto.SHA256}}
+		v, err := NewSignatureVerifier(SignatureOptions{Keys: map[string]any{testKeyProvider: nil}, IgnoreTLog: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		store, artifact := attestationtest.NewStore(t, ArtifactTypeDSSE, attestationtest.NewLayer(ArtifactTypeDSSE, envelope, nil))
+		store.ManifestErr = errors.New("manifest error")
+		if _, err := v.Verify(context.Background(), newVerifyOptions(store, artifact)); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("key provider error", func(t *testing.T) {
+		testKeys = nil
+		v, err := NewSignatureVerifier(SignatureOptions{Keys: map[string]any{testKeyProvider: nil}, IgnoreTLog: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		store, artifact := attestationtest.NewStore(t, ArtifactTypeDSSE, attestationtest.NewLayer(ArtifactTypeDSSE, envelope, nil))
+		if _, err := v.Verify(context.Background(), newVerifyOptions(store, artifact)); err == nil || !strings.Contains(err.Error(), "failed to get public keys") {
+			t.Fatalf("expected key provider error, got %v", err)
+		}
+	})
+
+	t.Run("trusted root error", func(t *testing.T) {
+		v, err := NewSignatureVerifier(SignatureOptions{
+			CertificateIdentity:   "user@example.com",
+			CertificateOIDCIssuer: "https://issuer.example.com",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		v.getTrustedRoot = func() (root.TrustedMaterial, error) {
+			return nil, errors.New("offline")
+		}
+		store, artifact := attestationtest.NewStore(t, ArtifactTypeDSSE, attestationtest.NewLayer(ArtifactTypeDSSE, envelope, nil))
+		if _, err := v.Verify(context.Background(), newVerifyOptions(store, artifact)); err == nil || !strings.Contains(err.Error(), "offline") {
+			t.Fatalf("expected trusted root error, got %v", err)
+		}
+	})
+}
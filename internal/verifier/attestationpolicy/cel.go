@@ -0,0 +1,98 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestationpolicy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+)
+
+// celCostLimit bounds the evaluation cost of CEL expressions.
+const celCostLimit = 1000000
+
+// anySliceType is the native type CEL lists are converted to.
+var anySliceType = reflect.TypeOf([]any{})
+
+// celEvaluator evaluates a CEL expression.
+type celEvaluator struct {
+	program cel.Program
+	message string
+}
+
+// newCELEvaluator compiles the CEL expression. The expression must evaluate
+// to a bool or a list.
+func newCELEvaluator(expression, message string) (*celEvaluator, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("predicateType", cel.StringType),
+		cel.Variable("predicate", cel.DynType),
+		cel.Variable("subject", cel.ListType(cel.DynType)),
+		cel.Variable("now", cel.TimestampType),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
+	}
+	switch ast.OutputType().Kind() {
+	case types.BoolKind, types.ListKind, types.DynKind:
+	default:
+		return nil, fmt.Errorf("CEL expression must evaluate to a bool or a list, got %s", ast.OutputType())
+	}
+	program, err := env.Program(ast, cel.CostLimit(celCostLimit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL program: %w", err)
+	}
+	if message == "" {
+		message = fmt.Sprintf("CEL expression %q evaluated to false", expression)
+	}
+	return &celEvaluator{program: program, message: message}, nil
+}
+
+// evaluate returns the message if the expression evaluates to false, or the
+// elements of the list the expression evaluates to.
+func (c *celEvaluator) evaluate(ctx context.Context, input *policyInput, now time.Time) ([]string, error) {
+	out, _, err := c.program.ContextEval(ctx, map[string]any{
+		"predicateType": input.PredicateType,
+		"predicate":     input.Predicate,
+		"subject":       input.Subject,
+		"now":           now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate CEL expression: %w", err)
+	}
+	switch value := out.Value().(type) {
+	case bool:
+		if value {
+			return nil, nil
+		}
+		return []string{c.message}, nil
+	default:
+		native, err := out.ConvertToNative(anySliceType)
+		if err != nil {
+			return nil, fmt.Errorf("CEL expression must evaluate to a bool or a list, got %s", out.Type())
+		}
+		return toMessages(native.([]any)), nil
+	}
+}
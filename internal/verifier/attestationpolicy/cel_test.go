@@ -0,0 +1,160 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestationpolicy
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewCELEvaluator(t *testing.T) {
+	tests := []struct {
+		name        string
+		expression  string
+		errContains string
+	}{
+		{
+			name:       "bool expression",
+			expression: "predicate.coverage >= 80",
+		},
+		{
+			name:       "list expression",
+			expression: `predicate.findings.filter(f, f.severity == "HIGH").map(f, f.id)`,
+		},
+		{
+			name:        "syntax error",
+			expression:  "predicate.coverage >=",
+			errContains: "failed to compile CEL expression",
+		},
+		{
+			name:        "undeclared variable",
+			expression:  "unknown.coverage >= 80",
+			errContains: "failed to compile CEL expression",
+		},
+		{
+			name:        "unsupported output type",
+			expression:  "predicateType",
+			errContains: "CEL expression must evaluate to a bool or a list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newCELEvaluator(tt.expression, "")
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCELEvaluator_Evaluate(t *testing.T) {
+	tests := []struct {
+		name         string
+		expression   string
+		message      string
+		predicate    any
+		wantMessages []string
+		errContains  string
+	}{
+		{
+			name:       "coverage passes",
+			expression: "predicate.coverage >= 80",
+			predicate:  map[string]any{"coverage": 85.5},
+		},
+		{
+			name:         "coverage fails with default message",
+			expression:   "predicate.coverage >= 80",
+			predicate:    map[string]any{"coverage": 42.0},
+			wantMessages: []string{`CEL expression "predicate.coverage >= 80" evaluated to false`},
+		},
+		{
+			name:         "coverage fails with custom message",
+			expression:   "predicate.coverage >= 80",
+			message:      "test coverage is below 80%",
+			predicate:    map[string]any{"coverage": 42.0},
+			wantMessages: []string{"test coverage is below 80%"},
+		},
+		{
+			name:       "recent scan passes",
+			expression: `now - timestamp(predicate.scanFinishedOn) <= duration("168h")`,
+			predicate:  map[string]any{"scanFinishedOn": "2025-06-09T00:00:00Z"},
+		},
+		{
+			name:         "stale scan fails",
+			expression:   `now - timestamp(predicate.scanFinishedOn) <= duration("168h")`,
+			predicate:    map[string]any{"scanFinishedOn": "2025-06-01T00:00:00Z"},
+			wantMessages: []string{`CEL expression "now - timestamp(predicate.scanFinishedOn) <= duration(\"168h\")" evaluated to false`},
+		},
+		{
+			name:       "empty list passes",
+			expression: `predicate.findings.filter(f, f.severity == "HIGH").map(f, f.id)`,
+			predicate:  map[string]any{"findings": []any{map[string]any{"id": "CVE-1", "severity": "LOW"}}},
+		},
+		{
+			name:       "list messages",
+			expression: `predicate.findings.filter(f, f.severity == "HIGH").map(f, "high severity finding " + f.id)`,
+			predicate: map[string]any{"findings": []any{
+				map[string]any{"id": "CVE-1", "severity": "HIGH"},
+				map[string]any{"id": "CVE-2", "severity": "LOW"},
+				map[string]any{"id": "CVE-3", "severity": "HIGH"},
+			}},
+			wantMessages: []string{"high severity finding CVE-1", "high severity finding CVE-3"},
+		},
+		{
+			name:        "missing field",
+			expression:  "predicate.coverage >= 80",
+			predicate:   map[string]any{},
+			errContains: "failed to evaluate CEL expression",
+		},
+		{
+			name:        "dynamic result is not a bool or a list",
+			expression:  "predicate.coverage",
+			predicate:   map[string]any{"coverage": 42.0},
+			errContains: "CEL expression must evaluate to a bool or a list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluator, err := newCELEvaluator(tt.expression, tt.message)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			messages, err := evaluator.evaluate(context.Background(), &policyInput{Predicate: tt.predicate, Subject: []any{}}, testNow)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(messages) != len(tt.wantMessages) || (len(messages) > 0 && !reflect.DeepEqual(messages, tt.wantMessages)) {
+				t.Errorf("expected messages %v, got %v", tt.wantMessages, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestationpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+)
+
+// Policy is a Rego or CEL policy evaluated against the predicates of one
+// predicate type. Exactly one of Rego and CEL must be set.
+type Policy struct {
+	// Rego is a Rego module in package "ratify.attestation". The module
+	// defines a "deny" set of messages, and the predicate passes the policy
+	// if the set is empty. Optional.
+	Rego string `json:"rego,omitempty"`
+
+	// CEL is a CEL expression evaluating to either a bool, where true passes
+	// the policy, or a list of messages, where an empty list passes the
+	// policy. Optional.
+	CEL string `json:"cel,omitempty"`
+
+	// Message is the message returned if a CEL expression evaluates to
+	// false. Optional.
+	Message string `json:"message,omitempty"`
+}
+
+// policyInput is the input of a policy.
+//
+// Rego policies access it as "input", e.g. "input.predicate.coverage". CEL
+// expressions access the fields as variables, e.g. "predicate.coverage", and
+// may use "now" for the current time.
+type policyInput struct {
+	PredicateType string `json:"predicateType"`
+	Predicate     any    `json:"predicate"`
+	Subject       []any  `json:"subject"`
+}
+
+// evaluator evaluates a compiled policy.
+type evaluator interface {
+	// evaluate returns the messages of the violated policy, or no messages if
+	// the input passes the policy.
+	evaluate(ctx context.Context, input *policyInput, now time.Time) ([]string, error)
+}
+
+// compile compiles the policy.
+func (p Policy) compile() (evaluator, error) {
+	switch {
+	case p.Rego != "" && p.CEL != "":
+		return nil, errors.New("only one of rego and cel can be set")
+	case p.Rego != "":
+		return newRegoEvaluator(p.Rego)
+	case p.CEL != "":
+		return newCELEvaluator(p.CEL, p.Message)
+	}
+	return nil, errors.New("either rego or cel must be set")
+}
+
+// newPolicyInput decodes the statement into the policy input.
+func newPolicyInput(statement *attestation.Statement) (*policyInput, error) {
+	input := &policyInput{PredicateType: statement.PredicateType}
+	if err := json.Unmarshal(statement.Predicate, &input.Predicate); err != nil {
+		return nil, fmt.Errorf("failed to decode predicate: %w", err)
+	}
+	raw, err := json.Marshal(statement.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode subject: %w", err)
+	}
+	if err := json.Unmarshal(raw, &input.Subject); err != nil {
+		return nil, fmt.Errorf("failed to decode subject: %w", err)
+	}
+	return input, nil
+}
+
+// toMap converts the input to the generic JSON representation.
+func (i *policyInput) toMap() map[string]any {
+	return map[string]any{
+		"predicateType": i.PredicateType,
+		"predicate":     i.Predicate,
+		"subject":       i.Subject,
+	}
+}
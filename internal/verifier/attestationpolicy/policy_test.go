@@ -0,0 +1,92 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestationpolicy
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+)
+
+func TestPolicy_Compile(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      Policy
+		errContains string
+	}{
+		{
+			name:   "rego",
+			policy: Policy{Rego: testCoverageRego},
+		},
+		{
+			name:   "cel",
+			policy: Policy{CEL: "predicate.coverage >= 80"},
+		},
+		{
+			name:        "both",
+			policy:      Policy{Rego: testCoverageRego, CEL: "predicate.coverage >= 80"},
+			errContains: "only one of rego and cel can be set",
+		},
+		{
+			name:        "none",
+			policy:      Policy{Message: "message"},
+			errContains: "either rego or cel must be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.policy.compile()
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewPolicyInput(t *testing.T) {
+	statement := &attestation.Statement{
+		PredicateType: "https://example.com/coverage/v1",
+		Subject:       []attestation.Subject{{Name: "app", Digest: map[string]string{"sha256": "abc"}}},
+		Predicate:     json.RawMessage(`{"coverage":85}`),
+	}
+	input, err := newPolicyInput(statement)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"predicateType": "https://example.com/coverage/v1",
+		"predicate":     map[string]any{"coverage": 85.0},
+		"subject":       []any{map[string]any{"name": "app", "digest": map[string]any{"sha256": "abc"}}},
+	}
+	if got := input.toMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("toMap() = %v, want %v", got, want)
+	}
+
+	statement.Predicate = json.RawMessage(`invalid`)
+	if _, err := newPolicyInput(statement); err == nil || !strings.Contains(err.Error(), "failed to decode predicate") {
+		t.Errorf("expected predicate decoding error, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package attestationpolicy provides a verifier evaluating Rego or CEL
+// policies against the predicates of signed in-toto attestations.
+package attestationpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+)
+
+const verifierTypeAttestationPolicy = "attestationpolicy"
+
+// Options contains the configuration options for creating a [Verifier].
+// The signature options are inlined, e.g. "keys" or "certificateIdentity".
+type Options struct {
+	attestation.SignatureOptions
+
+	// Policies maps predicate types, e.g. "https://cosign.sigstore.dev/attestation/vuln/v1",
+	// to the policies evaluated against their predicates. Required.
+	Policies map[string]Policy `json:"policies"`
+}
+
+// Detail is the detail of the verification result of a [Verifier].
+type Detail struct {
+	// Attestations lists the results of the attestations with a configured
+	// predicate type, and of the attestations failing signature
+	// verification.
+	Attestations []AttestationResult `json:"attestations"`
+}
+
+// AttestationResult is the result of a single attestation.
+type AttestationResult struct {
+	// Digest is the digest of the blob holding the attestation.
+	Digest digest.Digest `json:"digest"`
+
+	// PredicateType is the predicate type of the attestation.
+	PredicateType string `json:"predicateType,omitempty"`
+
+	// Messages lists the messages of the violated policy.
+	Messages []string `json:"messages,omitempty"`
+
+	// Error is the error verifying the signature or evaluating the policy.
+	Error string `json:"error,omitempty"`
+}
+
+// Verifier implements the [ratify.Verifier] interface evaluating policies
+// against the predicates of signed attestations. The verification passes if
+// at least one attestation is evaluated and all evaluated attestations pass
+// their policies. Attestations failing signature verification are reported
+// but not evaluated.
+type Verifier struct {
+	name      string
+	signature *attestation.SignatureVerifier
+	policies  map[string]evaluator
+	now       func() time.Time
+}
+
+func init() {
+	verifier.Register(verifierTypeAttestationPolicy, NewVerifier)
+}
+
+// NewVerifier creates a new attestation policy verifier instance based on the
+// provided options.
+func NewVerifier(opts verifier.NewOptions, _ []string) (ratify.Verifier, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("verifier name cannot be empty")
+	}
+
+	raw, err := json.Marshal(opts.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verifier parameters: %w", err)
+	}
+	var params Options
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verifier parameters: %w", err)
+	}
+	if len(params.Policies) == 0 {
+		return nil, fmt.Errorf("policies cannot be empty")
+	}
+	policies := make(map[string]evaluator, len(params.Policies))
+	for predicateType, policy := range params.Policies {
+		if predicateType == "" {
+			return nil, fmt.Errorf("predicate type cannot be empty")
+		}
+		evaluator, err := policy.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy for predicate type %q: %w", predicateType, err)
+		}
+		policies[predicateType] = evaluator
+	}
+	signature, err := attestation.NewSignatureVerifier(params.SignatureOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		name:      opts.Name,
+		signature: signature,
+		policies:  policies,
+		now:       time.Now,
+	}, nil
+}
+
+// Name returns the name of the verifier.
+func (v *Verifier) Name() string {
+	return v.name
+}
+
+// Type returns the type of the verifier which is always "attestationpolicy".
+func (v *Verifier) Type() string {
+	return verifierTypeAttestationPolicy
+}
+
+// Verifiable checks if the artifact is an in-toto attestation.
+func (v *Verifier) Verifiable(artifact ocispec.Descriptor) bool {
+	return attestation.IsAttestation(artifact)
+}
+
+// Verify verifies the signatures of the attestations in the artifact and
+// evaluates the policies configured for their predicate types.
+func (v *Verifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	attestations, err := v.signature.Verify(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &Detail{}
+	result := &ratify.VerificationResult{
+		Verifier: v,
+		Detail:   detail,
+	}
+	now := v.now()
+	evaluated, failed := 0, 0
+	for _, a := range attestations {
+		if a.Err != nil {
+			detail.Attestations = append(detail.Attestations, AttestationResult{
+				Digest: a.Digest,
+				Error:  a.Err.Error(),
+			})
+			continue
+		}
+		policy, ok := v.policies[a.Statement.PredicateType]
+		if !ok {
+			continue
+		}
+		evaluated++
+		attestationResult := AttestationResult{
+			Digest:        a.Digest,
+			PredicateType: a.Statement.PredicateType,
+		}
+		messages, err := evaluate(ctx, policy, a.Statement, now)
+		if err != nil {
+			attestationResult.Error = err.Error()
+		}
+		attestationResult.Messages = messages
+		if err != nil || len(messages) > 0 {
+			failed++
+		}
+		detail.Attestations = append(detail.Attestations, attestationResult)
+	}
+
+	switch {
+	case evaluated == 0:
+		result.Err = fmt.Errorf("no verified attestation with a configured predicate type found in artifact %s", opts.ArtifactDescriptor.Digest)
+	case failed > 0:
+		result.Err = fmt.Errorf("attestation policy evaluation failed: %d of %d attestation(s) violate the policy", failed, evaluated)
+	default:
+		result.Description = "Attestation policy evaluation succeeded."
+	}
+	return result, nil
+}
+
+// evaluate evaluates the policy against the statement.
+func evaluate(ctx context.Context, policy evaluator, statement *attestation.Statement, now time.Time) ([]string, error) {
+	input, err := newPolicyInput(statement)
+	if err != nil {
+		return nil, err
+	}
+	return policy.evaluate(ctx, input, now)
+}
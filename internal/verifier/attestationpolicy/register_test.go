@@ -0,0 +1,257 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestationpolicy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation/attestationtest"
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/keyprovider/inlineprovider"
+)
+
+const (
+	testName             = "attestationpolicy-1"
+	testRepo             = "registry.example.com/app"
+	testCoverageType     = "https://example.com/coverage/v1"
+	testVulnScanType     = "https://cosign.sigstore.dev/attestation/vuln/v1"
+	testCoverageCEL      = "predicate.coverage >= 80"
+	testCoverageMessage  = "test coverage is below 80%"
+	testVulnScanDuration = `now - timestamp(predicate.metadata.scanFinishedOn) <= duration("168h")`
+)
+
+// newStore returns a store serving an in-toto attestation artifact with the
+// DSSE envelopes as layers.
+func newStore(t *testing.T, envelopes ...string) (*attestationtest.Store, ocispec.Descriptor) {
+	t.Helper()
+	return attestationtest.NewStore(t, attestation.ArtifactTypeInToto, attestationtest.NewLayers(attestation.ArtifactTypeDSSE, envelopes...)...)
+}
+
+// parameters returns the verifier parameters trusting the key of the signer
+// with the policies.
+func parameters(signer *attestationtest.Signer, policies map[string]any) map[string]any {
+	return signer.SignatureParameters(map[string]any{"policies": policies})
+}
+
+func TestNewVerifier(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	tests := []struct {
+		name        string
+		opts        verifier.NewOptions
+		errContains string
+	}{
+		{
+			name: "valid options",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Parameters: parameters(signer, map[string]any{
+					testCoverageType: map[string]any{"cel": testCoverageCEL, "message": testCoverageMessage},
+					testVulnScanType: map[string]any{"rego": testCoverageRego},
+				}),
+			},
+		},
+		{
+			name:        "empty name",
+			opts:        verifier.NewOptions{},
+			errContains: "verifier name cannot be empty",
+		},
+		{
+			name:        "unmarshalable parameters",
+			opts:        verifier.NewOptions{Name: testName, Parameters: make(chan int)},
+			errContains: "failed to marshal verifier parameters",
+		},
+		{
+			name:        "invalid parameters",
+			opts:        verifier.NewOptions{Name: testName, Parameters: map[string]any{"policies": "invalid"}},
+			errContains: "failed to unmarshal verifier parameters",
+		},
+		{
+			name:        "no policies",
+			opts:        verifier.NewOptions{Name: testName, Parameters: parameters(signer, nil)},
+			errContains: "policies cannot be empty",
+		},
+		{
+			name: "empty predicate type",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Parameters: parameters(signer, map[string]any{"": map[string]any{"cel": testCoverageCEL}}),
+			},
+			errContains: "predicate type cannot be empty",
+		},
+		{
+			name: "invalid policy",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Parameters: parameters(signer, map[string]any{testCoverageType: map[string]any{"cel": "predicate.coverage >="}}),
+			},
+			errContains: `invalid policy for predicate type "https://example.com/coverage/v1"`,
+		},
+		{
+			name: "missing signature options",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Parameters: map[string]any{"policies": map[string]any{testCoverageType: map[string]any{"cel": testCoverageCEL}}},
+			},
+			errContains: "either keys or a certificate identity must be provided",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(tt.opts, nil)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.Name() != testName {
+				t.Errorf("expected name %q, got %q", testName, v.Name())
+			}
+			if v.Type() != verifierTypeAttestationPolicy {
+				t.Errorf("expected type %q, got %q", verifierTypeAttestationPolicy, v.Type())
+			}
+		})
+	}
+}
+
+func TestVerifiable(t *testing.T) {
+	v := &Verifier{}
+	if !v.Verifiable(ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: attestation.ArtifactTypeInToto}) {
+		t.Error("expected in-toto attestation to be verifiable")
+	}
+	if v.Verifiable(ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: "application/spdx+json"}) {
+		t.Error("expected SBOM not to be verifiable")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	otherSigner := attestationtest.NewSigner(t)
+	policies := map[string]any{
+		testCoverageType: map[string]any{"cel": testCoverageCEL, "message": testCoverageMessage},
+		testVulnScanType: map[string]any{"cel": testVulnScanDuration},
+	}
+	goodCoverage := signer.Sign(t, testCoverageType, `{"coverage":85}`)
+	badCoverage := signer.Sign(t, testCoverageType, `{"coverage":42}`)
+	recentScan := signer.Sign(t, testVulnScanType, `{"metadata":{"scanFinishedOn":"2025-06-09T00:00:00Z"}}`)
+
+	tests := []struct {
+		name        string
+		blobs       []string
+		wantErr     string
+		wantResults []AttestationResult
+	}{
+		{
+			name:  "all attestations pass",
+			blobs: []string{goodCoverage, recentScan},
+			wantResults: []AttestationResult{
+				{Digest: digest.FromString(goodCoverage), PredicateType: testCoverageType},
+				{Digest: digest.FromString(recentScan), PredicateType: testVulnScanType},
+			},
+		},
+		{
+			name:    "policy violation",
+			blobs:   []string{badCoverage, recentScan},
+			wantErr: "1 of 2 attestation(s) violate the policy",
+			wantResults: []AttestationResult{
+				{Digest: digest.FromString(badCoverage), PredicateType: testCoverageType, Messages: []string{testCoverageMessage}},
+				{Digest: digest.FromString(recentScan), PredicateType: testVulnScanType},
+			},
+		},
+		{
+			name:    "evaluation error",
+			blobs:   []string{signer.Sign(t, testVulnScanType, `{"metadata":{}}`)},
+			wantErr: "1 of 1 attestation(s) violate the policy",
+		},
+		{
+			name:    "unconfigured predicate types are ignored",
+			blobs:   []string{signer.Sign(t, "https://slsa.dev/provenance/v1", `{}`)},
+			wantErr: "no verified attestation with a configured predicate type found",
+		},
+		{
+			name:    "untrusted signature is not evaluated",
+			blobs:   []string{otherSigner.Sign(t, testCoverageType, `{"coverage":85}`)},
+			wantErr: "no verified attestation with a configured predicate type found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(verifier.NewOptions{Name: testName, Parameters: parameters(signer, policies)}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			v.(*Verifier).now = func() time.Time { return testNow }
+			store, artifact := newStore(t, tt.blobs...)
+			result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+				Store:              store,
+				Repository:         testRepo,
+				SubjectDescriptor:  ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: attestationtest.Subject},
+				ArtifactDescriptor: artifact,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr == "" {
+				if result.Err != nil {
+					t.Fatalf("unexpected result error: %v", result.Err)
+				}
+			} else if result.Err == nil || !strings.Contains(result.Err.Error(), tt.wantErr) {
+				t.Fatalf("expected result error containing %q, got %v", tt.wantErr, result.Err)
+			}
+			if tt.wantResults != nil {
+				if got := result.Detail.(*Detail).Attestations; !reflect.DeepEqual(got, tt.wantResults) {
+					t.Errorf("expected results %+v, got %+v", tt.wantResults, got)
+				}
+			}
+		})
+	}
+}
+
+func TestVerify_StoreError(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	v, err := NewVerifier(verifier.NewOptions{
+		Name:       testName,
+		Parameters: parameters(signer, map[string]any{testCoverageType: map[string]any{"cel": testCoverageCEL}}),
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store, artifact := newStore(t)
+	store.ManifestErr = errors.New("manifest error")
+	if _, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+		Store:              store,
+		Repository:         testRepo,
+		SubjectDescriptor:  ocispec.Descriptor{Digest: attestationtest.Subject},
+		ArtifactDescriptor: artifact,
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestationpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// regoQuery is the query of the messages of a Rego policy.
+const regoQuery = "data.ratify.attestation.deny"
+
+// regoEvaluator evaluates a Rego policy.
+type regoEvaluator struct {
+	query rego.PreparedEvalQuery
+}
+
+// newRegoEvaluator compiles the Rego module.
+func newRegoEvaluator(module string) (*regoEvaluator, error) {
+	query, err := rego.New(
+		rego.Query(regoQuery),
+		rego.Module("policy.rego", module),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare rego query: %w", err)
+	}
+	return &regoEvaluator{query: query}, nil
+}
+
+// evaluate returns the elements of the "deny" set.
+func (r *regoEvaluator) evaluate(ctx context.Context, input *policyInput, now time.Time) ([]string, error) {
+	results, err := r.query.Eval(ctx, rego.EvalInput(input.toMap()), rego.EvalTime(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate rego policy: %w", err)
+	}
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return nil, errors.New("rego policy does not define data.ratify.attestation.deny")
+	}
+	values, ok := results[0].Expressions[0].Value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type of deny: %T", results[0].Expressions[0].Value)
+	}
+	return toMessages(values), nil
+}
+
+// toMessages converts policy results to messages.
+func toMessages(values []any) []string {
+	messages := make([]string, 0, len(values))
+	for _, value := range values {
+		if message, ok := value.(string); ok {
+			messages = append(messages, message)
+			continue
+		}
+		messages = append(messages, fmt.Sprint(value))
+	}
+	return messages
+}
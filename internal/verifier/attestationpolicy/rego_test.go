@@ -0,0 +1,144 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestationpolicy
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCoverageRego = `package ratify.attestation
+
+deny contains msg if {
+	input.predicate.coverage < 80
+	msg := sprintf("coverage %v%% is below 80%%", [input.predicate.coverage])
+}
+
+deny contains "scan is older than 7 days" if {
+	time.parse_rfc3339_ns(input.predicate.scanFinishedOn) < time.now_ns() - 7 * 24 * 60 * 60 * 1000000000
+}
+`
+
+var testNow = time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+func TestNewRegoEvaluator(t *testing.T) {
+	tests := []struct {
+		name        string
+		module      string
+		errContains string
+	}{
+		{
+			name:   "valid module",
+			module: testCoverageRego,
+		},
+		{
+			name:        "invalid module",
+			module:      "package",
+			errContains: "failed to prepare rego query",
+		},
+		{
+			name:        "v0 syntax",
+			module:      "package ratify.attestation\n\ndeny[msg] { msg := \"denied\" }",
+			errContains: "failed to prepare rego query",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newRegoEvaluator(tt.module)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRegoEvaluator_Evaluate(t *testing.T) {
+	tests := []struct {
+		name         string
+		module       string
+		predicate    any
+		wantMessages []string
+		errContains  string
+	}{
+		{
+			name:      "passes",
+			module:    testCoverageRego,
+			predicate: map[string]any{"coverage": 85.5, "scanFinishedOn": "2025-06-09T00:00:00Z"},
+		},
+		{
+			name:         "low coverage and stale scan",
+			module:       testCoverageRego,
+			predicate:    map[string]any{"coverage": 42, "scanFinishedOn": "2025-06-01T00:00:00Z"},
+			wantMessages: []string{"coverage 42% is below 80%", "scan is older than 7 days"},
+		},
+		{
+			name:         "non-string messages",
+			module:       "package ratify.attestation\n\ndeny contains {\"reason\": \"denied\"} if { true }",
+			predicate:    map[string]any{},
+			wantMessages: []string{"map[reason:denied]"},
+		},
+		{
+			name:        "deny not defined",
+			module:      "package ratify.attestation\n\nallow := true",
+			predicate:   map[string]any{},
+			errContains: "does not define data.ratify.attestation.deny",
+		},
+		{
+			name:        "deny is not a set",
+			module:      "package ratify.attestation\n\ndeny := \"denied\"",
+			predicate:   map[string]any{},
+			errContains: "unexpected result type of deny",
+		},
+		{
+			name:        "evaluation error",
+			module:      "package ratify.attestation\n\nx := 1 if { true }\n\nx := 2 if { true }\n\ndeny contains msg if { msg := x }",
+			predicate:   map[string]any{},
+			errContains: "failed to evaluate rego policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluator, err := newRegoEvaluator(tt.module)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			messages, err := evaluator.evaluate(context.Background(), &policyInput{Predicate: tt.predicate}, testNow)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(messages) != len(tt.wantMessages) || (len(messages) > 0 && !reflect.DeepEqual(messages, tt.wantMessages)) {
+				t.Errorf("expected messages %v, got %v", tt.wantMessages, messages)
+			}
+		})
+	}
+}
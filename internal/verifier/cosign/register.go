@@ -29,6 +29,7 @@ import (
 
 	"github.com/notaryproject/ratify/v2/internal/verifier"
 	"github.com/notaryproject/ratify/v2/internal/verifier/keyprovider"
+	"github.com/notaryproject/ratify/v2/internal/verifier/trustedroot"
 )
 
 const (
@@ -95,6 +96,10 @@ type ScopedOptions struct {
 	// keyless options are provided, only keys are used.
 	// And only one set of key provider is allowed to be provided.
 	Keys map[string]any `json:"keys,omitempty"`
+
+	// Options customizes the trusted root, e.g. for a private Sigstore
+	// deployment. Optional.
+	trustedroot.Options
 }
 
 // Options contains the configuration options for creating a [Verifier].
@@ -294,7 +299,8 @@ func (v *Verifier) registerRegistry(scope string, verifier *cosign.Verifier) err
 
 // toVerifierOptions converts [ScopedOptions] to [cosign.VerifierOptions].
 // It creates identity policies for keyless verification based on the
-// certificate identity and OIDC issuer configuration.
+// certificate identity and OIDC issuer configuration, and sets the trusted
+// root if a custom Sigstore deployment is configured.
 func toVerifierOptions(s *ScopedOptions, name string) (*cosign.VerifierOptions, error) {
 	if len(s.Keys) > 1 {
 		return nil, fmt.Errorf("only one set of key provider is allowed")
@@ -302,6 +308,12 @@ func toVerifierOptions(s *ScopedOptions, name string) (*cosign.VerifierOptions,
 	opts := &cosign.VerifierOptions{
 		Name: name,
 	}
+	trustedRoot, tufOpts, err := trustedroot.Load(&s.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trusted root: %w", err)
+	}
+	opts.TrustedRoot = trustedRoot
+	opts.TUFOptions = tufOpts
 	if len(s.Keys) == 0 {
 		opts.IgnoreCTLog = s.IgnoreCTLog
 		opts.IgnoreTLog = s.IgnoreTLog
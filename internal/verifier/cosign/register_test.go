@@ -18,8 +18,12 @@ package cosign
 import (
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 	"time"
 
@@ -29,6 +33,7 @@ import (
 
 	"github.com/notaryproject/ratify/v2/internal/verifier"
 	"github.com/notaryproject/ratify/v2/internal/verifier/keyprovider"
+	"github.com/notaryproject/ratify/v2/internal/verifier/trustedroot"
 )
 
 const testVerifierName = "test-cosign-verifier"
@@ -773,6 +778,35 @@ func TestToVerifierOptions(t *testing.T) {
 			wantErr:     true,
 			errContains: "failed to create key provider",
 		},
+		{
+			name:         "custom trusted root",
+			verifierName: "test-policy",
+			input: &ScopedOptions{
+				CertificateIdentity:   "test@example.com",
+				CertificateOIDCIssuer: "https://oidc.example.com",
+				Options: trustedroot.Options{
+					RekorPublicKeys: []trustedroot.TransparencyLogOptions{{PublicKey: testRekorPublicKey(t)}},
+				},
+			},
+			wantErr: false,
+			validate: func(t *testing.T, opts *cosign.VerifierOptions) {
+				if opts.TrustedRoot == nil {
+					t.Errorf("TrustedRoot = nil, want custom trusted root")
+				}
+				if len(opts.IdentityPolicies) != 1 {
+					t.Errorf("IdentityPolicies length = %v, want %v", len(opts.IdentityPolicies), 1)
+				}
+			},
+		},
+		{
+			name:         "invalid trusted root",
+			verifierName: "test-policy",
+			input: &ScopedOptions{
+				Options: trustedroot.Options{TrustedRoot: "{"},
+			},
+			wantErr:     true,
+			errContains: "failed to create trusted root",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1341,3 +1375,41 @@ func TestConvertProviderKeysToCosignKeys_NilInput(t *testing.T) {
 		t.Errorf("expected empty slice for nil input, got length %d", len(result))
 	}
 }
+
+func TestNewVerifier_CustomTrustedRoot(t *testing.T) {
+	// A keyless verifier with a custom trusted root is created without
+	// fetching the public-good trusted root.
+	_, err := NewVerifier(verifier.NewOptions{
+		Type: verifierTypeCosign,
+		Name: testVerifierName,
+		Parameters: Options{
+			TrustPolicies: []*ScopedOptions{
+				{
+					Scopes:                []string{"registry.internal"},
+					CertificateIdentity:   "build@example.com",
+					CertificateOIDCIssuer: "https://oidc.internal",
+					Options: trustedroot.Options{
+						RekorPublicKeys: []trustedroot.TransparencyLogOptions{{PublicKey: testRekorPublicKey(t)}},
+					},
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// testRekorPublicKey generates a PEM encoded public key of a Rekor log.
+func testRekorPublicKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
@@ -0,0 +1,115 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/plugin"
+	pluginframework "github.com/notaryproject/notation-plugin-framework-go/plugin"
+)
+
+const defaultPluginTimeout = 10 * time.Second
+
+// pluginManager is a [plugin.Manager] that bounds the execution time of the
+// plugins it returns.
+type pluginManager struct {
+	plugin.Manager
+	timeout time.Duration
+}
+
+// newPluginManager creates a plugin manager for the plugins installed in the
+// directory as "<name>/notation-<name>", following the Notation plugin spec.
+func newPluginManager(pluginDir string, timeout time.Duration) (*pluginManager, error) {
+	info, err := os.Stat(pluginDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access plugin directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("plugin directory %s is not a directory", pluginDir)
+	}
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+	return &pluginManager{
+		Manager: plugin.NewCLIManager(dir.NewSysFS(pluginDir)),
+		timeout: timeout,
+	}, nil
+}
+
+// Get implements [plugin.Manager]. The name comes from the extended
+// attributes of the signature, which is not yet authenticated when the plugin
+// is looked up, so only names of a single plain path element are accepted.
+func (m *pluginManager) Get(ctx context.Context, name string) (pluginframework.Plugin, error) {
+	if !isValidPluginName(name) {
+		return nil, fmt.Errorf("invalid plugin name %q", name)
+	}
+	p, err := m.Manager.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutPlugin{
+		Plugin:  p,
+		name:    name,
+		timeout: m.timeout,
+	}, nil
+}
+
+// isValidPluginName reports whether the name is a single plain path element
+// that cannot escape the plugin directory.
+func isValidPluginName(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		!strings.ContainsAny(name, `/\`) && name == filepath.Base(name)
+}
+
+// timeoutPlugin is a plugin whose verification commands are cancelled after
+// the timeout.
+type timeoutPlugin struct {
+	pluginframework.Plugin
+	name    string
+	timeout time.Duration
+}
+
+// GetMetadata implements [pluginframework.GenericPlugin].
+func (p *timeoutPlugin) GetMetadata(ctx context.Context, req *pluginframework.GetMetadataRequest) (*pluginframework.GetMetadataResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	resp, err := p.Plugin.GetMetadata(ctx, req)
+	return resp, p.wrapError(ctx, err)
+}
+
+// VerifySignature implements [pluginframework.VerifyPlugin].
+func (p *timeoutPlugin) VerifySignature(ctx context.Context, req *pluginframework.VerifySignatureRequest) (*pluginframework.VerifySignatureResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	resp, err := p.Plugin.VerifySignature(ctx, req)
+	return resp, p.wrapError(ctx, err)
+}
+
+// wrapError reports a timeout if the plugin failed after the deadline.
+func (p *timeoutPlugin) wrapError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("plugin %s did not complete within %s: %w", p.name, p.timeout, err)
+	}
+	return err
+}
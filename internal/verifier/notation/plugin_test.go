@@ -0,0 +1,212 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	pluginframework "github.com/notaryproject/notation-plugin-framework-go/plugin"
+)
+
+const testPluginMetadata = `{"name":"test","description":"test plugin","version":"1.0.0","url":"https://example.com","supportedContractVersions":["1.0"],"capabilities":["SIGNATURE_VERIFIER.TRUSTED_IDENTITY"]}`
+
+// installTestPlugin installs a shell script as the plugin "test".
+func installTestPlugin(t *testing.T, pluginDir, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on windows")
+	}
+	if err := os.MkdirAll(filepath.Join(pluginDir, "test"), 0700); err != nil {
+		t.Fatalf("failed to create plugin directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginDir, "test", "notation-test"), []byte("#!/bin/sh\n"+script+"\n"), 0700); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+}
+
+// blockingPlugin blocks verification until the context is done.
+type blockingPlugin struct {
+	pluginframework.Plugin
+}
+
+func (p *blockingPlugin) VerifySignature(ctx context.Context, _ *pluginframework.VerifySignatureRequest) (*pluginframework.VerifySignatureResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestNewPluginManager(t *testing.T) {
+	pluginDir := t.TempDir()
+	pluginFile := filepath.Join(pluginDir, "file")
+	if err := os.WriteFile(pluginFile, nil, 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		dir             string
+		timeout         time.Duration
+		expectedTimeout time.Duration
+		expectErr       bool
+	}{
+		{
+			name:            "Default timeout",
+			dir:             pluginDir,
+			expectedTimeout: defaultPluginTimeout,
+		},
+		{
+			name:            "Custom timeout",
+			dir:             pluginDir,
+			timeout:         time.Minute,
+			expectedTimeout: time.Minute,
+		},
+		{
+			name:      "Missing directory",
+			dir:       filepath.Join(pluginDir, "missing"),
+			expectErr: true,
+		},
+		{
+			name:      "Not a directory",
+			dir:       pluginFile,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager, err := newPluginManager(test.dir, test.timeout)
+			if test.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", test.expectErr, err)
+			}
+			if err == nil && manager.timeout != test.expectedTimeout {
+				t.Fatalf("Expected timeout %s, got %s", test.expectedTimeout, manager.timeout)
+			}
+		})
+	}
+}
+
+func TestPluginManager_Get(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        string
+		timeout       time.Duration
+		expectedError string
+	}{
+		{
+			name:   "Plugin metadata",
+			script: "echo '" + testPluginMetadata + "'",
+		},
+		{
+			name:          "Plugin failure",
+			script:        "echo '{\"errorCode\":\"GENERIC\",\"errorMessage\":\"failed\"}' >&2; exit 1",
+			expectedError: "failed",
+		},
+		{
+			name:          "Plugin timeout",
+			script:        "exec sleep 10",
+			timeout:       100 * time.Millisecond,
+			expectedError: "plugin test did not complete within 100ms",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pluginDir := t.TempDir()
+			installTestPlugin(t, pluginDir, test.script)
+			manager, err := newPluginManager(pluginDir, test.timeout)
+			if err != nil {
+				t.Fatalf("failed to create plugin manager: %v", err)
+			}
+			names, err := manager.List(context.Background())
+			if err != nil || len(names) != 1 || names[0] != "test" {
+				t.Fatalf("Expected plugin test to be listed, got %v, %v", names, err)
+			}
+
+			p, err := manager.Get(context.Background(), "test")
+			if err != nil {
+				t.Fatalf("failed to get plugin: %v", err)
+			}
+			metadata, err := p.GetMetadata(context.Background(), &pluginframework.GetMetadataRequest{})
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get plugin metadata: %v", err)
+			}
+			if metadata.Name != "test" {
+				t.Fatalf("Expected plugin name test, got %s", metadata.Name)
+			}
+		})
+	}
+}
+
+func TestPluginManager_GetMissingPlugin(t *testing.T) {
+	manager, err := newPluginManager(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("failed to create plugin manager: %v", err)
+	}
+	if _, err := manager.Get(context.Background(), "missing"); err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+}
+
+func TestTimeoutPlugin_VerifySignature(t *testing.T) {
+	p := &timeoutPlugin{
+		Plugin:  &blockingPlugin{},
+		name:    "test",
+		timeout: 10 * time.Millisecond,
+	}
+	_, err := p.VerifySignature(context.Background(), &pluginframework.VerifySignatureRequest{})
+	if err == nil || !strings.Contains(err.Error(), "plugin test did not complete within 10ms") {
+		t.Fatalf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestPluginManager_GetInvalidName(t *testing.T) {
+	pluginDir := t.TempDir()
+	installTestPlugin(t, pluginDir, "echo '"+testPluginMetadata+"'")
+	manager, err := newPluginManager(filepath.Join(pluginDir, "test"), 0)
+	if err != nil {
+		t.Fatalf("failed to create plugin manager: %v", err)
+	}
+
+	tests := []string{
+		"",
+		".",
+		"..",
+		"../test",
+		"x/../../test",
+		"x/../../../../usr/bin/sh",
+		"/usr/bin/sh",
+		`..\\test`,
+	}
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := manager.Get(context.Background(), name)
+			if err == nil || !strings.Contains(err.Error(), "invalid plugin name") {
+				t.Fatalf("Expected invalid plugin name error, got %v", err)
+			}
+		})
+	}
+}
@@ -16,13 +16,17 @@ limitations under the License.
 package notation
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 
+	"github.com/notaryproject/notation-go/plugin"
+	notationverifier "github.com/notaryproject/notation-go/verifier"
 	"github.com/notaryproject/notation-go/verifier/trustpolicy"
 	"github.com/notaryproject/notation-go/verifier/truststore"
 	"github.com/notaryproject/ratify-go"
-	"github.com/notaryproject/ratify-verifier-go/notation"
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
 	"github.com/notaryproject/ratify/v2/internal/verifier"
 	"github.com/notaryproject/ratify/v2/internal/verifier/keyprovider"
 )
@@ -31,28 +35,111 @@ const (
 	verifierTypeNotation = "notation"
 	trustStoreName       = "ratify"
 	typeKey              = "type"
+	nameKey              = "name"
+)
+
+// Revocation modes of trust policies.
+const (
+	// revocationModeEnforce fails verification if the signing certificate is
+	// revoked or its revocation status is unknown.
+	revocationModeEnforce = "enforce"
+
+	// revocationModeRevokedOnly fails verification only if the signing
+	// certificate is revoked. An unknown revocation status is logged.
+	revocationModeRevokedOnly = "revokedOnly"
+
+	// revocationModeLog logs revoked and unknown revocation statuses without
+	// failing verification.
+	revocationModeLog = "log"
+
+	// revocationModeSkip skips the revocation check.
+	revocationModeSkip = "skip"
 )
 
 // trustStoreOptions is a map of options for the trust stores. The value of the
 // "type" key must be one of the following: "ca", "tsa", or "signingAuthority".
 // If the "type" key is not present, the default type is "ca".
+// The value of the "name" key is the name of the trust store, which trust
+// policies refer to as "<type>:<name>". If the "name" key is not present, the
+// default name is "ratify".
 // Other keys in the map are used to create key providers. Each trust store can
 // have multiple key providers.
 type trustStoreOptions map[string]any
 
+// trustPolicyOptions defines a Notation trust policy.
+type trustPolicyOptions struct {
+	// Name is the name of the trust policy. Required.
+	Name string `json:"name"`
+
+	// Scopes is a list of registry scopes the trust policy applies to.
+	// Optional. If not provided, the default scope is "*".
+	Scopes []string `json:"scopes"`
+
+	// TrustedIdentities is a list of trusted identities. Optional. If not
+	// provided, default identity is "*". Must be empty if the verification
+	// level is "skip".
+	TrustedIdentities []string `json:"trustedIdentities"`
+
+	// TrustStores is a list of trust stores in the "<type>:<name>" format,
+	// e.g. "ca:partner". Optional. If not provided, all trust stores are used.
+	// Must be empty if the verification level is "skip".
+	TrustStores []string `json:"trustStores"`
+
+	// VerificationLevel is one of "strict", "permissive", "audit" or "skip".
+	// Optional. If not provided, the default level is "strict".
+	VerificationLevel string `json:"verificationLevel"`
+
+	// Override overrides the actions of individual validations of the
+	// verification level, e.g. {"revocation": "log"}. Optional.
+	Override map[trustpolicy.ValidationType]trustpolicy.ValidationAction `json:"override"`
+
+	// RevocationMode is one of "enforce", "revokedOnly", "log" or "skip".
+	// "revokedOnly" fails verification if the signing certificate is revoked
+	// but not if its revocation status is unknown, e.g. because the OCSP
+	// responder and CRL distribution point are unreachable. Optional. If not
+	// provided, the action of the verification level applies. Cannot be
+	// combined with a revocation override.
+	RevocationMode string `json:"revocationMode"`
+}
+
 type options struct {
 	// Scopes is a list of registry scopes to be used by the Notation
 	// verifier. Optional. If not provided, the default scope is "*".
+	// Cannot be combined with TrustPolicies.
 	Scopes []string `json:"scopes"`
 
 	// TrustedIdentities is a list of trusted identities to be used by the
 	// Notation verifier. Optional. If not provided, default identity is "*".
+	// Cannot be combined with TrustPolicies.
 	TrustedIdentities []string `json:"trustedIdentities"`
 
+	// TrustPolicies is a list of trust policies, each applying to its own
+	// registry scopes. Optional. If not provided, a single strict trust policy
+	// is created from Scopes and TrustedIdentities.
+	TrustPolicies []trustPolicyOptions `json:"trustPolicies"`
+
 	// Certificates is a list of certificates to be used by the Notation
 	// verifier. Certificates would be loaded into trust store for Notation
 	// verifier to access. Required.
 	Certificates []trustStoreOptions `json:"certificates"`
+
+	// Revocation configures the OCSP and CRL revocation checks, including the
+	// CRL cache and CRLs preloaded for air-gapped clusters. Optional.
+	Revocation revocationOptions `json:"revocation"`
+
+	// PluginDirectory is the directory of the Notation verification plugins,
+	// each installed as "<name>/notation-<name>". Signatures whose extended
+	// attributes name a verification plugin are verified by invoking the
+	// plugin. Optional. If not provided, such signatures fail verification.
+	PluginDirectory string `json:"pluginDirectory"`
+
+	// PluginTimeout is the timeout of each plugin execution. Optional. If not
+	// provided, the default timeout is 10s.
+	PluginTimeout jsonutil.Duration `json:"pluginTimeout"`
+
+	// PluginConfig is the configuration passed to verification plugins.
+	// Optional.
+	PluginConfig map[string]string `json:"pluginConfig"`
 }
 
 func init() {
@@ -67,28 +154,63 @@ func init() {
 			return nil, fmt.Errorf("failed to unmarshal verifier parameters: %w", err)
 		}
 
-		trustStore, types, err := initTrustStore(params.Certificates)
+		trustStore, storeNames, err := initTrustStore(params.Certificates)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize trust store: %w", err)
 		}
+		trustPolicyDoc, err := initTrustPolicyDocument(&params, storeNames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize trust policy document: %w", err)
+		}
 
-		notationOpts := &notation.VerifierOptions{
-			Name:           opts.Name,
-			TrustPolicyDoc: initTrustPolicyDocument(params.Scopes, params.TrustedIdentities, types),
-			TrustStore:     trustStore,
+		crlFetcher, err := newCRLFetcher(context.Background(), params.Revocation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize CRL fetcher: %w", err)
+		}
+		codeSigningValidator, timestampingValidator, err := newRevocationValidators(params.Revocation, crlFetcher)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize revocation validators: %w", err)
+		}
+		var manager plugin.Manager
+		if params.PluginDirectory != "" {
+			if manager, err = newPluginManager(params.PluginDirectory, params.PluginTimeout.Duration()); err != nil {
+				return nil, fmt.Errorf("failed to initialize plugin manager: %w", err)
+			}
+		}
+		v, err := notationverifier.NewWithOptions(trustPolicyDoc, trustStore, manager, notationverifier.VerifierOptions{
+			RevocationCodeSigningValidator:  codeSigningValidator,
+			RevocationTimestampingValidator: timestampingValidator,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create notation verifier: %w", err)
 		}
 
-		return notation.NewVerifier(notationOpts)
+		revokedOnlyPolicies := make(map[string]struct{})
+		for _, policy := range params.TrustPolicies {
+			if policy.RevocationMode == revocationModeRevokedOnly {
+				revokedOnlyPolicies[policy.Name] = struct{}{}
+			}
+		}
+		return &Verifier{
+			name:                opts.Name,
+			verifier:            v,
+			trustPolicyDoc:      trustPolicyDoc,
+			revokedOnlyPolicies: revokedOnlyPolicies,
+			pluginConfig:        params.PluginConfig,
+		}, nil
 	})
 }
 
-func initTrustStore(opts []trustStoreOptions) (truststore.X509TrustStore, []truststore.Type, error) {
+// initTrustStore creates the trust store and returns the names of the named
+// trust stores in the "<type>:<name>" format.
+func initTrustStore(opts []trustStoreOptions) (truststore.X509TrustStore, []string, error) {
 	if len(opts) == 0 {
 		return nil, nil, fmt.Errorf("no trust store options provided")
 	}
 
 	trustStore := newTrustStore()
-	types := make(map[truststore.Type]struct{})
+	var storeNames []string
+	seen := make(map[string]struct{})
 	for _, opt := range opts {
 		var err error
 		storeType := truststore.TypeCA
@@ -97,13 +219,21 @@ func initTrustStore(opts []trustStoreOptions) (truststore.X509TrustStore, []trus
 				return nil, nil, fmt.Errorf("failed to get trust store type: %w", err)
 			}
 		}
-		if _, exists := types[storeType]; exists {
-			return nil, nil, fmt.Errorf("duplicate trust store type %s detected. Please check your configuration to ensure each trust store type is unique", storeType)
+		namedStore := trustStoreName
+		if nameVal, ok := opt[nameKey]; ok {
+			if namedStore, ok = nameVal.(string); !ok || namedStore == "" {
+				return nil, nil, fmt.Errorf("trust store name must be a non-empty string")
+			}
+		}
+		storeName := fmt.Sprintf("%s:%s", storeType, namedStore)
+		if _, exists := seen[storeName]; exists {
+			return nil, nil, fmt.Errorf("duplicate trust store %s detected. Please check your configuration to ensure each trust store type and name is unique", storeName)
 		}
-		types[storeType] = struct{}{}
+		seen[storeName] = struct{}{}
+		storeNames = append(storeNames, storeName)
 
 		for key, val := range opt {
-			if key == typeKey {
+			if key == typeKey || key == nameKey {
 				continue
 			}
 			provider, err := keyprovider.CreateKeyProvider(key, val)
@@ -111,14 +241,10 @@ func initTrustStore(opts []trustStoreOptions) (truststore.X509TrustStore, []trus
 				return nil, nil, fmt.Errorf("failed to get key provider %s: %w", key, err)
 			}
 
-			trustStore.addKeyProvider(storeType, trustStoreName, provider)
+			trustStore.addKeyProvider(storeType, namedStore, provider)
 		}
 	}
-	names := make([]truststore.Type, 0, len(types))
-	for storeType := range types {
-		names = append(names, storeType)
-	}
-	return trustStore, names, nil
+	return trustStore, storeNames, nil
 }
 
 func getTrustStoreType(val any) (truststore.Type, error) {
@@ -133,29 +259,105 @@ func getTrustStoreType(val any) (truststore.Type, error) {
 	return storeType, nil
 }
 
-func initTrustPolicyDocument(scopes, trustedIdentities []string, storeTypes []truststore.Type) *trustpolicy.Document {
+// initTrustPolicyDocument creates the trust policy document from the trust
+// policies, or a single strict trust policy named "default" if no trust
+// policies are configured.
+func initTrustPolicyDocument(opts *options, storeNames []string) (*trustpolicy.Document, error) {
+	policies := opts.TrustPolicies
+	if len(policies) == 0 {
+		policies = []trustPolicyOptions{{
+			Name:              "default",
+			Scopes:            opts.Scopes,
+			TrustedIdentities: opts.TrustedIdentities,
+		}}
+	} else if len(opts.Scopes) > 0 || len(opts.TrustedIdentities) > 0 {
+		return nil, fmt.Errorf("scopes and trustedIdentities cannot be combined with trustPolicies")
+	}
+
+	doc := &trustpolicy.Document{
+		Version:       "1.0",
+		TrustPolicies: make([]trustpolicy.TrustPolicy, 0, len(policies)),
+	}
+	for _, policy := range policies {
+		trustPolicy, err := toTrustPolicy(policy, storeNames)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trust policy %q: %w", policy.Name, err)
+		}
+		doc.TrustPolicies = append(doc.TrustPolicies, trustPolicy)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// toTrustPolicy converts the options to a Notation trust policy. Trust stores
+// and trusted identities default to all trust stores and "*" unless the
+// verification level is "skip".
+func toTrustPolicy(opts trustPolicyOptions, storeNames []string) (trustpolicy.TrustPolicy, error) {
+	level := opts.VerificationLevel
+	if level == "" {
+		level = trustpolicy.LevelStrict.Name
+	}
+	scopes := opts.Scopes
 	if len(scopes) == 0 {
 		scopes = []string{"*"}
 	}
-	if len(trustedIdentities) == 0 {
-		trustedIdentities = []string{"*"}
+	trustStores := opts.TrustStores
+	trustedIdentities := opts.TrustedIdentities
+	if level != trustpolicy.LevelSkip.Name {
+		for _, store := range trustStores {
+			if !slices.Contains(storeNames, store) {
+				return trustpolicy.TrustPolicy{}, fmt.Errorf("trust store %s is not configured", store)
+			}
+		}
+		if len(trustStores) == 0 {
+			trustStores = storeNames
+		}
+		if len(trustedIdentities) == 0 {
+			trustedIdentities = []string{"*"}
+		}
 	}
-	trustStoreNames := make([]string, len(storeTypes))
-	for i, storeType := range storeTypes {
-		trustStoreNames[i] = fmt.Sprintf("%s:%s", storeType, trustStoreName)
+	override, err := revocationOverride(opts.RevocationMode, opts.Override)
+	if err != nil {
+		return trustpolicy.TrustPolicy{}, err
 	}
-	return &trustpolicy.Document{
-		Version: "1.0",
-		TrustPolicies: []trustpolicy.TrustPolicy{
-			{
-				Name:           "default",
-				RegistryScopes: scopes,
-				SignatureVerification: trustpolicy.SignatureVerification{
-					VerificationLevel: "strict",
-				},
-				TrustStores:       trustStoreNames,
-				TrustedIdentities: trustedIdentities,
-			},
+	return trustpolicy.TrustPolicy{
+		Name:           opts.Name,
+		RegistryScopes: scopes,
+		SignatureVerification: trustpolicy.SignatureVerification{
+			VerificationLevel: level,
+			Override:          override,
 		},
+		TrustStores:       trustStores,
+		TrustedIdentities: trustedIdentities,
+	}, nil
+}
+
+// revocationOverride adds the revocation action of the revocation mode to the
+// overrides. The "revokedOnly" mode logs the revocation check in Notation and
+// fails revoked signatures after verification.
+func revocationOverride(mode string, override map[trustpolicy.ValidationType]trustpolicy.ValidationAction) (map[trustpolicy.ValidationType]trustpolicy.ValidationAction, error) {
+	var action trustpolicy.ValidationAction
+	switch mode {
+	case "":
+		return override, nil
+	case revocationModeEnforce:
+		action = trustpolicy.ActionEnforce
+	case revocationModeRevokedOnly, revocationModeLog:
+		action = trustpolicy.ActionLog
+	case revocationModeSkip:
+		action = trustpolicy.ActionSkip
+	default:
+		return nil, fmt.Errorf("invalid revocation mode %s", mode)
+	}
+	if _, ok := override[trustpolicy.TypeRevocation]; ok {
+		return nil, fmt.Errorf("revocationMode cannot be combined with a revocation override")
+	}
+	result := make(map[trustpolicy.ValidationType]trustpolicy.ValidationAction, len(override)+1)
+	for validation, validationAction := range override {
+		result[validation] = validationAction
 	}
+	result[trustpolicy.TypeRevocation] = action
+	return result, nil
 }
@@ -20,11 +20,19 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
 	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
 	"github.com/notaryproject/ratify/v2/internal/verifier"
 	"github.com/notaryproject/ratify/v2/internal/verifier/keyprovider"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 const testName = "notation-test"
@@ -169,6 +177,150 @@ func TestNewVerifier(t *testing.T) {
 			},
 			expectErr: false, // Should not fail during initialization with lazy loading
 		},
+		{
+			name: "Multiple trust policies",
+			opts: verifier.NewOptions{
+				Type: verifierTypeNotation,
+				Name: testName,
+				Parameters: options{
+					Certificates: []trustStoreOptions{
+						{
+							"type":              "ca",
+							mockKeyProviderName: nil,
+						},
+						{
+							"type":              "ca",
+							"name":              "partner",
+							mockKeyProviderName: nil,
+						},
+					},
+					TrustPolicies: []trustPolicyOptions{
+						{
+							Name:              "internal",
+							Scopes:            []string{"registry.example.com/internal/app"},
+							TrustedIdentities: []string{"x509.subject: C=US, ST=WA, O=Internal, CN=internal"},
+							TrustStores:       []string{"ca:ratify"},
+						},
+						{
+							Name:              "partner",
+							Scopes:            []string{"registry.example.com/partner/app"},
+							TrustStores:       []string{"ca:partner"},
+							VerificationLevel: "permissive",
+							Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+								trustpolicy.TypeRevocation: trustpolicy.ActionSkip,
+							},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Revocation options",
+			opts: verifier.NewOptions{
+				Type: verifierTypeNotation,
+				Name: testName,
+				Parameters: options{
+					Certificates: []trustStoreOptions{
+						{
+							"type":              "ca",
+							mockKeyProviderName: nil,
+						},
+					},
+					TrustPolicies: []trustPolicyOptions{
+						{
+							Name:           "default",
+							RevocationMode: "revokedOnly",
+						},
+					},
+					Revocation: revocationOptions{
+						OCSPTimeout: jsonutil.Duration(time.Second),
+						CRLCache: crlCacheOptions{
+							Dir:    t.TempDir(),
+							MaxAge: jsonutil.Duration(time.Hour),
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Plugin directory",
+			opts: verifier.NewOptions{
+				Type: verifierTypeNotation,
+				Name: testName,
+				Parameters: options{
+					Certificates: []trustStoreOptions{
+						{
+							"type":              "ca",
+							mockKeyProviderName: nil,
+						},
+					},
+					PluginDirectory: t.TempDir(),
+					PluginTimeout:   jsonutil.Duration(time.Minute),
+					PluginConfig:    map[string]string{"key": "value"},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Missing plugin directory",
+			opts: verifier.NewOptions{
+				Type: verifierTypeNotation,
+				Name: testName,
+				Parameters: options{
+					Certificates: []trustStoreOptions{
+						{
+							"type":              "ca",
+							mockKeyProviderName: nil,
+						},
+					},
+					PluginDirectory: filepath.Join(t.TempDir(), "missing"),
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid preloaded CRL",
+			opts: verifier.NewOptions{
+				Type: verifierTypeNotation,
+				Name: testName,
+				Parameters: options{
+					Certificates: []trustStoreOptions{
+						{
+							"type":              "ca",
+							mockKeyProviderName: nil,
+						},
+					},
+					Revocation: revocationOptions{
+						PreloadCRLs: []preloadCRLOptions{{URL: "http://example.com/ca.crl"}},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid trust policy",
+			opts: verifier.NewOptions{
+				Type: verifierTypeNotation,
+				Name: testName,
+				Parameters: options{
+					Certificates: []trustStoreOptions{
+						{
+							"type":              "ca",
+							mockKeyProviderName: nil,
+						},
+					},
+					TrustPolicies: []trustPolicyOptions{
+						{
+							Name:              "invalid",
+							VerificationLevel: "unknown",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
 		{
 			name: "Valid notation options",
 			opts: verifier.NewOptions{
@@ -197,6 +349,53 @@ func TestNewVerifier(t *testing.T) {
 	}
 }
 
+func TestNewVerifier_SkipPolicy(t *testing.T) {
+	keyprovider.RegisterKeyProvider(mockKeyProviderName, createMockKeyProvider)
+
+	v, err := verifier.New(verifier.NewOptions{
+		Type: verifierTypeNotation,
+		Name: testName,
+		Parameters: options{
+			Certificates: []trustStoreOptions{
+				{
+					"type":              "ca",
+					mockKeyProviderName: nil,
+				},
+			},
+			TrustPolicies: []trustPolicyOptions{
+				{
+					Name:              "unsigned",
+					Scopes:            []string{testRepo},
+					VerificationLevel: "skip",
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// The signature is not parsed for a skip policy, so the outcome carries
+	// no envelope content.
+	store, artifactDesc := newMockStore(t, "not a signature envelope")
+	result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+		Store:              store,
+		Repository:         testRepo,
+		SubjectDescriptor:  ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("subject")},
+		ArtifactDescriptor: artifactDesc,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Err != nil {
+		t.Fatalf("Expected verification to pass, got: %v", result.Err)
+	}
+	expected := map[string]string{"RevocationStatus": "skipped"}
+	if !reflect.DeepEqual(result.Detail, expected) {
+		t.Fatalf("Expected detail %v, got %v", expected, result.Detail)
+	}
+}
+
 func TestGetTrustStoreType(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -299,3 +498,296 @@ func TestGetTrustStoreType(t *testing.T) {
 		})
 	}
 }
+
+func TestInitTrustStore_Names(t *testing.T) {
+	keyprovider.RegisterKeyProvider(mockKeyProviderName, createMockKeyProvider)
+
+	tests := []struct {
+		name      string
+		opts      []trustStoreOptions
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name: "Default names",
+			opts: []trustStoreOptions{
+				{"type": "ca", mockKeyProviderName: nil},
+				{"type": "tsa", mockKeyProviderName: nil},
+			},
+			expected: []string{"ca:ratify", "tsa:ratify"},
+		},
+		{
+			name: "Named trust stores of the same type",
+			opts: []trustStoreOptions{
+				{mockKeyProviderName: nil},
+				{"name": "partner", mockKeyProviderName: nil},
+			},
+			expected: []string{"ca:ratify", "ca:partner"},
+		},
+		{
+			name: "Duplicate named trust store",
+			opts: []trustStoreOptions{
+				{"name": "partner", mockKeyProviderName: nil},
+				{"type": "ca", "name": "partner", mockKeyProviderName: nil},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Non-string name",
+			opts: []trustStoreOptions{
+				{"name": 1, mockKeyProviderName: nil},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Empty name",
+			opts: []trustStoreOptions{
+				{"name": "", mockKeyProviderName: nil},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, names, err := initTrustStore(test.opts)
+			if test.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", test.expectErr, err)
+			}
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Fatalf("Expected trust store names %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestInitTrustPolicyDocument(t *testing.T) {
+	storeNames := []string{"ca:ratify", "ca:partner", "tsa:ratify"}
+
+	tests := []struct {
+		name      string
+		opts      *options
+		expected  []trustpolicy.TrustPolicy
+		expectErr bool
+	}{
+		{
+			name: "Default trust policy",
+			opts: &options{},
+			expected: []trustpolicy.TrustPolicy{
+				{
+					Name:                  "default",
+					RegistryScopes:        []string{"*"},
+					SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+					TrustStores:           storeNames,
+					TrustedIdentities:     []string{"*"},
+				},
+			},
+		},
+		{
+			name: "Default trust policy with scopes and identities",
+			opts: &options{
+				Scopes:            []string{"registry.example.com/app"},
+				TrustedIdentities: []string{"x509.subject: C=US, ST=WA, O=Test, CN=test"},
+			},
+			expected: []trustpolicy.TrustPolicy{
+				{
+					Name:                  "default",
+					RegistryScopes:        []string{"registry.example.com/app"},
+					SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+					TrustStores:           storeNames,
+					TrustedIdentities:     []string{"x509.subject: C=US, ST=WA, O=Test, CN=test"},
+				},
+			},
+		},
+		{
+			name: "Multiple trust policies",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{
+					{
+						Name:              "internal",
+						Scopes:            []string{"registry.example.com/internal/app"},
+						TrustedIdentities: []string{"x509.subject: C=US, ST=WA, O=Internal, CN=internal"},
+						TrustStores:       []string{"ca:ratify", "tsa:ratify"},
+					},
+					{
+						Name:              "partner",
+						Scopes:            []string{"registry.example.com/partner/app"},
+						TrustStores:       []string{"ca:partner"},
+						VerificationLevel: "audit",
+						Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+							trustpolicy.TypeExpiry: trustpolicy.ActionEnforce,
+						},
+					},
+					{
+						Name:              "unsigned",
+						Scopes:            []string{"registry.example.com/public/app"},
+						VerificationLevel: "skip",
+					},
+				},
+			},
+			expected: []trustpolicy.TrustPolicy{
+				{
+					Name:                  "internal",
+					RegistryScopes:        []string{"registry.example.com/internal/app"},
+					SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+					TrustStores:           []string{"ca:ratify", "tsa:ratify"},
+					TrustedIdentities:     []string{"x509.subject: C=US, ST=WA, O=Internal, CN=internal"},
+				},
+				{
+					Name:           "partner",
+					RegistryScopes: []string{"registry.example.com/partner/app"},
+					SignatureVerification: trustpolicy.SignatureVerification{
+						VerificationLevel: "audit",
+						Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+							trustpolicy.TypeExpiry: trustpolicy.ActionEnforce,
+						},
+					},
+					TrustStores:       []string{"ca:partner"},
+					TrustedIdentities: []string{"*"},
+				},
+				{
+					Name:                  "unsigned",
+					RegistryScopes:        []string{"registry.example.com/public/app"},
+					SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "skip"},
+				},
+			},
+		},
+		{
+			name: "Trust policies combined with top-level scopes",
+			opts: &options{
+				Scopes:        []string{"registry.example.com/app"},
+				TrustPolicies: []trustPolicyOptions{{Name: "test"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Unknown trust store",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{{Name: "test", TrustStores: []string{"ca:unknown"}}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid verification level",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{{Name: "test", VerificationLevel: "unknown"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid override",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{{
+					Name: "test",
+					Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+						trustpolicy.TypeIntegrity: trustpolicy.ActionLog,
+					},
+				}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Revocation modes",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{
+					{
+						Name:           "internal",
+						Scopes:         []string{"registry.example.com/internal/app"},
+						RevocationMode: "revokedOnly",
+						Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+							trustpolicy.TypeExpiry: trustpolicy.ActionLog,
+						},
+					},
+					{
+						Name:              "partner",
+						Scopes:            []string{"registry.example.com/partner/app"},
+						VerificationLevel: "permissive",
+						RevocationMode:    "enforce",
+					},
+				},
+			},
+			expected: []trustpolicy.TrustPolicy{
+				{
+					Name:           "internal",
+					RegistryScopes: []string{"registry.example.com/internal/app"},
+					SignatureVerification: trustpolicy.SignatureVerification{
+						VerificationLevel: "strict",
+						Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+							trustpolicy.TypeExpiry:     trustpolicy.ActionLog,
+							trustpolicy.TypeRevocation: trustpolicy.ActionLog,
+						},
+					},
+					TrustStores:       storeNames,
+					TrustedIdentities: []string{"*"},
+				},
+				{
+					Name:           "partner",
+					RegistryScopes: []string{"registry.example.com/partner/app"},
+					SignatureVerification: trustpolicy.SignatureVerification{
+						VerificationLevel: "permissive",
+						Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+							trustpolicy.TypeRevocation: trustpolicy.ActionEnforce,
+						},
+					},
+					TrustStores:       storeNames,
+					TrustedIdentities: []string{"*"},
+				},
+			},
+		},
+		{
+			name: "Invalid revocation mode",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{{Name: "test", RevocationMode: "unknown"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Revocation mode combined with revocation override",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{{
+					Name:           "test",
+					RevocationMode: "skip",
+					Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+						trustpolicy.TypeRevocation: trustpolicy.ActionLog,
+					},
+				}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Duplicate trust policy names",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{
+					{Name: "test", Scopes: []string{"registry.example.com/a"}},
+					{Name: "test", Scopes: []string{"registry.example.com/b"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Overlapping scopes",
+			opts: &options{
+				TrustPolicies: []trustPolicyOptions{
+					{Name: "a", Scopes: []string{"registry.example.com/app"}},
+					{Name: "b", Scopes: []string{"registry.example.com/app"}},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := initTrustPolicyDocument(test.opts, storeNames)
+			if test.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", test.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(doc.TrustPolicies, test.expected) {
+				t.Fatalf("Expected trust policies %+v, got %+v", test.expected, doc.TrustPolicies)
+			}
+		})
+	}
+}
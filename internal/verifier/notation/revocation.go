@@ -0,0 +1,377 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/revocation"
+	corecrl "github.com/notaryproject/notation-core-go/revocation/crl"
+	"github.com/notaryproject/notation-core-go/revocation/purpose"
+	"github.com/notaryproject/notation-core-go/revocation/result"
+	"github.com/notaryproject/notation-go/verifier/crl"
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
+	"github.com/notaryproject/ratify/v2/internal/pod"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	defaultOCSPTimeout = 2 * time.Second
+	defaultCRLTimeout  = 5 * time.Second
+)
+
+// revocationOptions configures how the revocation status of certificates is
+// checked.
+type revocationOptions struct {
+	// OCSPTimeout is the timeout of OCSP requests. Optional. If not provided,
+	// the default timeout is 2s.
+	OCSPTimeout jsonutil.Duration `json:"ocspTimeout"`
+
+	// CRLTimeout is the timeout of CRL downloads. Optional. If not provided,
+	// the default timeout is 5s.
+	CRLTimeout jsonutil.Duration `json:"crlTimeout"`
+
+	// CRLCache configures the cache of downloaded CRLs. Optional.
+	CRLCache crlCacheOptions `json:"crlCache"`
+
+	// PreloadCRLs is a list of CRLs loaded into the cache on startup, so that
+	// revocation can be checked in clusters without access to the CRL
+	// distribution points. Optional.
+	PreloadCRLs []preloadCRLOptions `json:"preloadCRLs"`
+}
+
+// crlCacheOptions configures the CRL cache.
+type crlCacheOptions struct {
+	// Dir is the directory of the file system tier of the cache. CRLs cached
+	// in the directory survive restarts. Optional. If not provided, CRLs are
+	// only cached in memory.
+	Dir string `json:"dir"`
+
+	// MaxAge is the maximum age of a cached CRL, measured from its thisUpdate
+	// time, before it is downloaded again. A CRL past its nextUpdate time is
+	// never used. If the download fails, the cached CRL is used until its
+	// nextUpdate time. Optional. If not provided, cached CRLs are used until
+	// their nextUpdate time.
+	MaxAge jsonutil.Duration `json:"maxAge"`
+}
+
+// preloadCRLOptions references a CRL to be preloaded into the cache. Exactly
+// one of File and ConfigMap must be provided.
+type preloadCRLOptions struct {
+	// URL is the distribution point URL of the CRL, as listed in the CRL
+	// distribution points extension of the certificates. Required.
+	URL string `json:"url"`
+
+	// File is the path of a PEM or DER encoded CRL file. Optional.
+	File string `json:"file"`
+
+	// ConfigMap references a ConfigMap key holding a PEM encoded CRL or, in
+	// binary data, a DER encoded CRL. Optional.
+	ConfigMap *configMapKeyReference `json:"configMap"`
+}
+
+// configMapKeyReference references a key of a Kubernetes ConfigMap.
+type configMapKeyReference struct {
+	// Namespace is the namespace of the ConfigMap. Optional. If not provided,
+	// the namespace of Ratify is used.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the ConfigMap. Required.
+	Name string `json:"name"`
+
+	// Key is the key of the CRL in the ConfigMap. Required.
+	Key string `json:"key"`
+}
+
+// newKubernetesClient creates the client used to read preloaded CRLs from
+// ConfigMaps.
+var newKubernetesClient = func() (kubernetes.Interface, error) {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes config: %w", err)
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// newCRLFetcher creates a CRL fetcher backed by the configured cache and
+// loads the preloaded CRLs into the cache.
+func newCRLFetcher(ctx context.Context, opts revocationOptions) (*crlFetcher, error) {
+	timeout := opts.CRLTimeout.Duration()
+	if timeout <= 0 {
+		timeout = defaultCRLTimeout
+	}
+	httpFetcher, err := corecrl.NewHTTPFetcher(&http.Client{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	cache := &crlCache{
+		bundles: make(map[string]*corecrl.Bundle),
+	}
+	if opts.CRLCache.Dir != "" {
+		if cache.fileCache, err = crl.NewFileCache(opts.CRLCache.Dir); err != nil {
+			return nil, err
+		}
+	}
+	fetcher := &crlFetcher{
+		httpFetcher: httpFetcher,
+		cache:       cache,
+		maxAge:      opts.CRLCache.MaxAge.Duration(),
+		now:         time.Now,
+	}
+
+	var client kubernetes.Interface
+	for _, preload := range opts.PreloadCRLs {
+		if preload.URL == "" {
+			return nil, fmt.Errorf("url is required for preloaded CRLs")
+		}
+		var data []byte
+		switch {
+		case preload.File != "" && preload.ConfigMap != nil:
+			return nil, fmt.Errorf("only one of file and configMap is allowed for preloaded CRL %s", preload.URL)
+		case preload.File != "":
+			if data, err = os.ReadFile(preload.File); err != nil {
+				return nil, fmt.Errorf("failed to read CRL file for %s: %w", preload.URL, err)
+			}
+		case preload.ConfigMap != nil:
+			if client == nil {
+				if client, err = newKubernetesClient(); err != nil {
+					return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+				}
+			}
+			if data, err = readConfigMapKey(ctx, client, preload.ConfigMap); err != nil {
+				return nil, fmt.Errorf("failed to read CRL ConfigMap for %s: %w", preload.URL, err)
+			}
+		default:
+			return nil, fmt.Errorf("either file or configMap is required for preloaded CRL %s", preload.URL)
+		}
+		baseCRL, err := parseCRL(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse preloaded CRL %s: %w", preload.URL, err)
+		}
+		if err := cache.Set(ctx, preload.URL, &corecrl.Bundle{BaseCRL: baseCRL}); err != nil {
+			return nil, fmt.Errorf("failed to cache preloaded CRL %s: %w", preload.URL, err)
+		}
+	}
+	return fetcher, nil
+}
+
+// newRevocationValidators creates the revocation validators of the code
+// signing and timestamping certificate chains, sharing the CRL fetcher.
+func newRevocationValidators(opts revocationOptions, fetcher corecrl.Fetcher) (codeSigning, timestamping revocation.Validator, err error) {
+	timeout := opts.OCSPTimeout.Duration()
+	if timeout <= 0 {
+		timeout = defaultOCSPTimeout
+	}
+	ocspClient := &http.Client{Timeout: timeout}
+	codeSigning, err = revocation.NewWithOptions(revocation.Options{
+		OCSPHTTPClient:   ocspClient,
+		CRLFetcher:       fetcher,
+		CertChainPurpose: purpose.CodeSigning,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	codeSigning = &recordingValidator{Validator: codeSigning}
+	timestamping, err = revocation.NewWithOptions(revocation.Options{
+		OCSPHTTPClient:   ocspClient,
+		CRLFetcher:       fetcher,
+		CertChainPurpose: purpose.Timestamping,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return codeSigning, timestamping, nil
+}
+
+// revocationRecorderKey is the context key of the [revocationRecorder].
+type revocationRecorderKey struct{}
+
+// revocationRecorder records the certificate revocation results of a single
+// verification.
+type revocationRecorder struct {
+	mu      sync.Mutex
+	results []*result.CertRevocationResult
+}
+
+// withRevocationRecorder returns a context in which [recordingValidator]
+// records the revocation results in the returned recorder.
+func withRevocationRecorder(ctx context.Context) (context.Context, *revocationRecorder) {
+	recorder := &revocationRecorder{}
+	return context.WithValue(ctx, revocationRecorderKey{}, recorder), recorder
+}
+
+// Results returns the recorded revocation results.
+func (r *revocationRecorder) Results() []*result.CertRevocationResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.results
+}
+
+// recordingValidator is a [revocation.Validator] recording the results of
+// the wrapped validator in the [revocationRecorder] of the context, so that
+// the revocation status is derived from the results rather than from error
+// messages.
+type recordingValidator struct {
+	revocation.Validator
+}
+
+// ValidateContext validates the certificate chain and records the results.
+func (v *recordingValidator) ValidateContext(ctx context.Context, opts revocation.ValidateContextOptions) ([]*result.CertRevocationResult, error) {
+	results, err := v.Validator.ValidateContext(ctx, opts)
+	if recorder, ok := ctx.Value(revocationRecorderKey{}).(*revocationRecorder); ok {
+		recorder.mu.Lock()
+		recorder.results = append(recorder.results, results...)
+		recorder.mu.Unlock()
+	}
+	return results, err
+}
+
+// readConfigMapKey reads the value of a ConfigMap key, looking in the binary
+// data if the key is not found in the data.
+func readConfigMapKey(ctx context.Context, client kubernetes.Interface, ref *configMapKeyReference) ([]byte, error) {
+	if ref.Name == "" || ref.Key == "" {
+		return nil, fmt.Errorf("name and key are required for ConfigMap references")
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = pod.Namespace()
+	}
+	configMap, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := configMap.Data[ref.Key]; ok {
+		return []byte(value), nil
+	}
+	if value, ok := configMap.BinaryData[ref.Key]; ok {
+		return value, nil
+	}
+	return nil, fmt.Errorf("key %s not found in ConfigMap %s/%s", ref.Key, namespace, ref.Name)
+}
+
+// parseCRL parses a PEM or DER encoded CRL.
+func parseCRL(data []byte) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "X509 CRL" {
+			return nil, fmt.Errorf("unexpected PEM block type %s", block.Type)
+		}
+		data = block.Bytes
+	}
+	return x509.ParseRevocationList(data)
+}
+
+// crlFetcher is a [corecrl.Fetcher] that serves CRLs from the cache while they
+// are fresh, and falls back to cached CRLs that have not reached their
+// nextUpdate time if the download fails.
+type crlFetcher struct {
+	httpFetcher corecrl.Fetcher
+	cache       *crlCache
+	maxAge      time.Duration
+	now         func() time.Time
+}
+
+// Fetch implements [corecrl.Fetcher].
+func (f *crlFetcher) Fetch(ctx context.Context, url string) (*corecrl.Bundle, error) {
+	cached, err := f.cache.Get(ctx, url)
+	if err != nil && !errors.Is(err, corecrl.ErrCacheMiss) {
+		logrus.Warnf("failed to get CRL %s from cache: %v", url, err)
+	}
+	now := f.now()
+	if cached != nil && isEffective(cached, now) && f.isFresh(cached, now) {
+		return cached, nil
+	}
+
+	bundle, err := f.httpFetcher.Fetch(ctx, url)
+	if err != nil {
+		if cached != nil && isEffective(cached, now) {
+			logrus.Warnf("failed to download CRL %s, using cached CRL issued at %s: %v", url, cached.BaseCRL.ThisUpdate, err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err := f.cache.Set(ctx, url, bundle); err != nil {
+		logrus.Warnf("failed to cache CRL %s: %v", url, err)
+	}
+	return bundle, nil
+}
+
+// isFresh reports whether the bundle is within the configured maximum age.
+func (f *crlFetcher) isFresh(bundle *corecrl.Bundle, now time.Time) bool {
+	return f.maxAge <= 0 || now.Sub(bundle.BaseCRL.ThisUpdate) <= f.maxAge
+}
+
+// isEffective reports whether the CRLs of the bundle have not reached their
+// nextUpdate time.
+func isEffective(bundle *corecrl.Bundle, now time.Time) bool {
+	effective := func(list *x509.RevocationList) bool {
+		return !list.NextUpdate.IsZero() && !now.After(list.NextUpdate)
+	}
+	return effective(bundle.BaseCRL) && (bundle.DeltaCRL == nil || effective(bundle.DeltaCRL))
+}
+
+// crlCache is a [corecrl.Cache] with an in-memory tier and an optional file
+// system tier.
+type crlCache struct {
+	mu        sync.RWMutex
+	bundles   map[string]*corecrl.Bundle
+	fileCache corecrl.Cache
+}
+
+// Get implements [corecrl.Cache]. Bundles found in the file system tier are
+// promoted to the in-memory tier. Unlike the file system tier, the in-memory
+// tier also returns bundles past their nextUpdate time, so that callers can
+// decide whether to use them.
+func (c *crlCache) Get(ctx context.Context, url string) (*corecrl.Bundle, error) {
+	c.mu.RLock()
+	bundle, ok := c.bundles[url]
+	c.mu.RUnlock()
+	if ok {
+		return bundle, nil
+	}
+	if c.fileCache == nil {
+		return nil, corecrl.ErrCacheMiss
+	}
+	bundle, err := c.fileCache.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.bundles[url] = bundle
+	c.mu.Unlock()
+	return bundle, nil
+}
+
+// Set implements [corecrl.Cache].
+func (c *crlCache) Set(ctx context.Context, url string, bundle *corecrl.Bundle) error {
+	c.mu.Lock()
+	c.bundles[url] = bundle
+	c.mu.Unlock()
+	if c.fileCache != nil {
+		return c.fileCache.Set(ctx, url, bundle)
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corecrl "github.com/notaryproject/notation-core-go/revocation/crl"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testCRLURL = "http://example.com/ca.crl"
+
+// createTestCRL creates a DER encoded CRL with the given validity.
+func createTestCRL(t *testing.T, thisUpdate, nextUpdate time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             thisUpdate.Add(-time.Hour),
+		NotAfter:              nextUpdate.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}, cert, key)
+	if err != nil {
+		t.Fatalf("failed to create CRL: %v", err)
+	}
+	return crlDER
+}
+
+// createTestBundle creates a CRL bundle with the given validity.
+func createTestBundle(t *testing.T, thisUpdate, nextUpdate time.Time) *corecrl.Bundle {
+	t.Helper()
+	crl, err := x509.ParseRevocationList(createTestCRL(t, thisUpdate, nextUpdate))
+	if err != nil {
+		t.Fatalf("failed to parse CRL: %v", err)
+	}
+	return &corecrl.Bundle{BaseCRL: crl}
+}
+
+type mockCRLFetcher struct {
+	bundle *corecrl.Bundle
+	calls  int
+}
+
+func (m *mockCRLFetcher) Fetch(_ context.Context, _ string) (*corecrl.Bundle, error) {
+	m.calls++
+	if m.bundle == nil {
+		return nil, errors.New("network unreachable")
+	}
+	return m.bundle, nil
+}
+
+func TestParseCRL(t *testing.T) {
+	now := time.Now()
+	der := createTestCRL(t, now, now.Add(time.Hour))
+
+	tests := []struct {
+		name      string
+		data      []byte
+		expectErr bool
+	}{
+		{
+			name: "DER encoded CRL",
+			data: der,
+		},
+		{
+			name: "PEM encoded CRL",
+			data: pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}),
+		},
+		{
+			name:      "Unexpected PEM block type",
+			data:      pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			expectErr: true,
+		},
+		{
+			name:      "Malformed CRL",
+			data:      []byte("invalid"),
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseCRL(test.data)
+			if test.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", test.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestCRLFetcher_Fetch(t *testing.T) {
+	now := time.Now()
+	fresh := createTestBundle(t, now.Add(-time.Minute), now.Add(time.Hour))
+	aged := createTestBundle(t, now.Add(-2*time.Hour), now.Add(time.Hour))
+	expired := createTestBundle(t, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	downloaded := createTestBundle(t, now, now.Add(time.Hour))
+
+	tests := []struct {
+		name           string
+		cached         *corecrl.Bundle
+		downloaded     *corecrl.Bundle
+		maxAge         time.Duration
+		expected       *corecrl.Bundle
+		expectDownload bool
+		expectErr      bool
+	}{
+		{
+			name:           "Cache miss",
+			downloaded:     downloaded,
+			expected:       downloaded,
+			expectDownload: true,
+		},
+		{
+			name:           "Cache miss and download failure",
+			expectDownload: true,
+			expectErr:      true,
+		},
+		{
+			name:       "Fresh cached CRL",
+			cached:     fresh,
+			downloaded: downloaded,
+			maxAge:     time.Hour,
+			expected:   fresh,
+		},
+		{
+			name:       "Cached CRL without maximum age",
+			cached:     aged,
+			downloaded: downloaded,
+			expected:   aged,
+		},
+		{
+			name:           "Cached CRL exceeding maximum age",
+			cached:         aged,
+			downloaded:     downloaded,
+			maxAge:         time.Hour,
+			expected:       downloaded,
+			expectDownload: true,
+		},
+		{
+			name:           "Cached CRL exceeding maximum age and download failure",
+			cached:         aged,
+			maxAge:         time.Hour,
+			expected:       aged,
+			expectDownload: true,
+		},
+		{
+			name:           "Expired cached CRL",
+			cached:         expired,
+			downloaded:     downloaded,
+			expected:       downloaded,
+			expectDownload: true,
+		},
+		{
+			name:           "Expired cached CRL and download failure",
+			cached:         expired,
+			expectDownload: true,
+			expectErr:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := &crlCache{bundles: make(map[string]*corecrl.Bundle)}
+			if test.cached != nil {
+				cache.bundles[testCRLURL] = test.cached
+			}
+			httpFetcher := &mockCRLFetcher{bundle: test.downloaded}
+			fetcher := &crlFetcher{
+				httpFetcher: httpFetcher,
+				cache:       cache,
+				maxAge:      test.maxAge,
+				now:         func() time.Time { return now },
+			}
+
+			bundle, err := fetcher.Fetch(context.Background(), testCRLURL)
+			if test.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", test.expectErr, err)
+			}
+			if bundle != test.expected {
+				t.Fatalf("Expected bundle %v, got %v", test.expected, bundle)
+			}
+			if test.expectDownload != (httpFetcher.calls > 0) {
+				t.Fatalf("Expected download: %v, got %d downloads", test.expectDownload, httpFetcher.calls)
+			}
+			if test.downloaded != nil && test.expectDownload && cache.bundles[testCRLURL] != test.downloaded {
+				t.Fatalf("Expected downloaded CRL to be cached")
+			}
+		})
+	}
+}
+
+func TestCRLCache_FileTier(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Now()
+	bundle := createTestBundle(t, now, now.Add(time.Hour))
+
+	fetcher, err := newCRLFetcher(ctx, revocationOptions{CRLCache: crlCacheOptions{Dir: dir}})
+	if err != nil {
+		t.Fatalf("failed to create CRL fetcher: %v", err)
+	}
+	if err := fetcher.cache.Set(ctx, testCRLURL, bundle); err != nil {
+		t.Fatalf("failed to cache CRL: %v", err)
+	}
+
+	// A new cache in the same directory serves the CRL from the file system.
+	fetcher, err = newCRLFetcher(ctx, revocationOptions{CRLCache: crlCacheOptions{Dir: dir}})
+	if err != nil {
+		t.Fatalf("failed to create CRL fetcher: %v", err)
+	}
+	cached, err := fetcher.cache.Get(ctx, testCRLURL)
+	if err != nil {
+		t.Fatalf("failed to get CRL from cache: %v", err)
+	}
+	if cached.BaseCRL.Number.Cmp(bundle.BaseCRL.Number) != 0 || !cached.BaseCRL.ThisUpdate.Equal(bundle.BaseCRL.ThisUpdate) {
+		t.Fatalf("Expected cached CRL %v, got %v", bundle.BaseCRL, cached.BaseCRL)
+	}
+	if _, ok := fetcher.cache.bundles[testCRLURL]; !ok {
+		t.Fatalf("Expected CRL to be promoted to the in-memory tier")
+	}
+
+	if _, err := fetcher.cache.Get(ctx, "http://example.com/other.crl"); !errors.Is(err, corecrl.ErrCacheMiss) {
+		t.Fatalf("Expected cache miss, got %v", err)
+	}
+}
+
+func TestNewCRLFetcher_Preload(t *testing.T) {
+	now := time.Now()
+	der := createTestCRL(t, now, now.Add(time.Hour))
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+	crlFile := filepath.Join(t.TempDir(), "ca.crl")
+	if err := os.WriteFile(crlFile, pemData, 0600); err != nil {
+		t.Fatalf("failed to write CRL file: %v", err)
+	}
+	malformedFile := filepath.Join(t.TempDir(), "malformed.crl")
+	if err := os.WriteFile(malformedFile, []byte("invalid"), 0600); err != nil {
+		t.Fatalf("failed to write CRL file: %v", err)
+	}
+	client := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "crls", Namespace: "gatekeeper-system"},
+			Data:       map[string]string{"ca.crl": string(pemData)},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "crls", Namespace: "partner"},
+			BinaryData: map[string][]byte{"ca.crl": der},
+		},
+	)
+	original := newKubernetesClient
+	newKubernetesClient = func() (kubernetes.Interface, error) {
+		return client, nil
+	}
+	defer func() {
+		newKubernetesClient = original
+	}()
+
+	tests := []struct {
+		name      string
+		preload   preloadCRLOptions
+		expectErr bool
+	}{
+		{
+			name:    "CRL file",
+			preload: preloadCRLOptions{URL: testCRLURL, File: crlFile},
+		},
+		{
+			name:    "ConfigMap in Ratify namespace",
+			preload: preloadCRLOptions{URL: testCRLURL, ConfigMap: &configMapKeyReference{Name: "crls", Key: "ca.crl"}},
+		},
+		{
+			name:    "ConfigMap binary data",
+			preload: preloadCRLOptions{URL: testCRLURL, ConfigMap: &configMapKeyReference{Namespace: "partner", Name: "crls", Key: "ca.crl"}},
+		},
+		{
+			name:      "Missing URL",
+			preload:   preloadCRLOptions{File: crlFile},
+			expectErr: true,
+		},
+		{
+			name:      "Missing source",
+			preload:   preloadCRLOptions{URL: testCRLURL},
+			expectErr: true,
+		},
+		{
+			name:      "Both file and ConfigMap",
+			preload:   preloadCRLOptions{URL: testCRLURL, File: crlFile, ConfigMap: &configMapKeyReference{Name: "crls", Key: "ca.crl"}},
+			expectErr: true,
+		},
+		{
+			name:      "Missing file",
+			preload:   preloadCRLOptions{URL: testCRLURL, File: filepath.Join(t.TempDir(), "missing.crl")},
+			expectErr: true,
+		},
+		{
+			name:      "Missing ConfigMap",
+			preload:   preloadCRLOptions{URL: testCRLURL, ConfigMap: &configMapKeyReference{Name: "missing", Key: "ca.crl"}},
+			expectErr: true,
+		},
+		{
+			name:      "Missing ConfigMap key",
+			preload:   preloadCRLOptions{URL: testCRLURL, ConfigMap: &configMapKeyReference{Name: "crls", Key: "missing.crl"}},
+			expectErr: true,
+		},
+		{
+			name:      "Incomplete ConfigMap reference",
+			preload:   preloadCRLOptions{URL: testCRLURL, ConfigMap: &configMapKeyReference{Name: "crls"}},
+			expectErr: true,
+		},
+		{
+			name:      "Malformed CRL",
+			preload:   preloadCRLOptions{URL: testCRLURL, File: malformedFile},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fetcher, err := newCRLFetcher(context.Background(), revocationOptions{
+				PreloadCRLs: []preloadCRLOptions{test.preload},
+			})
+			if test.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", test.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			// The preloaded CRL is served without network access.
+			fetcher.httpFetcher = &mockCRLFetcher{}
+			bundle, err := fetcher.Fetch(context.Background(), testCRLURL)
+			if err != nil {
+				t.Fatalf("failed to fetch preloaded CRL: %v", err)
+			}
+			if !bundle.BaseCRL.ThisUpdate.Equal(now.UTC().Truncate(time.Second)) {
+				t.Fatalf("Expected preloaded CRL issued at %v, got %v", now, bundle.BaseCRL.ThisUpdate)
+			}
+		})
+	}
+}
+
+func TestNewCRLFetcher_KubernetesClientError(t *testing.T) {
+	original := newKubernetesClient
+	newKubernetesClient = func() (kubernetes.Interface, error) {
+		return nil, errors.New("no kubernetes config")
+	}
+	defer func() {
+		newKubernetesClient = original
+	}()
+
+	_, err := newCRLFetcher(context.Background(), revocationOptions{
+		PreloadCRLs: []preloadCRLOptions{{URL: testCRLURL, ConfigMap: &configMapKeyReference{Name: "crls", Key: "ca.crl"}}},
+	})
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+}
+
+func TestNewRevocationValidators(t *testing.T) {
+	codeSigning, timestamping, err := newRevocationValidators(revocationOptions{}, &mockCRLFetcher{})
+	if err != nil {
+		t.Fatalf("failed to create revocation validators: %v", err)
+	}
+	if codeSigning == nil || timestamping == nil {
+		t.Fatalf("Expected revocation validators, got %v and %v", codeSigning, timestamping)
+	}
+}
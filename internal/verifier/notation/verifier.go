@@ -0,0 +1,187 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/revocation/result"
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	revocationStatusGood    = "good"
+	revocationStatusRevoked = "revoked"
+	revocationStatusUnknown = "unknown"
+	revocationStatusSkipped = "skipped"
+)
+
+// Verifier is a ratify.Verifier implementation that verifies Notation
+// signatures and reports the revocation status of the signing certificate.
+type Verifier struct {
+	name           string
+	verifier       notation.Verifier
+	trustPolicyDoc *trustpolicy.Document
+
+	// revokedOnlyPolicies is the set of trust policies failing verification
+	// only if the signing certificate is revoked, but not if the revocation
+	// status is unknown.
+	revokedOnlyPolicies map[string]struct{}
+
+	// pluginConfig is the configuration passed to verification plugins.
+	pluginConfig map[string]string
+}
+
+// Name returns the name of the verifier.
+func (v *Verifier) Name() string {
+	return v.name
+}
+
+// Type returns the type of the verifier which is always `notation`.
+func (v *Verifier) Type() string {
+	return verifierTypeNotation
+}
+
+// Verifiable returns true if the artifact is a Notation signature.
+func (v *Verifier) Verifiable(artifact ocispec.Descriptor) bool {
+	return artifact.ArtifactType == notationregistry.ArtifactTypeNotation && artifact.MediaType == ocispec.MediaTypeImageManifest
+}
+
+// Verify verifies the Notation signature. The result details include the
+// revocation status of the signing certificate, and the reason if the status
+// is not good.
+func (v *Verifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	signatureDesc, err := getSignatureBlobDesc(ctx, opts.Store, opts.Repository, opts.ArtifactDescriptor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signature blob descriptor: %w", err)
+	}
+
+	signatureBlob, err := opts.Store.FetchBlob(ctx, opts.Repository, signatureDesc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signature blob: %w", err)
+	}
+
+	result := &ratify.VerificationResult{
+		Verifier: v,
+	}
+	artifactRef := opts.Repository + "@" + opts.SubjectDescriptor.Digest.String()
+	verifyOpts := notation.VerifierVerifyOptions{
+		SignatureMediaType: signatureDesc.MediaType,
+		ArtifactReference:  artifactRef,
+		PluginConfig:       v.pluginConfig,
+	}
+	ctx, recorder := withRevocationRecorder(ctx)
+	outcome, err := v.verifier.Verify(ctx, opts.SubjectDescriptor, signatureBlob, verifyOpts)
+	detail, revocationErr := outcomeDetail(outcome, recorder.Results())
+	if len(detail) > 0 {
+		result.Detail = detail
+	}
+	if err != nil {
+		result.Err = err
+		return result, nil
+	}
+	if detail["RevocationStatus"] == revocationStatusRevoked && v.isRevokedOnly(artifactRef) {
+		result.Err = revocationErr
+		return result, nil
+	}
+
+	result.Description = "Notation signature verification succeeded"
+	return result, nil
+}
+
+// isRevokedOnly reports whether the trust policy applicable to the artifact
+// has the "revokedOnly" revocation mode.
+func (v *Verifier) isRevokedOnly(artifactRef string) bool {
+	if len(v.revokedOnlyPolicies) == 0 {
+		return false
+	}
+	policy, err := v.trustPolicyDoc.GetApplicableTrustPolicy(artifactRef)
+	if err != nil {
+		return false
+	}
+	_, ok := v.revokedOnlyPolicies[policy.Name]
+	return ok
+}
+
+// outcomeDetail returns the signing certificate and the revocation status of
+// the verification outcome, along with the error of the revocation check. The
+// status of a failed check is derived from the certificate revocation
+// results recorded during the verification.
+func outcomeDetail(outcome *notation.VerificationOutcome, results []*result.CertRevocationResult) (map[string]string, error) {
+	detail := make(map[string]string)
+	if outcome == nil {
+		return detail, nil
+	}
+	if outcome.EnvelopeContent != nil && len(outcome.EnvelopeContent.SignerInfo.CertificateChain) > 0 {
+		cert := outcome.EnvelopeContent.SignerInfo.CertificateChain[0]
+		detail["Issuer"] = cert.Issuer.String()
+		detail["SN"] = cert.Subject.String()
+	}
+
+	for _, validation := range outcome.VerificationResults {
+		if validation == nil || validation.Type != trustpolicy.TypeRevocation {
+			continue
+		}
+		if validation.Error == nil {
+			detail["RevocationStatus"] = revocationStatusGood
+			return detail, nil
+		}
+		detail["RevocationStatus"] = revocationStatus(results)
+		detail["RevocationError"] = validation.Error.Error()
+		return detail, validation.Error
+	}
+	if outcome.VerificationLevel != nil && outcome.VerificationLevel.Enforcement[trustpolicy.TypeRevocation] == trustpolicy.ActionSkip {
+		detail["RevocationStatus"] = revocationStatusSkipped
+	}
+	return detail, nil
+}
+
+// revocationStatus classifies a failed revocation check by the certificate
+// revocation results. Failures without a revoked certificate, e.g.
+// unreachable OCSP responders and CRL distribution points or failing
+// verification plugins, mean the revocation status is unknown.
+func revocationStatus(results []*result.CertRevocationResult) string {
+	for _, certResult := range results {
+		if certResult != nil && certResult.Result == result.ResultRevoked {
+			return revocationStatusRevoked
+		}
+	}
+	return revocationStatusUnknown
+}
+
+func getSignatureBlobDesc(ctx context.Context, store ratify.Store, repo string, artifactDesc ocispec.Descriptor) (ocispec.Descriptor, error) {
+	manifestBytes, err := store.FetchManifest(ctx, repo, artifactDesc)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to fetch manifest for artifact: %w", err)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+
+	if len(manifest.Layers) != 1 {
+		return ocispec.Descriptor{}, fmt.Errorf("notation signature manifest requires exactly one signature envelope blob, got %d", len(manifest.Layers))
+	}
+
+	return manifest.Layers[0], nil
+}
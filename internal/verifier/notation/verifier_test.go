@@ -0,0 +1,448 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notation
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/revocation"
+	"github.com/notaryproject/notation-core-go/revocation/result"
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const testRepo = "registry.example.com/app"
+
+// mockStore serves a single Notation signature artifact.
+type mockStore struct {
+	manifest    []byte
+	blobs       map[digest.Digest][]byte
+	manifestErr error
+}
+
+func newMockStore(t *testing.T, blobs ...string) (*mockStore, ocispec.Descriptor) {
+	t.Helper()
+	store := &mockStore{blobs: make(map[digest.Digest][]byte)}
+	manifest := ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: notationregistry.ArtifactTypeNotation}
+	for _, blob := range blobs {
+		desc := ocispec.Descriptor{
+			MediaType: "application/jose+json",
+			Digest:    digest.FromString(blob),
+			Size:      int64(len(blob)),
+		}
+		store.blobs[desc.Digest] = []byte(blob)
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	store.manifest = manifestBytes
+	return store, ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: notationregistry.ArtifactTypeNotation,
+		Digest:       digest.FromBytes(manifestBytes),
+		Size:         int64(len(manifestBytes)),
+	}
+}
+
+func (m *mockStore) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, nil
+}
+
+func (m *mockStore) ListReferrers(_ context.Context, _ string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	return nil
+}
+
+func (m *mockStore) FetchBlob(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	blob, ok := m.blobs[desc.Digest]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return blob, nil
+}
+
+func (m *mockStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	if m.manifestErr != nil {
+		return nil, m.manifestErr
+	}
+	return m.manifest, nil
+}
+
+// mockNotationVerifier returns the configured outcome and records the
+// verification options. Like the Notation verifier, it checks the revocation
+// status of the signing certificate with the revocation validator.
+type mockNotationVerifier struct {
+	outcome    *notation.VerificationOutcome
+	err        error
+	opts       notation.VerifierVerifyOptions
+	revocation revocation.Validator
+}
+
+func (m *mockNotationVerifier) Verify(ctx context.Context, _ ocispec.Descriptor, _ []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	m.opts = opts
+	if m.revocation != nil {
+		_, _ = m.revocation.ValidateContext(ctx, revocation.ValidateContextOptions{})
+	}
+	return m.outcome, m.err
+}
+
+// mockRevocationValidator returns the configured certificate revocation
+// results.
+type mockRevocationValidator struct {
+	results []*result.CertRevocationResult
+}
+
+func (m *mockRevocationValidator) ValidateContext(_ context.Context, _ revocation.ValidateContextOptions) ([]*result.CertRevocationResult, error) {
+	return m.results, nil
+}
+
+// newTestOutcome creates a verification outcome with the given revocation
+// validation result.
+func newTestOutcome(revocation *notation.ValidationResult, level *trustpolicy.VerificationLevel) *notation.VerificationOutcome {
+	outcome := &notation.VerificationOutcome{
+		VerificationLevel: level,
+		EnvelopeContent: &signature.EnvelopeContent{
+			SignerInfo: signature.SignerInfo{
+				CertificateChain: []*x509.Certificate{{
+					Subject: pkix.Name{CommonName: "signer"},
+					Issuer:  pkix.Name{CommonName: "test-ca"},
+				}},
+			},
+		},
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+		},
+	}
+	if revocation != nil {
+		outcome.VerificationResults = append(outcome.VerificationResults, revocation)
+	}
+	return outcome
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	trustPolicyDoc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{
+				Name:                  "default",
+				RegistryScopes:        []string{testRepo},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:ratify"},
+				TrustedIdentities:     []string{"*"},
+			},
+			{
+				Name:                  "partner",
+				RegistryScopes:        []string{"*"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:ratify"},
+				TrustedIdentities:     []string{"*"},
+			},
+		},
+	}
+	revokedErr := fmt.Errorf("signing certificate with subject %q is revoked", "CN=signer")
+	unknownErr := fmt.Errorf("signing certificate with subject %q revocation status is unknown", "CN=signer")
+	// The subject of the certificate is controlled by the signer.
+	misleadingErr := fmt.Errorf("signing certificate with subject %q revocation status is unknown", "CN=signer is revoked")
+	pluginErr := errors.New(`revocation check by verification plugin "test" failed with reason "unavailable"`)
+	revoked := []*result.CertRevocationResult{{Result: result.ResultRevoked}, {Result: result.ResultOK}}
+	unknown := []*result.CertRevocationResult{{Result: result.ResultUnknown}, {Result: result.ResultOK}}
+
+	tests := []struct {
+		name           string
+		repo           string
+		blobs          []string
+		manifestErr    error
+		outcome        *notation.VerificationOutcome
+		certResults    []*result.CertRevocationResult
+		verifyErr      error
+		revokedOnly    []string
+		expectedDetail map[string]string
+		expectFailure  bool
+		expectErr      bool
+	}{
+		{
+			name:        "Manifest fetch failure",
+			blobs:       []string{"signature"},
+			manifestErr: errors.New("manifest not found"),
+			expectErr:   true,
+		},
+		{
+			name:      "Multiple signature blobs",
+			blobs:     []string{"signature", "other"},
+			expectErr: true,
+		},
+		{
+			name:    "Revocation status good",
+			blobs:   []string{"signature"},
+			outcome: newTestOutcome(&notation.ValidationResult{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionEnforce}, nil),
+			expectedDetail: map[string]string{
+				"Issuer":           "CN=test-ca",
+				"SN":               "CN=signer",
+				"RevocationStatus": "good",
+			},
+		},
+		{
+			name:        "Revocation status unknown enforced",
+			blobs:       []string{"signature"},
+			outcome:     newTestOutcome(&notation.ValidationResult{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionEnforce, Error: unknownErr}, nil),
+			certResults: unknown,
+			verifyErr:   unknownErr,
+			expectedDetail: map[string]string{
+				"Issuer":           "CN=test-ca",
+				"SN":               "CN=signer",
+				"RevocationStatus": "unknown",
+				"RevocationError":  unknownErr.Error(),
+			},
+			expectFailure: true,
+		},
+		{
+			name:        "Revocation status unknown with revokedOnly mode",
+			blobs:       []string{"signature"},
+			outcome:     newTestOutcome(&notation.ValidationResult{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionLog, Error: unknownErr}, nil),
+			certResults: unknown,
+			revokedOnly: []string{"default"},
+			expectedDetail: map[string]string{
+				"Issuer":           "CN=test-ca",
+				"SN":               "CN=signer",
+				"RevocationStatus": "unknown",
+				"RevocationError":  unknownErr.Error(),
+			},
+		},
+		{
+			name:        "Revoked with revokedOnly mode",
+			blobs:       []string{"signature"},
+			outcome:     newTestOutcome(&notation.ValidationResult{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionLog, Error: revokedErr}, nil),
+			certResults: revoked,
+			revokedOnly: []string{"default"},
+			expectedDetail: map[string]string{
+				"Issuer":           "CN=test-ca",
+				"SN":               "CN=signer",
+				"RevocationStatus": "revoked",
+				"RevocationError":  revokedErr.Error(),
+			},
+			expectFailure: true,
+		},
+		{
+			name:        "Revoked with revokedOnly mode of another trust policy",
+			repo:        "registry.example.com/other",
+			blobs:       []string{"signature"},
+			outcome:     newTestOutcome(&notation.ValidationResult{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionLog, Error: revokedErr}, nil),
+			certResults: revoked,
+			revokedOnly: []string{"default"},
+			expectedDetail: map[string]string{
+				"Issuer":           "CN=test-ca",
+				"SN":               "CN=signer",
+				"RevocationStatus": "revoked",
+				"RevocationError":  revokedErr.Error(),
+			},
+		},
+		{
+			name:        "Revocation status unknown with revoked in subject",
+			blobs:       []string{"signature"},
+			outcome:     newTestOutcome(&notation.ValidationResult{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionLog, Error: misleadingErr}, nil),
+			certResults: unknown,
+			revokedOnly: []string{"default"},
+			expectedDetail: map[string]string{
+				"Issuer":           "CN=test-ca",
+				"SN":               "CN=signer",
+				"RevocationStatus": "unknown",
+				"RevocationError":  misleadingErr.Error(),
+			},
+		},
+		{
+			name:        "Revocation plugin failure with revokedOnly mode",
+			blobs:       []string{"signature"},
+			outcome:     newTestOutcome(&notation.ValidationResult{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionLog, Error: pluginErr}, nil),
+			revokedOnly: []string{"default"},
+			expectedDetail: map[string]string{
+				"Issuer":           "CN=test-ca",
+				"SN":               "CN=signer",
+				"RevocationStatus": "unknown",
+				"RevocationError":  pluginErr.Error(),
+			},
+		},
+		{
+			name:  "Revocation check skipped",
+			blobs: []string{"signature"},
+			outcome: newTestOutcome(nil, &trustpolicy.VerificationLevel{
+				Name:        "strict",
+				Enforcement: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{trustpolicy.TypeRevocation: trustpolicy.ActionSkip},
+			}),
+			expectedDetail: map[string]string{
+				"Issuer":           "CN=test-ca",
+				"SN":               "CN=signer",
+				"RevocationStatus": "skipped",
+			},
+		},
+		{
+			name:          "Verification failure without outcome",
+			blobs:         []string{"signature"},
+			verifyErr:     errors.New("no applicable trust policy"),
+			expectFailure: true,
+		},
+		{
+			name:    "Verification level skip",
+			blobs:   []string{"signature"},
+			outcome: &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelSkip},
+			expectedDetail: map[string]string{
+				"RevocationStatus": "skipped",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, artifactDesc := newMockStore(t, test.blobs...)
+			store.manifestErr = test.manifestErr
+			notationVerifier := &mockNotationVerifier{
+				outcome:    test.outcome,
+				err:        test.verifyErr,
+				revocation: &recordingValidator{Validator: &mockRevocationValidator{results: test.certResults}},
+			}
+			v := &Verifier{
+				name:                testName,
+				verifier:            notationVerifier,
+				trustPolicyDoc:      trustPolicyDoc,
+				revokedOnlyPolicies: make(map[string]struct{}),
+				pluginConfig:        map[string]string{"key": "value"},
+			}
+			for _, policy := range test.revokedOnly {
+				v.revokedOnlyPolicies[policy] = struct{}{}
+			}
+			repo := test.repo
+			if repo == "" {
+				repo = testRepo
+			}
+			subjectDesc := ocispec.Descriptor{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Digest:    digest.FromString("subject"),
+			}
+
+			result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+				Store:              store,
+				Repository:         repo,
+				SubjectDescriptor:  subjectDesc,
+				ArtifactDescriptor: artifactDesc,
+			})
+			if test.expectErr != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", test.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if test.expectFailure != (result.Err != nil) {
+				t.Fatalf("Expected failure: %v, got: %v", test.expectFailure, result.Err)
+			}
+			detail, _ := result.Detail.(map[string]string)
+			if (len(test.expectedDetail) > 0 || len(detail) > 0) && !reflect.DeepEqual(detail, test.expectedDetail) {
+				t.Fatalf("Expected detail %v, got %v", test.expectedDetail, result.Detail)
+			}
+			if expected := repo + "@" + subjectDesc.Digest.String(); notationVerifier.opts.ArtifactReference != expected {
+				t.Fatalf("Expected artifact reference %s, got %s", expected, notationVerifier.opts.ArtifactReference)
+			}
+			if !reflect.DeepEqual(notationVerifier.opts.PluginConfig, v.pluginConfig) {
+				t.Fatalf("Expected plugin config %v, got %v", v.pluginConfig, notationVerifier.opts.PluginConfig)
+			}
+		})
+	}
+}
+
+func TestVerifier_Verifiable(t *testing.T) {
+	v := &Verifier{name: testName}
+	if v.Name() != testName {
+		t.Fatalf("Expected name %s, got %s", testName, v.Name())
+	}
+	if v.Type() != verifierTypeNotation {
+		t.Fatalf("Expected type %s, got %s", verifierTypeNotation, v.Type())
+	}
+
+	tests := []struct {
+		name     string
+		artifact ocispec.Descriptor
+		expected bool
+	}{
+		{
+			name:     "Notation signature",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: notationregistry.ArtifactTypeNotation},
+			expected: true,
+		},
+		{
+			name:     "Other artifact type",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: "application/spdx+json"},
+		},
+		{
+			name:     "Image index",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex, ArtifactType: notationregistry.ArtifactTypeNotation},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := v.Verifiable(test.artifact); got != test.expected {
+				t.Fatalf("Expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRevocationStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []*result.CertRevocationResult
+		expected string
+	}{
+		{
+			name:     "revoked leaf certificate",
+			results:  []*result.CertRevocationResult{{Result: result.ResultRevoked}, {Result: result.ResultOK}},
+			expected: revocationStatusRevoked,
+		},
+		{
+			name:     "revoked intermediate certificate",
+			results:  []*result.CertRevocationResult{{Result: result.ResultOK}, {Result: result.ResultRevoked}, {Result: result.ResultNonRevokable}},
+			expected: revocationStatusRevoked,
+		},
+		{
+			name:     "unknown status",
+			results:  []*result.CertRevocationResult{{Result: result.ResultUnknown}, {Result: result.ResultOK}},
+			expected: revocationStatusUnknown,
+		},
+		{
+			name:     "no results",
+			expected: revocationStatusUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := revocationStatus(test.results); got != test.expected {
+				t.Fatalf("Expected %s, got %s", test.expected, got)
+			}
+		})
+	}
+}
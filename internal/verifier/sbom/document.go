@@ -0,0 +1,249 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	spdxjson "github.com/spdx/tools-golang/json"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tagvalue"
+)
+
+const (
+	// artifactTypeSPDX is the artifact type of SPDX JSON SBOMs.
+	artifactTypeSPDX = "application/spdx+json"
+
+	// artifactTypeSPDXTagValue is the artifact type of SPDX tag-value SBOMs.
+	artifactTypeSPDXTagValue = "text/spdx"
+
+	// artifactTypeCycloneDX is the artifact type of CycloneDX JSON SBOMs.
+	artifactTypeCycloneDX = "application/vnd.cyclonedx+json"
+
+	// spdxNoAssertion and spdxNone are the SPDX values of an unknown and an
+	// absent license.
+	spdxNoAssertion = "NOASSERTION"
+	spdxNone        = "NONE"
+)
+
+// document is the format-independent content of an SBOM.
+type document struct {
+	// format is the SBOM format and version, e.g. "SPDX-2.3".
+	format   string
+	packages []sbomPackage
+}
+
+// sbomPackage is a package listed in an SBOM.
+type sbomPackage struct {
+	name    string
+	version string
+	// license is the license of the package as listed in the SBOM, empty if
+	// unknown.
+	license string
+	// licenseExpr is the parsed license, nil if unknown or invalid.
+	licenseExpr *licenseExpression
+	// licenseErr is set if the license is not a valid SPDX expression.
+	licenseErr error
+	purl       string
+}
+
+// isSBOMArtifact checks if the artifact is an SBOM in a supported format.
+func isSBOMArtifact(artifact ocispec.Descriptor) bool {
+	switch artifact.ArtifactType {
+	case artifactTypeSPDX, artifactTypeSPDXTagValue, artifactTypeCycloneDX:
+		return artifact.MediaType == ocispec.MediaTypeImageManifest
+	}
+	return false
+}
+
+// parseDocument parses an SBOM of the given artifact type.
+func parseDocument(artifactType string, content []byte) (*document, error) {
+	switch artifactType {
+	case artifactTypeSPDX:
+		return parseSPDX(content, spdxjson.Read)
+	case artifactTypeSPDXTagValue:
+		return parseSPDX(content, tagvalue.Read)
+	case artifactTypeCycloneDX:
+		return parseCycloneDX(content)
+	}
+	return nil, fmt.Errorf("unsupported SBOM artifact type %q", artifactType)
+}
+
+// parseSPDX parses an SPDX document with the reader of its encoding. The
+// concluded license of a package is preferred over the declared one.
+func parseSPDX(content []byte, read func(io.Reader) (*spdx.Document, error)) (*document, error) {
+	spdxDoc, err := read(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SPDX document: %w", err)
+	}
+
+	doc := &document{format: spdxDoc.SPDXVersion}
+	for _, pkg := range spdxDoc.Packages {
+		if pkg == nil {
+			continue
+		}
+		p := sbomPackage{
+			name:    pkg.PackageName,
+			version: pkg.PackageVersion,
+			license: spdxLicense(pkg.PackageLicenseConcluded),
+		}
+		if p.license == "" {
+			p.license = spdxLicense(pkg.PackageLicenseDeclared)
+		}
+		if p.license != "" {
+			p.licenseExpr, p.licenseErr = parseLicenseExpression(p.license)
+		}
+		for _, ref := range pkg.PackageExternalReferences {
+			if ref != nil && ref.RefType == "purl" {
+				p.purl = ref.Locator
+				break
+			}
+		}
+		doc.packages = append(doc.packages, p)
+	}
+	return doc, nil
+}
+
+// spdxLicense returns the license expression, or empty if the license is
+// unknown or absent.
+func spdxLicense(license string) string {
+	license = strings.TrimSpace(license)
+	if license == spdxNoAssertion || license == spdxNone {
+		return ""
+	}
+	return license
+}
+
+// cycloneDXDocument is the subset of a CycloneDX JSON document used for
+// verification.
+// See https://cyclonedx.org/docs/1.6/json/
+type cycloneDXDocument struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Components  []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	PURL       string               `json:"purl"`
+	Licenses   []cycloneDXLicense   `json:"licenses"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+// cycloneDXLicense is either a single license or an SPDX license expression.
+type cycloneDXLicense struct {
+	License *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"license"`
+	Expression string `json:"expression"`
+}
+
+// parseCycloneDX parses a CycloneDX JSON document including nested
+// components. Multiple licenses of a component are treated as all applying
+// to the component.
+func parseCycloneDX(content []byte) (*document, error) {
+	var bom cycloneDXDocument
+	if err := json.Unmarshal(content, &bom); err != nil {
+		return nil, fmt.Errorf("failed to parse CycloneDX document: %w", err)
+	}
+	if bom.BOMFormat != "CycloneDX" {
+		return nil, fmt.Errorf("failed to parse CycloneDX document: unexpected bomFormat %q", bom.BOMFormat)
+	}
+
+	doc := &document{format: "CycloneDX-" + bom.SpecVersion}
+	var walk func(components []cycloneDXComponent)
+	walk = func(components []cycloneDXComponent) {
+		for _, component := range components {
+			p := sbomPackage{
+				name:    component.Name,
+				version: component.Version,
+				purl:    component.PURL,
+			}
+			p.license, p.licenseExpr, p.licenseErr = cycloneDXLicenseExpression(component.Licenses)
+			doc.packages = append(doc.packages, p)
+			walk(component.Components)
+		}
+	}
+	walk(bom.Components)
+	return doc, nil
+}
+
+// cycloneDXLicenseExpression combines the licenses of a component into a
+// conjunction. It returns the combined license for display along with the
+// parsed expression. License names are free text rather than SPDX
+// identifiers, so they are matched as a whole and not parsed.
+func cycloneDXLicenseExpression(licenses []cycloneDXLicense) (string, *licenseExpression, error) {
+	var terms []string
+	var operands []*licenseExpression
+	var parseErr error
+	for _, license := range licenses {
+		var term string
+		var operand *licenseExpression
+		switch {
+		case license.Expression != "":
+			term = license.Expression
+			expr, err := parseLicenseExpression(term)
+			if err != nil && parseErr == nil {
+				parseErr = err
+			}
+			operand = expr
+		case license.License != nil && license.License.ID != "":
+			term = license.License.ID
+			operand = &licenseExpression{license: strings.TrimSpace(term)}
+		case license.License != nil && license.License.Name != "":
+			term = license.License.Name
+			operand = &licenseExpression{license: strings.TrimSpace(term)}
+		default:
+			continue
+		}
+		if len(licenses) > 1 {
+			term = "(" + term + ")"
+		}
+		terms = append(terms, term)
+		operands = append(operands, operand)
+	}
+
+	license := strings.Join(terms, " AND ")
+	switch {
+	case parseErr != nil:
+		return license, nil, parseErr
+	case len(operands) == 0:
+		return license, nil, nil
+	case len(operands) == 1:
+		return license, operands[0], nil
+	}
+	return license, &licenseExpression{operator: "AND", operands: operands}, nil
+}
+
+// appendFormat adds format to the comma separated list of formats unless it
+// is already listed.
+func appendFormat(formats, format string) string {
+	if formats == "" {
+		return format
+	}
+	if slices.Contains(strings.Split(formats, ", "), format) {
+		return formats
+	}
+	return formats + ", " + format
+}
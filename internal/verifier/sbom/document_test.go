@@ -0,0 +1,218 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "test",
+  "documentNamespace": "https://example.com/test",
+  "creationInfo": {"created": "2024-01-01T00:00:00Z", "creators": ["Tool: test"]},
+  "packages": [
+    {
+      "name": "musl",
+      "SPDXID": "SPDXRef-Package-musl",
+      "versionInfo": "1.2.4-r2",
+      "downloadLocation": "NOASSERTION",
+      "licenseConcluded": "MIT",
+      "licenseDeclared": "NOASSERTION",
+      "externalRefs": [
+        {"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:apk/alpine/musl@1.2.4-r2"}
+      ]
+    },
+    {
+      "name": "readline",
+      "SPDXID": "SPDXRef-Package-readline",
+      "versionInfo": "8.2.1-r1",
+      "downloadLocation": "NOASSERTION",
+      "licenseConcluded": "NOASSERTION",
+      "licenseDeclared": "GPL-3.0-or-later"
+    },
+    {
+      "name": "unknown",
+      "SPDXID": "SPDXRef-Package-unknown",
+      "downloadLocation": "NOASSERTION",
+      "licenseConcluded": "NONE"
+    }
+  ]
+}`
+
+const testCycloneDX = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "components": [
+    {
+      "name": "log4j-core",
+      "version": "2.14.1",
+      "purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+      "licenses": [{"license": {"id": "Apache-2.0"}}],
+      "components": [
+        {"name": "nested", "version": "1.0.0", "licenses": [{"expression": "MIT OR GPL-3.0-only"}]}
+      ]
+    },
+    {
+      "name": "multi",
+      "version": "2.0.0",
+      "licenses": [{"license": {"id": "MIT"}}, {"license": {"name": "Custom"}}]
+    },
+    {"name": "unlicensed", "version": "0.1.0"},
+    {
+      "name": "custom",
+      "version": "3.0.0",
+      "licenses": [{"license": {"name": "Custom License"}}, {"license": {"id": "GPL-3.0-only"}}]
+    },
+    {"name": "invalid", "version": "4.0.0", "licenses": [{"expression": "MIT AND"}]}
+  ]
+}`
+
+const testSPDXTagValue = `SPDXVersion: SPDX-2.2
+DataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+DocumentName: test
+DocumentNamespace: https://example.com/test
+Creator: Tool: test
+Created: 2024-01-01T00:00:00Z
+
+PackageName: alpine-baselayout
+SPDXID: SPDXRef-Package-alpine-baselayout
+PackageVersion: 3.4.3-r1
+PackageDownloadLocation: NOASSERTION
+FilesAnalyzed: false
+PackageLicenseConcluded: GPL-2.0-only
+PackageLicenseDeclared: GPL-2.0-only
+PackageCopyrightText: NOASSERTION
+`
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name         string
+		artifactType string
+		content      string
+		expected     *document
+		expectErr    bool
+	}{
+		{
+			name:         "SPDX",
+			artifactType: artifactTypeSPDX,
+			content:      testSPDX,
+			expected: &document{
+				format: "SPDX-2.3",
+				packages: []sbomPackage{
+					{name: "musl", version: "1.2.4-r2", license: "MIT", licenseExpr: &licenseExpression{license: "MIT"}, purl: "pkg:apk/alpine/musl@1.2.4-r2"},
+					{name: "readline", version: "8.2.1-r1", license: "GPL-3.0-or-later", licenseExpr: &licenseExpression{license: "GPL-3.0-or-later"}},
+					{name: "unknown"},
+				},
+			},
+		},
+		{
+			name:         "SPDX tag-value",
+			artifactType: artifactTypeSPDXTagValue,
+			content:      testSPDXTagValue,
+			expected: &document{
+				// Documents of earlier SPDX versions are converted to the
+				// latest version.
+				format: "SPDX-2.3",
+				packages: []sbomPackage{
+					{name: "alpine-baselayout", version: "3.4.3-r1", license: "GPL-2.0-only", licenseExpr: &licenseExpression{license: "GPL-2.0-only"}},
+				},
+			},
+		},
+		{
+			name:         "CycloneDX",
+			artifactType: artifactTypeCycloneDX,
+			content:      testCycloneDX,
+			expected: &document{
+				format: "CycloneDX-1.5",
+				packages: []sbomPackage{
+					{name: "log4j-core", version: "2.14.1", license: "Apache-2.0", licenseExpr: &licenseExpression{license: "Apache-2.0"}, purl: "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+					{name: "nested", version: "1.0.0", license: "MIT OR GPL-3.0-only", licenseExpr: &licenseExpression{
+						operator: "OR",
+						operands: []*licenseExpression{{license: "MIT"}, {license: "GPL-3.0-only"}},
+					}},
+					{name: "multi", version: "2.0.0", license: "(MIT) AND (Custom)", licenseExpr: &licenseExpression{
+						operator: "AND",
+						operands: []*licenseExpression{{license: "MIT"}, {license: "Custom"}},
+					}},
+					{name: "unlicensed", version: "0.1.0"},
+					// License names are free text and are not parsed as
+					// expressions.
+					{name: "custom", version: "3.0.0", license: "(Custom License) AND (GPL-3.0-only)", licenseExpr: &licenseExpression{
+						operator: "AND",
+						operands: []*licenseExpression{{license: "Custom License"}, {license: "GPL-3.0-only"}},
+					}},
+					{name: "invalid", version: "4.0.0", license: "MIT AND", licenseErr: licenseError("MIT AND")},
+				},
+			},
+		},
+		{
+			name:         "invalid SPDX",
+			artifactType: artifactTypeSPDX,
+			content:      `{"spdxVersion":`,
+			expectErr:    true,
+		},
+		{
+			name:         "invalid CycloneDX",
+			artifactType: artifactTypeCycloneDX,
+			content:      `[]`,
+			expectErr:    true,
+		},
+		{
+			name:         "not a CycloneDX document",
+			artifactType: artifactTypeCycloneDX,
+			content:      `{"bomFormat":"other"}`,
+			expectErr:    true,
+		},
+		{
+			name:         "unsupported artifact type",
+			artifactType: "application/vnd.test",
+			content:      `{}`,
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseDocument(tt.artifactType, []byte(tt.content))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if !reflect.DeepEqual(doc, tt.expected) {
+				t.Fatalf("expected document %+v, got %+v", tt.expected, doc)
+			}
+		})
+	}
+}
+
+func TestAppendFormat(t *testing.T) {
+	formats := appendFormat("", "SPDX-2.3")
+	formats = appendFormat(formats, "SPDX-2.3")
+	formats = appendFormat(formats, "CycloneDX-1.5")
+	if expected := "SPDX-2.3, CycloneDX-1.5"; formats != expected {
+		t.Fatalf("expected formats %q, got %q", expected, formats)
+	}
+}
+
+// licenseError returns the error of parsing an invalid license expression.
+func licenseError(expression string) error {
+	_, err := parseLicenseExpression(expression)
+	return err
+}
@@ -0,0 +1,366 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// licenseExpression is a node of a parsed SPDX license expression.
+// See https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/
+type licenseExpression struct {
+	// operator is "AND" or "OR" for compound expressions, empty for a single
+	// license.
+	operator string
+	operands []*licenseExpression
+
+	// license and exception are set for a single license, e.g.
+	// "GPL-2.0-only WITH Classpath-exception-2.0".
+	license   string
+	exception string
+}
+
+// parseLicenseExpression parses an SPDX license expression. Operators are
+// matched case-insensitively.
+func parseLicenseExpression(expression string) (*licenseExpression, error) {
+	p := &licenseParser{tokens: tokenizeLicenseExpression(expression)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", expression, err)
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("invalid license expression %q: unexpected token %q", expression, p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// tokenizeLicenseExpression splits an expression into parentheses and words.
+func tokenizeLicenseExpression(expression string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range expression {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// licenseParser is a recursive descent parser of SPDX license expressions
+// where WITH binds tighter than AND, which binds tighter than OR.
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *licenseParser) peekOperator(operator string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], operator)
+}
+
+func (p *licenseParser) parseOr() (*licenseExpression, error) {
+	return p.parseCompound("OR", p.parseAnd)
+}
+
+func (p *licenseParser) parseAnd() (*licenseExpression, error) {
+	return p.parseCompound("AND", p.parseWith)
+}
+
+func (p *licenseParser) parseCompound(operator string, parseOperand func() (*licenseExpression, error)) (*licenseExpression, error) {
+	operand, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*licenseExpression{operand}
+	for p.peekOperator(operator) {
+		p.pos++
+		if operand, err = parseOperand(); err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &licenseExpression{operator: operator, operands: operands}, nil
+}
+
+func (p *licenseParser) parseWith() (*licenseExpression, error) {
+	expr, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peekOperator("WITH") {
+		return expr, nil
+	}
+	if expr.license == "" {
+		return nil, fmt.Errorf("WITH must follow a license identifier")
+	}
+	p.pos++
+	exception, err := p.parseIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	expr.exception = exception
+	return expr, nil
+}
+
+func (p *licenseParser) parseAtom() (*licenseExpression, error) {
+	if p.pos < len(p.tokens) && p.tokens[p.pos] == "(" {
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expr, nil
+	}
+	license, err := p.parseIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	return &licenseExpression{license: license}, nil
+}
+
+func (p *licenseParser) parseIdentifier() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", fmt.Errorf("unexpected end of expression")
+	}
+	token := p.tokens[p.pos]
+	switch {
+	case token == "(" || token == ")":
+		return "", fmt.Errorf("unexpected token %q", token)
+	case strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH"):
+		return "", fmt.Errorf("unexpected operator %q", token)
+	}
+	p.pos++
+	return token, nil
+}
+
+// licenseMatcher matches licenses against a list of disallowed licenses.
+type licenseMatcher struct {
+	// disallowed maps a normalized license identifier to the configured
+	// entries. An empty exception matches the license with any exception.
+	disallowed map[string][]disallowedLicense
+}
+
+// disallowedLicense is a parsed entry of Options.DisallowedLicenses.
+type disallowedLicense struct {
+	entry     string
+	exception string
+}
+
+// newLicenseMatcher creates a matcher of the given disallowed licenses. Each
+// entry is a license identifier, optionally followed by "WITH <exception>".
+func newLicenseMatcher(licenses []string) (*licenseMatcher, error) {
+	m := &licenseMatcher{disallowed: make(map[string][]disallowedLicense)}
+	for _, entry := range licenses {
+		expr, err := parseLicenseExpression(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid disallowed license: %w", err)
+		}
+		if expr.license == "" {
+			return nil, fmt.Errorf("invalid disallowed license %q: compound expressions are not supported", entry)
+		}
+		key := normalizeLicenseID(expr.license)
+		m.disallowed[key] = append(m.disallowed[key], disallowedLicense{
+			entry:     entry,
+			exception: expr.exception,
+		})
+	}
+	return m, nil
+}
+
+// violations returns the disallowed licenses that cannot be avoided under the
+// license expression, or nil if the expression can be satisfied without any
+// disallowed license. For example, "MIT OR GPL-3.0-only" is allowed even if
+// GPL-3.0-only is disallowed, while "MIT AND GPL-3.0-only" is not.
+func (m *licenseMatcher) violations(expr *licenseExpression) []string {
+	if len(m.disallowed) == 0 {
+		return nil
+	}
+	matched := m.evaluate(expr)
+	if len(matched) == 0 {
+		return nil
+	}
+	slices.Sort(matched)
+	return matched
+}
+
+// evaluate returns the disallowed licenses making the expression disallowed.
+func (m *licenseMatcher) evaluate(expr *licenseExpression) []string {
+	switch expr.operator {
+	case "AND":
+		// A conjunction is disallowed if any of its operands is disallowed.
+		var matched []string
+		for _, operand := range expr.operands {
+			matched = appendUnique(matched, m.evaluate(operand)...)
+		}
+		return matched
+	case "OR":
+		// A disjunction is disallowed only if all of its operands are.
+		var matched []string
+		for _, operand := range expr.operands {
+			operandMatched := m.evaluate(operand)
+			if len(operandMatched) == 0 {
+				return nil
+			}
+			matched = appendUnique(matched, operandMatched...)
+		}
+		return matched
+	}
+
+	var matched []string
+	for _, disallowed := range m.disallowed[normalizeLicenseID(expr.license)] {
+		if disallowed.exception == "" || strings.EqualFold(disallowed.exception, expr.exception) {
+			matched = appendUnique(matched, disallowed.entry)
+		}
+	}
+	return matched
+}
+
+// normalizeLicenseID normalizes a license identifier for comparison. License
+// identifiers are case-insensitive, the "+" operator is equivalent to the
+// "-or-later" suffix, and the deprecated GNU identifiers without suffix are
+// equivalent to the "-only" ones.
+func normalizeLicenseID(id string) string {
+	id = strings.ToLower(id)
+	if strings.HasSuffix(id, "+") {
+		return strings.TrimSuffix(id, "+") + "-or-later"
+	}
+	return strings.TrimSuffix(id, "-only")
+}
+
+func appendUnique(values []string, newValues ...string) []string {
+	for _, value := range newValues {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// licenseAllowList matches licenses against a list of allowed licenses.
+type licenseAllowList struct {
+	// licenses maps a normalized license identifier to the allowed
+	// exceptions. An empty exception allows the license with any exception.
+	licenses map[string][]string
+
+	// expressions is the set of allowed compound expressions in canonical
+	// form.
+	expressions map[string]struct{}
+}
+
+// newLicenseAllowList creates an allow list of the given entries. Each entry
+// is a license identifier, optionally followed by "WITH <exception>", or a
+// compound SPDX license expression that is allowed as a whole.
+func newLicenseAllowList(entries []string) (*licenseAllowList, error) {
+	l := &licenseAllowList{
+		licenses:    make(map[string][]string),
+		expressions: make(map[string]struct{}),
+	}
+	for _, entry := range entries {
+		expr, err := parseLicenseExpression(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed license: %w", err)
+		}
+		if expr.operator != "" {
+			l.expressions[expr.canonical()] = struct{}{}
+			continue
+		}
+		key := normalizeLicenseID(expr.license)
+		l.licenses[key] = append(l.licenses[key], strings.ToLower(expr.exception))
+	}
+	return l, nil
+}
+
+// allowed checks whether the license expression is allowed, i.e. it is an
+// allowed compound expression or it can be satisfied with allowed licenses.
+// For example, "MIT OR GPL-3.0-only" is allowed if MIT is allowed, while
+// "MIT AND GPL-3.0-only" requires both licenses to be allowed.
+func (l *licenseAllowList) allowed(expr *licenseExpression) bool {
+	return l.evaluate(expr)
+}
+
+func (l *licenseAllowList) evaluate(expr *licenseExpression) bool {
+	if _, ok := l.expressions[expr.canonical()]; ok {
+		return true
+	}
+	switch expr.operator {
+	case "AND":
+		for _, operand := range expr.operands {
+			if !l.evaluate(operand) {
+				return false
+			}
+		}
+		return true
+	case "OR":
+		return slices.ContainsFunc(expr.operands, l.evaluate)
+	}
+	for _, exception := range l.licenses[normalizeLicenseID(expr.license)] {
+		if exception == "" || exception == strings.ToLower(expr.exception) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonical returns the canonical form of the expression, so that equivalent
+// expressions differing in case, operand order or parentheses are equal.
+func (expr *licenseExpression) canonical() string {
+	if expr.operator == "" {
+		if expr.exception == "" {
+			return normalizeLicenseID(expr.license)
+		}
+		return normalizeLicenseID(expr.license) + " with " + strings.ToLower(expr.exception)
+	}
+	var operands []string
+	var collect func(*licenseExpression)
+	collect = func(e *licenseExpression) {
+		for _, operand := range e.operands {
+			if operand.operator == e.operator {
+				// Flatten nested operands of the same operator, e.g.
+				// "(MIT OR Apache-2.0) OR BSD-3-Clause".
+				collect(operand)
+				continue
+			}
+			operands = append(operands, operand.canonical())
+		}
+	}
+	collect(expr)
+	slices.Sort(operands)
+	return "(" + strings.Join(operands, " "+strings.ToLower(expr.operator)+" ") + ")"
+}
@@ -0,0 +1,207 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLicenseExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		expectErr  bool
+	}{
+		{name: "single license", expression: "MIT"},
+		{name: "license with exception", expression: "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{name: "compound expression", expression: "(MIT OR Apache-2.0) AND BSD-3-Clause"},
+		{name: "lowercase operators", expression: "mit or apache-2.0"},
+		{name: "empty", expression: " ", expectErr: true},
+		{name: "missing operand", expression: "MIT AND", expectErr: true},
+		{name: "missing closing parenthesis", expression: "(MIT OR Apache-2.0", expectErr: true},
+		{name: "unexpected closing parenthesis", expression: "MIT)", expectErr: true},
+		{name: "missing operator", expression: "MIT Apache-2.0", expectErr: true},
+		{name: "exception of compound expression", expression: "(MIT OR Apache-2.0) WITH exception", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseLicenseExpression(tt.expression)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestNewLicenseMatcher(t *testing.T) {
+	tests := []struct {
+		name       string
+		disallowed []string
+		expectErr  bool
+	}{
+		{name: "no licenses"},
+		{name: "license identifiers", disallowed: []string{"GPL-3.0-only", "AGPL-3.0-or-later"}},
+		{name: "license with exception", disallowed: []string{"GPL-2.0-only WITH Classpath-exception-2.0"}},
+		{name: "compound expression", disallowed: []string{"MIT OR GPL-3.0-only"}, expectErr: true},
+		{name: "invalid expression", disallowed: []string{"(MIT"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newLicenseMatcher(tt.disallowed)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestLicenseMatcher_Violations(t *testing.T) {
+	matcher, err := newLicenseMatcher([]string{
+		"GPL-3.0-only",
+		"AGPL-3.0-or-later",
+		"GPL-2.0-only WITH Autoconf-exception-2.0",
+		"LicenseRef-Proprietary",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		license  string
+		expected []string
+	}{
+		{name: "allowed license", license: "MIT"},
+		{name: "disallowed license", license: "GPL-3.0-only", expected: []string{"GPL-3.0-only"}},
+		{name: "case insensitive", license: "gpl-3.0-ONLY", expected: []string{"GPL-3.0-only"}},
+		{name: "deprecated identifier", license: "GPL-3.0", expected: []string{"GPL-3.0-only"}},
+		{name: "plus operator", license: "AGPL-3.0+", expected: []string{"AGPL-3.0-or-later"}},
+		{name: "or later is a different license", license: "GPL-3.0-or-later"},
+		{name: "disjunction with allowed alternative", license: "MIT OR GPL-3.0-only"},
+		{name: "disjunction without allowed alternative", license: "GPL-3.0-only OR AGPL-3.0-or-later", expected: []string{"AGPL-3.0-or-later", "GPL-3.0-only"}},
+		{name: "conjunction", license: "MIT AND GPL-3.0-only", expected: []string{"GPL-3.0-only"}},
+		{name: "nested expression", license: "(MIT OR GPL-3.0-only) AND (Apache-2.0 OR BSD-3-Clause)"},
+		{name: "nested expression with violation", license: "(MIT AND GPL-3.0-only) OR (AGPL-3.0-or-later AND Apache-2.0)", expected: []string{"AGPL-3.0-or-later", "GPL-3.0-only"}},
+		{name: "license disallowed with any exception", license: "GPL-3.0-only WITH GCC-exception-3.1", expected: []string{"GPL-3.0-only"}},
+		{name: "disallowed exception", license: "GPL-2.0-only WITH Autoconf-exception-2.0", expected: []string{"GPL-2.0-only WITH Autoconf-exception-2.0"}},
+		{name: "other exception", license: "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{name: "license without disallowed exception", license: "GPL-2.0-only"},
+		{name: "license reference", license: "LicenseRef-Proprietary", expected: []string{"LicenseRef-Proprietary"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseLicenseExpression(tt.license)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := matcher.violations(expr); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected violations %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewLicenseAllowList(t *testing.T) {
+	tests := []struct {
+		name      string
+		licenses  []string
+		expectErr bool
+	}{
+		{name: "no licenses"},
+		{name: "licenses and expressions", licenses: []string{"MIT", "GPL-2.0-only WITH Classpath-exception-2.0", "MIT OR Apache-2.0"}},
+		{name: "invalid expression", licenses: []string{"MIT AND"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newLicenseAllowList(tt.licenses); (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestLicenseAllowList_Allowed(t *testing.T) {
+	allowList, err := newLicenseAllowList([]string{
+		"MIT",
+		"Apache-2.0",
+		"GPL-2.0-only WITH Classpath-exception-2.0",
+		"LGPL-2.1-only AND BSD-3-Clause",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		license  string
+		expected bool
+	}{
+		{name: "allowed license", license: "MIT", expected: true},
+		{name: "case insensitive", license: "apache-2.0", expected: true},
+		{name: "other license", license: "GPL-3.0-only"},
+		{name: "disjunction with allowed alternative", license: "GPL-3.0-only OR MIT", expected: true},
+		{name: "conjunction of allowed licenses", license: "MIT AND Apache-2.0", expected: true},
+		{name: "conjunction with other license", license: "MIT AND GPL-3.0-only"},
+		{name: "allowed license with any exception", license: "MIT WITH exception", expected: true},
+		{name: "allowed exception", license: "GPL-2.0-only WITH Classpath-exception-2.0", expected: true},
+		{name: "license without allowed exception", license: "GPL-2.0-only"},
+		{name: "allowed expression", license: "BSD-3-Clause AND lgpl-2.1-only", expected: true},
+		{name: "allowed expression within expression", license: "(LGPL-2.1-only AND BSD-3-Clause) OR GPL-3.0-only", expected: true},
+		{name: "part of allowed expression", license: "LGPL-2.1-only"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseLicenseExpression(tt.license)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := allowList.allowed(expr); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLicenseExpression_Canonical(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{expression: "MIT", expected: "mit"},
+		{expression: "GPL-2.0-only WITH Classpath-exception-2.0", expected: "gpl-2.0 with classpath-exception-2.0"},
+		{expression: "MIT OR Apache-2.0", expected: "(apache-2.0 or mit)"},
+		{expression: "(MIT OR Apache-2.0) OR BSD-3-Clause", expected: "(apache-2.0 or bsd-3-clause or mit)"},
+		{expression: "MIT AND (Apache-2.0 OR BSD-3-Clause)", expected: "((apache-2.0 or bsd-3-clause) and mit)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := parseLicenseExpression(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := expr.canonical(); got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+)
+
+const verifierTypeLicenseChecker = "licensechecker"
+
+// LicenseCheckerOptions contains the configuration options for creating a
+// [LicenseChecker].
+type LicenseCheckerOptions struct {
+	// AllowedLicenses is a list of allowed SPDX license identifiers or SPDX
+	// license expressions, e.g. "MIT" or "GPL-2.0-only WITH
+	// Classpath-exception-2.0". A package is allowed if its license
+	// expression is one of the allowed expressions, or if it can be satisfied
+	// with allowed licenses only. Required.
+	AllowedLicenses []string `json:"allowedLicenses"`
+
+	// AllowUnknownLicenses allows packages without a license or with the
+	// "NOASSERTION" or "NONE" license. Optional.
+	AllowUnknownLicenses bool `json:"allowUnknownLicenses,omitempty"`
+}
+
+// LicenseCheckerDetail is the detail of the verification result of a
+// [LicenseChecker].
+type LicenseCheckerDetail struct {
+	// Format is the SBOM format and version, e.g. "SPDX-2.3". The distinct
+	// formats of multiple SBOM blobs are comma separated.
+	Format string `json:"format"`
+
+	// DisallowedPackages lists the packages whose licenses are not allowed.
+	DisallowedPackages []DisallowedLicensePackage `json:"disallowedPackages,omitempty"`
+}
+
+// DisallowedLicensePackage is a package whose license is not allowed.
+type DisallowedLicensePackage struct {
+	// Name is the name of the package.
+	Name string `json:"name"`
+
+	// Version is the version of the package.
+	Version string `json:"version,omitempty"`
+
+	// PURL is the package URL of the package.
+	PURL string `json:"purl,omitempty"`
+
+	// License is the SPDX license expression of the package, empty if
+	// unknown.
+	License string `json:"license,omitempty"`
+
+	// Error is the reason the license could not be checked, e.g. an invalid
+	// license expression.
+	Error string `json:"error,omitempty"`
+}
+
+// LicenseChecker implements the [ratify.Verifier] interface checking that all
+// packages in an SBOM are licensed under allowed licenses.
+type LicenseChecker struct {
+	name                 string
+	allowList            *licenseAllowList
+	allowUnknownLicenses bool
+}
+
+func init() {
+	verifier.Register(verifierTypeLicenseChecker, NewLicenseChecker)
+}
+
+// NewLicenseChecker creates a new license checker instance based on the
+// provided options.
+func NewLicenseChecker(opts verifier.NewOptions, _ []string) (ratify.Verifier, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("verifier name cannot be empty")
+	}
+
+	raw, err := json.Marshal(opts.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verifier parameters: %w", err)
+	}
+	var params LicenseCheckerOptions
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verifier parameters: %w", err)
+	}
+	if len(params.AllowedLicenses) == 0 {
+		return nil, fmt.Errorf("allowedLicenses cannot be empty")
+	}
+	allowList, err := newLicenseAllowList(params.AllowedLicenses)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LicenseChecker{
+		name:                 opts.Name,
+		allowList:            allowList,
+		allowUnknownLicenses: params.AllowUnknownLicenses,
+	}, nil
+}
+
+// Name returns the name of the verifier.
+func (v *LicenseChecker) Name() string {
+	return v.name
+}
+
+// Type returns the type of the verifier which is always "licensechecker".
+func (v *LicenseChecker) Type() string {
+	return verifierTypeLicenseChecker
+}
+
+// Verifiable checks if the artifact is an SPDX or CycloneDX SBOM.
+func (v *LicenseChecker) Verifiable(artifact ocispec.Descriptor) bool {
+	return isSBOMArtifact(artifact)
+}
+
+// Verify checks the licenses of all packages in the SBOM.
+func (v *LicenseChecker) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	layers, err := verifier.FetchArtifactLayers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ratify.VerificationResult{
+		Verifier: v,
+	}
+	if len(layers) == 0 {
+		result.Err = fmt.Errorf("no SBOM blob found in artifact %s", opts.ArtifactDescriptor.Digest)
+		return result, nil
+	}
+
+	detail := &LicenseCheckerDetail{}
+	result.Detail = detail
+	for _, layer := range layers {
+		content, err := opts.Store.FetchBlob(ctx, opts.Repository, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch SBOM blob %s: %w", layer.Digest, err)
+		}
+		doc, err := parseDocument(opts.ArtifactDescriptor.ArtifactType, content)
+		if err != nil {
+			result.Err = err
+			return result, nil
+		}
+		detail.Format = appendFormat(detail.Format, doc.format)
+		for _, pkg := range doc.packages {
+			if (pkg.license == "" && v.allowUnknownLicenses) || (pkg.licenseExpr != nil && v.allowList.allowed(pkg.licenseExpr)) {
+				continue
+			}
+			disallowed := DisallowedLicensePackage{
+				Name:    pkg.name,
+				Version: pkg.version,
+				PURL:    pkg.purl,
+				License: pkg.license,
+			}
+			if pkg.licenseErr != nil {
+				disallowed.Error = pkg.licenseErr.Error()
+			}
+			detail.DisallowedPackages = append(detail.DisallowedPackages, disallowed)
+		}
+	}
+
+	if len(detail.DisallowedPackages) > 0 {
+		result.Err = fmt.Errorf("license check failed: found %d package(s) with disallowed licenses", len(detail.DisallowedPackages))
+		return result, nil
+	}
+	result.Description = "License check succeeded. All packages have allowed licenses."
+	return result, nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+)
+
+func TestNewLicenseChecker(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      verifier.NewOptions
+		expectErr bool
+	}{
+		{
+			name:      "empty name",
+			opts:      verifier.NewOptions{Type: verifierTypeLicenseChecker},
+			expectErr: true,
+		},
+		{
+			name: "invalid parameters",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeLicenseChecker,
+				Parameters: map[string]any{"allowedLicenses": "MIT"},
+			},
+			expectErr: true,
+		},
+		{
+			name:      "no allowed licenses",
+			opts:      verifier.NewOptions{Name: testName, Type: verifierTypeLicenseChecker},
+			expectErr: true,
+		},
+		{
+			name: "invalid allowed license",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeLicenseChecker,
+				Parameters: LicenseCheckerOptions{AllowedLicenses: []string{"MIT OR"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "valid parameters",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Type: verifierTypeLicenseChecker,
+				Parameters: map[string]any{
+					"allowedLicenses":      []string{"MIT", "Apache-2.0 OR BSD-3-Clause"},
+					"allowUnknownLicenses": true,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := verifier.New(tt.opts, nil)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if v.Name() != testName || v.Type() != verifierTypeLicenseChecker {
+				t.Fatalf("unexpected verifier name %s or type %s", v.Name(), v.Type())
+			}
+			if !v.Verifiable(ocispec.Descriptor{ArtifactType: artifactTypeSPDX, MediaType: ocispec.MediaTypeImageManifest}) {
+				t.Fatal("expected SPDX SBOM to be verifiable")
+			}
+		})
+	}
+}
+
+func TestLicenseChecker_Verify(t *testing.T) {
+	tests := []struct {
+		name         string
+		params       LicenseCheckerOptions
+		artifactType string
+		blobs        []string
+		manifestErr  error
+		expectErr    bool
+		expectFailed bool
+		expected     *LicenseCheckerDetail
+	}{
+		{
+			name:         "all licenses allowed",
+			params:       LicenseCheckerOptions{AllowedLicenses: []string{"MIT", "GPL-3.0-or-later"}, AllowUnknownLicenses: true},
+			artifactType: artifactTypeSPDX,
+			blobs:        []string{testSPDX},
+			expected:     &LicenseCheckerDetail{Format: "SPDX-2.3"},
+		},
+		{
+			name:         "disallowed and unknown licenses",
+			params:       LicenseCheckerOptions{AllowedLicenses: []string{"MIT"}},
+			artifactType: artifactTypeSPDX,
+			blobs:        []string{testSPDX},
+			expectFailed: true,
+			expected: &LicenseCheckerDetail{
+				Format: "SPDX-2.3",
+				DisallowedPackages: []DisallowedLicensePackage{
+					{Name: "readline", Version: "8.2.1-r1", License: "GPL-3.0-or-later"},
+					{Name: "unknown"},
+				},
+			},
+		},
+		{
+			name:         "CycloneDX license expressions",
+			params:       LicenseCheckerOptions{AllowedLicenses: []string{"Apache-2.0", "MIT AND Custom"}},
+			artifactType: artifactTypeCycloneDX,
+			blobs:        []string{testCycloneDX},
+			expectFailed: true,
+			expected: &LicenseCheckerDetail{
+				Format: "CycloneDX-1.5",
+				DisallowedPackages: []DisallowedLicensePackage{
+					{Name: "nested", Version: "1.0.0", License: "MIT OR GPL-3.0-only"},
+					{Name: "unlicensed", Version: "0.1.0"},
+					{Name: "custom", Version: "3.0.0", License: "(Custom License) AND (GPL-3.0-only)"},
+					{Name: "invalid", Version: "4.0.0", License: "MIT AND", Error: `invalid license expression "MIT AND": unexpected end of expression`},
+				},
+			},
+		},
+		{
+			name:         "SPDX tag-value",
+			params:       LicenseCheckerOptions{AllowedLicenses: []string{"GPL-2.0-only"}},
+			artifactType: artifactTypeSPDXTagValue,
+			blobs:        []string{testSPDXTagValue},
+			expected:     &LicenseCheckerDetail{Format: "SPDX-2.3"},
+		},
+		{
+			name:         "no blobs",
+			params:       LicenseCheckerOptions{AllowedLicenses: []string{"MIT"}},
+			artifactType: artifactTypeSPDX,
+			expectFailed: true,
+		},
+		{
+			name:         "invalid SBOM",
+			params:       LicenseCheckerOptions{AllowedLicenses: []string{"MIT"}},
+			artifactType: artifactTypeCycloneDX,
+			blobs:        []string{testSPDX},
+			expectFailed: true,
+		},
+		{
+			name:         "manifest error",
+			params:       LicenseCheckerOptions{AllowedLicenses: []string{"MIT"}},
+			artifactType: artifactTypeSPDX,
+			manifestErr:  errors.New("not found"),
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewLicenseChecker(verifier.NewOptions{Name: testName, Type: verifierTypeLicenseChecker, Parameters: tt.params}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			store, artifact := newMockStore(t, tt.blobs...)
+			store.manifestErr = tt.manifestErr
+			artifact.ArtifactType = tt.artifactType
+
+			result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+				Store:              store,
+				Repository:         testRepo,
+				ArtifactDescriptor: artifact,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if (result.Err != nil) != tt.expectFailed {
+				t.Fatalf("expected verification failure: %v, got: %v", tt.expectFailed, result.Err)
+			}
+			if tt.expected != nil && !reflect.DeepEqual(result.Detail, tt.expected) {
+				t.Fatalf("expected detail %+v, got %+v", tt.expected, result.Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+)
+
+const verifierTypeSBOM = "sbom"
+
+// Options contains the configuration options for creating a [Verifier].
+type Options struct {
+	// DisallowedLicenses is a list of SPDX license identifiers that packages
+	// must not be licensed under, e.g. "GPL-3.0-only". An identifier may be
+	// followed by "WITH <exception>" to only disallow the license with that
+	// exception; otherwise the license is disallowed with any exception.
+	// Package licenses are evaluated as SPDX license expressions, so that a
+	// package is only in violation if every choice of its licenses includes a
+	// disallowed license. Optional.
+	DisallowedLicenses []string `json:"disallowedLicenses,omitempty"`
+
+	// DisallowedPackages is a list of packages that must not be included.
+	// Optional.
+	DisallowedPackages []PackageRule `json:"disallowedPackages,omitempty"`
+}
+
+// PackageRule identifies disallowed versions of a package.
+type PackageRule struct {
+	// Name is the name of the package. Required.
+	Name string `json:"name"`
+
+	// Version is the exact version that is disallowed. If neither Version nor
+	// VersionRange is set, all versions are disallowed. Optional.
+	Version string `json:"version,omitempty"`
+
+	// VersionRange is the range of versions that are disallowed, e.g.
+	// ">=2.0.0 <2.17.1 || 3.0.0". Comparators separated by whitespace must all
+	// match, while comparator sets separated by "||" are alternatives.
+	// Versions are compared segment by segment to support the versioning
+	// schemes of different ecosystems. Optional.
+	VersionRange string `json:"versionRange,omitempty"`
+}
+
+// Detail is the detail of the verification result of a [Verifier].
+type Detail struct {
+	// Format is the SBOM format and version, e.g. "SPDX-2.3". The distinct
+	// formats of multiple SBOM blobs are comma separated.
+	Format string `json:"format"`
+
+	// LicenseViolations lists the packages with disallowed licenses.
+	LicenseViolations []LicenseViolation `json:"licenseViolations,omitempty"`
+
+	// PackageViolations lists the disallowed packages.
+	PackageViolations []PackageViolation `json:"packageViolations,omitempty"`
+}
+
+// LicenseViolation is a package licensed under disallowed licenses.
+type LicenseViolation struct {
+	// Name is the name of the package.
+	Name string `json:"name"`
+
+	// Version is the version of the package.
+	Version string `json:"version,omitempty"`
+
+	// PURL is the package URL of the package.
+	PURL string `json:"purl,omitempty"`
+
+	// License is the license expression of the package.
+	License string `json:"license"`
+
+	// DisallowedLicenses are the configured disallowed licenses that cannot
+	// be avoided under the license expression.
+	DisallowedLicenses []string `json:"disallowedLicenses"`
+
+	// Error is the reason the license could not be checked, e.g. an invalid
+	// license expression.
+	Error string `json:"error,omitempty"`
+}
+
+// PackageViolation is a disallowed package.
+type PackageViolation struct {
+	// Name is the name of the package.
+	Name string `json:"name"`
+
+	// Version is the version of the package.
+	Version string `json:"version,omitempty"`
+
+	// PURL is the package URL of the package.
+	PURL string `json:"purl,omitempty"`
+
+	// Rule is the disallowed package rule matched by the package.
+	Rule PackageRule `json:"rule"`
+}
+
+// packageMatcher is a parsed [PackageRule].
+type packageMatcher struct {
+	rule         PackageRule
+	versionRange versionRange
+}
+
+// matches reports whether the package version is disallowed by the rule.
+func (m packageMatcher) matches(version string) bool {
+	if m.rule.Version == "" && m.versionRange == nil {
+		return true
+	}
+	if m.rule.Version != "" && compareVersions(version, m.rule.Version) == 0 {
+		return true
+	}
+	return m.versionRange != nil && version != "" && m.versionRange.contains(version)
+}
+
+// Verifier implements the [ratify.Verifier] interface for SPDX JSON and
+// CycloneDX JSON SBOMs, enforcing disallowed licenses and packages.
+type Verifier struct {
+	name     string
+	licenses *licenseMatcher
+	packages map[string][]packageMatcher
+}
+
+func init() {
+	verifier.Register(verifierTypeSBOM, NewVerifier)
+}
+
+// NewVerifier creates a new SBOM verifier instance based on the provided
+// options.
+func NewVerifier(opts verifier.NewOptions, _ []string) (ratify.Verifier, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("verifier name cannot be empty")
+	}
+
+	raw, err := json.Marshal(opts.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verifier parameters: %w", err)
+	}
+	var params Options
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verifier parameters: %w", err)
+	}
+
+	licenses, err := newLicenseMatcher(params.DisallowedLicenses)
+	if err != nil {
+		return nil, err
+	}
+	packages := make(map[string][]packageMatcher)
+	for _, rule := range params.DisallowedPackages {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("disallowed package name cannot be empty")
+		}
+		matcher := packageMatcher{rule: rule}
+		if rule.VersionRange != "" {
+			if matcher.versionRange, err = parseVersionRange(rule.VersionRange); err != nil {
+				return nil, fmt.Errorf("invalid disallowed package %q: %w", rule.Name, err)
+			}
+		}
+		packages[rule.Name] = append(packages[rule.Name], matcher)
+	}
+
+	return &Verifier{
+		name:     opts.Name,
+		licenses: licenses,
+		packages: packages,
+	}, nil
+}
+
+// Name returns the name of the verifier.
+func (v *Verifier) Name() string {
+	return v.name
+}
+
+// Type returns the type of the verifier which is always "sbom".
+func (v *Verifier) Type() string {
+	return verifierTypeSBOM
+}
+
+// Verifiable checks if the artifact is an SPDX or CycloneDX JSON SBOM.
+func (v *Verifier) Verifiable(artifact ocispec.Descriptor) bool {
+	return isSBOMArtifact(artifact)
+}
+
+// Verify checks the packages of every SBOM blob of the artifact against the
+// disallowed licenses and packages.
+func (v *Verifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	layers, err := verifier.FetchArtifactLayers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ratify.VerificationResult{
+		Verifier: v,
+	}
+	if len(layers) == 0 {
+		result.Err = fmt.Errorf("no SBOM blob found in artifact %s", opts.ArtifactDescriptor.Digest)
+		return result, nil
+	}
+
+	detail := &Detail{}
+	for _, layer := range layers {
+		content, err := opts.Store.FetchBlob(ctx, opts.Repository, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch SBOM blob %s: %w", layer.Digest, err)
+		}
+		doc, err := parseDocument(opts.ArtifactDescriptor.ArtifactType, content)
+		if err != nil {
+			result.Err = err
+			return result, nil
+		}
+		detail.Format = appendFormat(detail.Format, doc.format)
+		v.checkPackages(doc, detail)
+	}
+
+	result.Detail = detail
+	if len(detail.LicenseViolations) > 0 || len(detail.PackageViolations) > 0 {
+		result.Err = fmt.Errorf("SBOM validation failed: found %d license violation(s) and %d package violation(s)", len(detail.LicenseViolations), len(detail.PackageViolations))
+		return result, nil
+	}
+	result.Description = "SBOM verification succeeded. No license or package violation found."
+	return result, nil
+}
+
+// checkPackages adds the violations of the packages of the document to the
+// detail.
+func (v *Verifier) checkPackages(doc *document, detail *Detail) {
+	for _, pkg := range doc.packages {
+		if pkg.licenseErr != nil && len(v.licenses.disallowed) > 0 {
+			// A license that cannot be parsed may hide disallowed licenses.
+			detail.LicenseViolations = append(detail.LicenseViolations, LicenseViolation{
+				Name:    pkg.name,
+				Version: pkg.version,
+				PURL:    pkg.purl,
+				License: pkg.license,
+				Error:   pkg.licenseErr.Error(),
+			})
+		} else if pkg.licenseExpr != nil {
+			if disallowed := v.licenses.violations(pkg.licenseExpr); len(disallowed) > 0 {
+				detail.LicenseViolations = append(detail.LicenseViolations, LicenseViolation{
+					Name:               pkg.name,
+					Version:            pkg.version,
+					PURL:               pkg.purl,
+					License:            pkg.license,
+					DisallowedLicenses: disallowed,
+				})
+			}
+		}
+		for _, matcher := range v.packages[pkg.name] {
+			if matcher.matches(pkg.version) {
+				detail.PackageViolations = append(detail.PackageViolations, PackageViolation{
+					Name:    pkg.name,
+					Version: pkg.version,
+					PURL:    pkg.purl,
+					Rule:    matcher.rule,
+				})
+				break
+			}
+		}
+	}
+}
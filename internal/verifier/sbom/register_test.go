@@ -0,0 +1,372 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+)
+
+const (
+	testName = "sbom-1"
+	testRepo = "registry.example.com/app"
+)
+
+// mockStore serves a single SBOM artifact.
+type mockStore struct {
+	manifest    []byte
+	blobs       map[digest.Digest][]byte
+	manifestErr error
+}
+
+func newMockStore(t *testing.T, blobs ...string) (*mockStore, ocispec.Descriptor) {
+	t.Helper()
+	store := &mockStore{blobs: make(map[digest.Digest][]byte)}
+	manifest := ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest}
+	for _, blob := range blobs {
+		desc := ocispec.Descriptor{
+			MediaType: "application/json",
+			Digest:    digest.FromString(blob),
+			Size:      int64(len(blob)),
+		}
+		store.blobs[desc.Digest] = []byte(blob)
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	store.manifest = manifestBytes
+	return store, ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifestBytes),
+		Size:      int64(len(manifestBytes)),
+	}
+}
+
+func (m *mockStore) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, nil
+}
+
+func (m *mockStore) ListReferrers(_ context.Context, _ string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	return nil
+}
+
+func (m *mockStore) FetchBlob(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	blob, ok := m.blobs[desc.Digest]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return blob, nil
+}
+
+func (m *mockStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	if m.manifestErr != nil {
+		return nil, m.manifestErr
+	}
+	return m.manifest, nil
+}
+
+func TestNewVerifier(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      verifier.NewOptions
+		expectErr bool
+	}{
+		{
+			name:      "empty name",
+			opts:      verifier.NewOptions{Type: verifierTypeSBOM},
+			expectErr: true,
+		},
+		{
+			name: "invalid parameters",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeSBOM,
+				Parameters: map[string]any{"disallowedLicenses": "MIT"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid disallowed license",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeSBOM,
+				Parameters: Options{DisallowedLicenses: []string{"MIT OR"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "empty disallowed package name",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeSBOM,
+				Parameters: Options{DisallowedPackages: []PackageRule{{Version: "1.0.0"}}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid version range",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeSBOM,
+				Parameters: Options{DisallowedPackages: []PackageRule{{Name: "log4j-core", VersionRange: "<"}}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no parameters",
+			opts: verifier.NewOptions{Name: testName, Type: verifierTypeSBOM},
+		},
+		{
+			name: "valid parameters",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Type: verifierTypeSBOM,
+				Parameters: map[string]any{
+					"disallowedLicenses": []string{"GPL-3.0-only"},
+					"disallowedPackages": []map[string]string{{"name": "log4j-core", "versionRange": ">=2.0.0 <2.17.1"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := verifier.New(tt.opts, nil)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if v.Name() != testName || v.Type() != verifierTypeSBOM {
+				t.Fatalf("unexpected verifier name %s or type %s", v.Name(), v.Type())
+			}
+		})
+	}
+}
+
+func TestVerifiable(t *testing.T) {
+	v, err := NewVerifier(verifier.NewOptions{Name: testName, Type: verifierTypeSBOM}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		artifact ocispec.Descriptor
+		expected bool
+	}{
+		{
+			name:     "SPDX",
+			artifact: ocispec.Descriptor{ArtifactType: artifactTypeSPDX, MediaType: ocispec.MediaTypeImageManifest},
+			expected: true,
+		},
+		{
+			name:     "SPDX tag-value",
+			artifact: ocispec.Descriptor{ArtifactType: artifactTypeSPDXTagValue, MediaType: ocispec.MediaTypeImageManifest},
+			expected: true,
+		},
+		{
+			name:     "CycloneDX",
+			artifact: ocispec.Descriptor{ArtifactType: artifactTypeCycloneDX, MediaType: ocispec.MediaTypeImageManifest},
+			expected: true,
+		},
+		{
+			name:     "other artifact type",
+			artifact: ocispec.Descriptor{ArtifactType: "application/vnd.cncf.notary.signature", MediaType: ocispec.MediaTypeImageManifest},
+		},
+		{
+			name:     "other media type",
+			artifact: ocispec.Descriptor{ArtifactType: artifactTypeSPDX, MediaType: ocispec.MediaTypeImageIndex},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.Verifiable(tt.artifact); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	params := Options{
+		DisallowedLicenses: []string{"GPL-3.0-only", "GPL-3.0-or-later"},
+		DisallowedPackages: []PackageRule{
+			{Name: "log4j-core", VersionRange: ">=2.0.0 <2.17.1"},
+			{Name: "musl", Version: "1.2.3-r0"},
+			{Name: "unlicensed"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		params       Options
+		artifactType string
+		blobs        []string
+		manifestErr  error
+		expectErr    bool
+		expectFailed bool
+		expected     *Detail
+	}{
+		{
+			name:         "SPDX without violations",
+			params:       Options{DisallowedLicenses: []string{"Apache-2.0"}, DisallowedPackages: []PackageRule{{Name: "musl", VersionRange: "<1.2.4"}}},
+			artifactType: artifactTypeSPDX,
+			blobs:        []string{testSPDX},
+			expected:     &Detail{Format: "SPDX-2.3"},
+		},
+		{
+			name:         "SPDX license violation",
+			params:       params,
+			artifactType: artifactTypeSPDX,
+			blobs:        []string{testSPDX},
+			expectFailed: true,
+			expected: &Detail{
+				Format: "SPDX-2.3",
+				LicenseViolations: []LicenseViolation{
+					{Name: "readline", Version: "8.2.1-r1", License: "GPL-3.0-or-later", DisallowedLicenses: []string{"GPL-3.0-or-later"}},
+				},
+			},
+		},
+		{
+			name:         "CycloneDX license and package violations",
+			params:       params,
+			artifactType: artifactTypeCycloneDX,
+			blobs:        []string{testCycloneDX},
+			expectFailed: true,
+			expected: &Detail{
+				Format: "CycloneDX-1.5",
+				LicenseViolations: []LicenseViolation{
+					{
+						Name:               "custom",
+						Version:            "3.0.0",
+						License:            "(Custom License) AND (GPL-3.0-only)",
+						DisallowedLicenses: []string{"GPL-3.0-only"},
+					},
+					{
+						Name:    "invalid",
+						Version: "4.0.0",
+						License: "MIT AND",
+						Error:   `invalid license expression "MIT AND": unexpected end of expression`,
+					},
+				},
+				PackageViolations: []PackageViolation{
+					{
+						Name:    "log4j-core",
+						Version: "2.14.1",
+						PURL:    "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+						Rule:    PackageRule{Name: "log4j-core", VersionRange: ">=2.0.0 <2.17.1"},
+					},
+					{
+						Name:    "unlicensed",
+						Version: "0.1.0",
+						Rule:    PackageRule{Name: "unlicensed"},
+					},
+				},
+			},
+		},
+		{
+			name:         "multiple blobs",
+			params:       params,
+			artifactType: artifactTypeSPDX,
+			blobs:        []string{testSPDX, testSPDX},
+			expectFailed: true,
+			expected: &Detail{
+				Format: "SPDX-2.3",
+				LicenseViolations: []LicenseViolation{
+					{Name: "readline", Version: "8.2.1-r1", License: "GPL-3.0-or-later", DisallowedLicenses: []string{"GPL-3.0-or-later"}},
+					{Name: "readline", Version: "8.2.1-r1", License: "GPL-3.0-or-later", DisallowedLicenses: []string{"GPL-3.0-or-later"}},
+				},
+			},
+		},
+		{
+			name:         "multiple blobs with different formats",
+			params:       params,
+			artifactType: artifactTypeCycloneDX,
+			blobs: []string{
+				`{"bomFormat":"CycloneDX","specVersion":"1.4","components":[{"name":"zlib","version":"1.3"}]}`,
+				`{"bomFormat":"CycloneDX","specVersion":"1.5","components":[{"name":"musl","version":"1.2.4"}]}`,
+			},
+			expected: &Detail{Format: "CycloneDX-1.4, CycloneDX-1.5"},
+		},
+		{
+			name:         "no blobs",
+			params:       params,
+			artifactType: artifactTypeSPDX,
+			expectFailed: true,
+		},
+		{
+			name:         "invalid SBOM",
+			params:       params,
+			artifactType: artifactTypeCycloneDX,
+			blobs:        []string{testSPDX},
+			expectFailed: true,
+		},
+		{
+			name:         "manifest error",
+			params:       params,
+			artifactType: artifactTypeSPDX,
+			manifestErr:  errors.New("not found"),
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(verifier.NewOptions{Name: testName, Type: verifierTypeSBOM, Parameters: tt.params}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			store, artifact := newMockStore(t, tt.blobs...)
+			store.manifestErr = tt.manifestErr
+			artifact.ArtifactType = tt.artifactType
+
+			result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+				Store:              store,
+				Repository:         testRepo,
+				ArtifactDescriptor: artifact,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if (result.Err != nil) != tt.expectFailed {
+				t.Fatalf("expected verification failure: %v, got: %v", tt.expectFailed, result.Err)
+			}
+			if result.Verifier != v {
+				t.Fatal("expected result to reference the verifier")
+			}
+			if tt.expected != nil && !reflect.DeepEqual(result.Detail, tt.expected) {
+				t.Fatalf("expected detail %+v, got %+v", tt.expected, result.Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// versionRange is a parsed range of package versions. It matches a version if
+// any of its comparator sets matches.
+type versionRange [][]versionComparator
+
+// versionComparator compares a version against a bound.
+type versionComparator struct {
+	operator string
+	version  string
+}
+
+// versionOperators are the supported comparison operators, longest first so
+// that prefixes are matched correctly.
+var versionOperators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// parseVersionRange parses a version range, e.g. ">=2.0.0 <2.17.1 || 3.0.0".
+// Comparators separated by whitespace must all match, while comparator sets
+// separated by "||" are alternatives. A version without operator must match
+// exactly, and "*" matches any version.
+func parseVersionRange(s string) (versionRange, error) {
+	var r versionRange
+	for _, set := range strings.Split(s, "||") {
+		fields := strings.Fields(set)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid version range %q: empty comparator set", s)
+		}
+		var comparators []versionComparator
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+			if field == "*" {
+				comparators = append(comparators, versionComparator{operator: "*"})
+				continue
+			}
+			operator := "="
+			for _, op := range versionOperators {
+				if strings.HasPrefix(field, op) {
+					operator = op
+					field = strings.TrimPrefix(field, op)
+					break
+				}
+			}
+			if operator == "==" {
+				operator = "="
+			}
+			// Allow whitespace between the operator and the version.
+			if field == "" && i+1 < len(fields) {
+				i++
+				field = fields[i]
+			}
+			if field == "" {
+				return nil, fmt.Errorf("invalid version range %q: missing version after %q", s, operator)
+			}
+			comparators = append(comparators, versionComparator{operator: operator, version: field})
+		}
+		r = append(r, comparators)
+	}
+	return r, nil
+}
+
+// contains reports whether the version is within the range.
+func (r versionRange) contains(version string) bool {
+	for _, set := range r {
+		matched := true
+		for _, c := range set {
+			if !c.matches(version) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c versionComparator) matches(version string) bool {
+	if c.operator == "*" {
+		return true
+	}
+	cmp := compareVersions(version, c.version)
+	switch c.operator {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// compareVersions compares two package versions and returns -1, 0 or 1. As
+// SBOMs contain versions of many ecosystems (e.g. "1.2.3", "v1.2.3",
+// "3.4.0-r0" or "2.36-9+deb12u4"), versions are compared segment by segment
+// instead of following a specific versioning scheme. A segment is a run of
+// digits, compared numerically, or a run of letters, compared
+// lexicographically; other characters only separate segments. A leading "v"
+// is ignored and missing numeric segments count as zero, so that "1.0" equals
+// "1.0.0". Otherwise a version with additional segments is greater, unless
+// they start with a pre-release tag, so that "2.17.1-rc1" < "2.17.1" while
+// "3.4.0-r1" > "3.4.0".
+func compareVersions(a, b string) int {
+	segmentsA := versionSegments(a)
+	segmentsB := versionSegments(b)
+	for i := 0; i < len(segmentsA) && i < len(segmentsB); i++ {
+		if cmp := compareVersionSegments(segmentsA[i], segmentsB[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	switch {
+	case len(segmentsA) < len(segmentsB):
+		return -compareExtraSegments(segmentsB[len(segmentsA):])
+	case len(segmentsA) > len(segmentsB):
+		return compareExtraSegments(segmentsA[len(segmentsB):])
+	}
+	return 0
+}
+
+// compareExtraSegments compares a version with the given additional segments
+// to the version without them. Zero segments are ignored.
+func compareExtraSegments(extra []string) int {
+	for _, segment := range extra {
+		if strings.Trim(segment, "0") == "" {
+			continue
+		}
+		if preReleaseTags[segment] {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+// preReleaseTags are the segments marking a version that precedes the release
+// it is a suffix of.
+var preReleaseTags = map[string]bool{
+	"alpha":     true,
+	"beta":      true,
+	"dev":       true,
+	"milestone": true,
+	"pre":       true,
+	"preview":   true,
+	"rc":        true,
+	"snapshot":  true,
+}
+
+// versionSegments splits a version into runs of digits and runs of letters.
+func versionSegments(version string) []string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		version = version[1:]
+	}
+	var segments []string
+	start := -1
+	startDigit := false
+	for i, r := range version {
+		isDigit := r >= '0' && r <= '9'
+		isLetter := unicode.IsLetter(r)
+		if start >= 0 {
+			if (isDigit && startDigit) || (isLetter && !startDigit) {
+				continue
+			}
+			segments = append(segments, version[start:i])
+			start = -1
+		}
+		if isDigit || isLetter {
+			start = i
+			startDigit = isDigit
+		}
+	}
+	if start >= 0 {
+		segments = append(segments, version[start:])
+	}
+	return segments
+}
+
+// compareVersionSegments compares two segments. Numeric segments are greater
+// than alphabetic ones, so that "1.0.1" > "1.0.beta".
+func compareVersionSegments(a, b string) int {
+	aNumeric := a[0] >= '0' && a[0] <= '9'
+	bNumeric := b[0] >= '0' && b[0] <= '9'
+	switch {
+	case aNumeric && !bNumeric:
+		return 1
+	case !aNumeric && bNumeric:
+		return -1
+	case aNumeric:
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.2.3", b: "1.2.3", expected: 0},
+		{a: "v1.2.3", b: "1.2.3", expected: 0},
+		{a: "1.2.3", b: "1.2.10", expected: -1},
+		{a: "1.10.0", b: "1.9.9", expected: 1},
+		{a: "1.2", b: "1.2.1", expected: -1},
+		{a: "1.0", b: "1.0.0", expected: 0},
+		{a: "1", b: "1.0.0.0", expected: 0},
+		{a: "1.0", b: "1.0.0.1", expected: -1},
+		{a: "1.0", b: "1.0.0-rc1", expected: 1},
+		{a: "1.0", b: "1.0.0-r1", expected: -1},
+		{a: "01.2", b: "1.2", expected: 0},
+		{a: "3.4.0-r1", b: "3.4.0-r0", expected: 1},
+		{a: "2.36-9+deb12u4", b: "2.36-9+deb12u10", expected: -1},
+		{a: "1.0.beta", b: "1.0.1", expected: -1},
+		{a: "1.0.0-alpha", b: "1.0.0-beta", expected: -1},
+		{a: "1.0.0rc1", b: "1.0.0rc2", expected: -1},
+		{a: "2.17.1-rc1", b: "2.17.1", expected: -1},
+		{a: "2.17.1-beta", b: "2.17.1", expected: -1},
+		{a: "1.0.0-SNAPSHOT", b: "1.0.0", expected: -1},
+		{a: "2.17.1-rc1", b: "2.17.0", expected: 1},
+		{a: "3.4.0-r0", b: "3.4.0", expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := compareVersions(tt.a, tt.b); got != tt.expected {
+				t.Fatalf("compareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+			}
+			if got := compareVersions(tt.b, tt.a); got != -tt.expected {
+				t.Fatalf("compareVersions(%q, %q) = %d, expected %d", tt.b, tt.a, got, -tt.expected)
+			}
+		})
+	}
+}
+
+func TestVersionRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		r         string
+		matches   []string
+		misses    []string
+		expectErr bool
+	}{
+		{
+			name:    "exact version",
+			r:       "1.2.3",
+			matches: []string{"1.2.3", "v1.2.3", "1.2.3.0"},
+			misses:  []string{"1.2.4"},
+		},
+		{
+			name:    "bounded range",
+			r:       ">=2.0.0 <2.17.1",
+			matches: []string{"2.0.0", "2.14.1", "2.17.0", "2.17.1-rc1"},
+			misses:  []string{"1.9.9", "2.17.1", "2.20.0"},
+		},
+		{
+			name:    "alternatives",
+			r:       "<1.0.0 || =3.0.0 || > 4",
+			matches: []string{"0.9.0", "3.0.0", "4.1"},
+			misses:  []string{"1.0.0", "3.0.1", "4"},
+		},
+		{
+			name:    "not equal",
+			r:       "!=1.0.0",
+			matches: []string{"1.0.1"},
+			misses:  []string{"1.0.0"},
+		},
+		{
+			name:    "wildcard",
+			r:       "*",
+			matches: []string{"1.0.0", "anything"},
+		},
+		{
+			name:    "distribution versions",
+			r:       "<=3.0.8-r0",
+			matches: []string{"3.0.7-r3", "3.0.8-r0"},
+			misses:  []string{"3.0.8-r1", "3.1.0-r0"},
+		},
+		{
+			name:      "empty comparator set",
+			r:         ">=1.0.0 ||",
+			expectErr: true,
+		},
+		{
+			name:      "missing version",
+			r:         ">=",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseVersionRange(tt.r)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			for _, version := range tt.matches {
+				if !r.contains(version) {
+					t.Errorf("expected %q to contain %q", tt.r, version)
+				}
+			}
+			for _, version := range tt.misses {
+				if r.contains(version) {
+					t.Errorf("expected %q not to contain %q", tt.r, version)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemavalidator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+)
+
+const verifierTypeSchemaValidator = "schemavalidator"
+
+// Options contains the configuration options for creating a [Verifier].
+type Options struct {
+	// ArtifactTypes is the list of artifact types validated by the verifier.
+	// Required.
+	ArtifactTypes []string `json:"artifactTypes"`
+
+	// Schemas maps blob media types to their JSON schemas. A schema is either
+	// a URL string or a [SchemaSource]. Blobs with other media types fail the
+	// validation. Required.
+	Schemas map[string]SchemaSource `json:"schemas"`
+}
+
+// Verifier implements the [ratify.Verifier] interface validating the blobs of
+// JSON artifacts against JSON schemas.
+type Verifier struct {
+	name          string
+	artifactTypes []string
+	schemas       map[string]*gojsonschema.Schema
+}
+
+func init() {
+	verifier.Register(verifierTypeSchemaValidator, NewVerifier)
+}
+
+// NewVerifier creates a new schema validator instance based on the provided
+// options. All schemas are compiled once here.
+func NewVerifier(opts verifier.NewOptions, _ []string) (ratify.Verifier, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("verifier name cannot be empty")
+	}
+
+	raw, err := json.Marshal(opts.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verifier parameters: %w", err)
+	}
+	var params Options
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verifier parameters: %w", err)
+	}
+	if len(params.ArtifactTypes) == 0 {
+		return nil, fmt.Errorf("artifactTypes cannot be empty")
+	}
+	if len(params.Schemas) == 0 {
+		return nil, fmt.Errorf("schemas cannot be empty")
+	}
+
+	v := &Verifier{
+		name:          opts.Name,
+		artifactTypes: params.ArtifactTypes,
+		schemas:       make(map[string]*gojsonschema.Schema, len(params.Schemas)),
+	}
+	for mediaType, source := range params.Schemas {
+		schema, err := source.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schema for media type %s: %w", mediaType, err)
+		}
+		v.schemas[mediaType] = schema
+	}
+	return v, nil
+}
+
+// Name returns the name of the verifier.
+func (v *Verifier) Name() string {
+	return v.name
+}
+
+// Type returns the type of the verifier which is always "schemavalidator".
+func (v *Verifier) Type() string {
+	return verifierTypeSchemaValidator
+}
+
+// Verifiable checks if the artifact type is one of the configured artifact
+// types.
+func (v *Verifier) Verifiable(artifact ocispec.Descriptor) bool {
+	return slices.Contains(v.artifactTypes, artifact.ArtifactType) && artifact.MediaType == ocispec.MediaTypeImageManifest
+}
+
+// Verify validates all blobs of the artifact against the schemas of their
+// media types.
+func (v *Verifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	result := &ratify.VerificationResult{
+		Verifier: v,
+	}
+	layers, err := verifier.FetchArtifactLayers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		result.Err = fmt.Errorf("no blobs found in artifact %s", opts.ArtifactDescriptor.Digest)
+		return result, nil
+	}
+
+	for _, layer := range layers {
+		schema, ok := v.schemas[layer.MediaType]
+		if !ok {
+			result.Err = fmt.Errorf("no schema configured for media type %s of blob %s", layer.MediaType, layer.Digest)
+			return result, nil
+		}
+		content, err := opts.Store.FetchBlob(ctx, opts.Repository, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch blob %s: %w", layer.Digest, err)
+		}
+		if err := Validate(schema, content); err != nil {
+			result.Err = fmt.Errorf("schema validation failed for blob %s with media type %s: %w", layer.Digest, layer.MediaType, err)
+			return result, nil
+		}
+	}
+	result.Description = "Schema validation succeeded"
+	return result, nil
+}
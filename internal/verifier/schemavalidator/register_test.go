@@ -0,0 +1,293 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemavalidator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+)
+
+const (
+	testName         = "schemavalidator-1"
+	testRepo         = "registry.example.com/app"
+	testArtifactType = "application/vnd.example.report"
+	testMediaType    = "application/vnd.example.report.v1+json"
+)
+
+// mockStore serves a single artifact.
+type mockStore struct {
+	manifest    []byte
+	blobs       map[digest.Digest][]byte
+	manifestErr error
+}
+
+func newMockStore(t *testing.T, mediaType string, blobs ...string) (*mockStore, ocispec.Descriptor) {
+	t.Helper()
+	store := &mockStore{blobs: make(map[digest.Digest][]byte)}
+	manifest := ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest}
+	for _, blob := range blobs {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromString(blob),
+			Size:      int64(len(blob)),
+		}
+		store.blobs[desc.Digest] = []byte(blob)
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	store.manifest = manifestBytes
+	return store, ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: testArtifactType,
+		Digest:       digest.FromBytes(manifestBytes),
+		Size:         int64(len(manifestBytes)),
+	}
+}
+
+func (m *mockStore) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, nil
+}
+
+func (m *mockStore) ListReferrers(_ context.Context, _ string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	return nil
+}
+
+func (m *mockStore) FetchBlob(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	blob, ok := m.blobs[desc.Digest]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return blob, nil
+}
+
+func (m *mockStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	if m.manifestErr != nil {
+		return nil, m.manifestErr
+	}
+	return m.manifest, nil
+}
+
+func TestNewVerifier(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      verifier.NewOptions
+		expectErr bool
+	}{
+		{
+			name:      "empty name",
+			opts:      verifier.NewOptions{Type: verifierTypeSchemaValidator},
+			expectErr: true,
+		},
+		{
+			name: "invalid parameters",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeSchemaValidator,
+				Parameters: map[string]any{"schemas": "schema.json"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no artifact types",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeSchemaValidator,
+				Parameters: Options{Schemas: map[string]SchemaSource{testMediaType: {Inline: json.RawMessage(testSchema)}}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no schemas",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeSchemaValidator,
+				Parameters: Options{ArtifactTypes: []string{testArtifactType}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid schema",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Type: verifierTypeSchemaValidator,
+				Parameters: Options{
+					ArtifactTypes: []string{testArtifactType},
+					Schemas:       map[string]SchemaSource{testMediaType: {Embedded: "unknown"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "valid parameters",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Type: verifierTypeSchemaValidator,
+				Parameters: map[string]any{
+					"artifactTypes": []string{testArtifactType},
+					"schemas": map[string]any{
+						testMediaType:            map[string]any{"inline": json.RawMessage(testSchema)},
+						"application/sarif+json": map[string]string{"embedded": EmbeddedSARIF},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := verifier.New(tt.opts, nil)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if v.Name() != testName || v.Type() != verifierTypeSchemaValidator {
+				t.Fatalf("unexpected verifier name %s or type %s", v.Name(), v.Type())
+			}
+		})
+	}
+}
+
+func TestVerifiable(t *testing.T) {
+	v, err := NewVerifier(verifier.NewOptions{
+		Name: testName,
+		Type: verifierTypeSchemaValidator,
+		Parameters: Options{
+			ArtifactTypes: []string{testArtifactType},
+			Schemas:       map[string]SchemaSource{testMediaType: {Inline: json.RawMessage(testSchema)}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		artifact ocispec.Descriptor
+		expected bool
+	}{
+		{
+			name:     "configured artifact type",
+			artifact: ocispec.Descriptor{ArtifactType: testArtifactType, MediaType: ocispec.MediaTypeImageManifest},
+			expected: true,
+		},
+		{
+			name:     "other artifact type",
+			artifact: ocispec.Descriptor{ArtifactType: "application/spdx+json", MediaType: ocispec.MediaTypeImageManifest},
+		},
+		{
+			name:     "other media type",
+			artifact: ocispec.Descriptor{ArtifactType: testArtifactType, MediaType: ocispec.MediaTypeImageIndex},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.Verifiable(tt.artifact); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	v, err := NewVerifier(verifier.NewOptions{
+		Name: testName,
+		Type: verifierTypeSchemaValidator,
+		Parameters: Options{
+			ArtifactTypes: []string{testArtifactType},
+			Schemas:       map[string]SchemaSource{testMediaType: {Inline: json.RawMessage(testSchema)}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		mediaType    string
+		blobs        []string
+		manifestErr  error
+		expectErr    bool
+		expectFailed bool
+	}{
+		{
+			name:      "valid blobs",
+			mediaType: testMediaType,
+			blobs:     []string{`{"name": "a"}`, `{"name": "b"}`},
+		},
+		{
+			name:         "invalid blob",
+			mediaType:    testMediaType,
+			blobs:        []string{`{"name": "a"}`, `{"name": 1}`},
+			expectFailed: true,
+		},
+		{
+			name:         "unconfigured media type",
+			mediaType:    "application/json",
+			blobs:        []string{`{"name": "a"}`},
+			expectFailed: true,
+		},
+		{
+			name:         "no blobs",
+			mediaType:    testMediaType,
+			expectFailed: true,
+		},
+		{
+			name:        "manifest error",
+			mediaType:   testMediaType,
+			manifestErr: errors.New("not found"),
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, artifact := newMockStore(t, tt.mediaType, tt.blobs...)
+			store.manifestErr = tt.manifestErr
+
+			result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+				Store:              store,
+				Repository:         testRepo,
+				ArtifactDescriptor: artifact,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if (result.Err != nil) != tt.expectFailed {
+				t.Fatalf("expected verification failure: %v, got: %v", tt.expectFailed, result.Err)
+			}
+			if result.Verifier != v {
+				t.Fatal("expected result to reference the verifier")
+			}
+		})
+	}
+}
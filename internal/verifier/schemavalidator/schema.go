@@ -0,0 +1,133 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemavalidator
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// EmbeddedSARIF is the name of the embedded SARIF 2.1.0 schema.
+const EmbeddedSARIF = "sarif-2.1.0"
+
+//go:embed schemas
+var embeddedSchemas embed.FS
+
+// SchemaSource is the source of a JSON schema. Exactly one of the fields must
+// be set. A plain string is decoded as a URL for compatibility with the
+// schema map of the v1 plugin.
+type SchemaSource struct {
+	// Inline is the schema document, either as a JSON object or as a JSON
+	// encoded string. Optional.
+	Inline json.RawMessage `json:"inline,omitempty"`
+
+	// File is the path to a schema file. Optional.
+	File string `json:"file,omitempty"`
+
+	// URL is the URL of the schema, e.g. "https://" or "file://". Optional.
+	URL string `json:"url,omitempty"`
+
+	// Embedded is the name of a schema embedded in Ratify, e.g.
+	// "sarif-2.1.0". Optional.
+	Embedded string `json:"embedded,omitempty"`
+}
+
+// UnmarshalJSON decodes a plain string as the URL of the schema.
+func (s *SchemaSource) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*s = SchemaSource{URL: url}
+		return nil
+	}
+	type plain SchemaSource
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// Compile loads and compiles the schema. Compiled schemas are safe for
+// concurrent use, so that they are compiled once and reused for every
+// validation.
+func (s SchemaSource) Compile() (*gojsonschema.Schema, error) {
+	loader, err := s.loader()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := gojsonschema.NewSchema(loader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile JSON schema: %w", err)
+	}
+	return schema, nil
+}
+
+func (s SchemaSource) loader() (gojsonschema.JSONLoader, error) {
+	var sources int
+	for _, set := range []bool{len(s.Inline) > 0, s.File != "", s.URL != "", s.Embedded != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, fmt.Errorf("exactly one of inline, file, url and embedded must be set for a schema")
+	}
+
+	switch {
+	case len(s.Inline) > 0:
+		content := []byte(s.Inline)
+		if bytes.HasPrefix(bytes.TrimSpace(content), []byte(`"`)) {
+			var inline string
+			if err := json.Unmarshal(content, &inline); err != nil {
+				return nil, fmt.Errorf("failed to decode inline schema: %w", err)
+			}
+			content = []byte(inline)
+		}
+		return gojsonschema.NewBytesLoader(content), nil
+	case s.File != "":
+		content, err := os.ReadFile(s.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+		return gojsonschema.NewBytesLoader(content), nil
+	case s.URL != "":
+		return gojsonschema.NewReferenceLoader(s.URL), nil
+	}
+	content, err := embeddedSchemas.ReadFile("schemas/" + s.Embedded + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("embedded schema %q not found", s.Embedded)
+	}
+	return gojsonschema.NewBytesLoader(content), nil
+}
+
+// Validate validates the JSON content against the compiled schema. All schema
+// violations are joined into the returned error.
+func Validate(schema *gojsonschema.Schema, content []byte) error {
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(content))
+	if err != nil {
+		return fmt.Errorf("failed to validate against JSON schema: %w", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+	var errs []error
+	for _, desc := range result.Errors() {
+		errs = append(errs, errors.New(desc.String()))
+	}
+	return fmt.Errorf("content does not match JSON schema: %w", errors.Join(errs...))
+}
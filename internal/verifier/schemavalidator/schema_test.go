@@ -0,0 +1,138 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemavalidator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "name": {"type": "string"}
+  }
+}`
+
+func TestSchemaSource_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		expectErr bool
+		expected  SchemaSource
+	}{
+		{
+			name:     "URL string",
+			data:     `"https://json.schemastore.org/sarif-2.1.0-rtm.5.json"`,
+			expected: SchemaSource{URL: "https://json.schemastore.org/sarif-2.1.0-rtm.5.json"},
+		},
+		{
+			name:     "object",
+			data:     `{"file":"/schemas/report.json"}`,
+			expected: SchemaSource{File: "/schemas/report.json"},
+		},
+		{
+			name:      "invalid",
+			data:      `1`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var source SchemaSource
+			err := json.Unmarshal([]byte(tt.data), &source)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && !reflect.DeepEqual(source, tt.expected) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, source)
+			}
+		})
+	}
+}
+
+func TestSchemaSource_Compile(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(schemaFile, []byte(testSchema), 0600); err != nil {
+		t.Fatalf("failed to write schema file: %v", err)
+	}
+	inlineString, err := json.Marshal(testSchema)
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		source    SchemaSource
+		expectErr bool
+	}{
+		{name: "inline object", source: SchemaSource{Inline: json.RawMessage(testSchema)}},
+		{name: "inline string", source: SchemaSource{Inline: inlineString}},
+		{name: "file", source: SchemaSource{File: schemaFile}},
+		{name: "file URL", source: SchemaSource{URL: "file://" + schemaFile}},
+		{name: "embedded", source: SchemaSource{Embedded: EmbeddedSARIF}},
+		{name: "unknown embedded schema", source: SchemaSource{Embedded: "unknown"}, expectErr: true},
+		{name: "missing file", source: SchemaSource{File: filepath.Join(dir, "missing.json")}, expectErr: true},
+		{name: "invalid schema", source: SchemaSource{Inline: json.RawMessage(`{"type": 1}`)}, expectErr: true},
+		{name: "no source", source: SchemaSource{}, expectErr: true},
+		{name: "multiple sources", source: SchemaSource{File: schemaFile, Embedded: EmbeddedSARIF}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := tt.source.Compile()
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err == nil && schema == nil {
+				t.Fatal("expected compiled schema")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := SchemaSource{Inline: json.RawMessage(testSchema)}.Compile()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		expectErr bool
+	}{
+		{name: "valid", content: `{"name": "report"}`},
+		{name: "schema violation", content: `{"name": 1}`, expectErr: true},
+		{name: "missing property", content: `{}`, expectErr: true},
+		{name: "invalid JSON", content: `not json`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(schema, []byte(tt.content)); (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slsa
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	predicateTypeV02 = "https://slsa.dev/provenance/v0.2"
+	predicateTypeV1  = "https://slsa.dev/provenance/v1"
+)
+
+// provenance is the version independent view of a SLSA provenance predicate.
+type provenance struct {
+	builderID string
+	buildType string
+	// sources are the primary sources of the build. Resolved dependencies
+	// are not sources, as any repository fetched during the build is listed
+	// there.
+	sources   []source
+	materials []resourceDescriptor
+}
+
+// source is a source repository the artifact was built from.
+type source struct {
+	uri string
+	ref string
+}
+
+// String returns the source in the "uri@ref" form.
+func (s source) String() string {
+	if s.ref == "" {
+		return s.uri
+	}
+	return s.uri + "@" + s.ref
+}
+
+// resourceDescriptor is a material of a v0.2 predicate or a resolved
+// dependency of a v1 predicate.
+type resourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// provenanceV02 is the subset of a SLSA v0.2 provenance predicate used for
+// verification.
+// See https://slsa.dev/spec/v0.2/provenance
+type provenanceV02 struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType  string `json:"buildType"`
+	Invocation struct {
+		ConfigSource struct {
+			URI string `json:"uri"`
+		} `json:"configSource"`
+	} `json:"invocation"`
+	Materials []resourceDescriptor `json:"materials"`
+}
+
+// provenanceV1 is the subset of a SLSA v1 provenance predicate used for
+// verification.
+// See https://slsa.dev/spec/v1.0/provenance
+type provenanceV1 struct {
+	BuildDefinition struct {
+		BuildType          string `json:"buildType"`
+		ExternalParameters struct {
+			// Source is set by builders taking a source URI, e.g.
+			// "git+https://github.com/org/repo@refs/heads/main".
+			Source json.RawMessage `json:"source"`
+
+			// Workflow is set by GitHub Actions builders.
+			Workflow struct {
+				Repository string `json:"repository"`
+				Ref        string `json:"ref"`
+			} `json:"workflow"`
+		} `json:"externalParameters"`
+		ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+	} `json:"runDetails"`
+}
+
+// isProvenance checks if the predicate type is a supported SLSA provenance.
+func isProvenance(predicateType string) bool {
+	return predicateType == predicateTypeV02 || predicateType == predicateTypeV1
+}
+
+// parseProvenance parses a SLSA v0.2 or v1 provenance predicate.
+func parseProvenance(predicateType string, predicate []byte) (*provenance, error) {
+	switch predicateType {
+	case predicateTypeV02:
+		var p provenanceV02
+		if err := json.Unmarshal(predicate, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse SLSA v0.2 provenance: %w", err)
+		}
+		prov := &provenance{
+			builderID: p.Builder.ID,
+			buildType: p.BuildType,
+			materials: p.Materials,
+		}
+		if p.Invocation.ConfigSource.URI != "" {
+			prov.sources = append(prov.sources, parseSource(p.Invocation.ConfigSource.URI))
+		}
+		return prov, nil
+	case predicateTypeV1:
+		var p provenanceV1
+		if err := json.Unmarshal(predicate, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse SLSA v1 provenance: %w", err)
+		}
+		prov := &provenance{
+			builderID: p.RunDetails.Builder.ID,
+			buildType: p.BuildDefinition.BuildType,
+			materials: p.BuildDefinition.ResolvedDependencies,
+		}
+		params := p.BuildDefinition.ExternalParameters
+		if params.Workflow.Repository != "" {
+			prov.sources = append(prov.sources, source{
+				uri: normalizeURI(params.Workflow.Repository),
+				ref: params.Workflow.Ref,
+			})
+		}
+		var sourceURI string
+		if len(params.Source) > 0 && json.Unmarshal(params.Source, &sourceURI) == nil && sourceURI != "" {
+			prov.sources = append(prov.sources, parseSource(sourceURI))
+		}
+		return prov, nil
+	}
+	return nil, fmt.Errorf("unsupported predicate type %q", predicateType)
+}
+
+// parseSource splits a source URI like
+// "git+https://github.com/org/repo@refs/heads/main" into the repository URI
+// and the ref. Only an "@" in the path separates the ref, so that user info
+// of the authority is kept.
+func parseSource(uri string) source {
+	pathStart := 0
+	if _, after, ok := strings.Cut(uri, "://"); ok {
+		pathStart = len(uri) - len(after)
+		if i := strings.Index(after, "/"); i >= 0 {
+			pathStart += i
+		} else {
+			pathStart = len(uri)
+		}
+	}
+	if i := strings.LastIndex(uri, "@"); i > pathStart {
+		return source{uri: normalizeURI(uri[:i]), ref: uri[i+1:]}
+	}
+	return source{uri: normalizeURI(uri)}
+}
+
+// normalizeURI strips the "git+" prefix, the ".git" suffix and trailing
+// slashes of a repository URI.
+func normalizeURI(uri string) string {
+	uri = strings.TrimPrefix(uri, "git+")
+	uri = strings.TrimRight(uri, "/")
+	return strings.TrimSuffix(uri, ".git")
+}
+
+// matchPattern matches the value against the pattern. A pattern ending with
+// "*" matches any value with the preceding prefix.
+func matchPattern(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+	return pattern == value
+}
+
+// matchAny checks if the value matches any of the patterns.
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMaterial checks if any material has the digest.
+func (p *provenance) hasMaterial(d digest.Digest) bool {
+	for _, material := range p.materials {
+		if strings.EqualFold(material.Digest[d.Algorithm().String()], d.Encoded()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slsa
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	testSourceDigest   = "1111111111111111111111111111111111111111"
+	testMaterialDigest = "2222222222222222222222222222222222222222222222222222222222222222"
+
+	testProvenanceV02 = `{
+  "builder": {"id": "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v1.9.0"},
+  "buildType": "https://github.com/slsa-framework/slsa-github-generator/generic@v1",
+  "invocation": {
+    "configSource": {
+      "uri": "git+https://github.com/org/app@refs/heads/main",
+      "digest": {"sha1": "1111111111111111111111111111111111111111"},
+      "entryPoint": ".github/workflows/release.yml"
+    }
+  },
+  "materials": [
+    {"uri": "git+https://github.com/org/app@refs/heads/main", "digest": {"sha1": "1111111111111111111111111111111111111111"}},
+    {"uri": "pkg:docker/golang@1.22", "digest": {"sha256": "2222222222222222222222222222222222222222222222222222222222222222"}}
+  ]
+}`
+
+	testProvenanceV1 = `{
+  "buildDefinition": {
+    "buildType": "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1",
+    "externalParameters": {
+      "workflow": {
+        "ref": "refs/tags/v1.0.0",
+        "repository": "https://github.com/org/app",
+        "path": ".github/workflows/release.yml"
+      }
+    },
+    "resolvedDependencies": [
+      {"uri": "git+https://github.com/org/app@refs/tags/v1.0.0", "digest": {"gitCommit": "1111111111111111111111111111111111111111"}},
+      {"uri": "pkg:docker/golang@1.22", "digest": {"sha256": "2222222222222222222222222222222222222222222222222222222222222222"}}
+    ]
+  },
+  "runDetails": {
+    "builder": {"id": "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v2.0.0"}
+  }
+}`
+)
+
+func TestParseProvenance(t *testing.T) {
+	tests := []struct {
+		name          string
+		predicateType string
+		predicate     string
+		want          *provenance
+		errContains   string
+	}{
+		{
+			name:          "v0.2",
+			predicateType: predicateTypeV02,
+			predicate:     testProvenanceV02,
+			want: &provenance{
+				builderID: "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v1.9.0",
+				buildType: "https://github.com/slsa-framework/slsa-github-generator/generic@v1",
+				sources:   []source{{uri: "https://github.com/org/app", ref: "refs/heads/main"}},
+				materials: []resourceDescriptor{
+					{URI: "git+https://github.com/org/app@refs/heads/main", Digest: map[string]string{"sha1": testSourceDigest}},
+					{URI: "pkg:docker/golang@1.22", Digest: map[string]string{"sha256": testMaterialDigest}},
+				},
+			},
+		},
+		{
+			name:          "v1",
+			predicateType: predicateTypeV1,
+			predicate:     testProvenanceV1,
+			want: &provenance{
+				builderID: "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v2.0.0",
+				buildType: "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1",
+				sources:   []source{{uri: "https://github.com/org/app", ref: "refs/tags/v1.0.0"}},
+				materials: []resourceDescriptor{
+					{URI: "git+https://github.com/org/app@refs/tags/v1.0.0", Digest: map[string]string{"gitCommit": testSourceDigest}},
+					{URI: "pkg:docker/golang@1.22", Digest: map[string]string{"sha256": testMaterialDigest}},
+				},
+			},
+		},
+		{
+			name:          "v1 with source parameter",
+			predicateType: predicateTypeV1,
+			predicate:     `{"buildDefinition":{"buildType":"https://example.com/build","externalParameters":{"source":"git+https://git.example.com/app.git@v1"}},"runDetails":{"builder":{"id":"https://example.com/builder"}}}`,
+			want: &provenance{
+				builderID: "https://example.com/builder",
+				buildType: "https://example.com/build",
+				sources:   []source{{uri: "https://git.example.com/app", ref: "v1"}},
+			},
+		},
+		{
+			name:          "v1 resolved dependencies are not sources",
+			predicateType: predicateTypeV1,
+			predicate:     `{"buildDefinition":{"buildType":"https://example.com/build","resolvedDependencies":[{"uri":"git+https://github.com/org/app@refs/heads/main"}]},"runDetails":{"builder":{"id":"https://example.com/builder"}}}`,
+			want: &provenance{
+				builderID: "https://example.com/builder",
+				buildType: "https://example.com/build",
+				materials: []resourceDescriptor{{URI: "git+https://github.com/org/app@refs/heads/main"}},
+			},
+		},
+		{
+			name:          "v1 with non-string source parameter",
+			predicateType: predicateTypeV1,
+			predicate:     `{"buildDefinition":{"externalParameters":{"source":{"uri":"https://example.com"}}},"runDetails":{"builder":{"id":"https://example.com/builder"}}}`,
+			want:          &provenance{builderID: "https://example.com/builder"},
+		},
+		{
+			name:          "invalid v0.2",
+			predicateType: predicateTypeV02,
+			predicate:     `{"builder":"invalid"}`,
+			errContains:   "failed to parse SLSA v0.2 provenance",
+		},
+		{
+			name:          "invalid v1",
+			predicateType: predicateTypeV1,
+			predicate:     `[]`,
+			errContains:   "failed to parse SLSA v1 provenance",
+		},
+		{
+			name:          "unsupported predicate type",
+			predicateType: "https://example.com/predicate",
+			predicate:     `{}`,
+			errContains:   "unsupported predicate type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProvenance(tt.predicateType, []byte(tt.predicate))
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseProvenance() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want source
+	}{
+		{uri: "git+https://github.com/org/app@refs/heads/main", want: source{uri: "https://github.com/org/app", ref: "refs/heads/main"}},
+		{uri: "git+https://github.com/org/app.git", want: source{uri: "https://github.com/org/app"}},
+		{uri: "git+ssh://git@github.com/org/app", want: source{uri: "ssh://git@github.com/org/app"}},
+		{uri: "git+ssh://git@github.com/org/app@v1", want: source{uri: "ssh://git@github.com/org/app", ref: "v1"}},
+		{uri: "https://git@example.com", want: source{uri: "https://git@example.com"}},
+		{uri: "github.com/org/app@main", want: source{uri: "github.com/org/app", ref: "main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			if got := parseSource(tt.uri); got != tt.want {
+				t.Errorf("parseSource() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{pattern: "refs/heads/main", value: "refs/heads/main", want: true},
+		{pattern: "refs/heads/main", value: "refs/heads/mainline"},
+		{pattern: "refs/tags/*", value: "refs/tags/v1.0.0", want: true},
+		{pattern: "refs/tags/*", value: "refs/heads/main"},
+		{pattern: "*", value: "anything", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.value, func(t *testing.T) {
+			if got := matchPattern(tt.pattern, tt.value); got != tt.want {
+				t.Errorf("matchPattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvenance_HasMaterial(t *testing.T) {
+	prov, err := parseProvenance(predicateTypeV02, []byte(testProvenanceV02))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !prov.hasMaterial(digest.NewDigestFromEncoded(digest.SHA256, testMaterialDigest)) {
+		t.Error("expected material to be found")
+	}
+	if prov.hasMaterial(digest.FromString("missing")) {
+		t.Error("expected material not to be found")
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package slsa provides a verifier for SLSA provenance attestations.
+package slsa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+)
+
+const verifierTypeSLSA = "slsa"
+
+// Options contains the configuration options for creating a [Verifier].
+// The signature options are inlined, e.g. "keys" or "certificateIdentity".
+//
+// Patterns of builder IDs, build types, source URIs and source refs ending
+// with "*" match any value with the preceding prefix, e.g.
+// "https://github.com/slsa-framework/slsa-github-generator/*".
+type Options struct {
+	attestation.SignatureOptions
+
+	// BuilderIDs is a list of trusted builder ID patterns. Required.
+	BuilderIDs []string `json:"builderIDs"`
+
+	// BuildTypes is a list of allowed build type patterns. Optional.
+	BuildTypes []string `json:"buildTypes,omitempty"`
+
+	// SourceURIs is a list of allowed source repository URI patterns, e.g.
+	// "https://github.com/org/repo". The "git+" prefix and ".git" suffix are
+	// ignored. The patterns are matched against the primary source of the
+	// build, i.e. the "workflow" or "source" external parameter of v1
+	// provenance and the config source of v0.2 provenance, but not against
+	// resolved dependencies or materials. Optional.
+	SourceURIs []string `json:"sourceURIs,omitempty"`
+
+	// SourceRefs is a list of allowed source ref patterns, e.g.
+	// "refs/heads/main" or "refs/tags/*". Optional.
+	SourceRefs []string `json:"sourceRefs,omitempty"`
+
+	// RequiredMaterials is a list of digests, e.g. "sha256:...", that must be
+	// among the materials (v0.2) or resolved dependencies (v1) of the build.
+	// Optional.
+	RequiredMaterials []string `json:"requiredMaterials,omitempty"`
+}
+
+// Detail is the detail of the verification result of a [Verifier].
+type Detail struct {
+	// Provenances lists the results of the SLSA provenance attestations
+	// found in the artifact.
+	Provenances []ProvenanceResult `json:"provenances"`
+}
+
+// ProvenanceResult is the result of a single SLSA provenance attestation.
+type ProvenanceResult struct {
+	// Digest is the digest of the blob holding the attestation.
+	Digest digest.Digest `json:"digest"`
+
+	// PredicateType is the predicate type of the attestation.
+	PredicateType string `json:"predicateType,omitempty"`
+
+	// BuilderID is the ID of the builder.
+	BuilderID string `json:"builderID,omitempty"`
+
+	// BuildType is the build type.
+	BuildType string `json:"buildType,omitempty"`
+
+	// Sources lists the source repositories in the "uri@ref" form.
+	Sources []string `json:"sources,omitempty"`
+
+	// Violations lists the expectations the provenance does not meet.
+	Violations []string `json:"violations,omitempty"`
+
+	// Error is the error verifying the attestation signature.
+	Error string `json:"error,omitempty"`
+}
+
+// Verifier implements the [ratify.Verifier] interface for SLSA provenance
+// attestations. The verification passes if at least one signed provenance
+// meets all expectations.
+type Verifier struct {
+	name              string
+	signature         *attestation.SignatureVerifier
+	builderIDs        []string
+	buildTypes        []string
+	sourceURIs        []string
+	sourceRefs        []string
+	requiredMaterials []digest.Digest
+}
+
+func init() {
+	verifier.Register(verifierTypeSLSA, NewVerifier)
+}
+
+// NewVerifier creates a new SLSA provenance verifier instance based on the
+// provided options.
+func NewVerifier(opts verifier.NewOptions, _ []string) (ratify.Verifier, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("verifier name cannot be empty")
+	}
+
+	raw, err := json.Marshal(opts.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verifier parameters: %w", err)
+	}
+	var params Options
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verifier parameters: %w", err)
+	}
+	if len(params.BuilderIDs) == 0 {
+		return nil, fmt.Errorf("builderIDs cannot be empty")
+	}
+	requiredMaterials := make([]digest.Digest, 0, len(params.RequiredMaterials))
+	for _, material := range params.RequiredMaterials {
+		d, err := digest.Parse(material)
+		if err != nil {
+			return nil, fmt.Errorf("invalid required material digest %q: %w", material, err)
+		}
+		requiredMaterials = append(requiredMaterials, d)
+	}
+	sourceURIs := make([]string, 0, len(params.SourceURIs))
+	for _, uri := range params.SourceURIs {
+		sourceURIs = append(sourceURIs, normalizeURI(uri))
+	}
+	signature, err := attestation.NewSignatureVerifier(params.SignatureOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		name:              opts.Name,
+		signature:         signature,
+		builderIDs:        params.BuilderIDs,
+		buildTypes:        params.BuildTypes,
+		sourceURIs:        sourceURIs,
+		sourceRefs:        params.SourceRefs,
+		requiredMaterials: requiredMaterials,
+	}, nil
+}
+
+// Name returns the name of the verifier.
+func (v *Verifier) Name() string {
+	return v.name
+}
+
+// Type returns the type of the verifier which is always "slsa".
+func (v *Verifier) Type() string {
+	return verifierTypeSLSA
+}
+
+// Verifiable checks if the artifact is an in-toto attestation.
+func (v *Verifier) Verifiable(artifact ocispec.Descriptor) bool {
+	return attestation.IsAttestation(artifact)
+}
+
+// Verify verifies the signatures of the attestations in the artifact and
+// evaluates the SLSA provenances against the expectations. Attestations with
+// other predicate types are ignored.
+func (v *Verifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	attestations, err := v.signature.Verify(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &Detail{}
+	result := &ratify.VerificationResult{
+		Verifier: v,
+		Detail:   detail,
+	}
+	passed := false
+	for _, a := range attestations {
+		if a.Err != nil {
+			detail.Provenances = append(detail.Provenances, ProvenanceResult{
+				Digest: a.Digest,
+				Error:  a.Err.Error(),
+			})
+			continue
+		}
+		if !isProvenance(a.Statement.PredicateType) {
+			continue
+		}
+		provenanceResult := ProvenanceResult{
+			Digest:        a.Digest,
+			PredicateType: a.Statement.PredicateType,
+		}
+		prov, err := parseProvenance(a.Statement.PredicateType, a.Statement.Predicate)
+		if err != nil {
+			provenanceResult.Error = err.Error()
+		} else {
+			provenanceResult.BuilderID = prov.builderID
+			provenanceResult.BuildType = prov.buildType
+			for _, s := range prov.sources {
+				provenanceResult.Sources = append(provenanceResult.Sources, s.String())
+			}
+			provenanceResult.Violations = v.evaluate(prov)
+			passed = passed || len(provenanceResult.Violations) == 0
+		}
+		detail.Provenances = append(detail.Provenances, provenanceResult)
+	}
+
+	switch {
+	case passed:
+		result.Description = "SLSA provenance verification succeeded."
+	case len(detail.Provenances) == 0:
+		result.Err = fmt.Errorf("no SLSA provenance found in artifact %s", opts.ArtifactDescriptor.Digest)
+	default:
+		result.Err = fmt.Errorf("SLSA provenance verification failed: %s", summarize(detail.Provenances))
+	}
+	return result, nil
+}
+
+// evaluate returns the expectations the provenance does not meet.
+func (v *Verifier) evaluate(prov *provenance) []string {
+	var violations []string
+	if !matchAny(v.builderIDs, prov.builderID) {
+		violations = append(violations, fmt.Sprintf("builder ID %q is not trusted", prov.builderID))
+	}
+	if len(v.buildTypes) > 0 && !matchAny(v.buildTypes, prov.buildType) {
+		violations = append(violations, fmt.Sprintf("build type %q is not allowed", prov.buildType))
+	}
+	if len(v.sourceURIs) > 0 || len(v.sourceRefs) > 0 {
+		if !v.matchSource(prov.sources) {
+			violations = append(violations, "no allowed source repository found")
+		}
+	}
+	for _, material := range v.requiredMaterials {
+		if !prov.hasMaterial(material) {
+			violations = append(violations, fmt.Sprintf("required material %s not found", material))
+		}
+	}
+	return violations
+}
+
+// matchSource checks if any source matches both the allowed URIs and refs.
+func (v *Verifier) matchSource(sources []source) bool {
+	for _, s := range sources {
+		if len(v.sourceURIs) > 0 && !matchAny(v.sourceURIs, s.uri) {
+			continue
+		}
+		if len(v.sourceRefs) > 0 && !matchAny(v.sourceRefs, s.ref) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// summarize returns the reasons of the first failed provenance.
+func summarize(results []ProvenanceResult) string {
+	for _, r := range results {
+		if r.Error != "" {
+			return r.Error
+		}
+		if len(r.Violations) > 0 {
+			return strings.Join(r.Violations, "; ")
+		}
+	}
+	return "unknown error"
+}
@@ -0,0 +1,329 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slsa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation/attestationtest"
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/keyprovider/inlineprovider"
+)
+
+const (
+	testName = "slsa-1"
+	testRepo = "registry.example.com/app"
+)
+
+// newStore returns a store serving an in-toto attestation artifact with the
+// DSSE envelopes as layers.
+func newStore(t *testing.T, envelopes ...string) (*attestationtest.Store, ocispec.Descriptor) {
+	t.Helper()
+	return attestationtest.NewStore(t, attestation.ArtifactTypeInToto, attestationtest.NewLayers(attestation.ArtifactTypeDSSE, envelopes...)...)
+}
+
+func TestNewVerifier(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	tests := []struct {
+		name        string
+		opts        verifier.NewOptions
+		errContains string
+	}{
+		{
+			name: "valid options",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Parameters: signer.SignatureParameters(map[string]any{
+					"builderIDs":        []string{"https://example.com/builder"},
+					"requiredMaterials": []string{"sha256:" + testMaterialDigest},
+				}),
+			},
+		},
+		{
+			name:        "empty name",
+			opts:        verifier.NewOptions{},
+			errContains: "verifier name cannot be empty",
+		},
+		{
+			name:        "unmarshalable parameters",
+			opts:        verifier.NewOptions{Name: testName, Parameters: make(chan int)},
+			errContains: "failed to marshal verifier parameters",
+		},
+		{
+			name:        "invalid parameters",
+			opts:        verifier.NewOptions{Name: testName, Parameters: map[string]any{"builderIDs": "invalid"}},
+			errContains: "failed to unmarshal verifier parameters",
+		},
+		{
+			name:        "missing builder IDs",
+			opts:        verifier.NewOptions{Name: testName, Parameters: signer.SignatureParameters(map[string]any{})},
+			errContains: "builderIDs cannot be empty",
+		},
+		{
+			name: "invalid material digest",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Parameters: signer.SignatureParameters(map[string]any{
+					"builderIDs":        []string{"https://example.com/builder"},
+					"requiredMaterials": []string{"invalid"},
+				}),
+			},
+			errContains: "invalid required material digest",
+		},
+		{
+			name: "missing signature options",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Parameters: map[string]any{"builderIDs": []string{"https://example.com/builder"}},
+			},
+			errContains: "either keys or a certificate identity must be provided",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(tt.opts, nil)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.Name() != testName {
+				t.Errorf("expected name %q, got %q", testName, v.Name())
+			}
+			if v.Type() != verifierTypeSLSA {
+				t.Errorf("expected type %q, got %q", verifierTypeSLSA, v.Type())
+			}
+		})
+	}
+}
+
+func TestVerifiable(t *testing.T) {
+	v := &Verifier{}
+	tests := []struct {
+		name     string
+		artifact ocispec.Descriptor
+		want     bool
+	}{
+		{
+			name:     "in-toto attestation",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: attestation.ArtifactTypeInToto},
+			want:     true,
+		},
+		{
+			name:     "Sigstore bundle",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: attestation.ArtifactTypeSigstoreBundle},
+			want:     true,
+		},
+		{
+			name:     "other artifact",
+			artifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: "application/sarif+json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.Verifiable(tt.artifact); got != tt.want {
+				t.Errorf("Verifiable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	otherSigner := attestationtest.NewSigner(t)
+	provenanceV02 := signer.Sign(t, predicateTypeV02, testProvenanceV02)
+	provenanceV1 := signer.Sign(t, predicateTypeV1, testProvenanceV1)
+	provenanceOtherSource := signer.Sign(t, predicateTypeV1, `{
+  "buildDefinition": {
+    "externalParameters": {"workflow": {"repository": "https://github.com/other/app", "ref": "refs/heads/main"}},
+    "resolvedDependencies": [{"uri": "git+https://github.com/org/app@refs/heads/main"}]
+  },
+  "runDetails": {"builder": {"id": "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v2.0.0"}}
+}`)
+	generatorBuilders := []string{"https://github.com/slsa-framework/slsa-github-generator/.github/workflows/*"}
+
+	tests := []struct {
+		name           string
+		params         map[string]any
+		blobs          []string
+		wantErr        string
+		wantViolations [][]string
+	}{
+		{
+			name: "v0.2 provenance meets expectations",
+			params: map[string]any{
+				"builderIDs":        generatorBuilders,
+				"buildTypes":        []string{"https://github.com/slsa-framework/slsa-github-generator/generic@v1"},
+				"sourceURIs":        []string{"git+https://github.com/org/app.git"},
+				"sourceRefs":        []string{"refs/heads/main"},
+				"requiredMaterials": []string{"sha256:" + testMaterialDigest},
+			},
+			blobs:          []string{provenanceV02},
+			wantViolations: [][]string{nil},
+		},
+		{
+			name: "v1 provenance meets expectations",
+			params: map[string]any{
+				"builderIDs": generatorBuilders,
+				"sourceURIs": []string{"https://github.com/org/app"},
+				"sourceRefs": []string{"refs/tags/*"},
+			},
+			blobs:          []string{provenanceV1},
+			wantViolations: [][]string{nil},
+		},
+		{
+			name: "untrusted builder and wrong build type",
+			params: map[string]any{
+				"builderIDs": []string{"https://example.com/builder"},
+				"buildTypes": []string{"https://example.com/build"},
+			},
+			blobs:   []string{provenanceV1},
+			wantErr: "SLSA provenance verification failed",
+			wantViolations: [][]string{{
+				`builder ID "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v2.0.0" is not trusted`,
+				`build type "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1" is not allowed`,
+			}},
+		},
+		{
+			name: "source ref not allowed",
+			params: map[string]any{
+				"builderIDs": generatorBuilders,
+				"sourceURIs": []string{"https://github.com/org/app"},
+				"sourceRefs": []string{"refs/heads/main"},
+			},
+			blobs:          []string{provenanceV1},
+			wantErr:        "no allowed source repository found",
+			wantViolations: [][]string{{"no allowed source repository found"}},
+		},
+		{
+			name: "resolved dependency is not a source",
+			params: map[string]any{
+				"builderIDs": generatorBuilders,
+				"sourceURIs": []string{"https://github.com/org/app"},
+			},
+			blobs:          []string{provenanceOtherSource},
+			wantErr:        "no allowed source repository found",
+			wantViolations: [][]string{{"no allowed source repository found"}},
+		},
+		{
+			name: "missing material",
+			params: map[string]any{
+				"builderIDs":        generatorBuilders,
+				"requiredMaterials": []string{digest.FromString("missing").String()},
+			},
+			blobs:          []string{provenanceV02},
+			wantErr:        "required material",
+			wantViolations: [][]string{{fmt.Sprintf("required material %s not found", digest.FromString("missing"))}},
+		},
+		{
+			name:           "any matching provenance passes",
+			params:         map[string]any{"builderIDs": []string{"https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@*"}},
+			blobs:          []string{provenanceV1, provenanceV02},
+			wantViolations: [][]string{{`builder ID "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v2.0.0" is not trusted`}, nil},
+		},
+		{
+			name:           "untrusted signature",
+			params:         map[string]any{"builderIDs": generatorBuilders},
+			blobs:          []string{otherSigner.Sign(t, predicateTypeV1, testProvenanceV1)},
+			wantErr:        "failed to verify attestation signature",
+			wantViolations: [][]string{nil},
+		},
+		{
+			name:    "other predicate types are ignored",
+			params:  map[string]any{"builderIDs": generatorBuilders},
+			blobs:   []string{signer.Sign(t, "https://spdx.dev/Document", "{}")},
+			wantErr: "no SLSA provenance found",
+		},
+		{
+			name:           "invalid predicate",
+			params:         map[string]any{"builderIDs": generatorBuilders},
+			blobs:          []string{signer.Sign(t, predicateTypeV1, `{"runDetails":"invalid"}`)},
+			wantErr:        "failed to parse SLSA v1 provenance",
+			wantViolations: [][]string{nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(verifier.NewOptions{Name: testName, Parameters: signer.SignatureParameters(tt.params)}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			store, artifact := newStore(t, tt.blobs...)
+			result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+				Store:              store,
+				Repository:         testRepo,
+				SubjectDescriptor:  ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: attestationtest.Subject},
+				ArtifactDescriptor: artifact,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr == "" {
+				if result.Err != nil {
+					t.Fatalf("unexpected result error: %v", result.Err)
+				}
+			} else if result.Err == nil || !strings.Contains(result.Err.Error(), tt.wantErr) {
+				t.Fatalf("expected result error containing %q, got %v", tt.wantErr, result.Err)
+			}
+			detail := result.Detail.(*Detail)
+			if len(detail.Provenances) != len(tt.wantViolations) {
+				t.Fatalf("expected %d provenances, got %d", len(tt.wantViolations), len(detail.Provenances))
+			}
+			for i, p := range detail.Provenances {
+				if strings.Join(p.Violations, "\n") != strings.Join(tt.wantViolations[i], "\n") {
+					t.Errorf("provenance %d: expected violations %v, got %v", i, tt.wantViolations[i], p.Violations)
+				}
+			}
+		})
+	}
+}
+
+func TestVerify_StoreError(t *testing.T) {
+	signer := attestationtest.NewSigner(t)
+	v, err := NewVerifier(verifier.NewOptions{
+		Name:       testName,
+		Parameters: signer.SignatureParameters(map[string]any{"builderIDs": []string{"https://example.com/builder"}}),
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store, artifact := newStore(t)
+	store.ManifestErr = errors.New("manifest error")
+	if _, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+		Store:              store,
+		Repository:         testRepo,
+		SubjectDescriptor:  ocispec.Descriptor{Digest: attestationtest.Subject},
+		ArtifactDescriptor: artifact,
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,334 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trustedroot loads the trusted root of a Sigstore deployment from
+// configuration.
+package trustedroot
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+// Options defines the trusted root of a Sigstore deployment, e.g. a private
+// Fulcio and Rekor instance.
+type Options struct {
+	// TrustedRoot is an inline trusted_root.json document of a Sigstore
+	// deployment, e.g. a private Fulcio and Rekor instance. Optional. Only one
+	// of TrustedRoot, TrustedRootFile and TUFMirror is allowed. If none is
+	// provided, the trusted root of the Sigstore public-good instance is
+	// fetched, unless custom keys or timestamp authorities are provided.
+	TrustedRoot string `json:"trustedRoot,omitempty"`
+
+	// TrustedRootFile is the path of a trusted_root.json file. Optional.
+	TrustedRootFile string `json:"trustedRootFile,omitempty"`
+
+	// TUFMirror is a TUF repository serving the trusted_root.json. Optional.
+	TUFMirror *TUFMirrorOptions `json:"tufMirror,omitempty"`
+
+	// RekorPublicKeys are public keys of Rekor transparency logs added to the
+	// trusted root. Optional.
+	RekorPublicKeys []TransparencyLogOptions `json:"rekorPublicKeys,omitempty"`
+
+	// CTLogPublicKeys are public keys of certificate transparency logs added
+	// to the trusted root. Optional.
+	CTLogPublicKeys []TransparencyLogOptions `json:"ctLogPublicKeys,omitempty"`
+
+	// TimestampAuthorities are RFC 3161 timestamp authorities added to the
+	// trusted root. Optional.
+	TimestampAuthorities []TimestampAuthorityOptions `json:"timestampAuthorities,omitempty"`
+}
+
+// TUFMirrorOptions defines a TUF repository serving the trusted_root.json of
+// a Sigstore deployment.
+type TUFMirrorOptions struct {
+	// URL is the base URL of the TUF repository. Required.
+	URL string `json:"url"`
+
+	// Root is the initial root.json of the TUF repository. Optional. If
+	// neither Root nor RootFile is provided, the root of the Sigstore
+	// public-good instance is used, which suits mirrors of the public-good
+	// TUF repository.
+	Root string `json:"root,omitempty"`
+
+	// RootFile is the path of the initial root.json of the TUF repository.
+	// Optional.
+	RootFile string `json:"rootFile,omitempty"`
+
+	// CachePath is the directory caching the TUF metadata. Optional. If not
+	// provided, the default is $HOME/.sigstore/tuf.
+	CachePath string `json:"cachePath,omitempty"`
+}
+
+// TransparencyLogOptions defines the public key of a Rekor or certificate
+// transparency log.
+type TransparencyLogOptions struct {
+	// BaseURL is the base URL of the log. Optional.
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// PublicKey is the PEM encoded public key of the log. Required.
+	PublicKey string `json:"publicKey"`
+
+	// ValidFrom is the time from which the key is valid. Optional. If not
+	// provided, the key is valid for all entries before ValidUntil.
+	ValidFrom time.Time `json:"validFrom,omitempty"`
+
+	// ValidUntil is the time until which the key is valid. Optional. If not
+	// provided, the key does not expire.
+	ValidUntil time.Time `json:"validUntil,omitempty"`
+}
+
+// TimestampAuthorityOptions defines an RFC 3161 timestamp authority.
+type TimestampAuthorityOptions struct {
+	// URI is the URI of the timestamp authority. Optional.
+	URI string `json:"uri,omitempty"`
+
+	// CertificateChain is the PEM encoded certificate chain of the timestamp
+	// authority, ordered from the leaf to the root certificate. The last
+	// certificate must be a CA certificate. Required.
+	CertificateChain string `json:"certificateChain"`
+
+	// ValidFrom is the time from which timestamps are accepted. Optional.
+	ValidFrom time.Time `json:"validFrom,omitempty"`
+
+	// ValidUntil is the time until which timestamps are accepted. Optional.
+	ValidUntil time.Time `json:"validUntil,omitempty"`
+}
+
+// IsSet reports whether the options customize the trusted root.
+func (s *Options) IsSet() bool {
+	return s.TrustedRoot != "" || s.TrustedRootFile != "" || s.TUFMirror != nil ||
+		len(s.RekorPublicKeys) > 0 || len(s.CTLogPublicKeys) > 0 || len(s.TimestampAuthorities) > 0
+}
+
+// Load loads the trusted root from the options. A TUF mirror without custom
+// keys or timestamp authorities is returned as TUF options, so that the
+// caller fetches the trusted root. Otherwise, the trusted root is loaded from
+// the inline document, the file or the TUF mirror and extended with the
+// custom keys and timestamp authorities. Without any of these sources, the
+// trusted root only contains the custom keys and timestamp authorities, so no
+// network access is needed for verification. Both results are nil if the
+// options are not set.
+func Load(s *Options) (*root.TrustedRoot, *tuf.Options, error) {
+	if !s.IsSet() {
+		return nil, nil, nil
+	}
+	sources := 0
+	for _, set := range []bool{s.TrustedRoot != "", s.TrustedRootFile != "", s.TUFMirror != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, nil, fmt.Errorf("only one of trustedRoot, trustedRootFile and tufMirror is allowed")
+	}
+
+	var tufOpts *tuf.Options
+	if s.TUFMirror != nil {
+		var err error
+		if tufOpts, err = toTUFOptions(s.TUFMirror); err != nil {
+			return nil, nil, err
+		}
+		if len(s.RekorPublicKeys) == 0 && len(s.CTLogPublicKeys) == 0 && len(s.TimestampAuthorities) == 0 {
+			return nil, tufOpts, nil
+		}
+	}
+
+	var base *root.TrustedRoot
+	var err error
+	switch {
+	case s.TrustedRoot != "":
+		base, err = root.NewTrustedRootFromJSON([]byte(s.TrustedRoot))
+	case s.TrustedRootFile != "":
+		base, err = root.NewTrustedRootFromPath(s.TrustedRootFile)
+	case tufOpts != nil:
+		base, err = root.FetchTrustedRootWithOptions(tufOpts)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load trusted root: %w", err)
+	}
+
+	var certificateAuthorities []root.CertificateAuthority
+	var timestampAuthorities []root.TimestampingAuthority
+	ctLogs := make(map[string]*root.TransparencyLog)
+	rekorLogs := make(map[string]*root.TransparencyLog)
+	if base != nil {
+		certificateAuthorities = base.FulcioCertificateAuthorities()
+		timestampAuthorities = base.TimestampingAuthorities()
+		for id, log := range base.CTLogs() {
+			ctLogs[id] = log
+		}
+		for id, log := range base.RekorLogs() {
+			rekorLogs[id] = log
+		}
+	}
+	if err := addTransparencyLogs(rekorLogs, s.RekorPublicKeys); err != nil {
+		return nil, nil, fmt.Errorf("invalid Rekor public key: %w", err)
+	}
+	if err := addTransparencyLogs(ctLogs, s.CTLogPublicKeys); err != nil {
+		return nil, nil, fmt.Errorf("invalid CT log public key: %w", err)
+	}
+	for _, tsaOpts := range s.TimestampAuthorities {
+		tsa, err := toTimestampAuthority(tsaOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid timestamp authority: %w", err)
+		}
+		timestampAuthorities = append(timestampAuthorities, tsa)
+	}
+
+	trustedRoot, err := root.NewTrustedRoot(root.TrustedRootMediaType01, certificateAuthorities, ctLogs, timestampAuthorities, rekorLogs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return trustedRoot, nil, nil
+}
+
+// toTUFOptions converts [TUFMirrorOptions] to [tuf.Options].
+func toTUFOptions(m *TUFMirrorOptions) (*tuf.Options, error) {
+	if m.URL == "" {
+		return nil, fmt.Errorf("url is required for the TUF mirror")
+	}
+	if m.Root != "" && m.RootFile != "" {
+		return nil, fmt.Errorf("only one of root and rootFile is allowed for the TUF mirror")
+	}
+	opts := tuf.DefaultOptions().WithRepositoryBaseURL(m.URL)
+	switch {
+	case m.Root != "":
+		opts = opts.WithRoot([]byte(m.Root))
+	case m.RootFile != "":
+		rootJSON, err := os.ReadFile(m.RootFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TUF root: %w", err)
+		}
+		opts = opts.WithRoot(rootJSON)
+	}
+	if m.CachePath != "" {
+		opts = opts.WithCachePath(m.CachePath)
+	}
+	return opts, nil
+}
+
+// addTransparencyLogs adds the logs to the map keyed by the hex encoded log
+// ID, which is the SHA-256 digest of the DER encoded public key.
+func addTransparencyLogs(logs map[string]*root.TransparencyLog, opts []TransparencyLogOptions) error {
+	for _, logOpts := range opts {
+		der, err := decodePEM(logOpts.PublicKey, "PUBLIC KEY")
+		if err != nil {
+			return err
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return fmt.Errorf("failed to parse public key: %w", err)
+		}
+		validFrom := logOpts.ValidFrom
+		if validFrom.IsZero() {
+			// Rekor entries are rejected if the validity start is not set.
+			validFrom = time.Unix(0, 0)
+		}
+		id := sha256.Sum256(der)
+		logs[hex.EncodeToString(id[:])] = &root.TransparencyLog{
+			BaseURL:             logOpts.BaseURL,
+			ID:                  id[:],
+			ValidityPeriodStart: validFrom,
+			ValidityPeriodEnd:   logOpts.ValidUntil,
+			HashFunc:            crypto.SHA256,
+			PublicKey:           publicKey,
+			SignatureHashFunc:   signatureHash(publicKey),
+		}
+	}
+	return nil
+}
+
+// signatureHash returns the hash function of signatures created by the key.
+func signatureHash(publicKey crypto.PublicKey) crypto.Hash {
+	if key, ok := publicKey.(*ecdsa.PublicKey); ok {
+		switch key.Curve {
+		case elliptic.P384():
+			return crypto.SHA384
+		case elliptic.P521():
+			return crypto.SHA512
+		}
+	}
+	return crypto.SHA256
+}
+
+// toTimestampAuthority converts [TimestampAuthorityOptions] to a timestamp
+// authority. The first certificate of the chain is the leaf unless it is a CA
+// certificate, and the last one is the root, which must be a CA certificate.
+func toTimestampAuthority(opts TimestampAuthorityOptions) (*root.SigstoreTimestampingAuthority, error) {
+	var certs []*x509.Certificate
+	rest := []byte(opts.CertificateChain)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block type %s", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("certificate chain is empty")
+	}
+	if !certs[len(certs)-1].IsCA {
+		return nil, fmt.Errorf("certificate chain must end with a CA root certificate")
+	}
+
+	tsa := &root.SigstoreTimestampingAuthority{
+		URI:                 opts.URI,
+		ValidityPeriodStart: opts.ValidFrom,
+		ValidityPeriodEnd:   opts.ValidUntil,
+	}
+	for i, cert := range certs {
+		switch {
+		case i == len(certs)-1:
+			tsa.Root = cert
+		case i == 0 && !cert.IsCA:
+			tsa.Leaf = cert
+		default:
+			tsa.Intermediates = append(tsa.Intermediates, cert)
+		}
+	}
+	return tsa, nil
+}
+
+// decodePEM decodes a single PEM block of the given type.
+func decodePEM(data, blockType string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM data")
+	}
+	if block.Type != blockType {
+		return nil, fmt.Errorf("unexpected PEM block type %s", block.Type)
+	}
+	return block.Bytes, nil
+}
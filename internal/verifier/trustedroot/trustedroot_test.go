@@ -0,0 +1,448 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustedroot
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+// testPublicKey generates a PEM encoded public key and returns it with its
+// DER encoding.
+func testPublicKey(t *testing.T, curve elliptic.Curve) (string, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), der
+}
+
+// testCertificate generates a self-signed certificate and returns its DER
+// encoding.
+func testCertificate(t *testing.T, commonName string, isCA bool) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return der
+}
+
+// testTrustedRootJSON creates a trusted_root.json with a Fulcio CA and a
+// Rekor log.
+func testTrustedRootJSON(t *testing.T) string {
+	t.Helper()
+	_, rekorKey := testPublicKey(t, elliptic.P256())
+	logID := sha256.Sum256(rekorKey)
+	fulcioCert := testCertificate(t, "fulcio", true)
+	return fmt.Sprintf(`{
+	"mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.1",
+	"tlogs": [{
+		"baseUrl": "https://rekor.example.com",
+		"hashAlgorithm": "SHA2_256",
+		"publicKey": {
+			"rawBytes": %q,
+			"keyDetails": "PKIX_ECDSA_P256_SHA_256",
+			"validFor": {"start": "2024-01-01T00:00:00Z"}
+		},
+		"logId": {"keyId": %q}
+	}],
+	"certificateAuthorities": [{
+		"subject": {"organization": "example", "commonName": "fulcio"},
+		"uri": "https://fulcio.example.com",
+		"certChain": {"certificates": [{"rawBytes": %q}]},
+		"validFor": {"start": "2024-01-01T00:00:00Z"}
+	}],
+	"ctlogs": [],
+	"timestampAuthorities": []
+}`, base64.StdEncoding.EncodeToString(rekorKey), base64.StdEncoding.EncodeToString(logID[:]), base64.StdEncoding.EncodeToString(fulcioCert))
+}
+
+func TestLoad(t *testing.T) {
+	trustedRootJSON := testTrustedRootJSON(t)
+	dir := t.TempDir()
+	trustedRootFile := filepath.Join(dir, "trusted_root.json")
+	if err := os.WriteFile(trustedRootFile, []byte(trustedRootJSON), 0600); err != nil {
+		t.Fatalf("failed to write trusted root: %v", err)
+	}
+	tufRootFile := filepath.Join(dir, "root.json")
+	if err := os.WriteFile(tufRootFile, []byte(`{"signed":{}}`), 0600); err != nil {
+		t.Fatalf("failed to write TUF root: %v", err)
+	}
+	rekorKey, rekorDER := testPublicKey(t, elliptic.P256())
+	rekorID := sha256.Sum256(rekorDER)
+	ctKey, _ := testPublicKey(t, elliptic.P384())
+	tsaChain := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testCertificate(t, "tsa", false)})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testCertificate(t, "tsa-root", true)}))
+
+	tests := []struct {
+		name        string
+		input       *Options
+		wantErr     bool
+		errContains string
+		validate    func(*testing.T, *root.TrustedRoot, *tuf.Options)
+	}{
+		{
+			name:  "no trusted root options",
+			input: &Options{},
+			validate: func(t *testing.T, trustedRoot *root.TrustedRoot, tufOpts *tuf.Options) {
+				if trustedRoot != nil || tufOpts != nil {
+					t.Errorf("expected public-good trusted root, got %v, %v", trustedRoot, tufOpts)
+				}
+			},
+		},
+		{
+			name:  "inline trusted root",
+			input: &Options{TrustedRoot: trustedRootJSON},
+			validate: func(t *testing.T, trustedRoot *root.TrustedRoot, tufOpts *tuf.Options) {
+				if trustedRoot == nil {
+					t.Fatalf("expected trusted root")
+				}
+				if len(trustedRoot.FulcioCertificateAuthorities()) != 1 {
+					t.Errorf("FulcioCertificateAuthorities length = %v, want 1", len(trustedRoot.FulcioCertificateAuthorities()))
+				}
+				if len(trustedRoot.RekorLogs()) != 1 {
+					t.Errorf("RekorLogs length = %v, want 1", len(trustedRoot.RekorLogs()))
+				}
+			},
+		},
+		{
+			name:  "trusted root file",
+			input: &Options{TrustedRootFile: trustedRootFile},
+			validate: func(t *testing.T, trustedRoot *root.TrustedRoot, tufOpts *tuf.Options) {
+				if trustedRoot == nil || len(trustedRoot.FulcioCertificateAuthorities()) != 1 {
+					t.Errorf("expected trusted root with one certificate authority")
+				}
+			},
+		},
+		{
+			name: "trusted root with custom keys and timestamp authorities",
+			input: &Options{
+				TrustedRoot:          trustedRootJSON,
+				RekorPublicKeys:      []TransparencyLogOptions{{BaseURL: "https://rekor.internal", PublicKey: rekorKey}},
+				CTLogPublicKeys:      []TransparencyLogOptions{{PublicKey: ctKey, ValidUntil: time.Now().Add(time.Hour)}},
+				TimestampAuthorities: []TimestampAuthorityOptions{{URI: "https://tsa.internal", CertificateChain: tsaChain}},
+			},
+			validate: func(t *testing.T, trustedRoot *root.TrustedRoot, tufOpts *tuf.Options) {
+				if trustedRoot == nil {
+					t.Fatalf("expected trusted root")
+				}
+				if len(trustedRoot.FulcioCertificateAuthorities()) != 1 {
+					t.Errorf("FulcioCertificateAuthorities length = %v, want 1", len(trustedRoot.FulcioCertificateAuthorities()))
+				}
+				if len(trustedRoot.RekorLogs()) != 2 {
+					t.Errorf("RekorLogs length = %v, want 2", len(trustedRoot.RekorLogs()))
+				}
+				rekorLog, ok := trustedRoot.RekorLogs()[hex.EncodeToString(rekorID[:])]
+				if !ok {
+					t.Fatalf("expected Rekor log keyed by the key digest")
+				}
+				if rekorLog.BaseURL != "https://rekor.internal" || rekorLog.ValidityPeriodStart.IsZero() {
+					t.Errorf("unexpected Rekor log %+v", rekorLog)
+				}
+				if len(trustedRoot.CTLogs()) != 1 {
+					t.Errorf("CTLogs length = %v, want 1", len(trustedRoot.CTLogs()))
+				}
+				for _, ctLog := range trustedRoot.CTLogs() {
+					if ctLog.SignatureHashFunc != crypto.SHA384 {
+						t.Errorf("SignatureHashFunc = %v, want %v", ctLog.SignatureHashFunc, crypto.SHA384)
+					}
+				}
+				if len(trustedRoot.TimestampingAuthorities()) != 1 {
+					t.Errorf("TimestampingAuthorities length = %v, want 1", len(trustedRoot.TimestampingAuthorities()))
+				}
+			},
+		},
+		{
+			name:  "custom keys without trusted root",
+			input: &Options{RekorPublicKeys: []TransparencyLogOptions{{PublicKey: rekorKey}}},
+			validate: func(t *testing.T, trustedRoot *root.TrustedRoot, tufOpts *tuf.Options) {
+				if trustedRoot == nil {
+					t.Fatalf("expected trusted root")
+				}
+				if len(trustedRoot.FulcioCertificateAuthorities()) != 0 || len(trustedRoot.RekorLogs()) != 1 {
+					t.Errorf("expected trusted root with only the Rekor log")
+				}
+				if tufOpts != nil {
+					t.Errorf("expected no TUF options")
+				}
+			},
+		},
+		{
+			name: "TUF mirror",
+			input: &Options{TUFMirror: &TUFMirrorOptions{
+				URL:       "https://tuf.example.com",
+				Root:      `{"signed":{}}`,
+				CachePath: dir,
+			}},
+			validate: func(t *testing.T, trustedRoot *root.TrustedRoot, tufOpts *tuf.Options) {
+				if tufOpts == nil {
+					t.Fatalf("expected TUF options")
+				}
+				if tufOpts.RepositoryBaseURL != "https://tuf.example.com" || string(tufOpts.Root) != `{"signed":{}}` || tufOpts.CachePath != dir {
+					t.Errorf("unexpected TUF options %+v", tufOpts)
+				}
+				if trustedRoot != nil {
+					t.Errorf("expected no trusted root")
+				}
+			},
+		},
+		{
+			name:  "TUF mirror with root file",
+			input: &Options{TUFMirror: &TUFMirrorOptions{URL: "https://tuf.example.com", RootFile: tufRootFile}},
+			validate: func(t *testing.T, trustedRoot *root.TrustedRoot, tufOpts *tuf.Options) {
+				if tufOpts == nil || string(tufOpts.Root) != `{"signed":{}}` {
+					t.Errorf("expected TUF root from file")
+				}
+			},
+		},
+		{
+			name:        "multiple trusted root sources",
+			input:       &Options{TrustedRoot: trustedRootJSON, TrustedRootFile: trustedRootFile},
+			wantErr:     true,
+			errContains: "only one of trustedRoot, trustedRootFile and tufMirror is allowed",
+		},
+		{
+			name:        "invalid inline trusted root",
+			input:       &Options{TrustedRoot: "{"},
+			wantErr:     true,
+			errContains: "failed to load trusted root",
+		},
+		{
+			name:        "missing trusted root file",
+			input:       &Options{TrustedRootFile: filepath.Join(dir, "missing.json")},
+			wantErr:     true,
+			errContains: "failed to load trusted root",
+		},
+		{
+			name:        "TUF mirror without URL",
+			input:       &Options{TUFMirror: &TUFMirrorOptions{}},
+			wantErr:     true,
+			errContains: "url is required",
+		},
+		{
+			name:        "TUF mirror with root and root file",
+			input:       &Options{TUFMirror: &TUFMirrorOptions{URL: "https://tuf.example.com", Root: "{}", RootFile: tufRootFile}},
+			wantErr:     true,
+			errContains: "only one of root and rootFile is allowed",
+		},
+		{
+			name:        "TUF mirror with missing root file",
+			input:       &Options{TUFMirror: &TUFMirrorOptions{URL: "https://tuf.example.com", RootFile: filepath.Join(dir, "missing.json")}},
+			wantErr:     true,
+			errContains: "failed to read TUF root",
+		},
+		{
+			name: "unreachable TUF mirror with custom keys",
+			input: &Options{
+				TUFMirror:       &TUFMirrorOptions{URL: "http://127.0.0.1:1", CachePath: t.TempDir()},
+				RekorPublicKeys: []TransparencyLogOptions{{PublicKey: rekorKey}},
+			},
+			wantErr:     true,
+			errContains: "failed to load trusted root",
+		},
+		{
+			name:        "invalid Rekor public key",
+			input:       &Options{RekorPublicKeys: []TransparencyLogOptions{{PublicKey: "invalid"}}},
+			wantErr:     true,
+			errContains: "invalid Rekor public key",
+		},
+		{
+			name:        "CT log public key with unexpected PEM type",
+			input:       &Options{CTLogPublicKeys: []TransparencyLogOptions{{PublicKey: tsaChain}}},
+			wantErr:     true,
+			errContains: "invalid CT log public key",
+		},
+		{
+			name:        "empty timestamp authority chain",
+			input:       &Options{TimestampAuthorities: []TimestampAuthorityOptions{{}}},
+			wantErr:     true,
+			errContains: "invalid timestamp authority",
+		},
+		{
+			name:        "timestamp authority chain with unexpected PEM type",
+			input:       &Options{TimestampAuthorities: []TimestampAuthorityOptions{{CertificateChain: rekorKey}}},
+			wantErr:     true,
+			errContains: "invalid timestamp authority",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustedRoot, tufOpts, err := Load(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error to contain %q, got %q", tt.errContains, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.validate != nil {
+				tt.validate(t, trustedRoot, tufOpts)
+			}
+		})
+	}
+}
+
+func TestToTimestampAuthority(t *testing.T) {
+	leaf := testCertificate(t, "tsa", false)
+	intermediate := testCertificate(t, "tsa-intermediate", true)
+	rootCert := testCertificate(t, "tsa-root", true)
+	encode := func(certs ...[]byte) string {
+		var chain string
+		for _, cert := range certs {
+			chain += string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}))
+		}
+		return chain
+	}
+
+	tests := []struct {
+		name              string
+		chain             string
+		wantLeaf          bool
+		wantIntermediates int
+		wantRoot          string
+		wantErr           bool
+		errContains       string
+	}{
+		{
+			name:              "full chain",
+			chain:             encode(leaf, intermediate, rootCert),
+			wantLeaf:          true,
+			wantIntermediates: 1,
+			wantRoot:          "tsa-root",
+		},
+		{
+			name:              "chain without leaf",
+			chain:             encode(intermediate, rootCert),
+			wantIntermediates: 1,
+			wantRoot:          "tsa-root",
+		},
+		{
+			name:     "root only",
+			chain:    encode(rootCert),
+			wantRoot: "tsa-root",
+		},
+		{
+			name:        "leaf only",
+			chain:       encode(leaf),
+			wantErr:     true,
+			errContains: "must end with a CA root certificate",
+		},
+		{
+			name:        "chain ending with leaf",
+			chain:       encode(rootCert, leaf),
+			wantErr:     true,
+			errContains: "must end with a CA root certificate",
+		},
+		{
+			name:        "empty chain",
+			wantErr:     true,
+			errContains: "certificate chain is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsa, err := toTimestampAuthority(TimestampAuthorityOptions{CertificateChain: tt.chain})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (tsa.Leaf != nil) != tt.wantLeaf {
+				t.Errorf("Leaf = %v, want leaf %v", tsa.Leaf, tt.wantLeaf)
+			}
+			if len(tsa.Intermediates) != tt.wantIntermediates {
+				t.Errorf("Intermediates length = %v, want %v", len(tsa.Intermediates), tt.wantIntermediates)
+			}
+			if tsa.Root == nil || tsa.Root.Subject.CommonName != tt.wantRoot {
+				t.Errorf("Root = %v, want %v", tsa.Root, tt.wantRoot)
+			}
+		})
+	}
+}
+
+func TestSignatureHash(t *testing.T) {
+	tests := []struct {
+		name  string
+		curve elliptic.Curve
+		want  crypto.Hash
+	}{
+		{name: "P-256", curve: elliptic.P256(), want: crypto.SHA256},
+		{name: "P-384", curve: elliptic.P384(), want: crypto.SHA384},
+		{name: "P-521", curve: elliptic.P521(), want: crypto.SHA512},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ecdsa.GenerateKey(tt.curve, rand.Reader)
+			if err != nil {
+				t.Fatalf("failed to generate key: %v", err)
+			}
+			if got := signatureHash(&key.PublicKey); got != tt.want {
+				t.Errorf("signatureHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnerabilityreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// cycloneDXReport is the subset of a CycloneDX JSON document listing
+// vulnerabilities.
+type cycloneDXReport struct {
+	BOMFormat string `json:"bomFormat"`
+	Metadata  *struct {
+		Tools json.RawMessage `json:"tools"`
+	} `json:"metadata"`
+	Components      []cycloneDXComponent     `json:"components"`
+	Vulnerabilities []cycloneDXVulnerability `json:"vulnerabilities"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef     string               `json:"bom-ref"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXVulnerability struct {
+	ID      string `json:"id"`
+	Ratings []struct {
+		Score    float64 `json:"score"`
+		Severity string  `json:"severity"`
+	} `json:"ratings"`
+	Affects []struct {
+		Ref string `json:"ref"`
+	} `json:"affects"`
+}
+
+type cycloneDXTool struct {
+	Name string `json:"name"`
+}
+
+// parseCycloneDX extracts the scanner name and the vulnerabilities listed in a
+// CycloneDX JSON document. A vulnerability affecting several components
+// results in one finding per component.
+func parseCycloneDX(content []byte) (string, []finding, error) {
+	var report cycloneDXReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		return "", nil, fmt.Errorf("failed to parse CycloneDX report: %w", err)
+	}
+	if report.BOMFormat != "CycloneDX" {
+		return "", nil, fmt.Errorf("unexpected bomFormat %q in CycloneDX report", report.BOMFormat)
+	}
+	// An SBOM without vulnerabilities section is not a report of a scan that
+	// found nothing.
+	if report.Vulnerabilities == nil {
+		return "", nil, fmt.Errorf("CycloneDX document has no vulnerabilities section")
+	}
+
+	components := make(map[string]cycloneDXComponent)
+	var index func([]cycloneDXComponent)
+	index = func(list []cycloneDXComponent) {
+		for _, component := range list {
+			if component.BOMRef != "" {
+				components[component.BOMRef] = component
+			}
+			index(component.Components)
+		}
+	}
+	index(report.Components)
+
+	var found []finding
+	for _, vuln := range report.Vulnerabilities {
+		if vuln.ID == "" {
+			return "", nil, fmt.Errorf("vulnerability id not found in CycloneDX report")
+		}
+		f := finding{id: vulnerabilityID(vuln.ID)}
+		for _, rating := range vuln.Ratings {
+			if rating.Score > f.cvssScore {
+				f.cvssScore = rating.Score
+			}
+			severity := strings.ToLower(rating.Severity)
+			if f.severity == "" && severity != "" && severity != "unknown" {
+				f.severity = severity
+			}
+		}
+		if f.severity == "" && f.cvssScore > 0 {
+			f.severity = cvssSeverity(f.cvssScore)
+		}
+		if len(vuln.Affects) == 0 {
+			found = append(found, f)
+			continue
+		}
+		for _, affected := range vuln.Affects {
+			f := f
+			if component, ok := components[affected.Ref]; ok {
+				f.pkg = component.Name
+				f.installedVersion = component.Version
+			}
+			found = append(found, f)
+		}
+	}
+	return cycloneDXToolName(report), found, nil
+}
+
+// cycloneDXToolName returns the lowercased name of the first tool in the
+// metadata. Both the legacy tool array and the tool object of CycloneDX 1.5
+// are supported.
+func cycloneDXToolName(report cycloneDXReport) string {
+	if report.Metadata == nil || len(report.Metadata.Tools) == 0 {
+		return ""
+	}
+	var tools []cycloneDXTool
+	if err := json.Unmarshal(report.Metadata.Tools, &tools); err != nil {
+		var toolObject struct {
+			Components []cycloneDXTool `json:"components"`
+		}
+		if err := json.Unmarshal(report.Metadata.Tools, &toolObject); err != nil {
+			return ""
+		}
+		tools = toolObject.Components
+	}
+	if len(tools) == 0 {
+		return ""
+	}
+	return strings.ToLower(tools[0].Name)
+}
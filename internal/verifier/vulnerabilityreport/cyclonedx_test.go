@@ -0,0 +1,125 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnerabilityreport
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testCycloneDXReport = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "metadata": {
+    "tools": {
+      "components": [{"type": "application", "name": "Grype", "version": "0.74.0"}]
+    }
+  },
+  "components": [
+    {
+      "bom-ref": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+      "name": "log4j-core",
+      "version": "2.14.1",
+      "components": [
+        {"bom-ref": "pkg:maven/org.apache.logging.log4j/log4j-api@2.14.1", "name": "log4j-api", "version": "2.14.1"}
+      ]
+    }
+  ],
+  "vulnerabilities": [
+    {
+      "id": "CVE-2021-44228",
+      "ratings": [
+        {"score": 10, "severity": "critical", "method": "CVSSv31"},
+        {"score": 9.3, "severity": "critical", "method": "CVSSv2"}
+      ],
+      "affects": [{"ref": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"}]
+    },
+    {
+      "id": "CVE-2021-45046",
+      "ratings": [{"score": 0, "severity": "unknown"}, {"score": 3.7}],
+      "affects": [
+        {"ref": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+        {"ref": "pkg:maven/org.apache.logging.log4j/log4j-api@2.14.1"}
+      ]
+    }
+  ]
+}`
+
+func TestParseCycloneDX(t *testing.T) {
+	tests := []struct {
+		name            string
+		report          string
+		expectErr       bool
+		expectedScanner string
+		expected        []finding
+	}{
+		{
+			name:            "tool object",
+			report:          testCycloneDXReport,
+			expectedScanner: "grype",
+			expected: []finding{
+				{id: "CVE-2021-44228", severity: "critical", cvssScore: 10, pkg: "log4j-core", installedVersion: "2.14.1"},
+				{id: "CVE-2021-45046", severity: "low", cvssScore: 3.7, pkg: "log4j-core", installedVersion: "2.14.1"},
+				{id: "CVE-2021-45046", severity: "low", cvssScore: 3.7, pkg: "log4j-api", installedVersion: "2.14.1"},
+			},
+		},
+		{
+			name:            "legacy tool array without affected components",
+			report:          `{"bomFormat":"CycloneDX","metadata":{"tools":[{"name":"Trivy"}]},"vulnerabilities":[{"id":"CVE-2024-0001","ratings":[{"severity":"high"}]}]}`,
+			expectedScanner: "trivy",
+			expected:        []finding{{id: "CVE-2024-0001", severity: "high"}},
+		},
+		{
+			name:   "no vulnerabilities",
+			report: `{"bomFormat":"CycloneDX","vulnerabilities":[]}`,
+		},
+		{
+			name:      "SBOM without vulnerabilities section",
+			report:    `{"bomFormat":"CycloneDX","components":[{"name":"log4j-core","version":"2.14.1"}]}`,
+			expectErr: true,
+		},
+		{
+			name:      "missing vulnerability ID",
+			report:    `{"bomFormat":"CycloneDX","vulnerabilities":[{"ratings":[{"severity":"high"}]}]}`,
+			expectErr: true,
+		},
+		{
+			name:      "not CycloneDX",
+			report:    `{"bomFormat":"SPDX"}`,
+			expectErr: true,
+		},
+		{
+			name:      "invalid JSON",
+			report:    `not json`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner, found, err := parseCycloneDX([]byte(tt.report))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if scanner != tt.expectedScanner {
+				t.Fatalf("expected scanner %q, got %q", tt.expectedScanner, scanner)
+			}
+			if !reflect.DeepEqual(found, tt.expected) {
+				t.Fatalf("expected findings %+v, got %+v", tt.expected, found)
+			}
+		})
+	}
+}
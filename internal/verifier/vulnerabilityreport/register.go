@@ -0,0 +1,465 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnerabilityreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/owenrumney/go-sarif/v2/sarif"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+	"github.com/notaryproject/ratify/v2/internal/verifier/schemavalidator"
+)
+
+const (
+	verifierTypeVulnerabilityReport = "vulnerabilityreport"
+	artifactTypeSARIF               = "application/sarif+json"
+
+	// Reasons of a CVE violation.
+	reasonDenylist = "denylist"
+	reasonSeverity = "severity"
+	reasonCVSS     = "cvss"
+)
+
+// Options contains the configuration options for creating a [Verifier].
+type Options struct {
+	// SchemaURL is the URL of the JSON schema that SARIF reports are
+	// validated against. The schema is loaded once when the verifier is
+	// created. Defaults to the embedded SARIF 2.1.0 schema. Optional.
+	SchemaURL string `json:"schemaURL,omitempty"`
+
+	// CreatedAnnotationName is the annotation of the report artifact holding
+	// the RFC 3339 creation time of the report. Defaults to
+	// "org.opencontainers.image.created". Optional.
+	CreatedAnnotationName string `json:"createdAnnotationName,omitempty"`
+
+	// MaximumAge is the maximum age of the report, e.g. "24h". Reports
+	// without creation time fail if it is set. Optional.
+	MaximumAge jsonutil.Duration `json:"maximumAge,omitempty"`
+
+	// DisallowedSeverities is a list of severities, e.g. "critical", that the
+	// report must not contain. Severities are compared case-insensitively.
+	// Optional.
+	DisallowedSeverities []string `json:"disallowedSeverities,omitempty"`
+
+	// DenylistCVEs is a list of CVE IDs that the report must not contain.
+	// Optional.
+	DenylistCVEs []string `json:"denylistCVEs,omitempty"`
+
+	// AllowlistCVEs is a list of CVEs that are exempted from the disallowed
+	// severities and the CVSS threshold until they expire. A CVE cannot be
+	// both denylisted and allowlisted. Optional.
+	AllowlistCVEs []AllowedCVE `json:"allowlistCVEs,omitempty"`
+
+	// CVSSThreshold is the CVSS score at or above which a vulnerability is
+	// disallowed, e.g. 7.0. Vulnerabilities without a CVSS score are not
+	// checked against the threshold. Optional.
+	CVSSThreshold float64 `json:"cvssThreshold,omitempty"`
+
+	// CycloneDXArtifactTypes are the artifact types of CycloneDX
+	// vulnerability reports (VDR or VEX BOMs) to verify. CycloneDX SBOMs and
+	// vulnerability reports are usually attached with the same artifact type
+	// "application/vnd.cyclonedx+json", so CycloneDX reports are only
+	// verified if their artifact type is listed. Attach reports with a
+	// dedicated artifact type to keep them apart from SBOMs. If the shared
+	// type is listed, CycloneDX SBOMs without a vulnerabilities section fail
+	// verification. Optional.
+	CycloneDXArtifactTypes []string `json:"cycloneDXArtifactTypes,omitempty"`
+
+	// Passthrough skips the checks of the report and returns it in the
+	// result detail, so that the policy can evaluate it. Optional.
+	Passthrough bool `json:"passthrough,omitempty"`
+
+	// VEX enables suppressing vulnerabilities with the "not_affected" or
+	// "fixed" status in OpenVEX documents attached to the subject. Suppressed
+	// vulnerabilities are not checked. Optional.
+	VEX *VEXOptions `json:"vex,omitempty"`
+}
+
+// AllowedCVE is an exempted CVE.
+type AllowedCVE struct {
+	// ID is the CVE ID, e.g. "CVE-2023-1234". Required.
+	ID string `json:"id"`
+
+	// ExpiresAt is the RFC 3339 time after which the exemption no longer
+	// applies. Optional.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// Reason describes why the CVE is exempted. Optional.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Detail is the detail of the verification result of a [Verifier].
+type Detail struct {
+	// Scanner is the name of the scanner that created the report. The
+	// distinct scanners of multiple report blobs are comma separated.
+	Scanner string `json:"scanner,omitempty"`
+
+	// CreatedAt is the creation time of the report.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+
+	// Violations lists the disallowed vulnerabilities.
+	Violations []CVEViolation `json:"violations,omitempty"`
+
+	// Allowlisted lists the IDs of the vulnerabilities exempted by the
+	// allowlist.
+	Allowlisted []string `json:"allowlisted,omitempty"`
+
+	// ExpiredAllowlist lists the IDs of found vulnerabilities whose
+	// allowlist entry has expired.
+	ExpiredAllowlist []string `json:"expiredAllowlist,omitempty"`
+
+	// Suppressed lists the vulnerabilities suppressed by VEX statements.
+	Suppressed []VEXSuppression `json:"suppressed,omitempty"`
+
+	// IgnoredVEXDocuments lists the digests of the VEX documents that are
+	// ignored because they are invalid or lack a required valid signature.
+	IgnoredVEXDocuments []digest.Digest `json:"ignoredVEXDocuments,omitempty"`
+
+	// Report is the raw report if passthrough is enabled.
+	Report string `json:"report,omitempty"`
+}
+
+// CVEViolation is a disallowed vulnerability.
+type CVEViolation struct {
+	// ID is the CVE or advisory ID of the vulnerability.
+	ID string `json:"id"`
+
+	// Severity is the severity of the vulnerability.
+	Severity string `json:"severity,omitempty"`
+
+	// CVSSScore is the CVSS score of the vulnerability.
+	CVSSScore float64 `json:"cvssScore,omitempty"`
+
+	// Package is the name of the vulnerable package.
+	Package string `json:"package,omitempty"`
+
+	// InstalledVersion is the installed version of the vulnerable package.
+	InstalledVersion string `json:"installedVersion,omitempty"`
+
+	// Reasons lists why the vulnerability is disallowed: "denylist",
+	// "severity" or "cvss".
+	Reasons []string `json:"reasons"`
+}
+
+// Verifier implements the [ratify.Verifier] interface for SARIF and CycloneDX
+// vulnerability reports, e.g. created by Trivy or Grype.
+type Verifier struct {
+	name                  string
+	schema                *gojsonschema.Schema
+	createdAnnotationName string
+	maximumAge            time.Duration
+	disallowedSeverities  map[string]struct{}
+	denylist              map[string]struct{}
+	allowlist             map[string]AllowedCVE
+	cvssThreshold         float64
+	passthrough           bool
+	cycloneDXTypes        []string
+	vex                   *VEXOptions
+	vexSignature          *attestation.SignatureVerifier
+}
+
+func init() {
+	verifier.Register(verifierTypeVulnerabilityReport, NewVerifier)
+}
+
+// NewVerifier creates a new vulnerability report verifier instance based on
+// the provided options.
+func NewVerifier(opts verifier.NewOptions, _ []string) (ratify.Verifier, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("verifier name cannot be empty")
+	}
+
+	raw, err := json.Marshal(opts.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verifier parameters: %w", err)
+	}
+	var params Options
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verifier parameters: %w", err)
+	}
+	if params.MaximumAge < 0 {
+		return nil, fmt.Errorf("maximumAge cannot be negative")
+	}
+	if params.CVSSThreshold < 0 || params.CVSSThreshold > 10 {
+		return nil, fmt.Errorf("cvssThreshold must be between 0 and 10, got %v", params.CVSSThreshold)
+	}
+
+	schemaSource := schemavalidator.SchemaSource{URL: params.SchemaURL}
+	if params.SchemaURL == "" {
+		schemaSource = schemavalidator.SchemaSource{Embedded: schemavalidator.EmbeddedSARIF}
+	}
+	schema, err := schemaSource.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load report schema: %w", err)
+	}
+
+	v := &Verifier{
+		name:                  opts.Name,
+		schema:                schema,
+		createdAnnotationName: params.CreatedAnnotationName,
+		maximumAge:            params.MaximumAge.Duration(),
+		disallowedSeverities:  make(map[string]struct{}),
+		denylist:              make(map[string]struct{}),
+		allowlist:             make(map[string]AllowedCVE),
+		cvssThreshold:         params.CVSSThreshold,
+		passthrough:           params.Passthrough,
+		cycloneDXTypes:        params.CycloneDXArtifactTypes,
+		vex:                   params.VEX,
+	}
+	if v.vex != nil && v.vex.Signature != nil {
+		if v.vexSignature, err = attestation.NewSignatureVerifier(*v.vex.Signature); err != nil {
+			return nil, fmt.Errorf("failed to create VEX signature verifier: %w", err)
+		}
+	}
+	if v.createdAnnotationName == "" {
+		v.createdAnnotationName = ocispec.AnnotationCreated
+	}
+	for _, severity := range params.DisallowedSeverities {
+		v.disallowedSeverities[strings.ToLower(severity)] = struct{}{}
+	}
+	for _, cve := range params.DenylistCVEs {
+		v.denylist[strings.ToUpper(cve)] = struct{}{}
+	}
+	for _, allowed := range params.AllowlistCVEs {
+		if allowed.ID == "" {
+			return nil, fmt.Errorf("allowlisted CVE ID cannot be empty")
+		}
+		id := strings.ToUpper(allowed.ID)
+		if _, ok := v.denylist[id]; ok {
+			return nil, fmt.Errorf("CVE %s cannot be both denylisted and allowlisted", allowed.ID)
+		}
+		v.allowlist[id] = allowed
+	}
+	return v, nil
+}
+
+// Name returns the name of the verifier.
+func (v *Verifier) Name() string {
+	return v.name
+}
+
+// Type returns the type of the verifier which is always
+// "vulnerabilityreport".
+func (v *Verifier) Type() string {
+	return verifierTypeVulnerabilityReport
+}
+
+// Verifiable checks if the artifact is a SARIF report or has one of the
+// configured CycloneDX report artifact types.
+func (v *Verifier) Verifiable(artifact ocispec.Descriptor) bool {
+	return (artifact.ArtifactType == artifactTypeSARIF || slices.Contains(v.cycloneDXTypes, artifact.ArtifactType)) &&
+		artifact.MediaType == ocispec.MediaTypeImageManifest
+}
+
+// Verify checks the age of the report and the vulnerabilities it contains.
+func (v *Verifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	result := &ratify.VerificationResult{
+		Verifier: v,
+	}
+	detail := &Detail{}
+	result.Detail = detail
+
+	if created, ok := opts.ArtifactDescriptor.Annotations[v.createdAnnotationName]; ok {
+		createdAt, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			result.Err = fmt.Errorf("failed to parse report creation time %q: %w", created, err)
+			return result, nil
+		}
+		detail.CreatedAt = &createdAt
+	}
+	if v.maximumAge > 0 {
+		if detail.CreatedAt == nil {
+			result.Err = fmt.Errorf("report creation time annotation %s not found", v.createdAnnotationName)
+			return result, nil
+		}
+		if time.Since(*detail.CreatedAt) > v.maximumAge {
+			result.Err = fmt.Errorf("report created at %s is older than the maximum age %s", detail.CreatedAt.Format(time.RFC3339), v.maximumAge)
+			return result, nil
+		}
+	}
+
+	layers, err := verifier.FetchArtifactLayers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		result.Err = fmt.Errorf("no report blob found in artifact %s", opts.ArtifactDescriptor.Digest)
+		return result, nil
+	}
+	if v.passthrough && len(layers) > 1 {
+		result.Err = fmt.Errorf("passthrough supports a single report blob, found %d in artifact %s", len(layers), opts.ArtifactDescriptor.Digest)
+		return result, nil
+	}
+
+	// Every blob is a report, so that findings of additional reports are not
+	// skipped.
+	var found []finding
+	for _, layer := range layers {
+		content, err := opts.Store.FetchBlob(ctx, opts.Repository, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch report blob %s: %w", layer.Digest, err)
+		}
+
+		if v.passthrough {
+			detail.Report = string(content)
+			result.Description = "Vulnerability report validation skipped. Passthrough enabled."
+			return result, nil
+		}
+
+		var scanner string
+		var reportFindings []finding
+		if slices.Contains(v.cycloneDXTypes, opts.ArtifactDescriptor.ArtifactType) {
+			scanner, reportFindings, err = parseCycloneDX(content)
+		} else {
+			scanner, reportFindings, err = v.parseSARIF(content)
+		}
+		if err != nil {
+			result.Err = fmt.Errorf("invalid report blob %s: %w", layer.Digest, err)
+			return result, nil
+		}
+		detail.Scanner = appendScanner(detail.Scanner, scanner)
+		found = append(found, reportFindings...)
+	}
+
+	if v.vex != nil {
+		statements, ignored, err := v.fetchVEXStatements(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		detail.IgnoredVEXDocuments = ignored
+		found = suppress(found, statements, opts.SubjectDescriptor, detail)
+	}
+	if err := v.checkFindings(found, detail); err != nil {
+		result.Err = err
+		return result, nil
+	}
+	if len(detail.Violations) > 0 {
+		var ids []string
+		for _, violation := range detail.Violations {
+			ids = append(ids, violation.ID)
+		}
+		slices.Sort(ids)
+		result.Err = fmt.Errorf("found %d disallowed vulnerabilities: %s", len(detail.Violations), strings.Join(slices.Compact(ids), ", "))
+		return result, nil
+	}
+	result.Description = "Vulnerability report validation succeeded"
+	return result, nil
+}
+
+// parseSARIF validates the SARIF report against the schema and extracts the
+// scanner name and the vulnerabilities.
+func (v *Verifier) parseSARIF(content []byte) (string, []finding, error) {
+	if err := schemavalidator.Validate(v.schema, content); err != nil {
+		return "", nil, fmt.Errorf("invalid SARIF report: %w", err)
+	}
+	report, err := sarif.FromBytes(content)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse SARIF report: %w", err)
+	}
+	if len(report.Runs) == 0 {
+		return "", nil, fmt.Errorf("no runs found in SARIF report")
+	}
+	var scanner string
+	if driver := report.Runs[0].Tool.Driver; driver != nil {
+		scanner = strings.ToLower(driver.Name)
+	}
+	found, err := findings(report)
+	if err != nil {
+		return "", nil, err
+	}
+	return scanner, found, nil
+}
+
+// checkFindings adds the disallowed and allowlisted vulnerabilities to the
+// detail.
+func (v *Verifier) checkFindings(found []finding, detail *Detail) error {
+	type violationKey struct {
+		id, pkg, version string
+	}
+	seen := make(map[violationKey]struct{})
+	now := time.Now()
+	for _, f := range found {
+		var reasons []string
+		if _, ok := v.denylist[f.id]; ok {
+			reasons = append(reasons, reasonDenylist)
+		}
+		if len(v.disallowedSeverities) > 0 {
+			if f.severity == "" {
+				return fmt.Errorf("failed to determine the severity of vulnerability %s", f.id)
+			}
+			if _, ok := v.disallowedSeverities[f.severity]; ok {
+				reasons = append(reasons, reasonSeverity)
+			}
+		}
+		if v.cvssThreshold > 0 && f.cvssScore >= v.cvssThreshold {
+			reasons = append(reasons, reasonCVSS)
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		if allowed, ok := v.allowlist[f.id]; ok {
+			if allowed.ExpiresAt == nil || now.Before(*allowed.ExpiresAt) {
+				if !slices.Contains(detail.Allowlisted, f.id) {
+					detail.Allowlisted = append(detail.Allowlisted, f.id)
+				}
+				continue
+			}
+			if !slices.Contains(detail.ExpiredAllowlist, f.id) {
+				detail.ExpiredAllowlist = append(detail.ExpiredAllowlist, f.id)
+			}
+		}
+
+		key := violationKey{id: f.id, pkg: f.pkg, version: f.installedVersion}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		detail.Violations = append(detail.Violations, CVEViolation{
+			ID:               f.id,
+			Severity:         f.severity,
+			CVSSScore:        f.cvssScore,
+			Package:          f.pkg,
+			InstalledVersion: f.installedVersion,
+			Reasons:          reasons,
+		})
+	}
+	return nil
+}
+
+// appendScanner adds scanner to the comma separated list of scanners unless
+// it is empty or already listed.
+func appendScanner(scanners, scanner string) string {
+	switch {
+	case scanner == "" || slices.Contains(strings.Split(scanners, ", "), scanner):
+		return scanners
+	case scanners == "":
+		return scanner
+	}
+	return scanners + ", " + scanner
+}
@@ -0,0 +1,712 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnerabilityreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/notaryproject/ratify/v2/internal/encoding/jsonutil"
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation/attestationtest"
+	_ "github.com/notaryproject/ratify/v2/internal/verifier/keyprovider/inlineprovider"
+)
+
+const (
+	testName = "vulnerabilityreport-1"
+	testRepo = "registry.example.com/app"
+
+	// artifactTypeCycloneDX is the artifact type of CycloneDX documents,
+	// shared by SBOMs and vulnerability reports.
+	artifactTypeCycloneDX = "application/vnd.cyclonedx+json"
+
+	// testArtifactTypeCycloneDXVDR is a dedicated artifact type of CycloneDX
+	// vulnerability reports.
+	testArtifactTypeCycloneDXVDR = "application/vnd.example.cyclonedx.vdr+json"
+)
+
+// mockStore serves a single report artifact and, optionally, referrers of
+// the subject.
+type mockStore struct {
+	manifest     []byte
+	manifests    map[digest.Digest][]byte
+	blobs        map[digest.Digest][]byte
+	referrers    map[digest.Digest][]ocispec.Descriptor
+	manifestErr  error
+	referrersErr error
+}
+
+func newMockStore(t *testing.T, blobs ...string) (*mockStore, ocispec.Descriptor) {
+	t.Helper()
+	store := &mockStore{
+		manifests: make(map[digest.Digest][]byte),
+		blobs:     make(map[digest.Digest][]byte),
+		referrers: make(map[digest.Digest][]ocispec.Descriptor),
+	}
+	manifest := ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest}
+	for _, blob := range blobs {
+		desc := ocispec.Descriptor{
+			MediaType: artifactTypeSARIF,
+			Digest:    digest.FromString(blob),
+			Size:      int64(len(blob)),
+		}
+		store.blobs[desc.Digest] = []byte(blob)
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	store.manifest = manifestBytes
+	return store, ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactTypeSARIF,
+		Digest:       digest.FromBytes(manifestBytes),
+		Size:         int64(len(manifestBytes)),
+	}
+}
+
+// addReferrer adds an artifact with a single blob as a referrer of the
+// subject and returns its descriptor.
+func (m *mockStore) addReferrer(t *testing.T, subject digest.Digest, artifactType, blob string) ocispec.Descriptor {
+	t.Helper()
+	manifest := ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: artifactType}
+	if blob != "" {
+		layer := ocispec.Descriptor{
+			MediaType: artifactType,
+			Digest:    digest.FromString(blob),
+			Size:      int64(len(blob)),
+		}
+		m.blobs[layer.Digest] = []byte(blob)
+		manifest.Layers = append(manifest.Layers, layer)
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Digest:       digest.FromBytes(manifestBytes),
+		Size:         int64(len(manifestBytes)),
+	}
+	m.manifests[desc.Digest] = manifestBytes
+	m.referrers[subject] = append(m.referrers[subject], desc)
+	return desc
+}
+
+func (m *mockStore) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, nil
+}
+
+func (m *mockStore) ListReferrers(_ context.Context, ref string, artifactTypes []string, fn func(referrers []ocispec.Descriptor) error) error {
+	if m.referrersErr != nil {
+		return m.referrersErr
+	}
+	_, subject, _ := strings.Cut(ref, "@")
+	var referrers []ocispec.Descriptor
+	for _, referrer := range m.referrers[digest.Digest(subject)] {
+		if len(artifactTypes) == 0 || slices.Contains(artifactTypes, referrer.ArtifactType) {
+			referrers = append(referrers, referrer)
+		}
+	}
+	if len(referrers) == 0 {
+		return nil
+	}
+	return fn(referrers)
+}
+
+func (m *mockStore) FetchBlob(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	blob, ok := m.blobs[desc.Digest]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return blob, nil
+}
+
+func (m *mockStore) FetchManifest(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	if m.manifestErr != nil {
+		return nil, m.manifestErr
+	}
+	if manifest, ok := m.manifests[desc.Digest]; ok {
+		return manifest, nil
+	}
+	return m.manifest, nil
+}
+
+func TestNewVerifier(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      verifier.NewOptions
+		expectErr bool
+	}{
+		{
+			name:      "empty name",
+			opts:      verifier.NewOptions{Type: verifierTypeVulnerabilityReport},
+			expectErr: true,
+		},
+		{
+			name: "invalid parameters",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeVulnerabilityReport,
+				Parameters: map[string]any{"disallowedSeverities": "high"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative maximum age",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeVulnerabilityReport,
+				Parameters: map[string]any{"maximumAge": "-1h"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid CVSS threshold",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeVulnerabilityReport,
+				Parameters: Options{CVSSThreshold: 11},
+			},
+			expectErr: true,
+		},
+		{
+			name: "empty allowlisted CVE",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeVulnerabilityReport,
+				Parameters: Options{AllowlistCVEs: []AllowedCVE{{Reason: "no ID"}}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "CVE both denylisted and allowlisted",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Type: verifierTypeVulnerabilityReport,
+				Parameters: Options{
+					DenylistCVEs:  []string{"CVE-2023-5363"},
+					AllowlistCVEs: []AllowedCVE{{ID: "cve-2023-5363"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid schema URL",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeVulnerabilityReport,
+				Parameters: Options{SchemaURL: "file:///non-existent/schema.json"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid VEX signature options",
+			opts: verifier.NewOptions{
+				Name:       testName,
+				Type:       verifierTypeVulnerabilityReport,
+				Parameters: Options{VEX: &VEXOptions{Signature: &attestation.SignatureOptions{}}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no parameters",
+			opts: verifier.NewOptions{Name: testName, Type: verifierTypeVulnerabilityReport},
+		},
+		{
+			name: "valid parameters",
+			opts: verifier.NewOptions{
+				Name: testName,
+				Type: verifierTypeVulnerabilityReport,
+				Parameters: map[string]any{
+					"maximumAge":           "24h",
+					"disallowedSeverities": []string{"critical", "high"},
+					"denylistCVEs":         []string{"CVE-2021-44228"},
+					"allowlistCVEs":        []map[string]string{{"id": "CVE-2023-5363", "expiresAt": "2030-01-01T00:00:00Z"}},
+					"cvssThreshold":        9.0,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := verifier.New(tt.opts, nil)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if v.Name() != testName || v.Type() != verifierTypeVulnerabilityReport {
+				t.Fatalf("unexpected verifier name %s or type %s", v.Name(), v.Type())
+			}
+		})
+	}
+}
+
+func TestVerifiable(t *testing.T) {
+	v, err := NewVerifier(verifier.NewOptions{
+		Name:       testName,
+		Type:       verifierTypeVulnerabilityReport,
+		Parameters: Options{CycloneDXArtifactTypes: []string{testArtifactTypeCycloneDXVDR}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		artifact ocispec.Descriptor
+		expected bool
+	}{
+		{
+			name:     "SARIF",
+			artifact: ocispec.Descriptor{ArtifactType: artifactTypeSARIF, MediaType: ocispec.MediaTypeImageManifest},
+			expected: true,
+		},
+		{
+			name:     "CycloneDX report",
+			artifact: ocispec.Descriptor{ArtifactType: testArtifactTypeCycloneDXVDR, MediaType: ocispec.MediaTypeImageManifest},
+			expected: true,
+		},
+		{
+			name:     "CycloneDX SBOM",
+			artifact: ocispec.Descriptor{ArtifactType: artifactTypeCycloneDX, MediaType: ocispec.MediaTypeImageManifest},
+		},
+		{
+			name:     "other artifact type",
+			artifact: ocispec.Descriptor{ArtifactType: "application/spdx+json", MediaType: ocispec.MediaTypeImageManifest},
+		},
+		{
+			name:     "other media type",
+			artifact: ocispec.Descriptor{ArtifactType: artifactTypeSARIF, MediaType: ocispec.MediaTypeImageIndex},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.Verifiable(tt.artifact); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	recent := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name         string
+		params       Options
+		artifactType string
+		annotations  map[string]string
+		blobs        []string
+		manifestErr  error
+		expectErr    bool
+		expectFailed bool
+		expected     *Detail
+	}{
+		{
+			name:     "no checks",
+			blobs:    []string{testTrivyReport},
+			expected: &Detail{Scanner: "trivy"},
+		},
+		{
+			name:         "disallowed severities",
+			params:       Options{DisallowedSeverities: []string{"Critical"}},
+			blobs:        []string{testGrypeReport},
+			expectFailed: true,
+			expected: &Detail{
+				Scanner: "grype",
+				Violations: []CVEViolation{
+					{ID: "CVE-2022-48174", Severity: "critical", CVSSScore: 9.8, Package: "busybox", InstalledVersion: "1.36.1-r0", Reasons: []string{reasonSeverity}},
+				},
+			},
+		},
+		{
+			name:         "multiple report blobs",
+			params:       Options{DenylistCVEs: []string{"CVE-2022-48174"}},
+			blobs:        []string{testTrivyReport, testGrypeReport},
+			expectFailed: true,
+			expected: &Detail{
+				Scanner: "trivy, grype",
+				Violations: []CVEViolation{
+					{ID: "CVE-2022-48174", Severity: "critical", CVSSScore: 9.8, Package: "busybox", InstalledVersion: "1.36.1-r0", Reasons: []string{reasonDenylist}},
+				},
+			},
+		},
+		{
+			name:         "invalid second report blob",
+			blobs:        []string{testTrivyReport, `not json`},
+			expectFailed: true,
+		},
+		{
+			name:         "passthrough with multiple report blobs",
+			params:       Options{Passthrough: true},
+			blobs:        []string{testTrivyReport, testGrypeReport},
+			expectFailed: true,
+		},
+		{
+			name:         "unknown severity",
+			params:       Options{DisallowedSeverities: []string{"critical"}},
+			blobs:        []string{testTrivyReport},
+			expectFailed: true,
+			expected:     &Detail{Scanner: "trivy"},
+		},
+		{
+			name:         "denylist and CVSS threshold",
+			params:       Options{DenylistCVEs: []string{"cve-2023-0001"}, CVSSThreshold: 7},
+			blobs:        []string{testTrivyReport},
+			expectFailed: true,
+			expected: &Detail{
+				Scanner: "trivy",
+				Violations: []CVEViolation{
+					{ID: "CVE-2023-5363", Severity: "high", CVSSScore: 7.5, Package: "libcrypto3", InstalledVersion: "3.1.2-r0", Reasons: []string{reasonCVSS}},
+					{ID: "CVE-2023-5363", Severity: "high", CVSSScore: 7.5, Package: "libssl3", InstalledVersion: "3.1.2-r0", Reasons: []string{reasonCVSS}},
+					{ID: "CVE-2023-0001", Package: "zlib", InstalledVersion: "1.2.13-r1", Reasons: []string{reasonDenylist}},
+				},
+			},
+		},
+		{
+			name: "allowlisted CVE",
+			params: Options{
+				CVSSThreshold: 7,
+				AllowlistCVEs: []AllowedCVE{{ID: "CVE-2023-5363", ExpiresAt: &future, Reason: "not exploitable"}},
+			},
+			blobs:    []string{testTrivyReport},
+			expected: &Detail{Scanner: "trivy", Allowlisted: []string{"CVE-2023-5363"}},
+		},
+		{
+			name: "expired allowlisted CVE",
+			params: Options{
+				CVSSThreshold: 9,
+				AllowlistCVEs: []AllowedCVE{{ID: "CVE-2022-48174", ExpiresAt: &past}},
+			},
+			blobs:        []string{testGrypeReport},
+			expectFailed: true,
+			expected: &Detail{
+				Scanner:          "grype",
+				ExpiredAllowlist: []string{"CVE-2022-48174"},
+				Violations: []CVEViolation{
+					{ID: "CVE-2022-48174", Severity: "critical", CVSSScore: 9.8, Package: "busybox", InstalledVersion: "1.36.1-r0", Reasons: []string{reasonCVSS}},
+				},
+			},
+		},
+		{
+			name:        "report within maximum age",
+			params:      Options{MaximumAge: jsonutil.Duration(time.Hour)},
+			annotations: map[string]string{ocispec.AnnotationCreated: recent.Format(time.RFC3339)},
+			blobs:       []string{testGrypeReport},
+			expected:    &Detail{Scanner: "grype", CreatedAt: &recent},
+		},
+		{
+			name:         "report older than maximum age",
+			params:       Options{MaximumAge: jsonutil.Duration(time.Second)},
+			annotations:  map[string]string{ocispec.AnnotationCreated: recent.Format(time.RFC3339)},
+			blobs:        []string{testGrypeReport},
+			expectFailed: true,
+		},
+		{
+			name:         "missing creation time",
+			params:       Options{MaximumAge: jsonutil.Duration(time.Hour)},
+			blobs:        []string{testGrypeReport},
+			expectFailed: true,
+		},
+		{
+			name:         "invalid creation time",
+			annotations:  map[string]string{ocispec.AnnotationCreated: "yesterday"},
+			blobs:        []string{testGrypeReport},
+			expectFailed: true,
+		},
+		{
+			name:     "passthrough",
+			params:   Options{Passthrough: true, DisallowedSeverities: []string{"critical"}},
+			blobs:    []string{testGrypeReport},
+			expected: &Detail{Report: testGrypeReport},
+		},
+		{
+			name:         "CycloneDX report",
+			params:       Options{CVSSThreshold: 9, CycloneDXArtifactTypes: []string{testArtifactTypeCycloneDXVDR}},
+			artifactType: testArtifactTypeCycloneDXVDR,
+			blobs:        []string{testCycloneDXReport},
+			expectFailed: true,
+			expected: &Detail{
+				Scanner: "grype",
+				Violations: []CVEViolation{
+					{ID: "CVE-2021-44228", Severity: "critical", CVSSScore: 10, Package: "log4j-core", InstalledVersion: "2.14.1", Reasons: []string{reasonCVSS}},
+				},
+			},
+		},
+		{
+			name:         "invalid CycloneDX report",
+			params:       Options{CycloneDXArtifactTypes: []string{testArtifactTypeCycloneDXVDR}},
+			artifactType: testArtifactTypeCycloneDXVDR,
+			blobs:        []string{testGrypeReport},
+			expectFailed: true,
+		},
+		{
+			name:         "CycloneDX SBOM with shared artifact type",
+			params:       Options{CycloneDXArtifactTypes: []string{artifactTypeCycloneDX}},
+			artifactType: artifactTypeCycloneDX,
+			blobs:        []string{`{"bomFormat":"CycloneDX","specVersion":"1.5","components":[{"name":"log4j-core","version":"2.14.1"}]}`},
+			expectFailed: true,
+		},
+		{
+			name:         "schema violation",
+			blobs:        []string{`{"version":"2.1.0"}`},
+			expectFailed: true,
+		},
+		{
+			name:         "invalid JSON",
+			blobs:        []string{`not json`},
+			expectFailed: true,
+		},
+		{
+			name:         "no blobs",
+			expectFailed: true,
+		},
+		{
+			name:        "manifest error",
+			manifestErr: errors.New("not found"),
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(verifier.NewOptions{Name: testName, Type: verifierTypeVulnerabilityReport, Parameters: tt.params}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			store, artifact := newMockStore(t, tt.blobs...)
+			store.manifestErr = tt.manifestErr
+			artifact.Annotations = tt.annotations
+			if tt.artifactType != "" {
+				artifact.ArtifactType = tt.artifactType
+			}
+
+			result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+				Store:              store,
+				Repository:         testRepo,
+				ArtifactDescriptor: artifact,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if (result.Err != nil) != tt.expectFailed {
+				t.Fatalf("expected verification failure: %v, got: %v", tt.expectFailed, result.Err)
+			}
+			if result.Verifier != v {
+				t.Fatal("expected result to reference the verifier")
+			}
+			if tt.expected != nil && !reflect.DeepEqual(result.Detail, tt.expected) {
+				t.Fatalf("expected detail %+v, got %+v", tt.expected, result.Detail)
+			}
+		})
+	}
+}
+
+func TestVerify_VEX(t *testing.T) {
+	subject := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("subject"),
+	}
+	vexDocument := `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "@id": "https://example.com/vex/1",
+  "timestamp": "2024-01-01T00:00:00Z",
+  "statements": [
+    {
+      "vulnerability": {"name": "CVE-2023-5363"},
+      "products": [{"@id": "pkg:oci/app@` + strings.Replace(subject.Digest.String(), ":", "%3A", 1) + `", "subcomponents": [{"@id": "pkg:apk/alpine/libcrypto3@3.1.2-r0"}]}],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path"
+    },
+    {
+      "vulnerability": {"name": "CVE-2023-0001"},
+      "status": "fixed"
+    },
+    {
+      "vulnerability": {"name": "CVE-2023-0001"},
+      "status": "affected",
+      "timestamp": "2023-01-01T00:00:00Z"
+    }
+  ]
+}`
+	params := Options{CVSSThreshold: 7, DenylistCVEs: []string{"CVE-2023-0001"}}
+	signer := attestationtest.NewSigner(t)
+	otherSigner := attestationtest.NewSigner(t)
+	signatureOpts := &VEXOptions{Signature: &attestation.SignatureOptions{
+		Keys:       map[string]any{"inline": map[string]any{"keys": signer.PublicKey}},
+		IgnoreTLog: true,
+	}}
+	// sign returns a DSSE envelope of a statement about the VEX document.
+	sign := func(signer *attestationtest.Signer, vex digest.Digest) string {
+		return signer.SignEnvelope(t, attestationtest.PayloadTypeInToto, attestationtest.NewStatement(vex, "https://sigstore.dev/cosign/sign/v1", "{}"))
+	}
+
+	tests := []struct {
+		name         string
+		vex          *VEXOptions
+		signature    func(vex digest.Digest) string
+		verified     bool
+		document     string
+		referrersErr error
+		expectErr    bool
+		expectFailed bool
+		expected     *Detail
+	}{
+		{
+			name:         "VEX disabled",
+			document:     vexDocument,
+			expectFailed: true,
+			expected: &Detail{
+				Scanner: "trivy",
+				Violations: []CVEViolation{
+					{ID: "CVE-2023-5363", Severity: "high", CVSSScore: 7.5, Package: "libcrypto3", InstalledVersion: "3.1.2-r0", Reasons: []string{reasonCVSS}},
+					{ID: "CVE-2023-5363", Severity: "high", CVSSScore: 7.5, Package: "libssl3", InstalledVersion: "3.1.2-r0", Reasons: []string{reasonCVSS}},
+					{ID: "CVE-2023-0001", Package: "zlib", InstalledVersion: "1.2.13-r1", Reasons: []string{reasonDenylist}},
+				},
+			},
+		},
+		{
+			name:         "suppressed by VEX statements",
+			vex:          &VEXOptions{},
+			document:     vexDocument,
+			expectFailed: true,
+			expected: &Detail{
+				Scanner: "trivy",
+				Violations: []CVEViolation{
+					{ID: "CVE-2023-5363", Severity: "high", CVSSScore: 7.5, Package: "libssl3", InstalledVersion: "3.1.2-r0", Reasons: []string{reasonCVSS}},
+				},
+				Suppressed: []VEXSuppression{
+					{ID: "CVE-2023-5363", Package: "libcrypto3", InstalledVersion: "3.1.2-r0", Status: vexStatusNotAffected, Justification: "vulnerable_code_not_in_execute_path", Document: "https://example.com/vex/1"},
+					{ID: "CVE-2023-0001", Package: "zlib", InstalledVersion: "1.2.13-r1", Status: vexStatusFixed, Document: "https://example.com/vex/1"},
+				},
+			},
+		},
+		{
+			name:         "VEX document without signature ignored",
+			vex:          signatureOpts,
+			document:     vexDocument,
+			expectFailed: true,
+		},
+		{
+			name:         "VEX document with valid signature",
+			vex:          signatureOpts,
+			signature:    func(vex digest.Digest) string { return sign(signer, vex) },
+			verified:     true,
+			document:     vexDocument,
+			expectFailed: true,
+		},
+		{
+			name:         "VEX document with untrusted signature ignored",
+			vex:          signatureOpts,
+			signature:    func(vex digest.Digest) string { return sign(otherSigner, vex) },
+			document:     vexDocument,
+			expectFailed: true,
+		},
+		{
+			name:         "VEX document with signature of another artifact ignored",
+			vex:          signatureOpts,
+			signature:    func(digest.Digest) string { return sign(signer, digest.FromString("other")) },
+			document:     vexDocument,
+			expectFailed: true,
+		},
+		{
+			name:         "invalid VEX document ignored",
+			vex:          &VEXOptions{},
+			document:     "not json",
+			expectFailed: true,
+		},
+		{
+			name:         "list referrers error",
+			vex:          &VEXOptions{},
+			document:     vexDocument,
+			referrersErr: errors.New("registry unavailable"),
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := params
+			params.VEX = tt.vex
+			v, err := NewVerifier(verifier.NewOptions{Name: testName, Type: verifierTypeVulnerabilityReport, Parameters: params}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			store, artifact := newMockStore(t, testTrivyReport)
+			store.referrersErr = tt.referrersErr
+			vexDesc := store.addReferrer(t, subject.Digest, artifactTypeOpenVEX, tt.document)
+			if tt.signature != nil {
+				store.addReferrer(t, vexDesc.Digest, attestation.ArtifactTypeDSSE, tt.signature(vexDesc.Digest))
+			}
+			if tt.expected != nil {
+				for i := range tt.expected.Suppressed {
+					tt.expected.Suppressed[i].Artifact = vexDesc.Digest
+				}
+			}
+
+			result, err := v.Verify(context.Background(), &ratify.VerifyOptions{
+				Store:              store,
+				Repository:         testRepo,
+				SubjectDescriptor:  subject,
+				ArtifactDescriptor: artifact,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if (result.Err != nil) != tt.expectFailed {
+				t.Fatalf("expected verification failure: %v, got: %v", tt.expectFailed, result.Err)
+			}
+			detail := result.Detail.(*Detail)
+			if tt.vex != nil && tt.vex.Signature != nil {
+				if ignored := len(detail.IgnoredVEXDocuments) == 1; ignored == tt.verified {
+					t.Fatalf("expected VEX document ignored: %v, got: %v", !tt.verified, detail.IgnoredVEXDocuments)
+				}
+				if suppressed := len(detail.Suppressed) > 0; suppressed != tt.verified {
+					t.Fatalf("expected suppression: %v, got: %+v", tt.verified, detail.Suppressed)
+				}
+			}
+			if tt.expected != nil && !reflect.DeepEqual(detail, tt.expected) {
+				t.Fatalf("expected detail %+v, got %+v", tt.expected, detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnerabilityreport
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/owenrumney/go-sarif/v2/sarif"
+)
+
+const (
+	// securitySeverityProperty is the rule property holding the CVSS score
+	// of the vulnerability, as set by Trivy and Grype.
+	securitySeverityProperty = "security-severity"
+)
+
+var (
+	// severityRegex matches the severity in the help text of Trivy and Grype
+	// rules, e.g. "Severity: HIGH".
+	severityRegex = regexp.MustCompile(`Severity:\s*(\w+)`)
+
+	// vulnerabilityIDRegex matches CVE and GitHub advisory IDs in rule IDs,
+	// as Grype appends the package name to the vulnerability ID.
+	vulnerabilityIDRegex = regexp.MustCompile(`(?i)^(CVE-\d{4}-\d+|GHSA(-[a-z0-9]{4}){3})`)
+)
+
+// finding is a vulnerability found by a scanner.
+type finding struct {
+	id               string
+	severity         string
+	cvssScore        float64
+	pkg              string
+	installedVersion string
+}
+
+// findings extracts the vulnerabilities reported by all runs of the SARIF
+// report.
+func findings(report *sarif.Report) ([]finding, error) {
+	var found []finding
+	for _, run := range report.Runs {
+		if run == nil {
+			continue
+		}
+		rules := make(map[string]*sarif.ReportingDescriptor)
+		if run.Tool.Driver != nil {
+			for _, rule := range run.Tool.Driver.Rules {
+				if rule != nil {
+					rules[rule.ID] = rule
+				}
+			}
+		}
+		for _, result := range run.Results {
+			if result == nil {
+				continue
+			}
+			if result.RuleID == nil || *result.RuleID == "" {
+				return nil, fmt.Errorf("rule id not found for result with message %q", messageText(result.Message))
+			}
+			f := finding{id: vulnerabilityID(*result.RuleID)}
+			fields := parseFields(messageText(result.Message))
+			if rule, ok := rules[*result.RuleID]; ok {
+				var help string
+				if rule.Help != nil && rule.Help.Text != nil {
+					help = *rule.Help.Text
+				}
+				if match := severityRegex.FindStringSubmatch(help); len(match) == 2 {
+					f.severity = strings.ToLower(match[1])
+				}
+				f.cvssScore = securitySeverity(rule.Properties)
+				for key, value := range parseFields(help) {
+					if _, ok := fields[key]; !ok {
+						fields[key] = value
+					}
+				}
+			}
+			if f.severity == "" && f.cvssScore > 0 {
+				f.severity = cvssSeverity(f.cvssScore)
+			}
+			f.pkg = fields["package"]
+			f.installedVersion = fields["installed version"]
+			if f.installedVersion == "" {
+				f.installedVersion = fields["version"]
+			}
+			found = append(found, f)
+		}
+	}
+	return found, nil
+}
+
+// vulnerabilityID returns the CVE or advisory ID of a rule ID, or the rule ID
+// itself if it does not start with one.
+func vulnerabilityID(ruleID string) string {
+	if match := vulnerabilityIDRegex.FindString(ruleID); match != "" {
+		return strings.ToUpper(match)
+	}
+	return ruleID
+}
+
+// parseFields parses the "Key: value" lines of a message, e.g. "Package:
+// busybox". Keys are lowercased.
+func parseFields(text string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if _, exists := fields[key]; !exists {
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+	return fields
+}
+
+func messageText(message sarif.Message) string {
+	if message.Text == nil {
+		return ""
+	}
+	return *message.Text
+}
+
+// securitySeverity returns the CVSS score in the rule properties, or 0 if it
+// is not set.
+func securitySeverity(properties sarif.Properties) float64 {
+	switch score := properties[securitySeverityProperty].(type) {
+	case string:
+		value, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			return 0
+		}
+		return value
+	case float64:
+		return score
+	}
+	return 0
+}
+
+// cvssSeverity maps a CVSS v3 score to its qualitative severity rating.
+func cvssSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	case score > 0:
+		return "low"
+	}
+	return "none"
+}
@@ -0,0 +1,205 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnerabilityreport
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/owenrumney/go-sarif/v2/sarif"
+)
+
+const testGrypeReport = `{
+  "version": "2.1.0",
+  "$schema": "https://json.schemastore.org/sarif-2.1.0-rtm.5.json",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "Grype",
+          "version": "0.71.0",
+          "informationUri": "https://github.com/anchore/grype",
+          "rules": [
+            {
+              "id": "CVE-2022-48174-busybox",
+              "name": "ApkMatcherExactDirectMatch",
+              "help": {
+                "text": "Vulnerability CVE-2022-48174\nSeverity: critical\nPackage: busybox\nVersion: 1.36.1-r0\nFix Version: 1.36.1-r1\nType: apk\nLocation: /lib/apk/db/installed"
+              },
+              "properties": {
+                "security-severity": "9.8"
+              }
+            },
+            {
+              "id": "GHSA-xxxx-yyyy-zzzz-openssl",
+              "name": "ApkMatcherExactDirectMatch",
+              "help": {
+                "text": "Vulnerability GHSA-xxxx-yyyy-zzzz\nSeverity: low\nPackage: openssl\nVersion: 3.1.1-r1"
+              },
+              "properties": {
+                "security-severity": "3.1"
+              }
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "CVE-2022-48174-busybox",
+          "message": {
+            "text": "The path /lib/apk/db/installed reports busybox at version 1.36.1-r0 which is a vulnerable (apk) package installed in the container"
+          }
+        },
+        {
+          "ruleId": "GHSA-xxxx-yyyy-zzzz-openssl",
+          "message": {
+            "text": "The path /lib/apk/db/installed reports openssl at version 3.1.1-r1 which is a vulnerable (apk) package installed in the container"
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+const testTrivyReport = `{
+  "version": "2.1.0",
+  "$schema": "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "Trivy",
+          "informationUri": "https://github.com/aquasecurity/trivy",
+          "version": "0.45.0",
+          "rules": [
+            {
+              "id": "CVE-2023-5363",
+              "name": "OsPackageVulnerability",
+              "help": {
+                "text": "Vulnerability CVE-2023-5363\nSeverity: HIGH\nPackage: libcrypto3\nFixed Version: 3.1.4-r0\nLink: [CVE-2023-5363](https://avd.aquasec.com/nvd/cve-2023-5363)"
+              },
+              "properties": {
+                "security-severity": "7.5"
+              }
+            },
+            {
+              "id": "CVE-2023-0001",
+              "name": "OsPackageVulnerability",
+              "help": {
+                "text": "Vulnerability CVE-2023-0001"
+              }
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "CVE-2023-5363",
+          "message": {
+            "text": "Package: libcrypto3\nInstalled Version: 3.1.2-r0\nVulnerability CVE-2023-5363\nSeverity: HIGH\nFixed Version: 3.1.4-r0\nLink: [CVE-2023-5363](https://avd.aquasec.com/nvd/cve-2023-5363)"
+          }
+        },
+        {
+          "ruleId": "CVE-2023-5363",
+          "message": {
+            "text": "Package: libssl3\nInstalled Version: 3.1.2-r0\nVulnerability CVE-2023-5363\nSeverity: HIGH\nFixed Version: 3.1.4-r0\nLink: [CVE-2023-5363](https://avd.aquasec.com/nvd/cve-2023-5363)"
+          }
+        },
+        {
+          "ruleId": "CVE-2023-0001",
+          "message": {
+            "text": "Package: zlib\nInstalled Version: 1.2.13-r1\nVulnerability CVE-2023-0001"
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+func TestFindings(t *testing.T) {
+	tests := []struct {
+		name      string
+		report    string
+		expectErr bool
+		expected  []finding
+	}{
+		{
+			name:   "Grype",
+			report: testGrypeReport,
+			expected: []finding{
+				{id: "CVE-2022-48174", severity: "critical", cvssScore: 9.8, pkg: "busybox", installedVersion: "1.36.1-r0"},
+				{id: "GHSA-XXXX-YYYY-ZZZZ", severity: "low", cvssScore: 3.1, pkg: "openssl", installedVersion: "3.1.1-r1"},
+			},
+		},
+		{
+			name:   "Trivy",
+			report: testTrivyReport,
+			expected: []finding{
+				{id: "CVE-2023-5363", severity: "high", cvssScore: 7.5, pkg: "libcrypto3", installedVersion: "3.1.2-r0"},
+				{id: "CVE-2023-5363", severity: "high", cvssScore: 7.5, pkg: "libssl3", installedVersion: "3.1.2-r0"},
+				{id: "CVE-2023-0001", pkg: "zlib", installedVersion: "1.2.13-r1"},
+			},
+		},
+		{
+			name:   "severity from CVSS score",
+			report: `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"scanner","rules":[{"id":"CVE-2024-0001","properties":{"security-severity":4.3}}]}},"results":[{"ruleId":"CVE-2024-0001","message":{"text":"found"}}]}]}`,
+			expected: []finding{
+				{id: "CVE-2024-0001", severity: "medium", cvssScore: 4.3},
+			},
+		},
+		{
+			name:      "missing rule ID",
+			report:    `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"scanner"}},"results":[{"message":{"text":"found"}}]}]}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := sarif.FromBytes([]byte(tt.report))
+			if err != nil {
+				t.Fatalf("failed to parse report: %v", err)
+			}
+			got, err := findings(report)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected findings %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCVSSSeverity(t *testing.T) {
+	tests := []struct {
+		score    float64
+		expected string
+	}{
+		{score: 0, expected: "none"},
+		{score: 0.1, expected: "low"},
+		{score: 4, expected: "medium"},
+		{score: 7, expected: "high"},
+		{score: 9, expected: "critical"},
+		{score: 10, expected: "critical"},
+	}
+
+	for _, tt := range tests {
+		if got := cvssSeverity(tt.score); got != tt.expected {
+			t.Errorf("cvssSeverity(%v) = %s, want %s", tt.score, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnerabilityreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+	"github.com/notaryproject/ratify/v2/internal/verifier/attestation"
+)
+
+const (
+	// artifactTypeOpenVEX is the artifact type of OpenVEX documents.
+	artifactTypeOpenVEX = "application/openvex+json"
+
+	// OpenVEX statuses suppressing a vulnerability.
+	vexStatusNotAffected = "not_affected"
+	vexStatusFixed       = "fixed"
+)
+
+// signatureArtifactTypes are the artifact types of VEX document signatures.
+var signatureArtifactTypes = []string{
+	attestation.ArtifactTypeSigstoreBundle,
+	attestation.ArtifactTypeDSSE,
+	attestation.ArtifactTypeInToto,
+}
+
+// VEXOptions configures suppressing vulnerabilities by OpenVEX documents
+// attached to the subject as referrers.
+type VEXOptions struct {
+	// Signature verifies the signatures of VEX documents with public keys or
+	// keyless. Optional. If provided, VEX documents are ignored unless they
+	// have a referrer with a valid signature of the document manifest, i.e. a
+	// Sigstore bundle or DSSE envelope of an in-toto statement about it, as
+	// created by "cosign sign --new-bundle-format" or "cosign attest".
+	Signature *attestation.SignatureOptions `json:"signature,omitempty"`
+}
+
+// VEXSuppression is a vulnerability suppressed by a VEX statement.
+type VEXSuppression struct {
+	// ID is the CVE or advisory ID of the vulnerability.
+	ID string `json:"id"`
+
+	// Package is the name of the vulnerable package.
+	Package string `json:"package,omitempty"`
+
+	// InstalledVersion is the installed version of the vulnerable package.
+	InstalledVersion string `json:"installedVersion,omitempty"`
+
+	// Status is the status of the statement, "not_affected" or "fixed".
+	Status string `json:"status"`
+
+	// Justification is the justification of a "not_affected" statement.
+	Justification string `json:"justification,omitempty"`
+
+	// Document is the ID of the OpenVEX document containing the statement.
+	Document string `json:"document,omitempty"`
+
+	// Artifact is the digest of the referrer containing the document.
+	Artifact digest.Digest `json:"artifact"`
+}
+
+// vexDocument is the subset of an OpenVEX document used for suppression.
+type vexDocument struct {
+	ID         string         `json:"@id"`
+	Timestamp  *time.Time     `json:"timestamp"`
+	Statements []vexStatement `json:"statements"`
+}
+
+type vexStatement struct {
+	Vulnerability vexVulnerability `json:"vulnerability"`
+	Products      []vexProduct     `json:"products"`
+	Status        string           `json:"status"`
+	Justification string           `json:"justification"`
+	Timestamp     *time.Time       `json:"timestamp"`
+}
+
+// vexVulnerability is the vulnerability of a statement. OpenVEX 0.0.1 uses
+// a plain string, later versions an object.
+type vexVulnerability struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// UnmarshalJSON accepts both the string and the object form.
+func (v *vexVulnerability) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		v.Name = name
+		return nil
+	}
+	type plain vexVulnerability
+	return json.Unmarshal(data, (*plain)(v))
+}
+
+type vexProduct struct {
+	ID            string            `json:"@id"`
+	Identifiers   map[string]string `json:"identifiers"`
+	Subcomponents []vexProduct      `json:"subcomponents"`
+}
+
+// vexStatementRef is a statement together with the document it belongs to.
+type vexStatementRef struct {
+	statement vexStatement
+	timestamp time.Time
+	document  string
+	artifact  digest.Digest
+}
+
+// fetchVEXStatements returns the statements of the OpenVEX documents attached
+// to the subject, ordered by time. Documents that cannot be parsed, and
+// documents without a valid signature if signatures are verified, are skipped
+// and returned as ignored.
+func (v *Verifier) fetchVEXStatements(ctx context.Context, opts *ratify.VerifyOptions) ([]vexStatementRef, []digest.Digest, error) {
+	var documents []ocispec.Descriptor
+	subject := opts.Repository + "@" + opts.SubjectDescriptor.Digest.String()
+	if err := opts.Store.ListReferrers(ctx, subject, []string{artifactTypeOpenVEX}, func(referrers []ocispec.Descriptor) error {
+		for _, referrer := range referrers {
+			if referrer.ArtifactType == artifactTypeOpenVEX {
+				documents = append(documents, referrer)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, fmt.Errorf("failed to list VEX documents of %s: %w", subject, err)
+	}
+
+	var statements []vexStatementRef
+	var ignored []digest.Digest
+	for _, desc := range documents {
+		if v.vexSignature != nil {
+			signed, err := v.hasValidSignature(ctx, opts.Store, opts.Repository, desc)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !signed {
+				logrus.Warnf("ignoring VEX document %s of %s without valid signature", desc.Digest, subject)
+				ignored = append(ignored, desc.Digest)
+				continue
+			}
+		}
+		doc, err := fetchVEXDocument(ctx, opts.Store, opts.Repository, desc)
+		if err != nil {
+			if errors.Is(err, errInvalidVEXDocument) {
+				logrus.Warnf("ignoring VEX document %s of %s: %v", desc.Digest, subject, err)
+				ignored = append(ignored, desc.Digest)
+				continue
+			}
+			return nil, nil, err
+		}
+		for _, statement := range doc.Statements {
+			ref := vexStatementRef{
+				statement: statement,
+				document:  doc.ID,
+				artifact:  desc.Digest,
+			}
+			if statement.Timestamp != nil {
+				ref.timestamp = *statement.Timestamp
+			} else if doc.Timestamp != nil {
+				ref.timestamp = *doc.Timestamp
+			}
+			statements = append(statements, ref)
+		}
+	}
+	slices.SortStableFunc(statements, func(a, b vexStatementRef) int {
+		return a.timestamp.Compare(b.timestamp)
+	})
+	return statements, ignored, nil
+}
+
+// errInvalidVEXDocument is returned if a VEX document cannot be parsed.
+var errInvalidVEXDocument = errors.New("invalid VEX document")
+
+// fetchVEXDocument fetches and parses the OpenVEX document stored in the
+// first layer of the artifact.
+func fetchVEXDocument(ctx context.Context, store ratify.Store, repo string, desc ocispec.Descriptor) (*vexDocument, error) {
+	layers, err := verifier.FetchArtifactLayers(ctx, &ratify.VerifyOptions{
+		Store:              store,
+		Repository:         repo,
+		ArtifactDescriptor: desc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("%w: no blob found", errInvalidVEXDocument)
+	}
+	content, err := store.FetchBlob(ctx, repo, layers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch VEX document blob %s: %w", layers[0].Digest, err)
+	}
+	var doc vexDocument
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidVEXDocument, err)
+	}
+	return &doc, nil
+}
+
+// hasValidSignature checks whether the artifact has a signature referrer
+// verified against the digest of the artifact manifest.
+func (v *Verifier) hasValidSignature(ctx context.Context, store ratify.Store, repo string, desc ocispec.Descriptor) (bool, error) {
+	var signatures []ocispec.Descriptor
+	err := store.ListReferrers(ctx, repo+"@"+desc.Digest.String(), signatureArtifactTypes, func(referrers []ocispec.Descriptor) error {
+		for _, referrer := range referrers {
+			if attestation.IsAttestation(referrer) {
+				signatures = append(signatures, referrer)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list signatures of VEX document %s: %w", desc.Digest, err)
+	}
+
+	for _, signature := range signatures {
+		attestations, err := v.vexSignature.Verify(ctx, &ratify.VerifyOptions{
+			Store:              store,
+			Repository:         repo,
+			SubjectDescriptor:  desc,
+			ArtifactDescriptor: signature,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to verify signature %s of VEX document %s: %w", signature.Digest, desc.Digest, err)
+		}
+		for _, result := range attestations {
+			if result.Err == nil {
+				return true, nil
+			}
+			logrus.Debugf("invalid signature %s of VEX document %s: %v", result.Digest, desc.Digest, result.Err)
+		}
+	}
+	return false, nil
+}
+
+// suppress removes the findings suppressed by the latest applicable VEX
+// statement and records them in the detail.
+func suppress(found []finding, statements []vexStatementRef, subject ocispec.Descriptor, detail *Detail) []finding {
+	var remaining []finding
+	for _, f := range found {
+		var applicable *vexStatementRef
+		for i := range statements {
+			if statements[i].applies(f, subject) {
+				applicable = &statements[i]
+			}
+		}
+		if applicable == nil || (applicable.statement.Status != vexStatusNotAffected && applicable.statement.Status != vexStatusFixed) {
+			remaining = append(remaining, f)
+			continue
+		}
+		detail.Suppressed = append(detail.Suppressed, VEXSuppression{
+			ID:               f.id,
+			Package:          f.pkg,
+			InstalledVersion: f.installedVersion,
+			Status:           applicable.statement.Status,
+			Justification:    applicable.statement.Justification,
+			Document:         applicable.document,
+			Artifact:         applicable.artifact,
+		})
+	}
+	return remaining
+}
+
+// applies checks whether the statement is about the vulnerability of the
+// finding in the subject. Statements without products apply to the subject
+// the document is attached to. Products identifying another image digest are
+// skipped, and products with subcomponents only apply to the listed packages.
+func (s vexStatementRef) applies(f finding, subject ocispec.Descriptor) bool {
+	if !s.statement.Vulnerability.matches(f.id) {
+		return false
+	}
+	if len(s.statement.Products) == 0 {
+		return true
+	}
+	for _, product := range s.statement.Products {
+		if !product.matchesSubject(subject) {
+			continue
+		}
+		if len(product.Subcomponents) == 0 {
+			return true
+		}
+		for _, component := range product.Subcomponents {
+			if component.matchesPackage(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v vexVulnerability) matches(id string) bool {
+	if strings.EqualFold(vulnerabilityID(v.Name), id) {
+		return true
+	}
+	for _, alias := range v.Aliases {
+		if strings.EqualFold(vulnerabilityID(alias), id) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSubject checks that the product does not identify an image digest
+// other than the subject's.
+func (p vexProduct) matchesSubject(subject ocispec.Descriptor) bool {
+	ids := []string{p.ID}
+	for _, identifier := range p.Identifiers {
+		ids = append(ids, identifier)
+	}
+	for _, id := range ids {
+		if unescaped, err := url.PathUnescape(id); err == nil {
+			id = unescaped
+		}
+		if strings.Contains(id, "sha256:") && !strings.Contains(id, subject.Digest.String()) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesPackage checks whether the subcomponent identifies the package of
+// the finding, either by package URL or by name.
+func (p vexProduct) matchesPackage(f finding) bool {
+	if f.pkg == "" {
+		return false
+	}
+	ids := []string{p.ID}
+	if purl := p.Identifiers["purl"]; purl != "" {
+		ids = append(ids, purl)
+	}
+	for _, id := range ids {
+		name, version := purlNameVersion(id)
+		if name == f.pkg && (version == "" || version == f.installedVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+// purlNameVersion returns the name and version of a package URL, e.g.
+// "pkg:apk/alpine/busybox@1.36.1-r0?arch=x86_64". Other identifiers are
+// returned as the name.
+func purlNameVersion(id string) (string, string) {
+	if !strings.HasPrefix(id, "pkg:") {
+		return id, ""
+	}
+	id, _, _ = strings.Cut(id, "#")
+	id, _, _ = strings.Cut(id, "?")
+	id, version, _ := strings.Cut(id, "@")
+	name := id[strings.LastIndex(id, "/")+1:]
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	if unescaped, err := url.PathUnescape(version); err == nil {
+		version = unescaped
+	}
+	return name, version
+}
@@ -0,0 +1,145 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vulnerabilityreport
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestVEXVulnerability_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		expectErr bool
+		expected  string
+	}{
+		{name: "string", data: `"CVE-2023-5363"`, expected: "CVE-2023-5363"},
+		{name: "object", data: `{"name":"CVE-2023-5363","aliases":["GHSA-xxxx-yyyy-zzzz"]}`, expected: "CVE-2023-5363"},
+		{name: "invalid", data: `1`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vuln vexVulnerability
+			err := json.Unmarshal([]byte(tt.data), &vuln)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if vuln.Name != tt.expected {
+				t.Fatalf("expected name %q, got %q", tt.expected, vuln.Name)
+			}
+		})
+	}
+}
+
+func TestVEXStatementRef_Applies(t *testing.T) {
+	subject := ocispec.Descriptor{Digest: digest.FromString("subject")}
+	other := digest.FromString("other")
+	f := finding{id: "CVE-2023-5363", pkg: "libcrypto3", installedVersion: "3.1.2-r0"}
+
+	tests := []struct {
+		name      string
+		statement vexStatement
+		expected  bool
+	}{
+		{
+			name:      "no products",
+			statement: vexStatement{Vulnerability: vexVulnerability{Name: "cve-2023-5363"}},
+			expected:  true,
+		},
+		{
+			name:      "alias",
+			statement: vexStatement{Vulnerability: vexVulnerability{Name: "GHSA-xxxx-yyyy-zzzz", Aliases: []string{"CVE-2023-5363"}}},
+			expected:  true,
+		},
+		{
+			name:      "other vulnerability",
+			statement: vexStatement{Vulnerability: vexVulnerability{Name: "CVE-2023-0001"}},
+		},
+		{
+			name: "subject product",
+			statement: vexStatement{
+				Vulnerability: vexVulnerability{Name: "CVE-2023-5363"},
+				Products:      []vexProduct{{ID: "registry.example.com/app@" + subject.Digest.String()}},
+			},
+			expected: true,
+		},
+		{
+			name: "other image product",
+			statement: vexStatement{
+				Vulnerability: vexVulnerability{Name: "CVE-2023-5363"},
+				Products:      []vexProduct{{ID: "pkg:oci/app", Identifiers: map[string]string{"purl": "pkg:oci/app@" + other.String()}}},
+			},
+		},
+		{
+			name: "matching subcomponent by name",
+			statement: vexStatement{
+				Vulnerability: vexVulnerability{Name: "CVE-2023-5363"},
+				Products:      []vexProduct{{ID: "pkg:oci/app", Subcomponents: []vexProduct{{ID: "libcrypto3"}}}},
+			},
+			expected: true,
+		},
+		{
+			name: "subcomponent with other version",
+			statement: vexStatement{
+				Vulnerability: vexVulnerability{Name: "CVE-2023-5363"},
+				Products:      []vexProduct{{ID: "pkg:oci/app", Subcomponents: []vexProduct{{ID: "pkg:apk/alpine/libcrypto3@3.1.4-r0"}}}},
+			},
+		},
+		{
+			name: "other subcomponent",
+			statement: vexStatement{
+				Vulnerability: vexVulnerability{Name: "CVE-2023-5363"},
+				Products:      []vexProduct{{ID: "pkg:oci/app", Subcomponents: []vexProduct{{ID: "pkg:apk/alpine/libssl3"}}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref := vexStatementRef{statement: tt.statement}
+			if got := ref.applies(f, subject); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPurlNameVersion(t *testing.T) {
+	tests := []struct {
+		id              string
+		expectedName    string
+		expectedVersion string
+	}{
+		{id: "pkg:apk/alpine/busybox@1.36.1-r0?arch=x86_64", expectedName: "busybox", expectedVersion: "1.36.1-r0"},
+		{id: "pkg:npm/%40angular/core@16.0.0", expectedName: "core", expectedVersion: "16.0.0"},
+		{id: "pkg:golang/golang.org/x/net#http2", expectedName: "net"},
+		{id: "busybox", expectedName: "busybox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			name, version := purlNameVersion(tt.id)
+			if name != tt.expectedName || version != tt.expectedVersion {
+				t.Fatalf("expected %s@%s, got %s@%s", tt.expectedName, tt.expectedVersion, name, version)
+			}
+		})
+	}
+}
@@ -34,6 +34,13 @@ var (
 	systemErrorCount     instrument.Int64Counter
 	registryRequestCount instrument.Int64Counter
 	cacheBlobCount       instrument.Int64Counter
+	registryRetryCount   instrument.Int64Counter
+
+	// Credential provider Metrics
+	credentialProviderFailureCount instrument.Int64Counter
+
+	// Circuit breaker Metrics
+	circuitBreakerStateChangeCount instrument.Int64Counter
 
 	// Azure Metrics
 	aadExchangeDuration    instrument.Int64Histogram
@@ -51,6 +58,13 @@ const (
 	metricNameSystemErrorCount     = "ratify_system_error_count"
 	metricNameRegistryRequestCount = "ratify_registry_request_count"
 	metricNameBlobCacheCount       = "ratify_blob_cache_count"
+	metricNameRegistryRetryCount   = "ratify_registry_retry_count"
+
+	// Credential provider Metrics
+	metricNameCredentialProviderFailureCount = "ratify_credential_provider_failure_count"
+
+	// Circuit breaker Metrics
+	metricNameCircuitBreakerStateChangeCount = "ratify_circuit_breaker_state_change_count"
 
 	// Azure Metrics
 	metricNameAADExchangeDuration    = "ratify_aad_exchange_duration"
@@ -179,6 +193,21 @@ func initStatsReporter() error {
 		logrus.Error(err)
 		return err
 	}
+	registryRetryCount, err = meter.Int64Counter(metricNameRegistryRetryCount, instrument.WithDescription("registry request retry count"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	circuitBreakerStateChangeCount, err = meter.Int64Counter(metricNameCircuitBreakerStateChangeCount, instrument.WithDescription("circuit breaker state transition count"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	credentialProviderFailureCount, err = meter.Int64Counter(metricNameCredentialProviderFailureCount, instrument.WithDescription("registry credential exchange failure count"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
 	return nil
 }
 
@@ -303,3 +332,46 @@ func ReportBlobCacheCount(ctx context.Context, hit bool) {
 			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
 	}
 }
+
+// ReportRegistryRetryCount reports a retried registry request
+// Attributes:
+// registryHost: the host name of the registry
+// reason: the status code or "error" that triggered the retry
+// workload_namespace: the namespace where workload is deployed
+func ReportRegistryRetryCount(ctx context.Context, registryHost string, reason string) {
+	if registryRetryCount != nil {
+		registryRetryCount.Add(ctx, 1, instrument.WithAttributes(
+			attribute.KeyValue{Key: "registry_host", Value: attribute.StringValue(registryHost)},
+			attribute.KeyValue{Key: "reason", Value: attribute.StringValue(reason)},
+			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
+	}
+}
+
+// ReportCircuitBreakerStateChange reports a circuit breaker transitioning to a
+// new state
+// Attributes:
+// name: the name of the circuit breaker, e.g. the registry host
+// state: the new state of the circuit breaker
+func ReportCircuitBreakerStateChange(ctx context.Context, name string, state string) {
+	if circuitBreakerStateChangeCount != nil {
+		circuitBreakerStateChangeCount.Add(ctx, 1, instrument.WithAttributes(
+			attribute.KeyValue{Key: "name", Value: attribute.StringValue(name)},
+			attribute.KeyValue{Key: "state", Value: attribute.StringValue(state)}))
+	}
+}
+
+// ReportCredentialProviderFailure reports a failed registry credential exchange
+// Attributes:
+// provider: the name of the credential provider
+// registry_host: the host name of the registry
+// background: whether the failure happened in a background refresh
+// workload_namespace: the namespace where workload is deployed
+func ReportCredentialProviderFailure(ctx context.Context, provider string, registryHost string, background bool) {
+	if credentialProviderFailureCount != nil {
+		credentialProviderFailureCount.Add(ctx, 1, instrument.WithAttributes(
+			attribute.KeyValue{Key: "provider", Value: attribute.StringValue(provider)},
+			attribute.KeyValue{Key: "registry_host", Value: attribute.StringValue(registryHost)},
+			attribute.KeyValue{Key: "background", Value: attribute.BoolValue(background)},
+			attribute.KeyValue{Key: "workload_namespace", Value: attribute.StringValue(ctxUtils.GetNamespace(ctx))}))
+	}
+}
@@ -260,3 +260,71 @@ func TestReportBlobCacheCount(t *testing.T) {
 		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespac"])
 	}
 }
+
+func TestReportRegistryRetryCount(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	registryRetryCount = mockCounter
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportRegistryRetryCount(ctx, "test-registry", "503")
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportRegistryRetryCount() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["registry_host"] != "test-registry" {
+		t.Fatalf("expected registry_host attribute to be test-registry but got %s", mockCounter.Attributes["registry_host"])
+	}
+	if mockCounter.Attributes["reason"] != "503" {
+		t.Fatalf("expected reason attribute to be 503 but got %s", mockCounter.Attributes["reason"])
+	}
+	if mockCounter.Attributes["workload_namespace"] != testNamespace {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespace"])
+	}
+}
+
+func TestReportCircuitBreakerStateChange(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	circuitBreakerStateChangeCount = mockCounter
+	ReportCircuitBreakerStateChange(context.Background(), "test-registry", "open")
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportCircuitBreakerStateChange() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["name"] != "test-registry" {
+		t.Fatalf("expected name attribute to be test-registry but got %s", mockCounter.Attributes["name"])
+	}
+	if mockCounter.Attributes["state"] != "open" {
+		t.Fatalf("expected state attribute to be open but got %s", mockCounter.Attributes["state"])
+	}
+}
+
+func TestReportCredentialProviderFailure(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	credentialProviderFailureCount = mockCounter
+	ctx := ctxUtils.SetContextWithNamespace(context.Background(), testNamespace)
+	ReportCredentialProviderFailure(ctx, "azure", "test-registry", true)
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportCredentialProviderFailure() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["provider"] != "azure" {
+		t.Fatalf("expected provider attribute to be azure but got %s", mockCounter.Attributes["provider"])
+	}
+	if mockCounter.Attributes["registry_host"] != "test-registry" {
+		t.Fatalf("expected registry_host attribute to be test-registry but got %s", mockCounter.Attributes["registry_host"])
+	}
+	if mockCounter.Attributes["background"] != "true" {
+		t.Fatalf("expected background attribute to be true but got %s", mockCounter.Attributes["background"])
+	}
+	if mockCounter.Attributes["workload_namespace"] != testNamespace {
+		t.Fatalf("expected workload_namespace attribute to be %s but got %s", testNamespace, mockCounter.Attributes["workload_namespace"])
+	}
+}